@@ -21,10 +21,13 @@ import (
 	"github.com/lsm/fiso/internal/config"
 	"github.com/lsm/fiso/internal/delivery"
 	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/flowbuild"
 	"github.com/lsm/fiso/internal/interceptor"
 	"github.com/lsm/fiso/internal/interceptor/wasm"
 	"github.com/lsm/fiso/internal/observability"
 	"github.com/lsm/fiso/internal/pipeline"
+	"github.com/lsm/fiso/internal/schema"
+	"github.com/lsm/fiso/internal/sink"
 	httpsink "github.com/lsm/fiso/internal/sink/http"
 	kafkasink "github.com/lsm/fiso/internal/sink/kafka"
 	temporalsink "github.com/lsm/fiso/internal/sink/temporal"
@@ -34,7 +37,7 @@ import (
 	"github.com/lsm/fiso/internal/source/kafka"
 	"github.com/lsm/fiso/internal/tracing"
 	"github.com/lsm/fiso/internal/transform"
-	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
+	"github.com/lsm/fiso/internal/transform/celext"
 	wasmimpl "github.com/lsm/fiso/internal/wasm"
 )
 
@@ -98,7 +101,7 @@ func run() error {
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 	reg.MustRegister(collectors.NewGoCollector())
-	_ = observability.NewMetrics(reg)
+	flowMetrics := observability.NewMetrics(reg)
 
 	// Health server
 	health := observability.NewHealthServer()
@@ -141,7 +144,7 @@ func run() error {
 	runners := make([]*flowRunner, 0, len(flows))
 	for name, def := range flows {
 		logger.Info("building flow", "name", name)
-		p, err := buildPipeline(def, logger, httpPool, tracer)
+		p, err := buildPipeline(def, logger, httpPool, tracer, flowMetrics)
 		if err != nil {
 			return fmt.Errorf("build pipeline %s: %w", name, err)
 		}
@@ -208,7 +211,7 @@ func run() error {
 // buildPipeline builds a pipeline with Wasmer runtime support.
 // This is similar to fiso-flow's buildPipeline but uses the wasm factory
 // which supports both wazero and wasmer runtimes.
-func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool *httpsource.ServerPool, tracer trace.Tracer) (*pipeline.Pipeline, error) {
+func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool *httpsource.ServerPool, tracer trace.Tracer, metrics *observability.Metrics) (*pipeline.Pipeline, error) {
 	commitPolicy := delivery.NormalizeCommitPolicy(flowDef.ErrorHandling.CommitPolicy)
 	if commitPolicy == delivery.CommitPolicyKafkaTransaction {
 		if flowDef.Source.Type != "kafka" || flowDef.Sink.Type != "kafka" {
@@ -286,23 +289,117 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 	// Build transformer (optional)
 	var transformer transform.Transformer
 	var err error
-	if flowDef.Transform != nil && len(flowDef.Transform.Fields) > 0 {
-		transformer, err = unifiedxform.NewTransformer(flowDef.Transform.Fields)
+	if flowDef.Transform != nil {
+		transformer, err = flowbuild.Transformer(flowDef.Transform, flowDef.Lookups)
 		if err != nil {
 			return nil, fmt.Errorf("unified transformer: %w", err)
 		}
 	}
 
-	// Build sink
-	var sk interface {
-		Deliver(context.Context, []byte, map[string]string) error
-		Close() error
+	// Build sink, or the sinks of the routes
+	var sk sink.Sink
+	var router *pipeline.Router
+	if len(flowDef.Routes) > 0 {
+		router, err = flowbuild.Router(flowDef, func(sc config.SinkConfig) (sink.Sink, error) {
+			return buildSink(sc, flowDef, commitPolicy, tracer)
+		})
+	} else {
+		sk, err = buildSink(flowDef.Sink, flowDef, commitPolicy, tracer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Build DLQ handler
+	var dlqHandler *dlq.Handler
+	if flowDef.Source.Type == "kafka" {
+		clusterName, _ := flowDef.Source.Config["cluster"].(string)
+		cluster, found := flowDef.Kafka.Clusters[clusterName]
+		if !found {
+			return nil, fmt.Errorf("dlq publisher: cluster %q not found", clusterName)
+		}
+		pub, err := kafka.NewPublisher(&cluster)
+		if err != nil {
+			return nil, fmt.Errorf("dlq publisher: %w", err)
+		}
+		dlqHandler = dlq.NewHandler(pub)
+		if flowDef.ErrorHandling.DeadLetterTopic != "" {
+			dlqHandler = dlq.NewHandler(pub, dlq.WithTopicFunc(func(_ string) string {
+				return flowDef.ErrorHandling.DeadLetterTopic
+			}))
+		}
+	} else {
+		dlqHandler = dlq.NewHandler(&dlq.NoopPublisher{})
+	}
+
+	cfg := pipeline.Config{
+		FlowName:        flowDef.Name,
+		SourceType:      flowDef.Source.Type,
+		PropagateErrors: propagateErrors,
+		CommitPolicy:    commitPolicy,
+		Router:          router,
+		Metrics:         metrics,
+		CELFunctions:    []celext.Option{celext.WithLookups(flowDef.Lookups)},
+	}
+
+	if err := flowbuild.Stages(flowDef, &cfg); err != nil {
+		return nil, err
+	}
+
+	// Build interceptor chain with Wasmer runtime support
+	var chain *interceptor.Chain
+	if len(flowDef.Interceptors) > 0 {
+		var interceptors []interceptor.Interceptor
+		factory := wasmimpl.NewFactory()
+
+		for _, ic := range flowDef.Interceptors {
+			switch ic.Type {
+			case "wasm":
+				modulePath := getString(ic.Config, "module")
+				runtimeType := getString(ic.Config, "runtime")
+				if runtimeType == "" {
+					runtimeType = "wazero" // default
+				}
+
+				rtCfg := wasmimpl.Config{
+					Type:       wasmimpl.RuntimeType(runtimeType),
+					ModulePath: modulePath,
+				}
+
+				rt, err := factory.Create(context.Background(), rtCfg)
+				if err != nil {
+					return nil, fmt.Errorf("create wasm runtime for %s: %w", modulePath, err)
+				}
+
+				interceptors = append(interceptors, wasm.New(rt, modulePath))
+				logger.Info("loaded wasm interceptor", "module", modulePath, "runtime", runtimeType)
+
+			default:
+				return nil, fmt.Errorf("unsupported interceptor type: %s", ic.Type)
+			}
+		}
+		chain = interceptor.NewChain(interceptors...)
+	}
+
+	return pipeline.New(cfg, src, transformer, sk, dlqHandler, chain), nil
+}
+
+// buildSink builds the sink sc of flowDef.
+func buildSink(sc config.SinkConfig, flowDef *config.FlowDefinition, commitPolicy delivery.CommitPolicy, tracer trace.Tracer) (sink.Sink, error) {
+	// CloudEvents content mode shared by the http and kafka sinks
+	var ceMode string
+	if flowDef.CloudEvents != nil {
+		ceMode = flowDef.CloudEvents.Mode
+	}
+	var batchFormat string
+	if flowDef.Batch != nil {
+		batchFormat = flowDef.Batch.Format
 	}
 
-	switch flowDef.Sink.Type {
+	switch sc.Type {
 	case "http":
-		sinkURL, _ := flowDef.Sink.Config["url"].(string)
-		sinkMethod, _ := flowDef.Sink.Config["method"].(string)
+		sinkURL, _ := sc.Config["url"].(string)
+		sinkMethod, _ := sc.Config["method"].(string)
 
 		httpSink, err := httpsink.NewSink(httpsink.Config{
 			URL:    sinkURL,
@@ -312,36 +409,38 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 				InitialInterval: 200 * time.Millisecond,
 				MaxInterval:     30 * time.Second,
 			},
+			CloudEventsMode: ceMode,
+			BatchFormat:     batchFormat,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("http sink: %w", err)
 		}
 		httpSink.SetTracer(tracer)
-		sk = httpSink
+		return httpSink, nil
 
 	case "temporal":
 		tcfg := temporalsink.Config{
-			TaskQueue:    getString(flowDef.Sink.Config, "taskQueue"),
-			WorkflowType: getString(flowDef.Sink.Config, "workflowType"),
+			TaskQueue:    getString(sc.Config, "taskQueue"),
+			WorkflowType: getString(sc.Config, "workflowType"),
 		}
-		if v := getString(flowDef.Sink.Config, "hostPort"); v != "" {
+		if v := getString(sc.Config, "hostPort"); v != "" {
 			tcfg.HostPort = v
 		}
-		if v := getString(flowDef.Sink.Config, "namespace"); v != "" {
+		if v := getString(sc.Config, "namespace"); v != "" {
 			tcfg.Namespace = v
 		}
-		if v := getString(flowDef.Sink.Config, "workflowIdExpr"); v != "" {
+		if v := getString(sc.Config, "workflowIdExpr"); v != "" {
 			tcfg.WorkflowIDExpr = v
 		}
-		if v := getString(flowDef.Sink.Config, "signalName"); v != "" {
+		if v := getString(sc.Config, "signalName"); v != "" {
 			tcfg.SignalName = v
 		}
-		if v := getString(flowDef.Sink.Config, "mode"); v != "" {
+		if v := getString(sc.Config, "mode"); v != "" {
 			tcfg.Mode = temporalsink.Mode(v)
 		}
 
 		// Parse TLS config
-		if tlsRaw, ok := flowDef.Sink.Config["tls"].(map[string]interface{}); ok {
+		if tlsRaw, ok := sc.Config["tls"].(map[string]interface{}); ok {
 			if disabled, ok := tlsRaw["disabled"].(bool); ok {
 				tcfg.TLS.Disabled = disabled
 			}
@@ -363,7 +462,7 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 		}
 
 		// Parse auth config
-		if authRaw, ok := flowDef.Sink.Config["auth"].(map[string]interface{}); ok {
+		if authRaw, ok := sc.Config["auth"].(map[string]interface{}); ok {
 			if v := getString(authRaw, "apiKey"); v != "" {
 				tcfg.Auth.APIKey = v
 			}
@@ -396,7 +495,7 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 		}
 
 		// Parse typed params for cross-SDK compatibility
-		if paramsRaw, ok := flowDef.Sink.Config["params"].([]interface{}); ok {
+		if paramsRaw, ok := sc.Config["params"].([]interface{}); ok {
 			for _, p := range paramsRaw {
 				if pm, ok := p.(map[string]interface{}); ok {
 					if expr := getString(pm, "expr"); expr != "" {
@@ -417,12 +516,12 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 			return nil, fmt.Errorf("temporal sink: %w", err)
 		}
 		tSink.SetTracer(tracer)
-		sk = tSink
+		return tSink, nil
 
 	case "kafka":
-		topic, _ := flowDef.Sink.Config["topic"].(string)
+		topic, _ := sc.Config["topic"].(string)
 
-		clusterName, ok := flowDef.Sink.Config["cluster"].(string)
+		clusterName, ok := sc.Config["cluster"].(string)
 		if !ok || clusterName == "" {
 			return nil, fmt.Errorf("sink config: cluster name is required")
 		}
@@ -435,6 +534,21 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 			Cluster:              &cluster,
 			Topic:                topic,
 			RequireTransactional: commitPolicy == delivery.CommitPolicyKafkaTransaction,
+			CloudEventsMode:      ceMode,
+		}
+
+		// Serialize with a registry schema (optional)
+		if raw, ok := sc.Config["encoding"].(map[string]interface{}); ok {
+			encCfg, err := schema.EncoderConfigFromMap(raw)
+			if err != nil {
+				return nil, fmt.Errorf("sink encoding: %w", err)
+			}
+			if encCfg.Subject == "" {
+				encCfg.Subject = topic + "-value" // TopicNameStrategy
+			}
+			if kafkaSinkCfg.Encoder, err = schema.NewEncoder(encCfg); err != nil {
+				return nil, fmt.Errorf("sink encoding: %w", err)
+			}
 		}
 
 		kSink, err := kafkasink.NewSink(kafkaSinkCfg)
@@ -442,90 +556,11 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 			return nil, fmt.Errorf("kafka sink: %w", err)
 		}
 		kSink.SetTracer(tracer)
-		sk = kSink
+		return kSink, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported sink type: %s", flowDef.Sink.Type)
-	}
-
-	// Build DLQ handler
-	var dlqHandler *dlq.Handler
-	if flowDef.Source.Type == "kafka" {
-		clusterName, _ := flowDef.Source.Config["cluster"].(string)
-		cluster, found := flowDef.Kafka.Clusters[clusterName]
-		if !found {
-			return nil, fmt.Errorf("dlq publisher: cluster %q not found", clusterName)
-		}
-		pub, err := kafka.NewPublisher(&cluster)
-		if err != nil {
-			return nil, fmt.Errorf("dlq publisher: %w", err)
-		}
-		dlqHandler = dlq.NewHandler(pub)
-		if flowDef.ErrorHandling.DeadLetterTopic != "" {
-			dlqHandler = dlq.NewHandler(pub, dlq.WithTopicFunc(func(_ string) string {
-				return flowDef.ErrorHandling.DeadLetterTopic
-			}))
-		}
-	} else {
-		dlqHandler = dlq.NewHandler(&dlq.NoopPublisher{})
-	}
-
-	cfg := pipeline.Config{
-		FlowName:        flowDef.Name,
-		SourceType:      flowDef.Source.Type,
-		PropagateErrors: propagateErrors,
-		CommitPolicy:    commitPolicy,
-	}
-
-	// Apply CloudEvents overrides from config
-	if flowDef.CloudEvents != nil {
-		cfg.CloudEvents = &pipeline.CloudEventsOverrides{
-			ID:              flowDef.CloudEvents.ID,
-			Type:            flowDef.CloudEvents.Type,
-			Source:          flowDef.CloudEvents.Source,
-			Subject:         flowDef.CloudEvents.Subject,
-			Data:            flowDef.CloudEvents.Data,
-			DataContentType: flowDef.CloudEvents.DataContentType,
-			DataSchema:      flowDef.CloudEvents.DataSchema,
-		}
-	}
-
-	// Build interceptor chain with Wasmer runtime support
-	var chain *interceptor.Chain
-	if len(flowDef.Interceptors) > 0 {
-		var interceptors []interceptor.Interceptor
-		factory := wasmimpl.NewFactory()
-
-		for _, ic := range flowDef.Interceptors {
-			switch ic.Type {
-			case "wasm":
-				modulePath := getString(ic.Config, "module")
-				runtimeType := getString(ic.Config, "runtime")
-				if runtimeType == "" {
-					runtimeType = "wazero" // default
-				}
-
-				rtCfg := wasmimpl.Config{
-					Type:       wasmimpl.RuntimeType(runtimeType),
-					ModulePath: modulePath,
-				}
-
-				rt, err := factory.Create(context.Background(), rtCfg)
-				if err != nil {
-					return nil, fmt.Errorf("create wasm runtime for %s: %w", modulePath, err)
-				}
-
-				interceptors = append(interceptors, wasm.New(rt, modulePath))
-				logger.Info("loaded wasm interceptor", "module", modulePath, "runtime", runtimeType)
-
-			default:
-				return nil, fmt.Errorf("unsupported interceptor type: %s", ic.Type)
-			}
-		}
-		chain = interceptor.NewChain(interceptors...)
+		return nil, fmt.Errorf("unsupported sink type: %s", sc.Type)
 	}
-
-	return pipeline.New(cfg, src, transformer, sk, dlqHandler, chain), nil
 }
 
 func getString(m map[string]interface{}, key string) string {
@@ -23,18 +23,29 @@ import (
 	"github.com/lsm/fiso/internal/config"
 	"github.com/lsm/fiso/internal/delivery"
 	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/flowbuild"
 	"github.com/lsm/fiso/internal/interceptor"
 	"github.com/lsm/fiso/internal/interceptor/wasm"
 	internal_kafka "github.com/lsm/fiso/internal/kafka"
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
+	"github.com/lsm/fiso/internal/link/admin"
 	"github.com/lsm/fiso/internal/link/auth"
+	"github.com/lsm/fiso/internal/link/bulkhead"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
 	"github.com/lsm/fiso/internal/link/discovery"
+	"github.com/lsm/fiso/internal/link/envelope"
+	"github.com/lsm/fiso/internal/link/fault"
+	linkheaders "github.com/lsm/fiso/internal/link/headers"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/proxy"
 	"github.com/lsm/fiso/internal/link/ratelimit"
+	"github.com/lsm/fiso/internal/link/rewrite"
+	"github.com/lsm/fiso/internal/link/validation"
 	"github.com/lsm/fiso/internal/observability"
 	"github.com/lsm/fiso/internal/pipeline"
+	"github.com/lsm/fiso/internal/schema"
+	"github.com/lsm/fiso/internal/sink"
 	httpsink "github.com/lsm/fiso/internal/sink/http"
 	kafkasink "github.com/lsm/fiso/internal/sink/kafka"
 	temporalsink "github.com/lsm/fiso/internal/sink/temporal"
@@ -44,7 +55,7 @@ import (
 	kafka_source "github.com/lsm/fiso/internal/source/kafka"
 	"github.com/lsm/fiso/internal/tracing"
 	"github.com/lsm/fiso/internal/transform"
-	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
+	"github.com/lsm/fiso/internal/transform/celext"
 	wasmruntime "github.com/lsm/fiso/internal/wasm"
 	"github.com/lsm/fiso/internal/wasmer"
 )
@@ -118,7 +129,7 @@ func run() error {
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 	reg.MustRegister(collectors.NewGoCollector())
-	_ = observability.NewMetrics(reg)
+	flowMetrics := observability.NewMetrics(reg)
 	linkMetrics := link.NewMetrics(reg)
 
 	// Health server (shared)
@@ -190,7 +201,7 @@ func run() error {
 
 	if len(flows) > 0 {
 		for name, def := range flows {
-			p, err := buildPipeline(def, logger, httpPool, tracer)
+			p, err := buildPipeline(def, logger, httpPool, tracer, flowMetrics)
 			if err != nil {
 				return fmt.Errorf("build flow %s: %w", name, err)
 			}
@@ -238,17 +249,7 @@ func run() error {
 			breakers := make(map[string]*circuitbreaker.Breaker)
 			for _, t := range linkCfg.Targets {
 				if t.CircuitBreaker.Enabled {
-					cbCfg := circuitbreaker.DefaultConfig()
-					if t.CircuitBreaker.FailureThreshold > 0 {
-						cbCfg.FailureThreshold = t.CircuitBreaker.FailureThreshold
-					}
-					if t.CircuitBreaker.SuccessThreshold > 0 {
-						cbCfg.SuccessThreshold = t.CircuitBreaker.SuccessThreshold
-					}
-					if d, parseErr := time.ParseDuration(t.CircuitBreaker.ResetTimeout); parseErr == nil {
-						cbCfg.ResetTimeout = d
-					}
-					breakers[t.Name] = circuitbreaker.New(cbCfg)
+					breakers[t.Name] = circuitbreaker.New(circuitbreaker.FromLinkConfig(t.CircuitBreaker))
 				}
 			}
 
@@ -284,9 +285,52 @@ func run() error {
 				return fmt.Errorf("load link interceptors: %w", err)
 			}
 
+			faults := fault.FromConfig(linkCfg.Targets)
+			bulkheads := bulkhead.FromConfig(linkCfg.Targets, linkMetrics)
+			limiters := adaptive.FromConfig(linkCfg.Targets, linkMetrics)
+			headerPolicies, err := linkheaders.FromConfig(linkCfg.Targets)
+			if err != nil {
+				return fmt.Errorf("compile link header rules: %w", err)
+			}
+			rewriters, err := rewrite.FromConfig(linkCfg.Targets)
+			if err != nil {
+				return fmt.Errorf("compile link rewrite rules: %w", err)
+			}
+			validators, err := validation.FromConfig(linkCfg.Targets)
+			if err != nil {
+				return fmt.Errorf("compile link request validation: %w", err)
+			}
+			envelopes, err := envelope.FromConfig(linkCfg.Targets)
+			if err != nil {
+				return fmt.Errorf("compile link kafka envelopes: %w", err)
+			}
+			if linkCfg.Admin.Enabled {
+				adminToken, err := admin.LoadToken(linkCfg.Admin.TokenRef)
+				if err != nil {
+					return fmt.Errorf("load link admin token: %w", err)
+				}
+				mux.Handle("/admin/", admin.NewHandler(admin.Config{
+					Token:        adminToken,
+					Targets:      store,
+					Breakers:     breakers,
+					Bulkheads:    bulkheads,
+					Adaptive:     limiters,
+					RateLimiter:  rateLimiter,
+					Interceptors: interceptorRegistry,
+					Faults:       faults,
+					Logger:       logger,
+				}))
+			}
+
 			handlerCfg := proxy.Config{
 				Targets:       store,
 				Breakers:      breakers,
+				Bulkheads:     bulkheads,
+				Adaptive:      limiters,
+				Headers:       headerPolicies,
+				Rewriters:     rewriters,
+				Validators:    validators,
+				Envelopes:     envelopes,
 				RateLimiter:   rateLimiter,
 				Auth:          authProvider,
 				Resolver:      discovery.NewDNSResolver(),
@@ -295,6 +339,7 @@ func run() error {
 				KafkaRegistry: clusterRegistry,
 				KafkaPool:     publisherPool,
 				Interceptors:  interceptorRegistry,
+				Faults:        faults,
 			}
 			handler := proxy.NewHandler(handlerCfg)
 			handler.SetTracer(tracer)
@@ -340,7 +385,7 @@ func run() error {
 	return nil
 }
 
-func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool *httpsource.ServerPool, tracer trace.Tracer) (*pipeline.Pipeline, error) {
+func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool *httpsource.ServerPool, tracer trace.Tracer, metrics *observability.Metrics) (*pipeline.Pipeline, error) {
 	commitPolicy := delivery.NormalizeCommitPolicy(flowDef.ErrorHandling.CommitPolicy)
 	if commitPolicy == delivery.CommitPolicyKafkaTransaction {
 		if flowDef.Source.Type != "kafka" || flowDef.Sink.Type != "kafka" {
@@ -413,75 +458,25 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 
 	var transformer transform.Transformer
 	var err error
-	if flowDef.Transform != nil && len(flowDef.Transform.Fields) > 0 {
-		transformer, err = unifiedxform.NewTransformer(flowDef.Transform.Fields)
+	if flowDef.Transform != nil {
+		transformer, err = flowbuild.Transformer(flowDef.Transform, flowDef.Lookups)
 		if err != nil {
 			return nil, fmt.Errorf("unified transformer: %w", err)
 		}
 	}
 
-	var sk interface {
-		Deliver(context.Context, []byte, map[string]string) error
-		Close() error
-	}
-
-	switch flowDef.Sink.Type {
-	case "http":
-		sinkURL, _ := flowDef.Sink.Config["url"].(string)
-		sinkMethod, _ := flowDef.Sink.Config["method"].(string)
-		httpSink, err := httpsink.NewSink(httpsink.Config{
-			URL:    sinkURL,
-			Method: sinkMethod,
-			Retry:  httpsink.RetryConfig{MaxAttempts: flowDef.ErrorHandling.MaxRetries, InitialInterval: 200 * time.Millisecond, MaxInterval: 30 * time.Second},
+	// Build sink, or the sinks of the routes
+	var sk sink.Sink
+	var router *pipeline.Router
+	if len(flowDef.Routes) > 0 {
+		router, err = flowbuild.Router(flowDef, func(sc config.SinkConfig) (sink.Sink, error) {
+			return buildSink(sc, flowDef, commitPolicy, tracer)
 		})
-		if err != nil {
-			return nil, fmt.Errorf("http sink: %w", err)
-		}
-		httpSink.SetTracer(tracer)
-		sk = httpSink
-
-	case "temporal":
-		tcfg := temporalsink.Config{
-			TaskQueue:    getString(flowDef.Sink.Config, "taskQueue"),
-			WorkflowType: getString(flowDef.Sink.Config, "workflowType"),
-		}
-		if v := getString(flowDef.Sink.Config, "hostPort"); v != "" {
-			tcfg.HostPort = v
-		}
-		if v := getString(flowDef.Sink.Config, "namespace"); v != "" {
-			tcfg.Namespace = v
-		}
-		// ... simpler version of temporal config parsing ...
-		client, err := newTemporalSDKClient(tcfg)
-		if err != nil {
-			return nil, fmt.Errorf("temporal client: %w", err)
-		}
-		tSink, err := temporalsink.NewSink(client, tcfg)
-		if err != nil {
-			return nil, fmt.Errorf("temporal sink: %w", err)
-		}
-		tSink.SetTracer(tracer)
-		sk = tSink
-
-	case "kafka":
-		topic, _ := flowDef.Sink.Config["topic"].(string)
-		clusterName, ok := flowDef.Sink.Config["cluster"].(string)
-		if !ok || clusterName == "" {
-			return nil, fmt.Errorf("sink config: cluster name is required")
-		}
-		cluster, found := flowDef.Kafka.Clusters[clusterName]
-		if !found {
-			return nil, fmt.Errorf("sink config: cluster %q not found", clusterName)
-		}
-		kSink, err := kafkasink.NewSink(kafkasink.Config{Cluster: &cluster, Topic: topic, RequireTransactional: commitPolicy == delivery.CommitPolicyKafkaTransaction})
-		if err != nil {
-			return nil, fmt.Errorf("kafka sink: %w", err)
-		}
-		kSink.SetTracer(tracer)
-		sk = kSink
-
-	default:
-		return nil, fmt.Errorf("unsupported sink type: %s", flowDef.Sink.Type)
+	} else {
+		sk, err = buildSink(flowDef.Sink, flowDef, commitPolicy, tracer)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	// DLQ logic
@@ -506,7 +501,11 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 		dlqHandler = dlq.NewHandler(&dlq.NoopPublisher{})
 	}
 
-	cfg := pipeline.Config{FlowName: flowDef.Name, SourceType: flowDef.Source.Type, PropagateErrors: propagateErrors, CommitPolicy: commitPolicy}
+	cfg := pipeline.Config{FlowName: flowDef.Name, SourceType: flowDef.Source.Type, PropagateErrors: propagateErrors, CommitPolicy: commitPolicy, Router: router, Metrics: metrics, CELFunctions: []celext.Option{celext.WithLookups(flowDef.Lookups)}}
+
+	if err := flowbuild.Stages(flowDef, &cfg); err != nil {
+		return nil, err
+	}
 
 	// Interceptors
 	var chain *interceptor.Chain
@@ -539,6 +538,95 @@ func buildPipeline(flowDef *config.FlowDefinition, logger *slog.Logger, httpPool
 	return pipeline.New(cfg, src, transformer, sk, dlqHandler, chain), nil
 }
 
+// buildSink builds the sink sc of flowDef.
+func buildSink(sc config.SinkConfig, flowDef *config.FlowDefinition, commitPolicy delivery.CommitPolicy, tracer trace.Tracer) (sink.Sink, error) {
+	// CloudEvents content mode shared by the http and kafka sinks
+	var ceMode string
+	if flowDef.CloudEvents != nil {
+		ceMode = flowDef.CloudEvents.Mode
+	}
+	var batchFormat string
+	if flowDef.Batch != nil {
+		batchFormat = flowDef.Batch.Format
+	}
+
+	switch sc.Type {
+	case "http":
+		sinkURL, _ := sc.Config["url"].(string)
+		sinkMethod, _ := sc.Config["method"].(string)
+		httpSink, err := httpsink.NewSink(httpsink.Config{
+			URL:             sinkURL,
+			Method:          sinkMethod,
+			Retry:           httpsink.RetryConfig{MaxAttempts: flowDef.ErrorHandling.MaxRetries, InitialInterval: 200 * time.Millisecond, MaxInterval: 30 * time.Second},
+			CloudEventsMode: ceMode,
+			BatchFormat:     batchFormat,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("http sink: %w", err)
+		}
+		httpSink.SetTracer(tracer)
+		return httpSink, nil
+
+	case "temporal":
+		tcfg := temporalsink.Config{
+			TaskQueue:    getString(sc.Config, "taskQueue"),
+			WorkflowType: getString(sc.Config, "workflowType"),
+		}
+		if v := getString(sc.Config, "hostPort"); v != "" {
+			tcfg.HostPort = v
+		}
+		if v := getString(sc.Config, "namespace"); v != "" {
+			tcfg.Namespace = v
+		}
+		// ... simpler version of temporal config parsing ...
+		client, err := newTemporalSDKClient(tcfg)
+		if err != nil {
+			return nil, fmt.Errorf("temporal client: %w", err)
+		}
+		tSink, err := temporalsink.NewSink(client, tcfg)
+		if err != nil {
+			return nil, fmt.Errorf("temporal sink: %w", err)
+		}
+		tSink.SetTracer(tracer)
+		return tSink, nil
+
+	case "kafka":
+		topic, _ := sc.Config["topic"].(string)
+		clusterName, ok := sc.Config["cluster"].(string)
+		if !ok || clusterName == "" {
+			return nil, fmt.Errorf("sink config: cluster name is required")
+		}
+		cluster, found := flowDef.Kafka.Clusters[clusterName]
+		if !found {
+			return nil, fmt.Errorf("sink config: cluster %q not found", clusterName)
+		}
+		kafkaSinkCfg := kafkasink.Config{Cluster: &cluster, Topic: topic, RequireTransactional: commitPolicy == delivery.CommitPolicyKafkaTransaction, CloudEventsMode: ceMode}
+
+		// Serialize with a registry schema (optional)
+		if raw, ok := sc.Config["encoding"].(map[string]interface{}); ok {
+			encCfg, err := schema.EncoderConfigFromMap(raw)
+			if err != nil {
+				return nil, fmt.Errorf("sink encoding: %w", err)
+			}
+			if encCfg.Subject == "" {
+				encCfg.Subject = topic + "-value" // TopicNameStrategy
+			}
+			if kafkaSinkCfg.Encoder, err = schema.NewEncoder(encCfg); err != nil {
+				return nil, fmt.Errorf("sink encoding: %w", err)
+			}
+		}
+		kSink, err := kafkasink.NewSink(kafkaSinkCfg)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sink: %w", err)
+		}
+		kSink.SetTracer(tracer)
+		return kSink, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported sink type: %s", sc.Type)
+	}
+}
+
 func getString(m map[string]interface{}, key string) string {
 	v, _ := m[key].(string)
 	return v
@@ -20,12 +20,20 @@ import (
 
 	"github.com/lsm/fiso/internal/kafka"
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
+	"github.com/lsm/fiso/internal/link/admin"
 	"github.com/lsm/fiso/internal/link/auth"
+	"github.com/lsm/fiso/internal/link/bulkhead"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
 	"github.com/lsm/fiso/internal/link/discovery"
+	"github.com/lsm/fiso/internal/link/envelope"
+	"github.com/lsm/fiso/internal/link/fault"
+	linkheaders "github.com/lsm/fiso/internal/link/headers"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/proxy"
 	"github.com/lsm/fiso/internal/link/ratelimit"
+	"github.com/lsm/fiso/internal/link/rewrite"
+	"github.com/lsm/fiso/internal/link/validation"
 	"github.com/lsm/fiso/internal/observability"
 	"github.com/lsm/fiso/internal/tracing"
 	"github.com/lsm/fiso/internal/wasmer"
@@ -151,17 +159,7 @@ func run() error {
 	breakers := make(map[string]*circuitbreaker.Breaker)
 	for _, t := range cfg.Targets {
 		if t.CircuitBreaker.Enabled {
-			cbCfg := circuitbreaker.DefaultConfig()
-			if t.CircuitBreaker.FailureThreshold > 0 {
-				cbCfg.FailureThreshold = t.CircuitBreaker.FailureThreshold
-			}
-			if t.CircuitBreaker.SuccessThreshold > 0 {
-				cbCfg.SuccessThreshold = t.CircuitBreaker.SuccessThreshold
-			}
-			if d, parseErr := time.ParseDuration(t.CircuitBreaker.ResetTimeout); parseErr == nil {
-				cbCfg.ResetTimeout = d
-			}
-			breakers[t.Name] = circuitbreaker.New(cbCfg)
+			breakers[t.Name] = circuitbreaker.New(circuitbreaker.FromLinkConfig(t.CircuitBreaker))
 		}
 	}
 
@@ -208,10 +206,49 @@ func run() error {
 		return fmt.Errorf("load interceptors: %w", err)
 	}
 
+	// Build fault injector
+	faults := fault.FromConfig(cfg.Targets)
+
+	// Build bulkheads
+	bulkheads := bulkhead.FromConfig(cfg.Targets, metrics)
+
+	// Build adaptive concurrency limiters
+	limiters := adaptive.FromConfig(cfg.Targets, metrics)
+
+	// Compile header rules
+	headerPolicies, err := linkheaders.FromConfig(cfg.Targets)
+	if err != nil {
+		return fmt.Errorf("compile header rules: %w", err)
+	}
+
+	// Compile rewrite rules
+	rewriters, err := rewrite.FromConfig(cfg.Targets)
+	if err != nil {
+		return fmt.Errorf("compile rewrite rules: %w", err)
+	}
+
+	// Compile request validation
+	validators, err := validation.FromConfig(cfg.Targets)
+	if err != nil {
+		return fmt.Errorf("compile request validation: %w", err)
+	}
+
+	// Compile Kafka CloudEvents and schema policies
+	envelopes, err := envelope.FromConfig(cfg.Targets)
+	if err != nil {
+		return fmt.Errorf("compile kafka envelopes: %w", err)
+	}
+
 	// Build proxy handler
 	handlerCfg := proxy.Config{
 		Targets:       store,
 		Breakers:      breakers,
+		Bulkheads:     bulkheads,
+		Adaptive:      limiters,
+		Headers:       headerPolicies,
+		Rewriters:     rewriters,
+		Validators:    validators,
+		Envelopes:     envelopes,
 		RateLimiter:   rateLimiter,
 		Auth:          authProvider,
 		Resolver:      discovery.NewDNSResolver(),
@@ -220,6 +257,7 @@ func run() error {
 		KafkaRegistry: clusterRegistry,
 		KafkaPool:     publisherPool,
 		Interceptors:  interceptorRegistry,
+		Faults:        faults,
 	}
 	handler := proxy.NewHandler(handlerCfg)
 	// Set tracer for instrumentation
@@ -234,6 +272,26 @@ func run() error {
 	metricsMux.Handle("GET /healthz", health.Handler())
 	metricsMux.Handle("GET /readyz", health.Handler())
 
+	// Admin API (token protected)
+	if cfg.Admin.Enabled {
+		adminToken, err := admin.LoadToken(cfg.Admin.TokenRef)
+		if err != nil {
+			return fmt.Errorf("load admin token: %w", err)
+		}
+		metricsMux.Handle("/admin/", admin.NewHandler(admin.Config{
+			Token:        adminToken,
+			Targets:      store,
+			Breakers:     breakers,
+			Bulkheads:    bulkheads,
+			Adaptive:     limiters,
+			RateLimiter:  rateLimiter,
+			Interceptors: interceptorRegistry,
+			Faults:       faults,
+			Logger:       logger,
+		}))
+		logger.Info("admin API enabled", "addr", cfg.MetricsAddr)
+	}
+
 	metricsServer := &http.Server{
 		Addr:    cfg.MetricsAddr,
 		Handler: metricsMux,
@@ -22,6 +22,8 @@ Commands:
   logs                  Show logs from fiso services
   produce               Produce test events to Kafka
   consume               Consume and display events from Kafka
+  link status           Show runtime state of a running fiso-link
+  link import-openapi   Generate a link target from an OpenAPI document
 
 Run 'fiso <command> -h' for help on a specific command.`
 
@@ -57,6 +59,8 @@ func run() error {
 		return cli.RunProduce(os.Args[2:])
 	case "consume":
 		return cli.RunConsume(os.Args[2:])
+	case "link":
+		return cli.RunLink(os.Args[2:])
 	case "-h", "--help", "help":
 		fmt.Println(usage)
 		return nil
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lsm/fiso/internal/link/admin"
+)
+
+// linkHTTPClient is the client used to call the fiso-link admin API.
+// Tests can replace this to point at a stub server.
+var linkHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// RunLink dispatches link subcommands.
+func RunLink(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Println(`Usage: fiso link <command> [arguments]
+
+Commands:
+  status            Show runtime state of a running fiso-link
+  import-openapi    Generate a link target from an OpenAPI 3 document
+
+Run 'fiso link <command> -h' for help on a specific command.`)
+		return nil
+	}
+
+	switch args[0] {
+	case "status":
+		return RunLinkStatus(args[1:], os.Stdout)
+	case "import-openapi":
+		return RunLinkImportOpenAPI(args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown link subcommand %q\nRun 'fiso link -h' for usage", args[0])
+	}
+}
+
+// RunLinkStatus prints the state of each target reported by the admin API.
+func RunLinkStatus(args []string, out io.Writer) error {
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		fmt.Fprintln(out, `Usage: fiso link status [--addr <url>] [--token <token>] [--target <name>] [--json]
+
+Shows circuit breaker, rate limiter, interceptor and fault injection state
+for each target of a running fiso-link. Requires the admin API to be enabled
+(admin.enabled in the link config).
+
+Flags:
+  --addr     Admin API base URL (default: http://localhost:9090)
+  --token    Admin token (default: $FISO_LINK_ADMIN_TOKEN)
+  --target   Show a single target
+  --json     Print the raw JSON response
+
+Examples:
+  fiso link status
+  fiso link status --addr http://localhost:9091 --target crm`)
+		return nil
+	}
+
+	addr, err := parseStringFlag(args, "--addr")
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = "http://localhost:9090"
+	}
+	token, err := parseStringFlag(args, "--token")
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		token = os.Getenv("FISO_LINK_ADMIN_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("admin token required: use --token or set FISO_LINK_ADMIN_TOKEN")
+	}
+	target, err := parseStringFlag(args, "--target")
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimRight(addr, "/") + "/admin/targets"
+	if target != "" {
+		url += "/" + target
+	}
+	body, err := fetchLinkAdmin(url, token)
+	if err != nil {
+		return err
+	}
+
+	if hasFlag(args, "--json") {
+		_, err := out.Write(body)
+		return err
+	}
+
+	var statuses []admin.TargetStatus
+	if target != "" {
+		var st admin.TargetStatus
+		if err := json.Unmarshal(body, &st); err != nil {
+			return fmt.Errorf("decode admin response: %w", err)
+		}
+		statuses = []admin.TargetStatus{st}
+	} else if err := json.Unmarshal(body, &statuses); err != nil {
+		return fmt.Errorf("decode admin response: %w", err)
+	}
+
+	printLinkStatus(out, statuses)
+	return nil
+}
+
+func fetchLinkAdmin(url, token string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := linkHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call admin API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read admin response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("admin API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func printLinkStatus(out io.Writer, statuses []admin.TargetStatus) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TARGET\tPROTOCOL\tBREAKER\tFAILURES\tRATE LIMIT\tINTERCEPTORS\tFAULTS")
+	for _, st := range statuses {
+		breaker, failures := "-", "-"
+		if cb := st.CircuitBreaker; cb != nil {
+			breaker = cb.State
+			if cb.Forced {
+				breaker += " (forced)"
+			}
+			failures = fmt.Sprintf("%d/%d", cb.Failures, cb.FailureThreshold)
+		}
+		rate := "-"
+		if rl := st.RateLimit; rl != nil {
+			rate = fmt.Sprintf("%.1f/%d @ %g/s", rl.Tokens, rl.Burst, rl.Limit)
+		}
+		interceptors := "-"
+		if len(st.Interceptors) > 0 {
+			interceptors = fmt.Sprintf("out:%d in:%d", len(st.Interceptors["outbound"]), len(st.Interceptors["inbound"]))
+		}
+		faults := "off"
+		if st.FaultsEnabled {
+			faults = "on"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", st.Name, st.Protocol, breaker, failures, rate, interceptors, faults)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,646 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/openapi"
+)
+
+// idempotentMethods are marked retryable in imported targets (RFC 9110 §9.2.2).
+var idempotentMethods = map[string]bool{"GET": true, "HEAD": true, "PUT": true, "DELETE": true, "OPTIONS": true}
+
+// RunLinkImportOpenAPI generates a link target, and optionally Go client
+// stubs, from an OpenAPI 3 document.
+func RunLinkImportOpenAPI(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintln(out, `Usage: fiso link import-openapi <spec> [flags]
+
+Generates a fiso-link target from an OpenAPI 3 document (YAML or JSON):
+allowedPaths from its operations, auth from securitySchemes, host and
+basePath from servers, and retries limited to idempotent methods.
+
+Flags:
+  --name       Target name (default: derived from info.title)
+  --server     Server URL to use instead of the first entry of servers
+  --output     Write the target YAML to a file instead of stdout
+  --validate   Validate request bodies against the document (validation.openapi)
+  --client     Also write Go client stubs to this file
+  --package    Package name for the client stubs (default: target name)
+  --link-addr  fiso-link address used by the client (default: http://localhost:3500)
+
+Examples:
+  fiso link import-openapi crm-openapi.yaml --name crm
+  fiso link import-openapi crm.json --validate --client ./crm/client.go`)
+		return nil
+	}
+
+	specPath := args[0]
+	if strings.HasPrefix(specPath, "-") {
+		return fmt.Errorf("spec file is required as the first argument")
+	}
+	flags := args[1:]
+	name, err := parseStringFlag(flags, "--name")
+	if err != nil {
+		return err
+	}
+	server, err := parseStringFlag(flags, "--server")
+	if err != nil {
+		return err
+	}
+	output, err := parseStringFlag(flags, "--output")
+	if err != nil {
+		return err
+	}
+	clientFile, err := parseStringFlag(flags, "--client")
+	if err != nil {
+		return err
+	}
+	pkg, err := parseStringFlag(flags, "--package")
+	if err != nil {
+		return err
+	}
+	linkAddr, err := parseStringFlag(flags, "--link-addr")
+	if err != nil {
+		return err
+	}
+	if linkAddr == "" {
+		linkAddr = "http://localhost:3500"
+	}
+
+	doc, err := openapi.Load(specPath)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", specPath, err)
+	}
+	if name == "" {
+		name = targetName(doc.Title(), specPath)
+	}
+	if server == "" {
+		if servers := doc.Servers(); len(servers) > 0 {
+			server = servers[0]
+		}
+	}
+
+	target, notes, err := importTarget(doc, name, server)
+	if err != nil {
+		return err
+	}
+	if hasFlag(flags, "--validate") {
+		abs, err := filepath.Abs(specPath)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", specPath, err)
+		}
+		target.Validation = &link.ValidationConfig{OpenAPI: abs}
+	}
+	if err := (&link.Config{Targets: []link.LinkTarget{target}}).Validate(); err != nil {
+		return fmt.Errorf("generated target is invalid: %w", err)
+	}
+
+	data, err := marshalTargetYAML(target, filepath.Base(specPath), notes)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		if err := os.WriteFile(output, data, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", output, err)
+		}
+		fmt.Fprintf(out, "Wrote target %q to %s\n", name, output)
+	} else if _, err := out.Write(data); err != nil {
+		return err
+	}
+
+	if clientFile != "" {
+		if pkg == "" {
+			pkg = packageName(name)
+		}
+		src, err := generateLinkClient(doc, name, pkg, strings.TrimRight(linkAddr, "/"), filepath.Base(specPath))
+		if err != nil {
+			return fmt.Errorf("generate client: %w", err)
+		}
+		if dir := filepath.Dir(clientFile); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("create %s: %w", dir, err)
+			}
+		}
+		if err := os.WriteFile(clientFile, src, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", clientFile, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote client stubs to %s\n", clientFile)
+	}
+	return nil
+}
+
+// importTarget builds a link target from an OpenAPI document. notes describe
+// settings that could not be derived and need manual attention.
+func importTarget(doc *openapi.Document, name, server string) (link.LinkTarget, []string, error) {
+	target := link.LinkTarget{
+		Name: name,
+		CircuitBreaker: link.CircuitBreakerConfig{
+			Enabled:          true,
+			FailureThreshold: 5,
+			ResetTimeout:     "30s",
+		},
+		Retry: link.RetryConfig{
+			MaxAttempts:     3,
+			Backoff:         "exponential",
+			InitialInterval: "200ms",
+			MaxInterval:     "30s",
+			Jitter:          0.2,
+		},
+	}
+
+	if server == "" {
+		return target, nil, fmt.Errorf("document has no servers; use --server")
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Hostname() == "" {
+		return target, nil, fmt.Errorf("server URL %q has no host; use --server", server)
+	}
+	target.Protocol = u.Scheme
+	target.Host = u.Hostname()
+	if p := u.Port(); p != "" {
+		target.Port, _ = strconv.Atoi(p)
+	}
+	target.BasePath = strings.TrimRight(u.Path, "/")
+
+	ops, err := doc.Operations()
+	if err != nil {
+		return target, nil, err
+	}
+
+	// Group methods by path pattern, keeping the document's method order.
+	var patterns []string
+	methods := make(map[string][]string)
+	retryable := make(map[string]bool)
+	var security []string
+	seenScheme := make(map[string]bool)
+	for _, op := range ops {
+		pattern, _ := openapi.PathPattern(op.Path)
+		if _, ok := methods[pattern]; !ok {
+			patterns = append(patterns, pattern)
+		}
+		if !containsString(methods[pattern], op.Method) {
+			methods[pattern] = append(methods[pattern], op.Method)
+		}
+		if idempotentMethods[op.Method] {
+			retryable[op.Method] = true
+		}
+		for _, s := range op.Security {
+			if !seenScheme[s] {
+				seenScheme[s] = true
+				security = append(security, s)
+			}
+		}
+	}
+	for _, p := range patterns {
+		target.AllowedPaths = append(target.AllowedPaths, strings.Join(methods[p], ",")+" "+p)
+	}
+	for _, m := range []string{"GET", "HEAD", "PUT", "DELETE", "OPTIONS"} {
+		if retryable[m] {
+			target.Retry.RetryableMethods = append(target.Retry.RetryableMethods, m)
+		}
+	}
+
+	notes, err := importAuth(doc, &target, security)
+	return target, notes, err
+}
+
+// importAuth maps the first security scheme used by the document's
+// operations to the target's auth or header configuration.
+func importAuth(doc *openapi.Document, target *link.LinkTarget, used []string) ([]string, error) {
+	target.Auth.Type = "none"
+	if len(used) == 0 {
+		return nil, nil
+	}
+	schemes, err := doc.SecuritySchemes()
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []string
+	if len(used) > 1 {
+		notes = append(notes, fmt.Sprintf("operations use several security schemes (%s); only %s was imported", strings.Join(used, ", "), used[0]))
+	}
+	scheme, ok := schemes[used[0]]
+	if !ok {
+		return append(notes, fmt.Sprintf("security scheme %q is not defined in components.securitySchemes", used[0])), nil
+	}
+
+	envPrefix := strings.ToUpper(strings.ReplaceAll(target.Name, "-", "_"))
+	switch {
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		target.Auth = link.AuthConfig{Type: "basic", SecretRef: &link.SecretRef{EnvVar: envPrefix + "_CREDENTIALS"}}
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+		target.Auth = link.AuthConfig{Type: "bearer", SecretRef: &link.SecretRef{EnvVar: envPrefix + "_TOKEN"}}
+	case scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+		target.Auth = link.AuthConfig{Type: "bearer", SecretRef: &link.SecretRef{EnvVar: envPrefix + "_TOKEN"}}
+		notes = append(notes, fmt.Sprintf("security scheme %q is %s; provide an access token in $%s_TOKEN or switch to vaultRef", used[0], scheme.Type, envPrefix))
+	case scheme.Type == "apiKey" && scheme.In == "header" && strings.EqualFold(scheme.Name, "Authorization"):
+		target.Auth = link.AuthConfig{Type: "apikey", SecretRef: &link.SecretRef{EnvVar: envPrefix + "_API_KEY"}}
+	case scheme.Type == "apiKey" && scheme.In == "header":
+		target.Headers = &link.HeadersConfig{Request: []link.HeaderRule{{
+			Action:    "set",
+			Name:      scheme.Name,
+			ValueFrom: &link.HeaderValueSource{SecretRef: &link.SecretRef{EnvVar: envPrefix + "_API_KEY"}},
+		}}}
+	default:
+		notes = append(notes, fmt.Sprintf("security scheme %q (type %s, in %s) is not supported; configure auth manually", used[0], scheme.Type, scheme.In))
+	}
+	return notes, nil
+}
+
+// marshalTargetYAML renders a target as a "targets:" snippet, omitting zero
+// values so only imported settings are shown.
+func marshalTargetYAML(target link.LinkTarget, source string, notes []string) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(struct {
+		Targets []link.LinkTarget `yaml:"targets"`
+	}{[]link.LinkTarget{target}}); err != nil {
+		return nil, fmt.Errorf("encode target: %w", err)
+	}
+	pruneZero(&node)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by fiso link import-openapi from %s.\n", source)
+	for _, n := range notes {
+		fmt.Fprintf(&buf, "# NOTE: %s\n", n)
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("encode target: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode target: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pruneZero removes mapping entries whose values are zero scalars or empty
+// collections.
+func pruneZero(n *yaml.Node) {
+	for _, c := range n.Content {
+		pruneZero(c)
+	}
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	kept := n.Content[:0]
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if isZeroNode(n.Content[i+1]) {
+			continue
+		}
+		kept = append(kept, n.Content[i], n.Content[i+1])
+	}
+	n.Content = kept
+}
+
+func isZeroNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!null":
+			return true
+		case "!!bool":
+			return n.Value == "false"
+		case "!!int", "!!float":
+			return n.Value == "0"
+		case "!!str":
+			return n.Value == ""
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	}
+	return false
+}
+
+// targetName derives a target name from the document title or file name.
+func targetName(title, specPath string) string {
+	base := title
+	if base == "" {
+		base = strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath))
+	}
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(base) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(b.String(), "-"), "-api")
+	if name == "" {
+		return "api"
+	}
+	return name
+}
+
+// packageName turns a target name into a Go package name.
+func packageName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) || token.IsKeyword(pkg) {
+		pkg = "client" + pkg
+	}
+	return pkg
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generateLinkClient renders Go client stubs that call a target through
+// fiso-link: one struct per object schema in components.schemas and one
+// method per operation.
+func generateLinkClient(doc *openapi.Document, target, pkg, linkAddr, source string) ([]byte, error) {
+	ops, err := doc.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// DefaultBaseURL is the fiso-link route of the %s target.\n", target)
+	fmt.Fprintf(&b, "const DefaultBaseURL = %q\n\n", linkAddr+"/link/"+target)
+	b.WriteString(`// Client calls the target through fiso-link, which adds auth, retries and
+// circuit breaking.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for DefaultBaseURL.
+func NewClient() *Client {
+	return &Client{BaseURL: DefaultBaseURL, HTTPClient: http.DefaultClient}
+}
+
+`)
+
+	schemas := doc.Schemas()
+	names := make([]string, 0, len(schemas))
+	for n := range schemas {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		writeSchemaType(&b, n, schemas[n])
+	}
+
+	used := make(map[string]bool)
+	needsFmt := false
+	for _, op := range ops {
+		writeOperation(&b, op, used, &needsFmt)
+	}
+
+	b.WriteString(`func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+`)
+
+	var head strings.Builder
+	fmt.Fprintf(&head, "// Code generated by fiso link import-openapi from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&head, "// Package %s calls the %q fiso-link target.\n", pkg, target)
+	fmt.Fprintf(&head, "package %s\n\n", pkg)
+	head.WriteString("import (\n\t\"bytes\"\n\t\"context\"\n\t\"encoding/json\"\n")
+	if needsFmt {
+		head.WriteString("\t\"fmt\"\n")
+	}
+	head.WriteString("\t\"io\"\n\t\"net/http\"\n\t\"net/url\"\n)\n\n")
+	return format.Source([]byte(head.String() + b.String()))
+}
+
+func writeSchemaType(b *strings.Builder, name string, schema interface{}) {
+	sm, _ := schema.(map[string]interface{})
+	typeName := goIdent(name)
+	fmt.Fprintf(b, "// %s is the %s schema.\n", typeName, name)
+	if desc, _ := sm["description"].(string); desc != "" {
+		fmt.Fprintf(b, "//\n// %s\n", firstLine(desc))
+	}
+	props, _ := sm["properties"].(map[string]interface{})
+	if props == nil {
+		fmt.Fprintf(b, "type %s %s\n\n", typeName, goType(sm, false))
+		return
+	}
+
+	required := make(map[string]bool)
+	if req, ok := sm["required"].([]interface{}); ok {
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	fields := make([]string, 0, len(props))
+	for f := range props {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	fmt.Fprintf(b, "type %s struct {\n", typeName)
+	for _, f := range fields {
+		tag := f
+		if !required[f] {
+			tag += ",omitempty"
+		}
+		ps, _ := props[f].(map[string]interface{})
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", goIdent(f), goType(ps, true), tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// goType maps a schema to a Go type. Schema references inside structs are
+// pointers so recursive schemas compile.
+func goType(s map[string]interface{}, field bool) string {
+	if ref, ok := s["$ref"].(string); ok {
+		t := goIdent(ref[strings.LastIndex(ref, "/")+1:])
+		if field {
+			return "*" + t
+		}
+		return t
+	}
+	switch s["type"] {
+	case "string":
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		items, _ := s["items"].(map[string]interface{})
+		return "[]" + goType(items, false)
+	case "object":
+		if ap, ok := s["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + goType(ap, false)
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+func writeOperation(b *strings.Builder, op openapi.Operation, used map[string]bool, needsFmt *bool) {
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(op.Method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(op.Path)
+	}
+	method := goIdent(name)
+	for i := 2; used[method]; i++ {
+		method = goIdent(name) + strconv.Itoa(i)
+	}
+	used[method] = true
+
+	params := []string{"ctx context.Context"}
+	reserved := map[string]bool{"ctx": true, "query": true, "body": true, "c": true}
+	pathParams := make(map[string]openapi.Parameter)
+	hasQuery := false
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathParams[p.Name] = p
+		case "query":
+			hasQuery = true
+		}
+	}
+	var parts []string
+	literal := ""
+	for _, seg := range strings.SplitAfter(op.Path, "/") {
+		trimmed := strings.TrimSuffix(seg, "/")
+		if !strings.HasPrefix(trimmed, "{") || !strings.HasSuffix(trimmed, "}") {
+			literal += seg
+			continue
+		}
+		pname := trimmed[1 : len(trimmed)-1]
+		arg := goParam(pname, reserved)
+		typ := goType(pathParams[pname].Schema, false)
+		if typ == "any" {
+			typ = "string"
+		}
+		params = append(params, arg+" "+typ)
+		if literal != "" {
+			parts = append(parts, strconv.Quote(literal))
+		}
+		if typ == "string" {
+			parts = append(parts, "url.PathEscape("+arg+")")
+		} else {
+			parts = append(parts, "url.PathEscape(fmt.Sprint("+arg+"))")
+			*needsFmt = true
+		}
+		literal = strings.TrimPrefix(seg, trimmed)
+	}
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(literal))
+	}
+	pathExpr := strings.Join(parts, " + ")
+	queryArg := "nil"
+	if hasQuery {
+		params = append(params, "query url.Values")
+		queryArg = "query"
+	}
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		bodyType := "any"
+		if s, ok := op.JSONSchema().(map[string]interface{}); ok {
+			bodyType = goType(s, true)
+		}
+		params = append(params, "body "+bodyType)
+		bodyArg = "body"
+	}
+
+	if op.Summary != "" {
+		fmt.Fprintf(b, "// %s calls %s %s: %s\n", method, op.Method, op.Path, firstLine(op.Summary))
+	} else {
+		fmt.Fprintf(b, "// %s calls %s %s.\n", method, op.Method, op.Path)
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (*http.Response, error) {\n", method, strings.Join(params, ", "))
+	fmt.Fprintf(b, "\treturn c.do(ctx, %q, %s, %s, %s)\n}\n\n", op.Method, pathExpr, queryArg, bodyArg)
+}
+
+// goIdent converts a name such as "get-customer_by id" to an exported Go
+// identifier ("GetCustomerById").
+func goIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
+
+// goParam converts a parameter name to an unexported Go identifier that does
+// not clash with keywords or the generated method's own variables.
+func goParam(s string, reserved map[string]bool) string {
+	id := goIdent(s)
+	id = strings.ToLower(id[:1]) + id[1:]
+	if token.IsKeyword(id) || reserved[id] {
+		id += "Param"
+	}
+	return id
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+const importTestSpec = `openapi: 3.0.3
+info: {title: CRM API, version: "2"}
+servers:
+  - url: https://api.crm.example.com:8443/v2/
+security:
+  - bearerAuth: []
+paths:
+  /customers:
+    get:
+      operationId: listCustomers
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+    post:
+      operationId: create-customer
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Customer'}
+  /customers/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer}}
+    get: {}
+    delete: {}
+  /customers/{id}/notes/{type}:
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema: {type: array, items: {type: string}}
+components:
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+  schemas:
+    Customer:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Customer'}
+`
+
+func writeImportSpec(t *testing.T, spec string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "crm.yaml")
+	if err := os.WriteFile(p, []byte(spec), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunLinkImportOpenAPI_Target(t *testing.T) {
+	spec := writeImportSpec(t, importTestSpec)
+	output := filepath.Join(t.TempDir(), "link.yaml")
+
+	var out bytes.Buffer
+	if err := RunLinkImportOpenAPI([]string{spec, "--output", output, "--validate"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), `Wrote target "crm"`) {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	cfg, err := link.LoadConfig(output)
+	if err != nil {
+		t.Fatalf("generated config does not load: %v", err)
+	}
+	target := cfg.Targets[0]
+	if target.Name != "crm" || target.Protocol != "https" || target.Host != "api.crm.example.com" || target.Port != 8443 || target.BasePath != "/v2" {
+		t.Errorf("unexpected server settings: %+v", target)
+	}
+	wantPaths := []string{"GET,POST /customers", "GET,DELETE /customers/*", "PUT /customers/*/notes/*"}
+	if !reflect.DeepEqual(target.AllowedPaths, wantPaths) {
+		t.Errorf("expected allowedPaths %v, got %v", wantPaths, target.AllowedPaths)
+	}
+	if !reflect.DeepEqual(target.Retry.RetryableMethods, []string{"GET", "PUT", "DELETE"}) {
+		t.Errorf("unexpected retryable methods %v", target.Retry.RetryableMethods)
+	}
+	if target.Auth.Type != "bearer" || target.Auth.SecretRef == nil || target.Auth.SecretRef.EnvVar != "CRM_TOKEN" {
+		t.Errorf("unexpected auth %+v", target.Auth)
+	}
+	if target.Validation == nil || target.Validation.OpenAPI == "" {
+		t.Error("expected validation.openapi to be set")
+	}
+}
+
+func TestRunLinkImportOpenAPI_APIKeyHeader(t *testing.T) {
+	spec := writeImportSpec(t, strings.NewReplacer(
+		"bearerAuth: []", "key: []",
+		"bearerAuth: {type: http, scheme: bearer}", "key: {type: apiKey, in: header, name: X-API-Key}",
+	).Replace(importTestSpec))
+
+	var out bytes.Buffer
+	if err := RunLinkImportOpenAPI([]string{spec, "--name", "my-crm"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"name: my-crm", "type: none", "name: X-API-Key", "envVar: MY_CRM_API_KEY"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunLinkImportOpenAPI_Client(t *testing.T) {
+	spec := writeImportSpec(t, importTestSpec)
+	clientFile := filepath.Join(t.TempDir(), "crmclient", "client.go")
+
+	var out bytes.Buffer
+	if err := RunLinkImportOpenAPI([]string{spec, "--client", clientFile, "--link-addr", "http://127.0.0.1:3500/"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src, err := os.ReadFile(clientFile)
+	if err != nil {
+		t.Fatalf("read client: %v", err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), clientFile, src, 0)
+	if err != nil {
+		t.Fatalf("generated client does not parse: %v", err)
+	}
+	if f.Name.Name != "crm" {
+		t.Errorf("expected package crm, got %s", f.Name.Name)
+	}
+	for _, want := range []string{
+		`const DefaultBaseURL = "http://127.0.0.1:3500/link/crm"`,
+		"func (c *Client) ListCustomers(ctx context.Context, query url.Values)",
+		"func (c *Client) CreateCustomer(ctx context.Context, body *Customer)",
+		"func (c *Client) GetCustomersById(ctx context.Context, id int64)",
+		"func (c *Client) PutCustomersByIdNotesByType(ctx context.Context, id string, typeParam string, body []string)",
+		"Parent *Customer `json:\"parent,omitempty\"`",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("client missing %q", want)
+		}
+	}
+}
+
+func TestRunLinkImportOpenAPI_Errors(t *testing.T) {
+	var out bytes.Buffer
+	if err := RunLinkImportOpenAPI([]string{"-h"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RunLinkImportOpenAPI([]string{"--name", "x"}, &out); err == nil {
+		t.Error("expected error without spec")
+	}
+	if err := RunLinkImportOpenAPI([]string{"/nonexistent.yaml"}, &out); err == nil {
+		t.Error("expected error for missing spec")
+	}
+	noServers := writeImportSpec(t, strings.Replace(importTestSpec, "  - url: https://api.crm.example.com:8443/v2/\n", "  - url: /v2\n", 1))
+	if err := RunLinkImportOpenAPI([]string{noServers}, &out); err == nil || !strings.Contains(err.Error(), "--server") {
+		t.Errorf("expected --server hint for relative server, got %v", err)
+	}
+	if err := RunLinkImportOpenAPI([]string{noServers, "--server", "http://localhost:8080"}, &out); err != nil {
+		t.Errorf("unexpected error with --server: %v", err)
+	}
+}
+
+func TestTargetAndPackageNames(t *testing.T) {
+	if got := targetName("Acme CRM API", "x.yaml"); got != "acme-crm" {
+		t.Errorf("targetName = %q", got)
+	}
+	if got := targetName("", "/specs/billing_v2.json"); got != "billing-v2" {
+		t.Errorf("targetName = %q", got)
+	}
+	if got := packageName("my-crm"); got != "mycrm" {
+		t.Errorf("packageName = %q", got)
+	}
+	if got := packageName("2go"); got != "client2go" {
+		t.Errorf("packageName = %q", got)
+	}
+}
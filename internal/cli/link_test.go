@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/link/admin"
+	"github.com/lsm/fiso/internal/link/ratelimit"
+)
+
+func newLinkAdminStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	statuses := []admin.TargetStatus{
+		{
+			Name:     "crm",
+			Protocol: "https",
+			CircuitBreaker: &admin.BreakerStatus{
+				State: "open", Forced: true, Failures: 2, FailureThreshold: 5,
+			},
+			RateLimit:     &ratelimit.Status{Target: "crm", Limit: 10, Burst: 20, Tokens: 12.5},
+			Interceptors:  map[string][]string{"outbound": {"a.wasm", "b.wasm"}},
+			FaultsEnabled: true,
+		},
+		{Name: "billing", Protocol: "http"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/admin/targets":
+			_ = json.NewEncoder(w).Encode(statuses)
+		case "/admin/targets/billing":
+			_ = json.NewEncoder(w).Encode(statuses[1])
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRunLink_Help(t *testing.T) {
+	if err := RunLink([]string{"-h"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RunLink([]string{"bogus"}); err == nil {
+		t.Fatal("expected error for unknown subcommand")
+	}
+}
+
+func TestRunLinkStatus_Table(t *testing.T) {
+	srv := newLinkAdminStub(t)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if err := RunLinkStatus([]string{"--addr", srv.URL, "--token", "tok"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"TARGET", "open (forced)", "2/5", "12.5/20 @ 10/s", "out:2 in:0", "billing"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunLinkStatus_SingleTargetJSON(t *testing.T) {
+	srv := newLinkAdminStub(t)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if err := RunLinkStatus([]string{"--addr", srv.URL + "/", "--token", "tok", "--target", "billing", "--json"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var st admin.TargetStatus
+	if err := json.Unmarshal(out.Bytes(), &st); err != nil {
+		t.Fatalf("expected raw JSON output: %v", err)
+	}
+	if st.Name != "billing" {
+		t.Errorf("expected billing, got %q", st.Name)
+	}
+}
+
+func TestRunLinkStatus_TokenFromEnv(t *testing.T) {
+	srv := newLinkAdminStub(t)
+	defer srv.Close()
+
+	t.Setenv("FISO_LINK_ADMIN_TOKEN", "tok")
+	var out bytes.Buffer
+	if err := RunLinkStatus([]string{"--addr", srv.URL}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunLinkStatus_Errors(t *testing.T) {
+	srv := newLinkAdminStub(t)
+	defer srv.Close()
+
+	t.Setenv("FISO_LINK_ADMIN_TOKEN", "")
+	var out bytes.Buffer
+	if err := RunLinkStatus([]string{"--addr", srv.URL}, &out); err == nil || !strings.Contains(err.Error(), "admin token required") {
+		t.Errorf("expected missing token error, got %v", err)
+	}
+	if err := RunLinkStatus([]string{"--addr", srv.URL, "--token", "wrong"}, &out); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+	if err := RunLinkStatus([]string{"--addr", srv.URL, "--token", "tok", "--target", "nope"}, &out); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+	if err := RunLinkStatus([]string{"--addr"}, &out); err == nil {
+		t.Error("expected error for --addr without value")
+	}
+}
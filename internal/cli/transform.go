@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/lsm/fiso/internal/config"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
 	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
 )
 
@@ -42,8 +46,13 @@ func RunTransformTest(args []string) error {
 Test a transform configuration without starting the full stack.
 
 Options:
-  --flow <path>    Path to flow YAML file (required)
-  --input <data>   Input JSON as string or path to JSON file (required)
+  --flow <path>           Path to flow YAML file (required)
+  --input <data>          Input JSON as string or path to JSON file (required)
+  --header <name=value>   Source event header, available as headers (repeatable)
+  --key <key>             Source event key, available as key
+  --topic <topic>         Source event topic, available as topic
+  --now <time>            RFC 3339 time returned by fiso.now() (default: current time)
+  --uuid <uuid>           Value returned by fiso.uuid() (default: random UUID)
 
 Examples:
   # Test with inline JSON
@@ -53,11 +62,19 @@ Examples:
   fiso transform test --flow fiso/flows/order-flow.yaml --input sample-order.json
 
   # Test with JSONL file (first line only)
-  fiso transform test --flow fiso/flows/order-flow.yaml --input sample-orders.jsonl`)
+  fiso transform test --flow fiso/flows/order-flow.yaml --input sample-orders.jsonl
+
+  # Test with source event metadata
+  fiso transform test --flow fiso/flows/order-flow.yaml --input sample-order.json --header x-tenant=acme --key ORD-1
+
+  # Test with a fixed time and UUID for reproducible output
+  fiso transform test --flow fiso/flows/order-flow.yaml --input sample-order.json --now 2024-01-01T00:00:00Z --uuid 00000000-0000-4000-8000-000000000000`)
 		return nil
 	}
 
-	var flowPath, inputData string
+	var flowPath, inputData, nowArg, uuidArg string
+	var evt source.Event
+	var headerArgs []string
 	for i := 0; i < len(args); i++ {
 		switch {
 		case args[i] == "--flow" && i+1 < len(args):
@@ -66,11 +83,58 @@ Examples:
 		case args[i] == "--input" && i+1 < len(args):
 			inputData = args[i+1]
 			i++
+		case args[i] == "--header" && i+1 < len(args):
+			headerArgs = append(headerArgs, args[i+1])
+			i++
+		case args[i] == "--key" && i+1 < len(args):
+			evt.Key = []byte(args[i+1])
+			i++
+		case args[i] == "--topic" && i+1 < len(args):
+			evt.Topic = args[i+1]
+			i++
+		case args[i] == "--now" && i+1 < len(args):
+			nowArg = args[i+1]
+			i++
+		case args[i] == "--uuid" && i+1 < len(args):
+			uuidArg = args[i+1]
+			i++
 		case strings.HasPrefix(args[i], "--flow="):
 			flowPath = strings.TrimPrefix(args[i], "--flow=")
 		case strings.HasPrefix(args[i], "--input="):
 			inputData = strings.TrimPrefix(args[i], "--input=")
+		case strings.HasPrefix(args[i], "--header="):
+			headerArgs = append(headerArgs, strings.TrimPrefix(args[i], "--header="))
+		case strings.HasPrefix(args[i], "--key="):
+			evt.Key = []byte(strings.TrimPrefix(args[i], "--key="))
+		case strings.HasPrefix(args[i], "--topic="):
+			evt.Topic = strings.TrimPrefix(args[i], "--topic=")
+		case strings.HasPrefix(args[i], "--now="):
+			nowArg = strings.TrimPrefix(args[i], "--now=")
+		case strings.HasPrefix(args[i], "--uuid="):
+			uuidArg = strings.TrimPrefix(args[i], "--uuid=")
+		}
+	}
+	for _, h := range headerArgs {
+		name, value, ok := strings.Cut(h, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("--header %q must be name=value", h)
+		}
+		if evt.Headers == nil {
+			evt.Headers = make(map[string]string)
+		}
+		evt.Headers[name] = value
+	}
+
+	var functions []celext.Option
+	if nowArg != "" {
+		now, err := time.Parse(time.RFC3339Nano, nowArg)
+		if err != nil {
+			return fmt.Errorf("--now %q must be an RFC 3339 time", nowArg)
 		}
+		functions = append(functions, celext.WithClock(func() time.Time { return now }))
+	}
+	if uuidArg != "" {
+		functions = append(functions, celext.WithUUID(func() string { return uuidArg }))
 	}
 
 	if flowPath == "" {
@@ -86,7 +150,7 @@ Examples:
 		return fmt.Errorf("load flow: %w", err)
 	}
 
-	if flow.Transform == nil || len(flow.Transform.Fields) == 0 {
+	if flow.Transform == nil {
 		return fmt.Errorf("flow %q does not have a transform configuration", flow.Name)
 	}
 
@@ -97,14 +161,15 @@ Examples:
 	}
 
 	// Create transformer
-	transformer, err := unifiedxform.NewTransformer(flow.Transform.Fields)
+	functions = append(functions, celext.WithLookups(flow.Lookups))
+	transformer, err := newTransformer(flow.Transform, functions...)
 	if err != nil {
 		return fmt.Errorf("create transformer: %w", err)
 	}
 
 	// Apply transform
 	ctx := context.Background()
-	outputJSON, err := transformer.Transform(ctx, inputJSON)
+	outputJSON, headers, err := transformer.TransformEvent(ctx, inputJSON, evt)
 	if err != nil {
 		return fmt.Errorf("transform error: %w", err)
 	}
@@ -120,9 +185,46 @@ Examples:
 	}
 
 	fmt.Println(string(pretty))
+
+	if len(headers) > 0 {
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println("\nHeaders:")
+		for _, name := range names {
+			fmt.Printf("  %s: %s\n", name, headers[name])
+		}
+	}
 	return nil
 }
 
+// newTransformer builds the unified transformer of tc with the fiso CEL
+// functions configured by functions.
+func newTransformer(tc *config.TransformConfig, functions ...celext.Option) (*unifiedxform.Transformer, error) {
+	steps := []unifiedxform.Step{{Fields: tc.Fields, OmitIf: tc.OmitIf}}
+	if len(tc.Steps) > 0 {
+		steps = make([]unifiedxform.Step, len(tc.Steps))
+		for i, s := range tc.Steps {
+			steps[i] = unifiedxform.Step{Let: letBindings(s.Let), Fields: s.Fields, OmitIf: s.OmitIf}
+		}
+	}
+	return unifiedxform.NewStepTransformer(steps,
+		unifiedxform.WithLet(letBindings(tc.Let)),
+		unifiedxform.WithHeaders(tc.Headers),
+		unifiedxform.WithFunctions(functions...),
+	)
+}
+
+func letBindings(let config.Bindings) []unifiedxform.Binding {
+	bindings := make([]unifiedxform.Binding, len(let))
+	for i, b := range let {
+		bindings[i] = unifiedxform.Binding{Name: b.Name, Expr: b.Expr}
+	}
+	return bindings
+}
+
 func loadFlow(path string) (*config.FlowDefinition, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -175,6 +175,161 @@ sink:
 	}
 }
 
+func TestRunTransformTest_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "test-flow.yaml")
+	writeTestFile(t, dir, "test-flow.yaml", `
+name: test-flow
+source:
+  type: kafka
+  config: {}
+transform:
+  fields:
+    order_id: key
+    tenant: headers["x-tenant"]
+  headers:
+    x-topic: topic
+sink:
+  type: http
+  config: {}
+`)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := RunTransformTest([]string{"--flow", flowPath, "--input", `{}`,
+		"--header", "x-tenant=acme", "--key", "ORD-1", "--topic=orders"})
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf strings.Builder
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"order_id": "ORD-1"`, `"tenant": "acme"`, "Headers:\n  x-topic: orders"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunTransformTest_Steps(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "test-flow.yaml")
+	writeTestFile(t, dir, "test-flow.yaml", `
+name: test-flow
+source:
+  type: http
+  config: {}
+transform:
+  let:
+    total: data.price * data.quantity
+  steps:
+    - fields:
+        order.id: data.id
+        order.total: total
+        order.note: data.note
+      omitIf:
+        order.note: '!has(data.note)'
+    - fields:
+        summary: 'data.order.id + ":" + string(data.order.total)'
+sink:
+  type: http
+  config: {}
+`)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := RunTransformTest([]string{"--flow", flowPath, "--input", `{"data": {"id": "A1", "price": 5, "quantity": 3}}`})
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf strings.Builder
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `"summary": "A1:15"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+	}
+}
+
+func TestRunTransformTest_FisoFunctions(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "test-flow.yaml")
+	writeTestFile(t, dir, "test-flow.yaml", `
+name: test-flow
+source:
+  type: http
+  config: {}
+lookups:
+  countries:
+    DE: Germany
+transform:
+  fields:
+    id: fiso.uuid()
+    processed: 'fiso.formatTime(fiso.now(), "DateOnly")'
+    country: 'fiso.lookup("countries", data.country, "unknown")'
+    digest: fiso.sha256(data.email)
+sink:
+  type: http
+  config: {}
+`)
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := RunTransformTest([]string{"--flow", flowPath, "--input", `{"data": {"country": "DE", "email": "a@b.c"}}`,
+		"--now", "2024-01-02T03:04:05Z", "--uuid=fixed-id"})
+	_ = w.Close()
+	os.Stdout = old
+
+	var buf strings.Builder
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"id": "fixed-id"`, `"processed": "2024-01-02"`, `"country": "Germany"`, `"digest": "`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	err = RunTransformTest([]string{"--flow", flowPath, "--input", `{}`, "--now", "yesterday"})
+	if err == nil || !strings.Contains(err.Error(), "RFC 3339") {
+		t.Errorf("expected an error for an invalid --now, got %v", err)
+	}
+}
+
+func TestRunTransformTest_InvalidHeaderFlag(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "test-flow.yaml")
+	writeTestFile(t, dir, "test-flow.yaml", `
+name: test-flow
+source:
+  type: kafka
+  config: {}
+transform:
+  fields:
+    id: data.id
+sink:
+  type: http
+  config: {}
+`)
+
+	err := RunTransformTest([]string{"--flow", flowPath, "--input", `{}`, "--header", "x-tenant"})
+	if err == nil || !strings.Contains(err.Error(), "must be name=value") {
+		t.Fatalf("expected header format error, got %v", err)
+	}
+}
+
 func TestRunTransformTest_MissingFlowFlag(t *testing.T) {
 	err := RunTransformTest([]string{"--input", `'{"test":"data"}'`})
 	if err == nil {
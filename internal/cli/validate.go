@@ -5,11 +5,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/lsm/fiso/internal/config"
+	"github.com/lsm/fiso/internal/enrich"
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/pipeline"
+	"github.com/lsm/fiso/internal/transform/celext"
 	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
 )
 
@@ -124,11 +128,53 @@ func validateFlowFile(path string) []validationError {
 		}
 	}
 
-	if flow.Transform != nil && len(flow.Transform.Fields) > 0 {
-		if _, err := unifiedxform.NewTransformer(flow.Transform.Fields); err != nil {
+	if flow.Filter != nil && flow.Filter.Expr != "" {
+		if _, err := unifiedxform.NewFilter(flow.Filter.Expr, celext.WithLookups(flow.Lookups)); err != nil {
 			errs = append(errs, validationError{
 				File:    path,
-				Field:   "transform.fields",
+				Field:   "filter.expr",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	for i, ec := range flow.Enrich {
+		functions := []celext.Option{celext.WithLookups(flow.Lookups)}
+		var field string
+		var err error
+		switch {
+		case ec.Link != nil && ec.Link.Target != "":
+			field = fmt.Sprintf("enrich[%d].link", i)
+			_, err = enrich.NewLink(enrich.LinkConfig{Target: ec.Link.Target, Path: ec.Link.Path, Body: ec.Link.Body, Functions: functions})
+		case ec.Table != nil && ec.Table.Key != "":
+			field = fmt.Sprintf("enrich[%d].table.key", i)
+			_, err = unifiedxform.NewExpression(ec.Table.Key, functions...)
+		}
+		if err != nil {
+			errs = append(errs, validationError{File: path, Field: field, Message: err.Error()})
+		}
+	}
+
+	// Durations and the window are checked by Validate; compile the key and
+	// reducers only.
+	if a := flow.Aggregate; a != nil && a.Key != "" {
+		spec := pipeline.AggregateSpec{Key: a.Key, Size: time.Second, Fields: a.Fields, Functions: []celext.Option{celext.WithLookups(flow.Lookups)}}
+		if _, err := pipeline.NewAggregate(spec); err != nil {
+			errs = append(errs, validationError{File: path, Field: "aggregate", Message: err.Error()})
+		}
+	}
+
+	if o := flow.Ordering; o != nil && o.Key != "" {
+		if _, err := pipeline.NewOrdering(o.Key, 0, celext.WithLookups(flow.Lookups)); err != nil {
+			errs = append(errs, validationError{File: path, Field: "ordering.key", Message: err.Error()})
+		}
+	}
+
+	if tc := flow.Transform; tc != nil && (len(tc.Fields) > 0 || len(tc.Steps) > 0) {
+		if _, err := newTransformer(tc, celext.WithLookups(flow.Lookups)); err != nil {
+			errs = append(errs, validationError{
+				File:    path,
+				Field:   "transform",
 				Message: err.Error(),
 			})
 		}
@@ -354,6 +354,88 @@ sink:
 	}
 }
 
+func TestValidateFlowFile_InvalidFilter(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "invalid-filter.yaml")
+
+	flowYAML := `name: cdc
+source:
+  type: http
+  config: {}
+filter:
+  expr: '"not a bool"'
+sink:
+  type: http
+  config: {}
+`
+	if err := os.WriteFile(flowPath, []byte(flowYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := validateFlowFile(flowPath)
+	if len(errs) != 1 || errs[0].Field != "filter.expr" {
+		t.Fatalf("expected one filter.expr error, got: %v", errs)
+	}
+}
+
+func TestValidateFlowFile_InvalidEnrich(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "invalid-enrich.yaml")
+
+	flowYAML := `name: orders
+source:
+  type: http
+  config: {}
+enrich:
+  - as: customer
+    link:
+      target: crm
+      path: '"/customers/" +'
+  - as: country
+    table:
+      file: countries.csv
+      key: data.country
+sink:
+  type: http
+  config: {}
+`
+	if err := os.WriteFile(flowPath, []byte(flowYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := validateFlowFile(flowPath)
+	if len(errs) != 1 || errs[0].Field != "enrich[0].link" {
+		t.Fatalf("expected one enrich[0].link error, got: %v", errs)
+	}
+}
+
+func TestValidateFlowFile_InvalidAggregate(t *testing.T) {
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "invalid-aggregate.yaml")
+
+	flowYAML := `name: orders
+source:
+  type: kafka
+  config: {}
+aggregate:
+  key: data.customerId
+  size: 1m
+  fields:
+    total: 'avg(data.amount)'
+sink:
+  type: http
+  config: {}
+`
+	if err := os.WriteFile(flowPath, []byte(flowYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := validateFlowFile(flowPath)
+	if len(errs) != 1 || errs[0].Field != "aggregate" {
+		t.Fatalf("expected one aggregate error, got: %v", errs)
+	}
+}
+
 func TestValidateFlowFile_ValidMapping(t *testing.T) {
 	dir := t.TempDir()
 	flowPath := filepath.Join(dir, "valid-mapping.yaml")
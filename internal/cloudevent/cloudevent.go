@@ -0,0 +1,218 @@
+// Package cloudevent converts CloudEvents between the structured JSON form
+// used inside the pipeline and the content modes of the HTTP, Kafka and gRPC
+// protocol bindings.
+package cloudevent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Content modes, set per flow with cloudevents.mode.
+const (
+	// ModeStructured sends the JSON envelope with content type
+	// application/cloudevents+json.
+	ModeStructured = "structured"
+	// ModeBinary sends the event data as the body and the attributes as
+	// headers.
+	ModeBinary = "binary"
+	// ModeNone sends the event data only, without CloudEvent attributes.
+	ModeNone = "none"
+)
+
+// ContentTypeStructured is the content type of structured-mode JSON events.
+const ContentTypeStructured = "application/cloudevents+json"
+
+// ValidMode reports whether mode is a content mode; the empty string
+// selects structured mode.
+func ValidMode(mode string) bool {
+	switch mode {
+	case "", ModeStructured, ModeBinary, ModeNone:
+		return true
+	}
+	return false
+}
+
+// Binding describes how a transport carries binary-mode attributes.
+type Binding struct {
+	// Prefix is prepended to attribute names to form header names.
+	Prefix string
+	// ContentType is the header carrying datacontenttype. When empty the
+	// attribute is sent like any other, as Prefix+"datacontenttype".
+	ContentType string
+	// PercentEncode escapes header values as the HTTP binding requires.
+	PercentEncode bool
+}
+
+// Protocol bindings.
+var (
+	HTTP  = Binding{Prefix: "ce-", ContentType: "Content-Type", PercentEncode: true}
+	Kafka = Binding{Prefix: "ce_", ContentType: "content-type"}
+	// GRPC carries attributes as metadata; gRPC reserves content-type.
+	GRPC = Binding{Prefix: "ce-", PercentEncode: true}
+)
+
+// Encode renders a structured JSON CloudEvent in mode for binding b and
+// returns the body and headers to send. Headers other than the content type
+// are preserved. Payloads that are not CloudEvents, and structured mode,
+// are returned unchanged.
+func Encode(mode string, b Binding, event []byte, headers map[string]string) ([]byte, map[string]string, error) {
+	if mode != ModeBinary && mode != ModeNone {
+		return event, headers, nil
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(event, &attrs); err != nil || attrs["specversion"] == nil {
+		return event, headers, nil
+	}
+
+	contentType := "application/json"
+	if raw, ok := attrs["datacontenttype"]; ok {
+		_ = json.Unmarshal(raw, &contentType)
+	}
+	data, err := eventData(attrs, contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(map[string]string, len(headers)+len(attrs))
+	for k, v := range headers {
+		if !strings.EqualFold(k, "content-type") {
+			out[k] = v
+		}
+	}
+	if b.ContentType != "" {
+		out[b.ContentType] = contentType
+	}
+	if mode == ModeNone {
+		return data, out, nil
+	}
+
+	for name, raw := range attrs {
+		switch name {
+		case "data", "data_base64":
+			continue
+		case "datacontenttype":
+			if b.ContentType != "" {
+				continue
+			}
+		}
+		value := attributeString(raw)
+		if b.PercentEncode {
+			value = percentEncode(value)
+		}
+		out[b.Prefix+name] = value
+	}
+	return data, out, nil
+}
+
+// eventData returns the data of a structured event as it is sent in binary
+// mode: JSON data as JSON, strings of other content types as their text,
+// and data_base64 decoded.
+func eventData(attrs map[string]json.RawMessage, contentType string) ([]byte, error) {
+	if raw, ok := attrs["data_base64"]; ok {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return nil, fmt.Errorf("data_base64 must be a string")
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode data_base64: %w", err)
+		}
+		return data, nil
+	}
+	raw, ok := attrs["data"]
+	if !ok {
+		return nil, nil
+	}
+	if !IsJSON(contentType) {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			return []byte(text), nil
+		}
+	}
+	return raw, nil
+}
+
+// FromBinary converts a binary-mode event received with binding b to a
+// structured JSON CloudEvent. It reports false when headers do not carry a
+// binary-mode event. Header names are matched case-insensitively.
+func FromBinary(b Binding, data []byte, headers map[string]string) ([]byte, bool, error) {
+	event := make(map[string]interface{})
+	contentType := ""
+	for k, v := range headers {
+		lower := strings.ToLower(k)
+		switch {
+		case b.ContentType != "" && lower == strings.ToLower(b.ContentType):
+			contentType = v
+		case strings.HasPrefix(lower, b.Prefix):
+			if b.PercentEncode {
+				if decoded, err := url.PathUnescape(v); err == nil {
+					v = decoded
+				}
+			}
+			event[strings.TrimPrefix(lower, b.Prefix)] = v
+		}
+	}
+	if _, ok := event["specversion"]; !ok {
+		return nil, false, nil
+	}
+	for _, required := range []string{"id", "source", "type"} {
+		if event[required] == nil || event[required] == "" {
+			return nil, true, fmt.Errorf("binary CloudEvent is missing %s", required)
+		}
+	}
+	if ct, ok := event["datacontenttype"].(string); ok && contentType == "" {
+		contentType = ct
+	}
+	if contentType != "" {
+		event["datacontenttype"] = contentType
+	}
+
+	switch {
+	case len(data) == 0:
+	case (contentType == "" || IsJSON(contentType)) && json.Valid(data):
+		event["data"] = json.RawMessage(data)
+	case strings.HasPrefix(contentType, "text/"):
+		event["data"] = string(data)
+	default:
+		event["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+	out, err := json.Marshal(event)
+	return out, true, err
+}
+
+// IsJSON reports whether contentType is a JSON media type.
+func IsJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || strings.HasPrefix(mediaType, "text/json")
+}
+
+// attributeString renders an attribute value as header text: strings
+// as-is, other JSON values in their JSON form.
+func attributeString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// percentEncode escapes space, double quote, percent and characters outside
+// printable ASCII, as the CloudEvents HTTP binding requires for header
+// values.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package cloudevent
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const structured = `{"specversion":"1.0","id":"evt-1","source":"fiso-flow/orders","type":"order.created",` +
+	`"subject":"Zürich \"hq\"","datacontenttype":"application/json","seq":7,"data":{"id":"o-1"}}`
+
+func TestEncode_Binary(t *testing.T) {
+	headers := map[string]string{"Content-Type": ContentTypeStructured, "fiso-correlation-id": "c-1"}
+
+	tests := []struct {
+		name    string
+		binding Binding
+		want    map[string]string
+	}{
+		{"http", HTTP, map[string]string{
+			"Content-Type":        "application/json",
+			"ce-specversion":      "1.0",
+			"ce-id":               "evt-1",
+			"ce-source":           "fiso-flow/orders",
+			"ce-type":             "order.created",
+			"ce-subject":          "Z%C3%BCrich%20%22hq%22",
+			"ce-seq":              "7",
+			"fiso-correlation-id": "c-1",
+		}},
+		{"kafka", Kafka, map[string]string{
+			"content-type":        "application/json",
+			"ce_specversion":      "1.0",
+			"ce_id":               "evt-1",
+			"ce_source":           "fiso-flow/orders",
+			"ce_type":             "order.created",
+			"ce_subject":          `Zürich "hq"`,
+			"ce_seq":              "7",
+			"fiso-correlation-id": "c-1",
+		}},
+		{"grpc", GRPC, map[string]string{
+			"ce-specversion":      "1.0",
+			"ce-id":               "evt-1",
+			"ce-source":           "fiso-flow/orders",
+			"ce-type":             "order.created",
+			"ce-subject":          "Z%C3%BCrich%20%22hq%22",
+			"ce-seq":              "7",
+			"ce-datacontenttype":  "application/json",
+			"fiso-correlation-id": "c-1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, out, err := Encode(ModeBinary, tt.binding, []byte(structured), headers)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(body) != `{"id":"o-1"}` {
+				t.Errorf("body = %s", body)
+			}
+			if !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("headers = %v, want %v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode_None(t *testing.T) {
+	body, out, err := Encode(ModeNone, HTTP, []byte(structured), map[string]string{"Content-Type": ContentTypeStructured})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if string(body) != `{"id":"o-1"}` {
+		t.Errorf("body = %s", body)
+	}
+	if !reflect.DeepEqual(out, map[string]string{"Content-Type": "application/json"}) {
+		t.Errorf("headers = %v", out)
+	}
+}
+
+func TestEncode_Passthrough(t *testing.T) {
+	headers := map[string]string{"Content-Type": ContentTypeStructured}
+	for _, mode := range []string{"", ModeStructured} {
+		body, out, _ := Encode(mode, HTTP, []byte(structured), headers)
+		if string(body) != structured || out["Content-Type"] != ContentTypeStructured {
+			t.Errorf("mode %q changed the event", mode)
+		}
+	}
+	body, out, _ := Encode(ModeBinary, HTTP, []byte(`{"id":1}`), headers)
+	if string(body) != `{"id":1}` || out["Content-Type"] != ContentTypeStructured {
+		t.Error("non-CloudEvent payload was changed")
+	}
+}
+
+func TestEncode_NonJSONData(t *testing.T) {
+	text := `{"specversion":"1.0","id":"1","source":"s","type":"t","datacontenttype":"text/plain","data":"hello"}`
+	body, out, _ := Encode(ModeBinary, Kafka, []byte(text), nil)
+	if string(body) != "hello" || out["content-type"] != "text/plain" {
+		t.Errorf("text data: body %q headers %v", body, out)
+	}
+
+	b64 := `{"specversion":"1.0","id":"1","source":"s","type":"t","datacontenttype":"application/octet-stream","data_base64":"AAE="}`
+	body, _, _ = Encode(ModeBinary, Kafka, []byte(b64), nil)
+	if !reflect.DeepEqual(body, []byte{0, 1}) {
+		t.Errorf("data_base64: body %v", body)
+	}
+
+	bad := `{"specversion":"1.0","id":"1","source":"s","type":"t","data_base64":"!"}`
+	if _, _, err := Encode(ModeBinary, Kafka, []byte(bad), nil); err == nil {
+		t.Error("expected error for invalid data_base64")
+	}
+}
+
+func TestFromBinary(t *testing.T) {
+	headers := map[string]string{
+		"Content-Type":   "application/json",
+		"Ce-Specversion": "1.0",
+		"Ce-Id":          "evt-1",
+		"Ce-Source":      "/orders",
+		"Ce-Type":        "order.created",
+		"Ce-Subject":     "Z%C3%BCrich",
+		"X-Other":        "ignored",
+	}
+	out, ok, err := FromBinary(HTTP, []byte(`{"id":"o-1"}`), headers)
+	if err != nil || !ok {
+		t.Fatalf("FromBinary() = %v, %v", ok, err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "evt-1",
+		"source":          "/orders",
+		"type":            "order.created",
+		"subject":         "Zürich",
+		"datacontenttype": "application/json",
+		"data":            map[string]interface{}{"id": "o-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromBinary() = %v, want %v", got, want)
+	}
+
+	// A structured event round-trips through binary mode.
+	body, bin, _ := Encode(ModeBinary, HTTP, []byte(structured), nil)
+	back, _, err := FromBinary(HTTP, body, bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a, b map[string]interface{}
+	_ = json.Unmarshal([]byte(structured), &a)
+	_ = json.Unmarshal(back, &b)
+	a["seq"] = "7" // extension values travel as strings
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("round trip = %v, want %v", b, a)
+	}
+}
+
+func TestFromBinary_DataKinds(t *testing.T) {
+	base := map[string]string{"ce-specversion": "1.0", "ce-id": "1", "ce-source": "s", "ce-type": "t"}
+	with := func(ct string) map[string]string {
+		h := map[string]string{"Content-Type": ct}
+		for k, v := range base {
+			h[k] = v
+		}
+		return h
+	}
+
+	out, _, _ := FromBinary(HTTP, []byte("hello"), with("text/plain"))
+	if !json.Valid(out) || !strings.Contains(string(out), `"data":"hello"`) {
+		t.Errorf("text data: %s", out)
+	}
+	out, _, _ = FromBinary(HTTP, []byte{0xff}, with("application/octet-stream"))
+	if !strings.Contains(string(out), `"data_base64":"/w=="`) {
+		t.Errorf("binary data: %s", out)
+	}
+
+	if _, ok, _ := FromBinary(HTTP, []byte(`{}`), map[string]string{"Content-Type": "application/json"}); ok {
+		t.Error("plain request detected as CloudEvent")
+	}
+	if _, ok, err := FromBinary(HTTP, nil, map[string]string{"ce-specversion": "1.0", "ce-id": "1"}); !ok || err == nil {
+		t.Errorf("expected missing attribute error, got %v %v", ok, err)
+	}
+}
+
+func TestValidMode(t *testing.T) {
+	for _, m := range []string{"", "structured", "binary", "none"} {
+		if !ValidMode(m) {
+			t.Errorf("ValidMode(%q) = false", m)
+		}
+	}
+	if ValidMode("batch") {
+		t.Error("ValidMode(batch) = true")
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/lsm/fiso/internal/delivery"
@@ -18,6 +20,12 @@ var (
 	validSourceTypes      = map[string]bool{"kafka": true, "grpc": true, "http": true}
 	validSinkTypes        = map[string]bool{"http": true, "grpc": true, "temporal": true, "kafka": true}
 	validInterceptorTypes = map[string]bool{"wasm": true, "grpc": true, "wasmer-app": true}
+	validSchemaFormats    = map[string]bool{"avro": true, "protobuf": true, "json": true}
+	validOnFailureModes   = map[string]bool{"dlq": true, "drop": true, "warn": true}
+	validCloudEventsModes = map[string]bool{"structured": true, "binary": true, "none": true}
+	validBatchFormats     = map[string]bool{"cloudevents": true, "ndjson": true}
+	validRouteModes       = map[string]bool{"first-match": true, "fan-out": true}
+	validWindows          = map[string]bool{"tumbling": true, "session": true}
 )
 
 // Validate checks the FlowDefinition for configuration errors.
@@ -35,37 +43,132 @@ func (f *FlowDefinition) Validate() error {
 		errs = append(errs, fmt.Errorf("source.type %q is not valid (must be one of: kafka, grpc, http)", f.Source.Type))
 	}
 
-	if f.Sink.Type == "" {
-		errs = append(errs, fmt.Errorf("sink.type is required"))
-	} else if !validSinkTypes[f.Sink.Type] {
-		errs = append(errs, fmt.Errorf("sink.type %q is not valid (must be one of: http, grpc, temporal, kafka)", f.Sink.Type))
+	if len(f.Routes) == 0 {
+		errs = append(errs, f.Sink.validate("sink")...)
+		if f.RouteMode != "" {
+			errs = append(errs, fmt.Errorf("routeMode requires routes"))
+		}
+	} else {
+		errs = append(errs, f.validateRoutes()...)
 	}
 
+	if d := f.Dedupe; d != nil {
+		if d.Window != "" {
+			if w, err := time.ParseDuration(d.Window); err != nil || w <= 0 {
+				errs = append(errs, fmt.Errorf("dedupe.window %q is not a valid duration", d.Window))
+			}
+		}
+		if d.CacheSize < 0 {
+			errs = append(errs, fmt.Errorf("dedupe.cacheSize must not be negative"))
+		}
+		if d.FileKeys < 0 {
+			errs = append(errs, fmt.Errorf("dedupe.fileKeys must not be negative"))
+		}
+	}
+
+	if f.Filter != nil && f.Filter.Expr == "" {
+		errs = append(errs, fmt.Errorf("filter.expr is required when filter is defined"))
+	}
+
+	errs = append(errs, f.validateEnrich()...)
+
 	// Transform validation
-	if f.Transform != nil && len(f.Transform.Fields) == 0 {
-		errs = append(errs, fmt.Errorf("transform: 'fields' is required when transform is defined"))
+	if f.Transform != nil {
+		errs = append(errs, f.Transform.validate("transform")...)
 	}
 
-	// Temporal sink validation.
-	if f.Sink.Type == "temporal" {
-		if f.Sink.Config == nil {
-			errs = append(errs, fmt.Errorf("sink.config is required for temporal sink"))
+	// Source schema decoding validation.
+	if raw, ok := f.Source.Config["schema"]; ok {
+		sc, ok := raw.(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Errorf("source.config.schema must be a mapping"))
 		} else {
-			if _, ok := f.Sink.Config["taskQueue"].(string); !ok {
-				errs = append(errs, fmt.Errorf("sink.config.taskQueue is required for temporal sink"))
+			if url, _ := sc["registryUrl"].(string); url == "" {
+				errs = append(errs, fmt.Errorf("source.config.schema.registryUrl is required"))
 			}
-			if _, ok := f.Sink.Config["workflowType"].(string); !ok {
-				errs = append(errs, fmt.Errorf("sink.config.workflowType is required for temporal sink"))
+			if format, _ := sc["format"].(string); !validSchemaFormats[format] {
+				errs = append(errs, fmt.Errorf("source.config.schema.format %q is not valid (must be one of: avro, protobuf, json)", format))
 			}
-			mode, _ := f.Sink.Config["mode"].(string)
-			if mode == "signal" {
-				if _, ok := f.Sink.Config["signalName"].(string); !ok {
-					errs = append(errs, fmt.Errorf("sink.config.signalName is required when mode is 'signal'"))
-				}
+		}
+	}
+
+	// Validate stage.
+	if v := f.Validation; v != nil {
+		if v.Before == nil && v.After == nil {
+			errs = append(errs, fmt.Errorf("validate requires before or after"))
+		}
+		errs = append(errs, v.Before.validate("validate.before")...)
+		errs = append(errs, v.After.validate("validate.after")...)
+		if v.OnFailure != "" && !validOnFailureModes[v.OnFailure] {
+			errs = append(errs, fmt.Errorf("validate.onFailure %q is not valid (must be one of: dlq, drop, warn)", v.OnFailure))
+		}
+	}
+
+	// CloudEvents content mode validation.
+	if f.CloudEvents != nil && f.CloudEvents.Mode != "" {
+		mode := f.CloudEvents.Mode
+		if !validCloudEventsModes[mode] {
+			errs = append(errs, fmt.Errorf("cloudevents.mode %q is not valid (must be one of: structured, binary, none)", mode))
+		}
+		for _, s := range f.sinks() {
+			_, encoding := s.Config["encoding"]
+			switch {
+			case !validCloudEventsModes[mode]:
+			case s.Type == "temporal" && mode != "structured":
+				errs = append(errs, fmt.Errorf("cloudevents.mode %q is not supported for temporal sinks", mode))
+			case s.Type == "kafka" && encoding && mode == "structured":
+				errs = append(errs, fmt.Errorf("cloudevents.mode structured cannot be used with %s.config.encoding", s.prefix))
 			}
 		}
 	}
 
+	// Batch validation.
+	if b := f.Batch; b != nil {
+		if b.MaxEvents < 0 {
+			errs = append(errs, fmt.Errorf("batch.maxEvents must not be negative"))
+		}
+		if b.MaxBytes < 0 {
+			errs = append(errs, fmt.Errorf("batch.maxBytes must not be negative"))
+		}
+		if b.MaxWait != "" {
+			if d, err := time.ParseDuration(b.MaxWait); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("batch.maxWait %q is not a valid duration", b.MaxWait))
+			}
+		}
+		if b.Format != "" && !validBatchFormats[b.Format] {
+			errs = append(errs, fmt.Errorf("batch.format %q is not valid (must be one of: cloudevents, ndjson)", b.Format))
+		}
+		if delivery.NormalizeCommitPolicy(f.ErrorHandling.CommitPolicy) == delivery.CommitPolicyKafkaTransaction {
+			errs = append(errs, fmt.Errorf("batch is not supported with errorHandling.commitPolicy kafka_transaction"))
+		}
+		if len(f.Routes) > 0 {
+			errs = append(errs, fmt.Errorf("batch cannot be used with routes"))
+		}
+		if f.Sink.Type == "http" && f.CloudEvents != nil {
+			switch {
+			case f.CloudEvents.Mode == "binary":
+				errs = append(errs, fmt.Errorf("batch cannot be used with cloudevents.mode binary on http sinks"))
+			case f.CloudEvents.Mode == "none" && b.Format != "ndjson":
+				errs = append(errs, fmt.Errorf("batch.format must be ndjson with cloudevents.mode none on http sinks"))
+			}
+		}
+	}
+
+	errs = append(errs, f.validateAggregate()...)
+
+	// Ordering validation.
+	if o := f.Ordering; o != nil {
+		if o.MaxInFlight < 0 {
+			errs = append(errs, fmt.Errorf("ordering.maxInFlight must not be negative"))
+		}
+		if f.Batch != nil || f.Aggregate != nil {
+			errs = append(errs, fmt.Errorf("ordering cannot be used with batch or aggregate"))
+		}
+		if delivery.NormalizeCommitPolicy(f.ErrorHandling.CommitPolicy) == delivery.CommitPolicyKafkaTransaction {
+			errs = append(errs, fmt.Errorf("ordering is not supported with errorHandling.commitPolicy kafka_transaction"))
+		}
+	}
+
 	// Interceptor validation.
 	for i, ic := range f.Interceptors {
 		if ic.Type == "" {
@@ -111,7 +214,9 @@ func (f *FlowDefinition) Validate() error {
 		if f.Source.Type != "kafka" {
 			errs = append(errs, fmt.Errorf("errorHandling.commitPolicy kafka_transaction requires source.type to be kafka"))
 		}
-		if f.Sink.Type != "kafka" {
+		if len(f.Routes) > 0 {
+			errs = append(errs, fmt.Errorf("routes are not supported with errorHandling.commitPolicy kafka_transaction"))
+		} else if f.Sink.Type != "kafka" {
 			errs = append(errs, fmt.Errorf("errorHandling.commitPolicy kafka_transaction requires sink.type to be kafka"))
 		}
 		if f.ErrorHandling.TransactionalID == "" {
@@ -129,14 +234,24 @@ func (f *FlowDefinition) Validate() error {
 
 // FlowDefinition represents a complete inbound pipeline configuration.
 type FlowDefinition struct {
-	Name          string                  `yaml:"name"`
-	Kafka         kafka.KafkaGlobalConfig `yaml:"kafka,omitempty"` // Named Kafka clusters
-	Source        SourceConfig            `yaml:"source"`
-	CloudEvents   *CloudEventsConfig      `yaml:"cloudevents,omitempty"`
-	Transform     *TransformConfig        `yaml:"transform,omitempty"`
-	Interceptors  []InterceptorConfig     `yaml:"interceptors,omitempty"`
-	Sink          SinkConfig              `yaml:"sink"`
-	ErrorHandling ErrorHandlingConfig     `yaml:"errorHandling"`
+	Name          string                       `yaml:"name"`
+	Kafka         kafka.KafkaGlobalConfig      `yaml:"kafka,omitempty"` // Named Kafka clusters
+	Source        SourceConfig                 `yaml:"source"`
+	Dedupe        *DedupeConfig                `yaml:"dedupe,omitempty"`
+	Filter        *FilterConfig                `yaml:"filter,omitempty"`
+	Enrich        []EnrichConfig               `yaml:"enrich,omitempty"` // Lookups merged into data before transform, in order
+	CloudEvents   *CloudEventsConfig           `yaml:"cloudevents,omitempty"`
+	Transform     *TransformConfig             `yaml:"transform,omitempty"`
+	Lookups       map[string]map[string]string `yaml:"lookups,omitempty"` // Tables of fiso.lookup in CEL expressions, by name
+	Validation    *ValidationConfig            `yaml:"validate,omitempty"`
+	Batch         *BatchConfig                 `yaml:"batch,omitempty"`
+	Aggregate     *AggregateConfig             `yaml:"aggregate,omitempty"`
+	Ordering      *OrderingConfig              `yaml:"ordering,omitempty"`
+	Interceptors  []InterceptorConfig          `yaml:"interceptors,omitempty"`
+	Sink          SinkConfig                   `yaml:"sink"`
+	Routes        []RouteConfig                `yaml:"routes,omitempty"`    // Replaces sink: deliver to the sinks of matching routes
+	RouteMode     string                       `yaml:"routeMode,omitempty"` // first-match | fan-out (default: first-match)
+	ErrorHandling ErrorHandlingConfig          `yaml:"errorHandling"`
 }
 
 // InterceptorConfig holds configuration for a pipeline interceptor.
@@ -163,6 +278,10 @@ type InterceptorConfig struct {
 //
 //	source: "my-service"    # Static string
 //	type: "order.created"   # Static type
+//
+// Mode selects how HTTP, gRPC and Kafka sinks send the event: structured
+// (the JSON envelope), binary (data as the body, attributes as ce-* or ce_*
+// headers) or none (data only).
 type CloudEventsConfig struct {
 	ID              string `yaml:"id,omitempty"`              // CloudEvent ID for idempotency
 	Type            string `yaml:"type,omitempty"`            // CloudEvent type
@@ -171,6 +290,7 @@ type CloudEventsConfig struct {
 	Data            string `yaml:"data,omitempty"`            // CloudEvent data (if empty, uses transformed payload)
 	DataContentType string `yaml:"datacontenttype,omitempty"` // CloudEvent datacontenttype (optional, default: application/json)
 	DataSchema      string `yaml:"dataschema,omitempty"`      // CloudEvent dataschema (optional)
+	Mode            string `yaml:"mode,omitempty"`            // Content mode: structured (default), binary or none
 }
 
 // SourceConfig holds source configuration.
@@ -179,10 +299,317 @@ type SourceConfig struct {
 	Config map[string]interface{} `yaml:"config"`
 }
 
+// DedupeConfig acknowledges events whose key was delivered within Window
+// without delivering them again, for example Kafka events redelivered after
+// a rebalance. Key is a CEL expression with the variables of CloudEvents
+// overrides; by default it is the CloudEvent ID. Keys are held in an LRU
+// cache and, with Path, in a file of fixed size that survives restarts.
+type DedupeConfig struct {
+	Key       string `yaml:"key,omitempty"`       // e.g. 'data.orderId + "-" + string(data.version)'
+	Window    string `yaml:"window,omitempty"`    // default: 1h
+	CacheSize int    `yaml:"cacheSize,omitempty"` // Keys held in memory (default: 100000)
+	Path      string `yaml:"path,omitempty"`      // Store file, one per flow (default: in memory only)
+	FileKeys  int    `yaml:"fileKeys,omitempty"`  // Keys the file has room for, 24 bytes each (default: 1048576)
+}
+
+// FilterConfig drops events before transform. Expr is a CEL condition with
+// the variables of a transform; events for which it is false are
+// acknowledged without delivery.
+type FilterConfig struct {
+	Expr string `yaml:"expr"`          // e.g. 'data.op != "r"'
+	Log  bool   `yaml:"log,omitempty"` // Log filtered events at debug level
+}
+
+// EnrichConfig looks up data for each event and stores it as data.<As>
+// before transform, from a fiso-link target or a lookup table. Failed
+// lookups, including timeouts, send the event to the DLQ as ENRICH_FAILED.
+type EnrichConfig struct {
+	As      string             `yaml:"as"`
+	Link    *EnrichLinkConfig  `yaml:"link,omitempty"`
+	Table   *EnrichTableConfig `yaml:"table,omitempty"`
+	Timeout string             `yaml:"timeout,omitempty"` // default: 5s
+}
+
+// EnrichLinkConfig calls a fiso-link target. Path and Body are CEL
+// expressions with the variables of a transform. Path is a string or a list
+// of segments; its segments are URL-escaped.
+type EnrichLinkConfig struct {
+	Target    string `yaml:"target"`
+	Addr      string `yaml:"addr,omitempty"`      // fiso-link address (default: http://localhost:3500)
+	Method    string `yaml:"method,omitempty"`    // GET | POST (default: GET)
+	Path      string `yaml:"path,omitempty"`      // e.g. '"/customers/" + data.customer_id' or '["customers", data.customer_id]'
+	Body      string `yaml:"body,omitempty"`      // e.g. '{"id": data.customer_id}'
+	CacheTTL  string `yaml:"cacheTTL,omitempty"`  // How long responses are cached (default: not cached)
+	CacheSize int    `yaml:"cacheSize,omitempty"` // default: 10000
+}
+
+// EnrichTableConfig looks up rows of a CSV or JSON file, loaded at startup.
+// Key is a CEL expression with the variables of a transform; events with no
+// matching row get null.
+type EnrichTableConfig struct {
+	File      string `yaml:"file"`                // .csv (with a header row) or .json
+	Key       string `yaml:"key"`                 // e.g. 'data.country'
+	KeyColumn string `yaml:"keyColumn,omitempty"` // default: the first CSV column, or "id" in JSON arrays
+}
+
+func (f *FlowDefinition) validateEnrich() []error {
+	var errs []error
+	seen := make(map[string]bool, len(f.Enrich))
+	for i, e := range f.Enrich {
+		prefix := fmt.Sprintf("enrich[%d]", i)
+		switch {
+		case e.As == "":
+			errs = append(errs, fmt.Errorf("%s.as is required", prefix))
+		case seen[e.As]:
+			errs = append(errs, fmt.Errorf("%s.as %q is not unique", prefix, e.As))
+		}
+		seen[e.As] = true
+		if (e.Link == nil) == (e.Table == nil) {
+			errs = append(errs, fmt.Errorf("%s: exactly one of link or table is required", prefix))
+		}
+		if e.Timeout != "" {
+			if d, err := time.ParseDuration(e.Timeout); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("%s.timeout %q is not a valid duration", prefix, e.Timeout))
+			}
+		}
+		if l := e.Link; l != nil {
+			if l.Target == "" {
+				errs = append(errs, fmt.Errorf("%s.link.target is required", prefix))
+			}
+			if m := strings.ToUpper(l.Method); m != "" && m != "GET" && m != "POST" {
+				errs = append(errs, fmt.Errorf("%s.link.method %q is not valid (must be one of: GET, POST)", prefix, l.Method))
+			}
+			if l.CacheTTL != "" {
+				if _, err := time.ParseDuration(l.CacheTTL); err != nil {
+					errs = append(errs, fmt.Errorf("%s.link.cacheTTL %q is not a valid duration", prefix, l.CacheTTL))
+				}
+			}
+			if l.CacheSize < 0 {
+				errs = append(errs, fmt.Errorf("%s.link.cacheSize must not be negative", prefix))
+			}
+		}
+		if t := e.Table; t != nil {
+			if t.File == "" {
+				errs = append(errs, fmt.Errorf("%s.table.file is required", prefix))
+			}
+			if t.Key == "" {
+				errs = append(errs, fmt.Errorf("%s.table.key is required", prefix))
+			}
+		}
+	}
+	return errs
+}
+
 // TransformConfig holds transform configuration using the unified fields syntax.
-// Each field value is a CEL expression that produces the output field value.
+// Each field value is a CEL expression that produces the output field value;
+// dotted field names such as customer.address.city build nested objects,
+// and `\.` is a literal dot in a key.
+// Headers are CEL expressions too, setting headers on the sink message.
+// Both see the source event's headers, key, topic, partition, offset and
+// correlationId, and the Let variables, which are evaluated once per event.
+//
+// Steps replace Fields with several field maps applied in order, each
+// reading the output of the one before it as data.
 type TransformConfig struct {
-	Fields map[string]string `yaml:"fields,omitempty"`
+	Let     Bindings          `yaml:"let,omitempty"`
+	Fields  map[string]string `yaml:"fields,omitempty"`
+	OmitIf  map[string]string `yaml:"omitIf,omitempty"` // field -> CEL condition omitting it when true
+	Steps   []TransformStep   `yaml:"steps,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"` // e.g. x-tenant: 'headers["x-tenant"]'
+}
+
+// TransformStep is one field map of a multi-step transform. Its Let
+// variables are visible to the step only.
+type TransformStep struct {
+	Let    Bindings          `yaml:"let,omitempty"`
+	Fields map[string]string `yaml:"fields"`
+	OmitIf map[string]string `yaml:"omitIf,omitempty"`
+}
+
+// Binding is a let variable: a name and its CEL expression.
+type Binding struct {
+	Name string
+	Expr string
+}
+
+// Bindings are let variables, written as a YAML mapping of names to CEL
+// expressions. They keep the order of the mapping, so a variable can
+// reference the ones before it.
+type Bindings []Binding
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (b *Bindings) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: let must be a mapping of names to expressions", value.Line)
+	}
+	bindings := make(Bindings, 0, len(value.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		var binding Binding
+		if err := value.Content[i].Decode(&binding.Name); err != nil {
+			return err
+		}
+		if err := value.Content[i+1].Decode(&binding.Expr); err != nil {
+			return err
+		}
+		bindings = append(bindings, binding)
+	}
+	*b = bindings
+	return nil
+}
+
+func (t *TransformConfig) validate(prefix string) []error {
+	var errs []error
+	switch {
+	case len(t.Steps) > 0 && (len(t.Fields) > 0 || len(t.OmitIf) > 0):
+		errs = append(errs, fmt.Errorf("%s: 'fields' and 'steps' are mutually exclusive", prefix))
+	case len(t.Steps) == 0 && len(t.Fields) == 0:
+		errs = append(errs, fmt.Errorf("%s: 'fields' is required when transform is defined", prefix))
+	}
+	for i, step := range t.Steps {
+		if len(step.Fields) == 0 {
+			errs = append(errs, fmt.Errorf("%s.steps[%d]: 'fields' is required", prefix, i))
+		}
+	}
+	return errs
+}
+
+// ValidationConfig configures JSON Schema validation of events. Before
+// checks the (decoded) source payload, After the transformed payload.
+type ValidationConfig struct {
+	Before    *SchemaRef `yaml:"before,omitempty"`
+	After     *SchemaRef `yaml:"after,omitempty"`
+	OnFailure string     `yaml:"onFailure,omitempty"` // dlq | drop | warn (default: dlq)
+}
+
+// BatchConfig groups events for delivery. A batch is delivered once it
+// holds MaxEvents events or MaxBytes bytes, or MaxWait after its first event.
+// HTTP sinks receive a batch in one request formatted as Format; Kafka sinks
+// produce it in one call. Kafka offsets are committed after the batch is
+// delivered.
+type BatchConfig struct {
+	MaxEvents int    `yaml:"maxEvents,omitempty"` // default: 100
+	MaxBytes  int    `yaml:"maxBytes,omitempty"`  // default: no limit
+	MaxWait   string `yaml:"maxWait,omitempty"`   // default: 1s
+	Format    string `yaml:"format,omitempty"`    // cloudevents | ndjson (default: cloudevents)
+}
+
+// AggregateConfig delivers one event per key and window instead of every
+// event. Key and the reducers of Fields are CEL expressions with the
+// variables of CloudEvents overrides, evaluated against the transformed
+// event. Debounce is shorthand for a session window that delivers the
+// latest event of a key once the key had no events for that long. Kafka
+// offsets are committed once the windows of the events are delivered.
+type AggregateConfig struct {
+	Key         string            `yaml:"key"`                   // e.g. 'data.customerId'
+	Window      string            `yaml:"window,omitempty"`      // tumbling | session (default: tumbling)
+	Size        string            `yaml:"size,omitempty"`        // Window length, or the gap that closes a session
+	Debounce    string            `yaml:"debounce,omitempty"`    // e.g. 5s; instead of window, size and fields
+	MaxDuration string            `yaml:"maxDuration,omitempty"` // Sessions close at most this long after their first event
+	Fields      map[string]string `yaml:"fields,omitempty"`      // e.g. total: 'sum(data.amount)' (default: the latest event)
+	MaxKeys     int               `yaml:"maxKeys,omitempty"`     // Open windows (default: 10000)
+	MaxEvents   int               `yaml:"maxEvents,omitempty"`   // Events per window (default: 1000)
+}
+
+// OrderingConfig processes the events of a key one at a time, in order, and
+// the events of different keys in parallel, up to MaxInFlight events at
+// once. Key is a CEL expression with the variables of CloudEvents
+// overrides, evaluated against the decoded source payload; by
+// default it is the Kafka message key. Events with an empty key are not
+// ordered. Kafka offsets are committed once every event before them was
+// processed.
+type OrderingConfig struct {
+	Key         string `yaml:"key,omitempty"`         // e.g. 'data.accountId'
+	MaxInFlight int    `yaml:"maxInFlight,omitempty"` // default: 16
+}
+
+func (f *FlowDefinition) validateAggregate() []error {
+	a := f.Aggregate
+	if a == nil {
+		return nil
+	}
+	var errs []error
+	if a.Key == "" {
+		errs = append(errs, fmt.Errorf("aggregate.key is required"))
+	}
+	switch {
+	case a.Size == "" && a.Debounce == "":
+		errs = append(errs, fmt.Errorf("aggregate requires size or debounce"))
+	case a.Size != "" && a.Debounce != "":
+		errs = append(errs, fmt.Errorf("aggregate.size and aggregate.debounce are mutually exclusive"))
+	}
+	if a.Debounce != "" && (a.Window != "" || len(a.Fields) > 0) {
+		errs = append(errs, fmt.Errorf("aggregate.debounce cannot be used with window or fields"))
+	}
+	if a.Window != "" && !validWindows[a.Window] {
+		errs = append(errs, fmt.Errorf("aggregate.window %q is not valid (must be one of: tumbling, session)", a.Window))
+	}
+	for _, d := range []struct{ field, value string }{
+		{"size", a.Size}, {"debounce", a.Debounce}, {"maxDuration", a.MaxDuration},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			errs = append(errs, fmt.Errorf("aggregate.%s %q is not a valid duration", d.field, d.value))
+		}
+	}
+	if a.MaxDuration != "" && a.Debounce == "" && a.Window != "session" {
+		errs = append(errs, fmt.Errorf("aggregate.maxDuration requires a session window or debounce"))
+	}
+	if a.MaxKeys < 0 {
+		errs = append(errs, fmt.Errorf("aggregate.maxKeys must not be negative"))
+	}
+	if a.MaxEvents < 0 {
+		errs = append(errs, fmt.Errorf("aggregate.maxEvents must not be negative"))
+	}
+	if f.Batch != nil {
+		errs = append(errs, fmt.Errorf("aggregate cannot be used with batch"))
+	}
+	// Other sources acknowledge an event once it is added to a window, so
+	// a crash or failed delivery would lose it.
+	if f.Source.Type != "" && f.Source.Type != "kafka" {
+		errs = append(errs, fmt.Errorf("aggregate requires source.type to be kafka"))
+	}
+	if delivery.NormalizeCommitPolicy(f.ErrorHandling.CommitPolicy) == delivery.CommitPolicyKafkaTransaction {
+		errs = append(errs, fmt.Errorf("aggregate is not supported with errorHandling.commitPolicy kafka_transaction"))
+	}
+	return errs
+}
+
+// SchemaRef locates a JSON Schema: inline, in a file, or as the latest
+// version of a schema registry subject. Exactly one of Schema, SchemaFile or
+// Subject must be set.
+type SchemaRef struct {
+	Schema      map[string]interface{} `yaml:"schema,omitempty"`      // Inline JSON Schema
+	SchemaFile  string                 `yaml:"schemaFile,omitempty"`  // Path to a JSON or YAML schema file
+	RegistryURL string                 `yaml:"registryUrl,omitempty"` // Required with subject
+	Subject     string                 `yaml:"subject,omitempty"`     // e.g. "orders-value"
+	CacheTTL    string                 `yaml:"cacheTTL,omitempty"`    // How long the latest schema is cached (default: 5m)
+}
+
+func (r *SchemaRef) validate(prefix string) []error {
+	if r == nil {
+		return nil
+	}
+	var errs []error
+	set := 0
+	for _, ok := range []bool{r.Schema != nil, r.SchemaFile != "", r.Subject != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		errs = append(errs, fmt.Errorf("%s: exactly one of schema, schemaFile or subject is required", prefix))
+	}
+	if r.Subject != "" && r.RegistryURL == "" {
+		errs = append(errs, fmt.Errorf("%s.registryUrl is required with subject", prefix))
+	}
+	if r.CacheTTL != "" {
+		if _, err := time.ParseDuration(r.CacheTTL); err != nil {
+			errs = append(errs, fmt.Errorf("%s.cacheTTL %q is not a valid duration", prefix, r.CacheTTL))
+		}
+	}
+	return errs
 }
 
 // SinkConfig holds sink configuration.
@@ -191,6 +618,131 @@ type SinkConfig struct {
 	Config map[string]interface{} `yaml:"config"`
 }
 
+func (s *SinkConfig) validate(prefix string) []error {
+	var errs []error
+
+	if s.Type == "" {
+		errs = append(errs, fmt.Errorf("%s.type is required", prefix))
+	} else if !validSinkTypes[s.Type] {
+		errs = append(errs, fmt.Errorf("%s.type %q is not valid (must be one of: http, grpc, temporal, kafka)", prefix, s.Type))
+	}
+
+	// Temporal sink validation.
+	if s.Type == "temporal" {
+		if s.Config == nil {
+			errs = append(errs, fmt.Errorf("%s.config is required for temporal sink", prefix))
+		} else {
+			if _, ok := s.Config["taskQueue"].(string); !ok {
+				errs = append(errs, fmt.Errorf("%s.config.taskQueue is required for temporal sink", prefix))
+			}
+			if _, ok := s.Config["workflowType"].(string); !ok {
+				errs = append(errs, fmt.Errorf("%s.config.workflowType is required for temporal sink", prefix))
+			}
+			mode, _ := s.Config["mode"].(string)
+			if mode == "signal" {
+				if _, ok := s.Config["signalName"].(string); !ok {
+					errs = append(errs, fmt.Errorf("%s.config.signalName is required when mode is 'signal'", prefix))
+				}
+			}
+		}
+	}
+
+	// Kafka sink encoding validation.
+	if raw, ok := s.Config["encoding"]; ok {
+		ec, ok := raw.(map[string]interface{})
+		switch {
+		case s.Type != "kafka":
+			errs = append(errs, fmt.Errorf("%s.config.encoding is only supported for kafka sinks", prefix))
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s.config.encoding must be a mapping", prefix))
+		default:
+			if url, _ := ec["registryUrl"].(string); url == "" {
+				errs = append(errs, fmt.Errorf("%s.config.encoding.registryUrl is required", prefix))
+			}
+			if format, _ := ec["format"].(string); !validSchemaFormats[format] {
+				errs = append(errs, fmt.Errorf("%s.config.encoding.format %q is not valid (must be one of: avro, protobuf, json)", prefix, format))
+			}
+			if v, ok := ec["version"]; ok {
+				if version, ok := v.(int); !ok || version <= 0 {
+					errs = append(errs, fmt.Errorf("%s.config.encoding.version must be a positive integer", prefix))
+				}
+			}
+			if register, _ := ec["autoRegister"].(bool); register {
+				if _, ok := ec["version"]; ok {
+					errs = append(errs, fmt.Errorf("%s.config.encoding: version and autoRegister are mutually exclusive", prefix))
+				}
+				schemaText, _ := ec["schema"].(string)
+				schemaFile, _ := ec["schemaFile"].(string)
+				if (schemaText == "") == (schemaFile == "") {
+					errs = append(errs, fmt.Errorf("%s.config.encoding: autoRegister requires exactly one of schema or schemaFile", prefix))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// RouteConfig delivers the events matching When to its own sink. When is a
+// CEL condition over the transformed event, available as data:
+//
+//	when: 'data.total > 1000'
+//
+// Routes are tried in order. In first-match mode an event goes to the first
+// matching route only; in fan-out mode it goes to every matching route. An
+// event no route matches is sent to the DLQ.
+type RouteConfig struct {
+	Name      string           `yaml:"name"`
+	When      string           `yaml:"when,omitempty"`      // CEL condition (default: match every event)
+	Transform *TransformConfig `yaml:"transform,omitempty"` // Applied after the flow transform, for this route only
+	Sink      SinkConfig       `yaml:"sink"`
+}
+
+func (f *FlowDefinition) validateRoutes() []error {
+	var errs []error
+	if f.Sink.Type != "" || f.Sink.Config != nil {
+		errs = append(errs, fmt.Errorf("sink and routes are mutually exclusive"))
+	}
+	if f.RouteMode != "" && !validRouteModes[f.RouteMode] {
+		errs = append(errs, fmt.Errorf("routeMode %q is not valid (must be one of: first-match, fan-out)", f.RouteMode))
+	}
+	names := make(map[string]bool)
+	for i := range f.Routes {
+		r := &f.Routes[i]
+		prefix := fmt.Sprintf("routes[%d]", i)
+		switch {
+		case r.Name == "":
+			errs = append(errs, fmt.Errorf("%s.name is required", prefix))
+		case names[r.Name]:
+			errs = append(errs, fmt.Errorf("%s.name %q is not unique", prefix, r.Name))
+		}
+		names[r.Name] = true
+		if r.Transform != nil {
+			errs = append(errs, r.Transform.validate(prefix+".transform")...)
+		}
+		errs = append(errs, r.Sink.validate(prefix+".sink")...)
+	}
+	return errs
+}
+
+// flowSink is a sink of a flow with its position in the definition.
+type flowSink struct {
+	*SinkConfig
+	prefix string
+}
+
+// sinks returns the sink of the flow, or the sinks of its routes.
+func (f *FlowDefinition) sinks() []flowSink {
+	if len(f.Routes) == 0 {
+		return []flowSink{{&f.Sink, "sink"}}
+	}
+	sinks := make([]flowSink, len(f.Routes))
+	for i := range f.Routes {
+		sinks[i] = flowSink{&f.Routes[i].Sink, fmt.Sprintf("routes[%d].sink", i)}
+	}
+	return sinks
+}
+
 // ErrorHandlingConfig holds error handling configuration.
 type ErrorHandlingConfig struct {
 	DeadLetterTopic string `yaml:"deadLetterTopic"`
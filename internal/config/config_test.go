@@ -466,6 +466,29 @@ func TestFlowDefinition_Validate(t *testing.T) {
 				Transform: &TransformConfig{Fields: map[string]string{"a": "data.b", "status": `"processed"`}},
 			},
 		},
+		{
+			name: "transform fields and steps are mutually exclusive",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "http"},
+				Sink:   SinkConfig{Type: "http"},
+				Transform: &TransformConfig{
+					Fields: map[string]string{"a": "data.b"},
+					Steps:  []TransformStep{{Fields: map[string]string{"a": "data.b"}}},
+				},
+			},
+			wantErr: "'fields' and 'steps' are mutually exclusive",
+		},
+		{
+			name: "transform step without fields",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "http"},
+				Sink:      SinkConfig{Type: "http"},
+				Transform: &TransformConfig{Steps: []TransformStep{{Fields: map[string]string{"a": "data.b"}}, {}}},
+			},
+			wantErr: "transform.steps[1]: 'fields' is required",
+		},
 		{
 			name: "temporal sink valid",
 			flow: FlowDefinition{
@@ -477,6 +500,674 @@ func TestFlowDefinition_Validate(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "source schema valid",
+			flow: FlowDefinition{
+				Name: "t",
+				Source: SourceConfig{Type: "kafka", Config: map[string]interface{}{
+					"schema": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro"},
+				}},
+				Sink: SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "source schema missing registryUrl",
+			flow: FlowDefinition{
+				Name: "t",
+				Source: SourceConfig{Type: "kafka", Config: map[string]interface{}{
+					"schema": map[string]interface{}{"format": "protobuf"},
+				}},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: "source.config.schema.registryUrl is required",
+		},
+		{
+			name: "source schema invalid format",
+			flow: FlowDefinition{
+				Name: "t",
+				Source: SourceConfig{Type: "kafka", Config: map[string]interface{}{
+					"schema": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "thrift"},
+				}},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: "source.config.schema.format",
+		},
+		{
+			name: "source schema not a mapping",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka", Config: map[string]interface{}{"schema": "avro"}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "source.config.schema must be a mapping",
+		},
+		{
+			name: "validate before and after",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Validation: &ValidationConfig{
+					Before:    &SchemaRef{SchemaFile: "schemas/order.json"},
+					After:     &SchemaRef{RegistryURL: "http://registry:8081", Subject: "orders-value", CacheTTL: "1m"},
+					OnFailure: "warn",
+				},
+				Sink: SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "validate without stages",
+			flow: FlowDefinition{
+				Name:       "t",
+				Source:     SourceConfig{Type: "kafka"},
+				Validation: &ValidationConfig{OnFailure: "drop"},
+				Sink:       SinkConfig{Type: "http"},
+			},
+			wantErr: "validate requires before or after",
+		},
+		{
+			name: "validate schema and schemaFile",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Validation: &ValidationConfig{
+					Before: &SchemaRef{Schema: map[string]interface{}{"type": "object"}, SchemaFile: "order.json"},
+				},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: "validate.before: exactly one of schema, schemaFile or subject is required",
+		},
+		{
+			name: "validate subject without registry",
+			flow: FlowDefinition{
+				Name:       "t",
+				Source:     SourceConfig{Type: "kafka"},
+				Validation: &ValidationConfig{After: &SchemaRef{Subject: "orders-value"}},
+				Sink:       SinkConfig{Type: "http"},
+			},
+			wantErr: "validate.after.registryUrl is required with subject",
+		},
+		{
+			name: "validate invalid onFailure",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Validation: &ValidationConfig{
+					Before:    &SchemaRef{SchemaFile: "order.json"},
+					OnFailure: "ignore",
+				},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: "validate.onFailure",
+		},
+		{
+			name: "kafka sink encoding valid",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro", "version": 2},
+				}},
+			},
+		},
+		{
+			name: "kafka sink encoding auto register",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "protobuf", "autoRegister": true, "schemaFile": "order.proto"},
+				}},
+			},
+		},
+		{
+			name: "sink encoding on http sink",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "http", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro"},
+				}},
+			},
+			wantErr: "sink.config.encoding is only supported for kafka sinks",
+		},
+		{
+			name: "kafka sink encoding invalid version",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro", "version": "latest"},
+				}},
+			},
+			wantErr: "sink.config.encoding.version must be a positive integer",
+		},
+		{
+			name: "kafka sink encoding auto register without schema",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro", "autoRegister": true},
+				}},
+			},
+			wantErr: "autoRegister requires exactly one of schema or schemaFile",
+		},
+		{
+			name: "kafka sink encoding missing registry",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"format": "json"},
+				}},
+			},
+			wantErr: "sink.config.encoding.registryUrl is required",
+		},
+		{
+			name: "cloudevents binary mode",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "http"},
+				CloudEvents: &CloudEventsConfig{Mode: "binary"},
+				Sink:        SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "cloudevents invalid mode",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "http"},
+				CloudEvents: &CloudEventsConfig{Mode: "batched"},
+				Sink:        SinkConfig{Type: "http"},
+			},
+			wantErr: `cloudevents.mode "batched" is not valid`,
+		},
+		{
+			name: "cloudevents binary mode on temporal sink",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "http"},
+				CloudEvents: &CloudEventsConfig{Mode: "binary"},
+				Sink: SinkConfig{Type: "temporal", Config: map[string]interface{}{
+					"taskQueue":    "q",
+					"workflowType": "W",
+				}},
+			},
+			wantErr: "not supported for temporal sinks",
+		},
+		{
+			name: "cloudevents structured mode with kafka encoding",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "kafka"},
+				CloudEvents: &CloudEventsConfig{Mode: "structured"},
+				Sink: SinkConfig{Type: "kafka", Config: map[string]interface{}{
+					"encoding": map[string]interface{}{"registryUrl": "http://registry:8081", "format": "avro"},
+				}},
+			},
+			wantErr: "cloudevents.mode structured cannot be used with sink.config.encoding",
+		},
+		{
+			name: "batch valid",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Batch:  &BatchConfig{MaxEvents: 500, MaxBytes: 1 << 20, MaxWait: "250ms", Format: "ndjson"},
+				Sink:   SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "batch invalid maxWait",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Batch:  &BatchConfig{MaxWait: "soon"},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `batch.maxWait "soon" is not a valid duration`,
+		},
+		{
+			name: "batch invalid format",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Batch:  &BatchConfig{Format: "csv"},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `batch.format "csv" is not valid`,
+		},
+		{
+			name: "batch negative maxEvents",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Batch:  &BatchConfig{MaxEvents: -1},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "batch.maxEvents must not be negative",
+		},
+		{
+			name: "batch with kafka transactions",
+			flow: FlowDefinition{
+				Name:          "t",
+				Source:        SourceConfig{Type: "kafka"},
+				Batch:         &BatchConfig{},
+				Sink:          SinkConfig{Type: "kafka"},
+				ErrorHandling: ErrorHandlingConfig{CommitPolicy: "kafka_transaction", TransactionalID: "tx"},
+			},
+			wantErr: "batch is not supported with errorHandling.commitPolicy kafka_transaction",
+		},
+		{
+			name: "batch with binary mode on http sink",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "kafka"},
+				CloudEvents: &CloudEventsConfig{Mode: "binary"},
+				Batch:       &BatchConfig{},
+				Sink:        SinkConfig{Type: "http"},
+			},
+			wantErr: "batch cannot be used with cloudevents.mode binary on http sinks",
+		},
+		{
+			name: "batch cloudevents format with mode none",
+			flow: FlowDefinition{
+				Name:        "t",
+				Source:      SourceConfig{Type: "kafka"},
+				CloudEvents: &CloudEventsConfig{Mode: "none"},
+				Batch:       &BatchConfig{},
+				Sink:        SinkConfig{Type: "http"},
+			},
+			wantErr: "batch.format must be ndjson with cloudevents.mode none",
+		},
+		{
+			name: "dedupe valid",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Dedupe: &DedupeConfig{Key: "data.orderId", Window: "24h", CacheSize: 1000, Path: "/var/lib/fiso/orders.log"},
+				Sink:   SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "dedupe invalid window",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Dedupe: &DedupeConfig{Window: "-1h"},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `dedupe.window "-1h" is not a valid duration`,
+		},
+		{
+			name: "dedupe negative cacheSize",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Dedupe: &DedupeConfig{CacheSize: -1},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "dedupe.cacheSize must not be negative",
+		},
+		{
+			name: "dedupe negative fileKeys",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Dedupe: &DedupeConfig{FileKeys: -1},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "dedupe.fileKeys must not be negative",
+		},
+		{
+			name: "ordering valid",
+			flow: FlowDefinition{
+				Name:     "t",
+				Source:   SourceConfig{Type: "kafka"},
+				Ordering: &OrderingConfig{Key: "data.accountId", MaxInFlight: 32},
+				Sink:     SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "ordering negative maxInFlight",
+			flow: FlowDefinition{
+				Name:     "t",
+				Source:   SourceConfig{Type: "kafka"},
+				Ordering: &OrderingConfig{MaxInFlight: -1},
+				Sink:     SinkConfig{Type: "http"},
+			},
+			wantErr: "ordering.maxInFlight must not be negative",
+		},
+		{
+			name: "ordering with batch",
+			flow: FlowDefinition{
+				Name:     "t",
+				Source:   SourceConfig{Type: "kafka"},
+				Ordering: &OrderingConfig{},
+				Batch:    &BatchConfig{},
+				Sink:     SinkConfig{Type: "http"},
+			},
+			wantErr: "ordering cannot be used with batch or aggregate",
+		},
+		{
+			name: "aggregate tumbling valid",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.customerId", Size: "1m", Fields: map[string]string{"total": "sum(data.amount)"}},
+				Sink:      SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "aggregate debounce valid",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Debounce: "5s", MaxDuration: "1m"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "aggregate missing key",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Size: "1m"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.key is required",
+		},
+		{
+			name: "aggregate missing size",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate requires size or debounce",
+		},
+		{
+			name: "aggregate size and debounce",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m", Debounce: "5s"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.size and aggregate.debounce are mutually exclusive",
+		},
+		{
+			name: "aggregate debounce with fields",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Debounce: "5s", Fields: map[string]string{"n": "count()"}},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.debounce cannot be used with window or fields",
+		},
+		{
+			name: "aggregate invalid window",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m", Window: "sliding"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.window \"sliding\" is not valid",
+		},
+		{
+			name: "aggregate invalid size",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "soon"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.size \"soon\" is not a valid duration",
+		},
+		{
+			name: "aggregate maxDuration on tumbling window",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m", MaxDuration: "5m"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.maxDuration requires a session window or debounce",
+		},
+		{
+			name: "aggregate negative maxKeys",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m", MaxKeys: -1},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate.maxKeys must not be negative",
+		},
+		{
+			name: "aggregate with batch",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m"},
+				Batch:     &BatchConfig{},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate cannot be used with batch",
+		},
+		{
+			name: "aggregate with http source",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "http"},
+				Aggregate: &AggregateConfig{Key: "data.id", Size: "1m"},
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "aggregate requires source.type to be kafka",
+		},
+		{
+			name: "filter valid",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Filter: &FilterConfig{Expr: `data.op != "r"`, Log: true},
+				Sink:   SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "filter missing expr",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Filter: &FilterConfig{},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "filter.expr is required",
+		},
+		{
+			name: "enrich valid",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{
+					{As: "customer", Link: &EnrichLinkConfig{Target: "crm", Path: `"/customers/" + data.id`, CacheTTL: "1m"}, Timeout: "2s"},
+					{As: "country", Table: &EnrichTableConfig{File: "countries.csv", Key: "data.country"}},
+				},
+				Sink: SinkConfig{Type: "http"},
+			},
+		},
+		{
+			name: "enrich missing as",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{Link: &EnrichLinkConfig{Target: "crm"}}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "enrich[0].as is required",
+		},
+		{
+			name: "enrich duplicate as",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{
+					{As: "customer", Link: &EnrichLinkConfig{Target: "crm"}},
+					{As: "customer", Link: &EnrichLinkConfig{Target: "erp"}},
+				},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: `enrich[1].as "customer" is not unique`,
+		},
+		{
+			name: "enrich link and table",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{
+					As:    "customer",
+					Link:  &EnrichLinkConfig{Target: "crm"},
+					Table: &EnrichTableConfig{File: "customers.csv", Key: "data.id"},
+				}},
+				Sink: SinkConfig{Type: "http"},
+			},
+			wantErr: "enrich[0]: exactly one of link or table is required",
+		},
+		{
+			name: "enrich invalid timeout",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{As: "customer", Link: &EnrichLinkConfig{Target: "crm"}, Timeout: "0s"}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `enrich[0].timeout "0s" is not a valid duration`,
+		},
+		{
+			name: "enrich link missing target",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{As: "customer", Link: &EnrichLinkConfig{}}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "enrich[0].link.target is required",
+		},
+		{
+			name: "enrich link invalid method",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{As: "customer", Link: &EnrichLinkConfig{Target: "crm", Method: "DELETE"}}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `enrich[0].link.method "DELETE" is not valid`,
+		},
+		{
+			name: "enrich link invalid cacheTTL",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{As: "customer", Link: &EnrichLinkConfig{Target: "crm", CacheTTL: "long"}}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: `enrich[0].link.cacheTTL "long" is not a valid duration`,
+		},
+		{
+			name: "enrich table missing key",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Enrich: []EnrichConfig{{As: "country", Table: &EnrichTableConfig{File: "countries.csv"}}},
+				Sink:   SinkConfig{Type: "http"},
+			},
+			wantErr: "enrich[0].table.key is required",
+		},
+		{
+			name: "routes valid",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				RouteMode: "fan-out",
+				Routes: []RouteConfig{
+					{Name: "audit", Sink: SinkConfig{Type: "kafka"}},
+					{Name: "high-value", When: "data.total > 1000", Sink: SinkConfig{Type: "http"}},
+				},
+			},
+		},
+		{
+			name: "routes with sink",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Sink:   SinkConfig{Type: "http"},
+				Routes: []RouteConfig{{Name: "a", Sink: SinkConfig{Type: "http"}}},
+			},
+			wantErr: "sink and routes are mutually exclusive",
+		},
+		{
+			name: "routes duplicate name",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Routes: []RouteConfig{
+					{Name: "a", Sink: SinkConfig{Type: "http"}},
+					{Name: "a", Sink: SinkConfig{Type: "http"}},
+				},
+			},
+			wantErr: `routes[1].name "a" is not unique`,
+		},
+		{
+			name: "routes invalid sink",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Routes: []RouteConfig{{Name: "a", Sink: SinkConfig{Type: "temporal", Config: map[string]interface{}{"taskQueue": "q"}}}},
+			},
+			wantErr: "routes[0].sink.config.workflowType is required for temporal sink",
+		},
+		{
+			name: "routes invalid mode",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				RouteMode: "broadcast",
+				Routes:    []RouteConfig{{Name: "a", Sink: SinkConfig{Type: "http"}}},
+			},
+			wantErr: `routeMode "broadcast" is not valid`,
+		},
+		{
+			name: "routeMode without routes",
+			flow: FlowDefinition{
+				Name:      "t",
+				Source:    SourceConfig{Type: "kafka"},
+				RouteMode: "fan-out",
+				Sink:      SinkConfig{Type: "http"},
+			},
+			wantErr: "routeMode requires routes",
+		},
+		{
+			name: "routes with batch",
+			flow: FlowDefinition{
+				Name:   "t",
+				Source: SourceConfig{Type: "kafka"},
+				Batch:  &BatchConfig{},
+				Routes: []RouteConfig{{Name: "a", Sink: SinkConfig{Type: "http"}}},
+			},
+			wantErr: "batch cannot be used with routes",
+		},
+		{
+			name: "routes with kafka transactions",
+			flow: FlowDefinition{
+				Name:          "t",
+				Source:        SourceConfig{Type: "kafka"},
+				Routes:        []RouteConfig{{Name: "a", Sink: SinkConfig{Type: "kafka"}}},
+				ErrorHandling: ErrorHandlingConfig{CommitPolicy: "kafka_transaction", TransactionalID: "tx"},
+			},
+			wantErr: "routes are not supported with errorHandling.commitPolicy kafka_transaction",
+		},
 		{
 			name: "temporal sink missing taskQueue",
 			flow: FlowDefinition{
@@ -803,6 +1494,86 @@ sink:
 	}
 }
 
+func TestLoad_TransformSteps(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "flow.yaml", `
+name: steps-flow
+source:
+  type: http
+  config: {}
+transform:
+  let:
+    subtotal: data.price * data.quantity
+    discount: 'subtotal > 100 ? 10 : 0'
+    after: subtotal - discount
+  steps:
+    - fields:
+        order.total: after
+      omitIf:
+        order.total: after == 0
+    - let:
+        large: data.order.total > 1000
+      fields:
+        total: data.order.total
+sink:
+  type: http
+  config: {}
+`)
+
+	flows, err := NewLoader(dir, nil).Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	tc := flows["steps-flow"].Transform
+	if tc == nil {
+		t.Fatal("expected transform config")
+	}
+	want := Bindings{
+		{Name: "subtotal", Expr: "data.price * data.quantity"},
+		{Name: "discount", Expr: "subtotal > 100 ? 10 : 0"},
+		{Name: "after", Expr: "subtotal - discount"},
+	}
+	if len(tc.Let) != len(want) {
+		t.Fatalf("let = %v, want %v", tc.Let, want)
+	}
+	for i := range want {
+		if tc.Let[i] != want[i] {
+			t.Errorf("let[%d] = %v, want %v", i, tc.Let[i], want[i])
+		}
+	}
+	if len(tc.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(tc.Steps))
+	}
+	if tc.Steps[0].OmitIf["order.total"] != "after == 0" {
+		t.Errorf("unexpected omitIf: %v", tc.Steps[0].OmitIf)
+	}
+	if len(tc.Steps[1].Let) != 1 || tc.Steps[1].Let[0].Name != "large" {
+		t.Errorf("unexpected step let: %v", tc.Steps[1].Let)
+	}
+}
+
+func TestLoad_TransformLetNotMapping(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "flow.yaml", `
+name: bad-let
+source:
+  type: http
+  config: {}
+transform:
+  let: [a, b]
+  fields:
+    a: "1"
+sink:
+  type: http
+  config: {}
+`)
+
+	_, err := NewLoader(dir, nil).loadFile(filepath.Join(dir, "flow.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "let must be a mapping") {
+		t.Fatalf("expected a let mapping error, got %v", err)
+	}
+}
+
 func TestWatch_NilOnChange(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, dir, "flow.yaml", `
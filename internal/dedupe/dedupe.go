@@ -0,0 +1,119 @@
+// Package dedupe remembers the keys of delivered events for a time window,
+// so that redelivered events can be recognized and dropped.
+package dedupe
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/lru"
+)
+
+// Store defaults.
+const (
+	DefaultWindow    = time.Hour
+	DefaultCacheSize = 100000
+	DefaultFileKeys  = 1 << 20
+)
+
+// Config configures a Store.
+type Config struct {
+	Window    time.Duration    // How long keys are remembered (default: DefaultWindow)
+	CacheSize int              // Keys held in the LRU cache (default: DefaultCacheSize)
+	Path      string           // Optional: file that keeps keys across restarts
+	FileKeys  int              // Keys the file has room for (default: DefaultFileKeys)
+	Clock     func() time.Time // default: time.Now
+}
+
+// Store remembers keys for a window. Recently used keys are held in an LRU
+// cache of CacheSize keys. With a Path, every key is also written to a file
+// of fixed size, about 24 bytes per key of FileKeys, that is searched for
+// keys missing from the cache, so keys evicted from the cache or remembered
+// before a restart are still found. The file stores 128-bit hashes of the
+// keys; when its part of the file is full, a key pushes out the key that
+// expires first. Memory use is bounded by CacheSize.
+type Store struct {
+	window time.Duration
+	clock  func() time.Time
+
+	mu    sync.Mutex
+	cache *lru.Cache
+	file  *fileStore // nil: in memory only
+}
+
+// Open creates a Store, opening or creating the file at cfg.Path if there
+// is one.
+func Open(cfg Config) (*Store, error) {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = DefaultCacheSize
+	}
+	if cfg.FileKeys <= 0 {
+		cfg.FileKeys = DefaultFileKeys
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = time.Now
+	}
+
+	s := &Store{window: cfg.Window, clock: cfg.Clock, cache: lru.New(cfg.CacheSize)}
+	if cfg.Path != "" {
+		f, err := openFileStore(cfg.Path, cfg.FileKeys, cfg.Clock())
+		if err != nil {
+			return nil, fmt.Errorf("dedupe store: %w", err)
+		}
+		s.file = f
+	}
+	return s, nil
+}
+
+// Seen reports whether key was added within the window.
+func (s *Store) Seen(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock()
+	if _, ok := s.cache.Get(key, now); ok {
+		return true, nil
+	}
+	if s.file == nil {
+		return false, nil
+	}
+	expires, ok, err := s.file.get(key)
+	if err != nil || !ok || !now.Before(expires) {
+		return false, err
+	}
+	s.cache.Put(key, nil, expires)
+	return true, nil
+}
+
+// Add remembers key for the window, starting now. With a Path, it returns
+// once the key is synced to disk; the sync happens outside the lock of the
+// store and is shared by concurrent calls.
+func (s *Store) Add(key string) error {
+	s.mu.Lock()
+	now := s.clock()
+	expires := now.Add(s.window)
+	s.cache.Put(key, nil, expires)
+	if s.file == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	err := s.file.put(key, expires, now)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.file.sync()
+}
+
+// Close closes the file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.close()
+}
@@ -0,0 +1,201 @@
+package dedupe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func mustSeen(t *testing.T, s *Store, key string, want bool) {
+	t.Helper()
+	seen, err := s.Seen(key)
+	if err != nil {
+		t.Fatalf("Seen(%q): %v", key, err)
+	}
+	if seen != want {
+		t.Errorf("Seen(%q) = %v, want %v", key, seen, want)
+	}
+}
+
+func mustAdd(t *testing.T, s *Store, key string) {
+	t.Helper()
+	if err := s.Add(key); err != nil {
+		t.Fatalf("Add(%q): %v", key, err)
+	}
+}
+
+func TestStore_Window(t *testing.T) {
+	c := &clock{now: time.Unix(1000, 0)}
+	s, err := Open(Config{Window: time.Minute, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	mustSeen(t, s, "a", false)
+	mustAdd(t, s, "a")
+	mustSeen(t, s, "a", true)
+	mustSeen(t, s, "b", false)
+
+	c.now = c.now.Add(time.Minute - time.Nanosecond)
+	mustSeen(t, s, "a", true)
+	c.now = c.now.Add(time.Nanosecond)
+	mustSeen(t, s, "a", false)
+}
+
+func TestStore_CacheEviction(t *testing.T) {
+	s, err := Open(Config{CacheSize: 2})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustAdd(t, s, "a")
+	mustAdd(t, s, "b")
+	mustSeen(t, s, "a", true) // b is now the least recently used
+	mustAdd(t, s, "c")
+	mustSeen(t, s, "b", false)
+	mustSeen(t, s, "a", true)
+	mustSeen(t, s, "c", true)
+}
+
+func TestStore_FileSurvivesEvictionAndRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedupe", "orders.db")
+	c := &clock{now: time.Unix(1000, 0)}
+
+	s, err := Open(Config{Window: time.Minute, CacheSize: 1, Path: path, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustAdd(t, s, "a")
+	mustAdd(t, s, "b") // evicts a from the cache
+	mustSeen(t, s, "a", true)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	c.now = c.now.Add(30 * time.Second)
+	s, err = Open(Config{Window: time.Minute, Path: path, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	mustSeen(t, s, "a", true)
+	mustSeen(t, s, "b", true)
+	mustSeen(t, s, "c", false)
+
+	c.now = c.now.Add(30 * time.Second)
+	mustSeen(t, s, "a", false)
+}
+
+func TestStore_FileFullBucketForgetsOldest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.db")
+	c := &clock{now: time.Unix(1000, 0)}
+	s, err := Open(Config{Window: time.Minute, CacheSize: 1, Path: path, FileKeys: bucketSlots, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	for i := 0; i <= bucketSlots; i++ {
+		mustAdd(t, s, fmt.Sprintf("key-%d", i))
+		c.now = c.now.Add(time.Millisecond)
+	}
+	mustSeen(t, s, "key-0", false)
+	mustSeen(t, s, "key-1", true)
+	mustSeen(t, s, fmt.Sprintf("key-%d", bucketSlots), true)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != fileSize(1) {
+		t.Errorf("file size = %d, want %d", info.Size(), fileSize(1))
+	}
+}
+
+func TestStore_FileResize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.db")
+	c := &clock{now: time.Unix(1000, 0)}
+	s, err := Open(Config{Window: time.Minute, Path: path, FileKeys: bucketSlots, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	mustAdd(t, s, "a")
+	c.now = c.now.Add(time.Minute)
+	mustAdd(t, s, "b")
+	_ = s.Close()
+
+	s, err = Open(Config{Window: time.Minute, Path: path, FileKeys: 4 * bucketSlots, Clock: c.Now})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	mustSeen(t, s, "a", false)
+	mustSeen(t, s, "b", true)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != fileSize(4) {
+		t.Errorf("file size = %d, want %d", info.Size(), fileSize(4))
+	}
+}
+
+func TestStore_FileConcurrentAdds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.db")
+	s, err := Open(Config{CacheSize: 1, Path: path})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := s.Add(fmt.Sprintf("key-%d-%d", w, i)); err != nil {
+					t.Errorf("Add: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	_ = s.Close()
+
+	s, err = Open(Config{Path: path})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	for w := 0; w < 8; w++ {
+		for i := 0; i < 50; i++ {
+			mustSeen(t, s, fmt.Sprintf("key-%d-%d", w, i), true)
+		}
+	}
+}
+
+func TestOpen_NotAStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.db")
+	if err := os.WriteFile(path, []byte("some other file content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(Config{Path: path}); err == nil || !strings.Contains(err.Error(), "not a dedupe store") {
+		t.Errorf("expected a not a dedupe store error, got %v", err)
+	}
+}
+
+func TestOpen_Error(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Open(Config{Path: dir}); err == nil {
+		t.Error("expected an error for a directory path")
+	}
+}
@@ -0,0 +1,259 @@
+package dedupe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The file is a hash table of fixed size: a header followed by buckets of
+// slots. A slot holds the first 128 bits of the SHA-256 hash of a key and
+// its expiry in Unix nanoseconds, big endian; a zero expiry marks an empty
+// slot. At 128 bits, distinct keys do not collide in practice.
+const (
+	hashSize    = 16
+	slotSize    = hashSize + 8
+	bucketSlots = 64
+	bucketSize  = bucketSlots * slotSize
+	headerSize  = 16 // magic and bucket count
+)
+
+var fileMagic = [8]byte{'f', 'i', 's', 'o', 'd', 'd', 'p', '1'}
+
+type keyHash [hashSize]byte
+
+// fileStore keeps key hashes in a file of fixed size, looking them up with
+// a read of one bucket, so memory use does not grow with the number of
+// keys. A key is written to the bucket its hash selects, into the slot that
+// already holds it, an empty or expired slot, or else the slot that expires
+// first: once a bucket is full of unexpired keys, the oldest are forgotten.
+// Writes are made durable by sync, which concurrent callers share. get and
+// put are not safe for concurrent use.
+type fileStore struct {
+	path    string
+	file    *os.File
+	buckets uint64
+
+	written atomic.Uint64 // slots written
+
+	syncMu sync.Mutex
+	synced uint64 // slots written before the last sync
+}
+
+// openFileStore opens the file at path, creating it with room for about
+// keys keys. A file created with another size is rebuilt with its
+// unexpired keys.
+func openFileStore(path string, keys int, now time.Time) (*fileStore, error) {
+	path = filepath.Clean(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	buckets := uint64((keys + bucketSlots - 1) / bucketSlots)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	s := &fileStore{path: path, file: f, buckets: buckets}
+	if err := s.load(now); err != nil {
+		_ = s.file.Close()
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// load initializes an empty file, or checks the header of an existing one
+// and rebuilds it if its size differs.
+func (s *fileStore) load(now time.Time) error {
+	var header [headerSize]byte
+	_, err := s.file.ReadAt(header[:], 0)
+	if errors.Is(err, io.EOF) {
+		return initFile(s.file, s.buckets)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:8], fileMagic[:]) {
+		return errors.New("not a dedupe store")
+	}
+	if buckets := binary.BigEndian.Uint64(header[8:]); buckets != s.buckets {
+		return s.resize(buckets, now)
+	}
+	// A crash while the file was created may have left it short.
+	return s.file.Truncate(fileSize(s.buckets))
+}
+
+func initFile(f *os.File, buckets uint64) error {
+	var header [headerSize]byte
+	copy(header[:], fileMagic[:])
+	binary.BigEndian.PutUint64(header[8:], buckets)
+	if _, err := f.WriteAt(header[:], 0); err != nil {
+		return err
+	}
+	if err := f.Truncate(fileSize(buckets)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func fileSize(buckets uint64) int64 {
+	return headerSize + int64(buckets)*bucketSize
+}
+
+// resize rewrites the file, which has the given number of buckets, with
+// s.buckets buckets and its unexpired keys.
+func (s *fileStore) resize(buckets uint64, now time.Time) error {
+	tmp, err := os.OpenFile(s.path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	resized := &fileStore{path: s.path, file: tmp, buckets: s.buckets}
+	if err := initFile(tmp, s.buckets); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	var bucket [bucketSize]byte
+	for b := uint64(0); b < buckets; b++ {
+		_, err := s.file.ReadAt(bucket[:], headerSize+int64(b)*bucketSize)
+		if errors.Is(err, io.EOF) {
+			break // left short by a crash while it was created
+		}
+		if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		for i := 0; i < bucketSlots; i++ {
+			h, expires := decodeSlot(bucket[i*slotSize:])
+			if expires <= now.UnixNano() {
+				continue
+			}
+			if err := resized.putHash(h, expires, now); err != nil {
+				_ = tmp.Close()
+				return err
+			}
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	_ = s.file.Close()
+	s.file = tmp
+	return nil
+}
+
+// get returns the expiry of key, if it is in the file.
+func (s *fileStore) get(key string) (time.Time, bool, error) {
+	h := hashKey(key)
+	var bucket [bucketSize]byte
+	if _, err := s.file.ReadAt(bucket[:], s.bucketOffset(h)); err != nil {
+		return time.Time{}, false, fmt.Errorf("read %s: %w", s.path, err)
+	}
+	for i := 0; i < bucketSlots; i++ {
+		sh, expires := decodeSlot(bucket[i*slotSize:])
+		if expires != 0 && sh == h {
+			return time.Unix(0, expires), true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// put writes key to the file. The write is durable once sync returns.
+func (s *fileStore) put(key string, expires, now time.Time) error {
+	if err := s.putHash(hashKey(key), expires.UnixNano(), now); err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *fileStore) putHash(h keyHash, expires int64, now time.Time) error {
+	off := s.bucketOffset(h)
+	var bucket [bucketSize]byte
+	if _, err := s.file.ReadAt(bucket[:], off); err != nil {
+		return err
+	}
+	slot, free := -1, -1
+	for i := 0; i < bucketSlots; i++ {
+		sh, se := decodeSlot(bucket[i*slotSize:])
+		if se != 0 && sh == h {
+			free = i
+			break
+		}
+		if se <= now.UnixNano() {
+			if free < 0 {
+				free = i
+			}
+			continue
+		}
+		if slot < 0 || se < expiryAt(bucket[:], slot) {
+			slot = i
+		}
+	}
+	if free >= 0 {
+		slot = free
+	}
+	if _, err := s.file.WriteAt(encodeSlot(h, expires), off+int64(slot)*slotSize); err != nil {
+		return err
+	}
+	s.written.Add(1)
+	return nil
+}
+
+// sync makes the slots written so far durable. Concurrent calls share one
+// fsync: a call whose writes were synced by another returns at once.
+func (s *fileStore) sync() error {
+	target := s.written.Load()
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if s.synced >= target {
+		return nil
+	}
+	target = s.written.Load()
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", s.path, err)
+	}
+	s.synced = target
+	return nil
+}
+
+func (s *fileStore) close() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.file.Close()
+}
+
+func (s *fileStore) bucketOffset(h keyHash) int64 {
+	return headerSize + int64(binary.BigEndian.Uint64(h[:8])%s.buckets)*bucketSize
+}
+
+func hashKey(key string) keyHash {
+	sum := sha256.Sum256([]byte(key))
+	return keyHash(sum[:hashSize])
+}
+
+func encodeSlot(h keyHash, expires int64) []byte {
+	slot := make([]byte, slotSize)
+	copy(slot, h[:])
+	binary.BigEndian.PutUint64(slot[hashSize:], uint64(expires))
+	return slot
+}
+
+func decodeSlot(slot []byte) (keyHash, int64) {
+	return keyHash(slot[:hashSize]), int64(binary.BigEndian.Uint64(slot[hashSize:slotSize]))
+}
+
+func expiryAt(bucket []byte, i int) int64 {
+	_, expires := decodeSlot(bucket[i*slotSize:])
+	return expires
+}
@@ -20,8 +20,13 @@ type FailureInfo struct {
 	RetryCount    int
 	FlowName      string
 	CorrelationID string
+	Route         string // Optional: the route of a flow whose delivery failed
 }
 
+// HeaderRoute names the failed route of DLQ messages from flows with
+// routes. A flow delivers an event carrying it only to that route.
+const HeaderRoute = "fiso-route"
+
 // Handler publishes failed events to a Dead Letter Queue topic.
 type Handler struct {
 	publisher Publisher
@@ -63,6 +68,9 @@ func (h *Handler) Send(ctx context.Context, key, value []byte, info FailureInfo)
 		"fiso-flow-name":      info.FlowName,
 		"fiso-correlation-id": info.CorrelationID,
 	}
+	if info.Route != "" {
+		headers[HeaderRoute] = info.Route
+	}
 
 	if err := h.publisher.Publish(ctx, topic, key, value, headers); err != nil {
 		return fmt.Errorf("dlq publish to %s: %w", topic, err)
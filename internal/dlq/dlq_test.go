@@ -104,6 +104,22 @@ func TestSend_HeadersPopulated(t *testing.T) {
 	if headers["fiso-failed-at"] == "" {
 		t.Error("fiso-failed-at header is empty")
 	}
+	if _, ok := headers[HeaderRoute]; ok {
+		t.Errorf("unexpected %s header without a route", HeaderRoute)
+	}
+}
+
+func TestSend_RouteHeader(t *testing.T) {
+	pub := &mockPublisher{}
+	h := NewHandler(pub)
+
+	err := h.Send(context.Background(), nil, []byte(`{}`), FailureInfo{FlowName: "orders", Route: "webhook"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := pub.published[0].headers[HeaderRoute]; got != "webhook" {
+		t.Errorf("header %s: got %q, want %q", HeaderRoute, got, "webhook")
+	}
 }
 
 func TestSend_CustomTopicFunc(t *testing.T) {
@@ -0,0 +1,34 @@
+package enrich
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/lru"
+)
+
+// cache holds lookup results for a TTL, evicting the least recently used
+// result when it is full.
+type cache struct {
+	ttl   time.Duration
+	clock func() time.Time
+
+	mu      sync.Mutex
+	entries *lru.Cache
+}
+
+func newCache(ttl time.Duration, size int, clock func() time.Time) *cache {
+	return &cache{ttl: ttl, clock: clock, entries: lru.New(size)}
+}
+
+func (c *cache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Get(key, c.clock())
+}
+
+func (c *cache) put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Put(key, value, c.clock().Add(c.ttl))
+}
@@ -0,0 +1,77 @@
+// Package enrich adds looked-up data to events before they are transformed:
+// responses of fiso-link targets or rows of static lookup tables.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lsm/fiso/internal/source"
+)
+
+// DefaultTimeout bounds each lookup when no timeout is configured.
+const DefaultTimeout = 5 * time.Second
+
+// Lookup finds the data of an event. input is the parsed JSON payload.
+type Lookup interface {
+	Lookup(ctx context.Context, input map[string]interface{}, evt source.Event) (interface{}, error)
+}
+
+// Enricher stores the result of a Lookup in the data object of events,
+// under a configured key.
+type Enricher struct {
+	as      string
+	lookup  Lookup
+	timeout time.Duration
+}
+
+// New creates an Enricher storing the result of lookup as data.<as>. A
+// zero timeout defaults to DefaultTimeout.
+func New(as string, lookup Lookup, timeout time.Duration) *Enricher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Enricher{as: as, lookup: lookup, timeout: timeout}
+}
+
+// Enrich implements pipeline.Enricher. Payloads without a data object get
+// one; the rest of the payload is kept as is.
+func (e *Enricher) Enrich(ctx context.Context, payload []byte, evt source.Event) ([]byte, error) {
+	var input map[string]interface{}
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, fmt.Errorf("enrich %s: unmarshal input: %w", e.as, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	v, err := e.lookup.Lookup(ctx, input, evt)
+	if err != nil {
+		return nil, fmt.Errorf("enrich %s: %w", e.as, err)
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("enrich %s: marshal result: %w", e.as, err)
+	}
+
+	// Merge into the raw payload so that numbers keep their precision
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &top); err != nil {
+		return nil, fmt.Errorf("enrich %s: unmarshal input: %w", e.as, err)
+	}
+	if top == nil {
+		top = make(map[string]json.RawMessage)
+	}
+	data := make(map[string]json.RawMessage)
+	if raw, ok := top["data"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("enrich %s: data is not an object", e.as)
+		}
+	}
+	data[e.as] = value
+	if top["data"], err = json.Marshal(data); err != nil {
+		return nil, fmt.Errorf("enrich %s: marshal data: %w", e.as, err)
+	}
+	return json.Marshal(top)
+}
@@ -0,0 +1,304 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/source"
+)
+
+type lookupFunc func(ctx context.Context, input map[string]interface{}, evt source.Event) (interface{}, error)
+
+func (f lookupFunc) Lookup(ctx context.Context, input map[string]interface{}, evt source.Event) (interface{}, error) {
+	return f(ctx, input, evt)
+}
+
+func constLookup(v interface{}) Lookup {
+	return lookupFunc(func(context.Context, map[string]interface{}, source.Event) (interface{}, error) { return v, nil })
+}
+
+func TestEnricher_Enrich(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"merges into data", `{"data":{"id":12345678901234567890},"type":"order"}`, `{"data":{"customer":{"tier":"gold"},"id":12345678901234567890},"type":"order"}`},
+		{"creates data", `{"type":"order"}`, `{"data":{"customer":{"tier":"gold"}},"type":"order"}`},
+		{"null data", `{"data":null}`, `{"data":{"customer":{"tier":"gold"}}}`},
+	}
+	e := New("customer", constLookup(map[string]interface{}{"tier": "gold"}), 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Enrich(context.Background(), []byte(tt.payload), source.Event{})
+			if err != nil {
+				t.Fatalf("Enrich: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Enrich = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnricher_Errors(t *testing.T) {
+	e := New("customer", constLookup("x"), 0)
+	for _, payload := range []string{`not json`, `{"data":"text"}`} {
+		if _, err := e.Enrich(context.Background(), []byte(payload), source.Event{}); err == nil {
+			t.Errorf("expected an error for %s", payload)
+		}
+	}
+
+	failing := New("customer", lookupFunc(func(context.Context, map[string]interface{}, source.Event) (interface{}, error) {
+		return nil, errors.New("unavailable")
+	}), 0)
+	_, err := failing.Enrich(context.Background(), []byte(`{}`), source.Event{})
+	if err == nil || !strings.Contains(err.Error(), "enrich customer: unavailable") {
+		t.Errorf("expected the lookup error, got %v", err)
+	}
+}
+
+func TestEnricher_Timeout(t *testing.T) {
+	slow := lookupFunc(func(ctx context.Context, _ map[string]interface{}, _ source.Event) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	e := New("customer", slow, 10*time.Millisecond)
+	_, err := e.Enrich(context.Background(), []byte(`{}`), source.Event{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestLink_Lookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/link/crm/customers/C1":
+			_, _ = w.Write([]byte(`{"tier":"gold"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/link/crm/search" && string(body) == `{"id":"C1"}`:
+			_, _ = w.Write([]byte(`[{"tier":"gold"}]`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	input := map[string]interface{}{"data": map[string]interface{}{"customer_id": "C1"}}
+
+	get, err := NewLink(LinkConfig{Addr: srv.URL, Target: "crm", Path: `"/customers/" + data.customer_id`})
+	if err != nil {
+		t.Fatalf("NewLink: %v", err)
+	}
+	v, err := get.Lookup(context.Background(), input, source.Event{})
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if m, _ := v.(map[string]interface{}); m["tier"] != "gold" {
+		t.Errorf("Lookup = %v", v)
+	}
+
+	post, err := NewLink(LinkConfig{Addr: srv.URL + "/", Target: "crm", Method: "post", Path: `"search"`, Body: `{"id": data.customer_id}`})
+	if err != nil {
+		t.Fatalf("NewLink: %v", err)
+	}
+	if _, err := post.Lookup(context.Background(), input, source.Event{}); err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+
+	missing, err := NewLink(LinkConfig{Addr: srv.URL, Target: "crm", Path: `"/customers/unknown"`})
+	if err != nil {
+		t.Fatalf("NewLink: %v", err)
+	}
+	_, err = missing.Lookup(context.Background(), input, source.Event{})
+	if err == nil || !strings.Contains(err.Error(), "returned 404: not found") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestLink_EscapesPath(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.EscapedPath()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	input := map[string]interface{}{"data": map[string]interface{}{"id": "a b/c?d#e"}}
+	tests := []struct {
+		name, path, want, wantErr string
+	}{
+		{"string", `"/customers/" + data.id`, "/link/crm/customers/a%20b/c%3Fd%23e", ""},
+		{"list", `["customers", data.id]`, "/link/crm/customers/a%20b%2Fc%3Fd%23e", ""},
+		{"dot segment", `"/customers/../admin"`, "", `segment ".." is not allowed`},
+		{"list of numbers", `["customers", 1]`, "", "list elements must be strings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			l, err := NewLink(LinkConfig{Addr: srv.URL, Target: "crm", Path: tt.path})
+			if err != nil {
+				t.Fatalf("NewLink: %v", err)
+			}
+			_, err = l.Lookup(context.Background(), input, source.Event{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("requested %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLink_Cache(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer srv.Close()
+
+	now := time.Unix(0, 0)
+	l, err := NewLink(LinkConfig{Addr: srv.URL, Target: "crm", Path: "data.id", CacheTTL: time.Minute, CacheSize: 1},
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("NewLink: %v", err)
+	}
+	lookup := func(id string) {
+		t.Helper()
+		input := map[string]interface{}{"data": map[string]interface{}{"id": id}}
+		if _, err := l.Lookup(context.Background(), input, source.Event{}); err != nil {
+			t.Fatalf("Lookup: %v", err)
+		}
+	}
+
+	lookup("a")
+	lookup("a")
+	if calls.Load() != 1 {
+		t.Fatalf("expected a cached response, got %d calls", calls.Load())
+	}
+	now = now.Add(time.Minute)
+	lookup("a")
+	if calls.Load() != 2 {
+		t.Fatalf("expected the entry to expire, got %d calls", calls.Load())
+	}
+	lookup("b") // evicts a
+	lookup("a")
+	if calls.Load() != 4 {
+		t.Fatalf("expected a full cache to evict, got %d calls", calls.Load())
+	}
+}
+
+func TestLink_Errors(t *testing.T) {
+	if _, err := NewLink(LinkConfig{}); err == nil {
+		t.Error("expected an error without target")
+	}
+	if _, err := NewLink(LinkConfig{Target: "crm", Path: ">>>"}); err == nil {
+		t.Error("expected an error for an invalid path")
+	}
+	if _, err := NewLink(LinkConfig{Target: "crm", Body: ">>>"}); err == nil {
+		t.Error("expected an error for an invalid body")
+	}
+
+	l, err := NewLink(LinkConfig{Addr: "http://127.0.0.1:1", Target: "crm", Path: "data.id"})
+	if err != nil {
+		t.Fatalf("NewLink: %v", err)
+	}
+	input := map[string]interface{}{"data": map[string]interface{}{"id": 1}}
+	if _, err := l.Lookup(context.Background(), input, source.Event{}); err == nil || !strings.Contains(err.Error(), "path: must be a string") {
+		t.Errorf("expected a path type error, got %v", err)
+	}
+	input = map[string]interface{}{"data": map[string]interface{}{"id": "1"}}
+	if _, err := l.Lookup(context.Background(), input, source.Event{}); err == nil || !strings.Contains(err.Error(), "link target crm") {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func writeTable(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write table: %v", err)
+	}
+	return path
+}
+
+func TestTable_Lookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		keyColumn string
+		key       string
+		want      string
+	}{
+		{"csv first column", "tiers.csv", "id,tier\nC1,gold\nC2,silver\n", "", `"C2"`, "silver"},
+		{"csv key column", "tiers.csv", "tier,customer\ngold,C1\n", "customer", `"C1"`, "gold"},
+		{"json object", "tiers.json", `{"C1": {"tier": "gold"}}`, "", `"C1"`, "gold"},
+		{"json array", "tiers.json", `[{"id": 7, "tier": "gold"}]`, "", "data.customer_id", "gold"},
+	}
+	input := map[string]interface{}{"data": map[string]interface{}{"customer_id": float64(7)}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := LoadTable(TableConfig{File: writeTable(t, tt.file, tt.content), Key: tt.key, KeyColumn: tt.keyColumn})
+			if err != nil {
+				t.Fatalf("LoadTable: %v", err)
+			}
+			v, err := table.Lookup(context.Background(), input, source.Event{})
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			if row, _ := v.(map[string]interface{}); row["tier"] != tt.want {
+				t.Errorf("Lookup = %v, want tier %s", v, tt.want)
+			}
+		})
+	}
+
+	table, err := LoadTable(TableConfig{File: writeTable(t, "tiers.csv", "id,tier\n"), Key: `"C9"`})
+	if err != nil {
+		t.Fatalf("LoadTable: %v", err)
+	}
+	if v, err := table.Lookup(context.Background(), input, source.Event{}); err != nil || v != nil {
+		t.Errorf("expected a missing row to be nil, got %v, %v", v, err)
+	}
+}
+
+func TestLoadTable_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  TableConfig
+	}{
+		{"no file", TableConfig{Key: `"a"`}},
+		{"no key", TableConfig{File: writeTable(t, "t.csv", "id\n")}},
+		{"missing file", TableConfig{File: filepath.Join(t.TempDir(), "missing.csv"), Key: `"a"`}},
+		{"format", TableConfig{File: writeTable(t, "t.yaml", "a: b"), Key: `"a"`}},
+		{"empty csv", TableConfig{File: writeTable(t, "t.csv", ""), Key: `"a"`}},
+		{"csv key column", TableConfig{File: writeTable(t, "t.csv", "id\n"), Key: `"a"`, KeyColumn: "code"}},
+		{"json scalar", TableConfig{File: writeTable(t, "t.json", `42`), Key: `"a"`}},
+		{"json row", TableConfig{File: writeTable(t, "t.json", `[1]`), Key: `"a"`}},
+		{"json key field", TableConfig{File: writeTable(t, "t.json", `[{"code": 1}]`), Key: `"a"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadTable(tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
+)
+
+// Link defaults.
+const (
+	DefaultLinkAddr  = "http://localhost:3500"
+	DefaultCacheSize = 10000
+)
+
+// maxResponseBytes bounds the link responses read.
+const maxResponseBytes = 1 << 20 // 1MB
+
+// HTTPClient abstracts HTTP calls for testing.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// LinkConfig configures a Link. Path and Body are CEL expressions with the
+// variables of a transform.
+type LinkConfig struct {
+	Addr      string        // fiso-link address (default: DefaultLinkAddr)
+	Target    string        // Link target name
+	Method    string        // default: GET
+	Path      string        // Optional: path under the target, e.g. '"/customers/" + data.customer_id' or '["customers", data.customer_id]'
+	Body      string        // Optional: request body, sent as JSON
+	CacheTTL  time.Duration // 0: no caching
+	CacheSize int           // default: DefaultCacheSize
+	Functions []celext.Option
+}
+
+// LinkOption configures a Link.
+type LinkOption func(*Link)
+
+// WithHTTPClient sets the HTTP client.
+func WithHTTPClient(c HTTPClient) LinkOption {
+	return func(l *Link) { l.client = c }
+}
+
+// WithClock sets the clock of the cache (for testing).
+func WithClock(clock func() time.Time) LinkOption {
+	return func(l *Link) { l.clock = clock }
+}
+
+// Link looks up data through a fiso-link target. Responses are cached by
+// request for CacheTTL.
+type Link struct {
+	target string
+	url    string
+	method string
+	path   *unifiedxform.Expression
+	body   *unifiedxform.Expression
+	client HTTPClient
+	clock  func() time.Time
+	cache  *cache
+}
+
+// NewLink creates a Link.
+func NewLink(cfg LinkConfig, opts ...LinkOption) (*Link, error) {
+	if cfg.Target == "" {
+		return nil, fmt.Errorf("link target is required")
+	}
+	addr := cfg.Addr
+	if addr == "" {
+		addr = DefaultLinkAddr
+	}
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	l := &Link{
+		target: cfg.Target,
+		url:    strings.TrimSuffix(addr, "/") + "/link/" + cfg.Target,
+		method: method,
+		client: http.DefaultClient,
+		clock:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	var err error
+	if cfg.Path != "" {
+		if l.path, err = unifiedxform.NewExpression(cfg.Path, cfg.Functions...); err != nil {
+			return nil, fmt.Errorf("path: %w", err)
+		}
+	}
+	if cfg.Body != "" {
+		if l.body, err = unifiedxform.NewExpression(cfg.Body, cfg.Functions...); err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+	}
+	if cfg.CacheTTL > 0 {
+		size := cfg.CacheSize
+		if size <= 0 {
+			size = DefaultCacheSize
+		}
+		l.cache = newCache(cfg.CacheTTL, size, l.clock)
+	}
+	return l, nil
+}
+
+// Lookup implements Lookup. It returns the JSON response of the target; a
+// response other than 2xx is an error.
+func (l *Link) Lookup(ctx context.Context, input map[string]interface{}, evt source.Event) (interface{}, error) {
+	url := l.url
+	if l.path != nil {
+		v, err := l.path.Eval(input, evt)
+		if err != nil {
+			return nil, fmt.Errorf("path: %w", err)
+		}
+		path, err := escapePath(v)
+		if err != nil {
+			return nil, fmt.Errorf("path: %w", err)
+		}
+		url += path
+	}
+	var body []byte
+	if l.body != nil {
+		v, err := l.body.Eval(input, evt)
+		if err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+		if body, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+	}
+
+	key := l.method + " " + url + "\n" + string(body)
+	if l.cache != nil {
+		if v, ok := l.cache.get(key); ok {
+			return v, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, l.method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("link target %s: %w", l.target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("link target %s returned %d: %s", l.target, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var v interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&v); err != nil {
+		return nil, fmt.Errorf("link target %s: decode response: %w", l.target, err)
+	}
+	if l.cache != nil {
+		l.cache.put(key, v)
+	}
+	return v, nil
+}
+
+// escapePath escapes the value of a path expression with url.PathEscape,
+// segment by segment. A string is split into segments at its slashes; each
+// element of a list is one segment, so that values containing slashes stay
+// in their segment. Dot segments are rejected, so that event data cannot
+// address paths outside the one built by the expression.
+func escapePath(v interface{}) (string, error) {
+	var segments []string
+	switch p := v.(type) {
+	case string:
+		segments = strings.Split(strings.TrimPrefix(p, "/"), "/")
+	case []interface{}:
+		for _, e := range p {
+			s, ok := e.(string)
+			if !ok {
+				return "", fmt.Errorf("list elements must be strings, got %T", e)
+			}
+			segments = append(segments, s)
+		}
+	default:
+		return "", fmt.Errorf("must be a string or a list of strings, got %T", v)
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		if s == "." || s == ".." {
+			return "", fmt.Errorf("segment %q is not allowed", s)
+		}
+		b.WriteString("/")
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,148 @@
+package enrich
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
+)
+
+// TableConfig configures a Table.
+type TableConfig struct {
+	File      string // .csv or .json file
+	Key       string // CEL expression of the row key, with the variables of a transform
+	KeyColumn string // Column or field holding the row key (default: first CSV column, or "id")
+	Functions []celext.Option
+}
+
+// Table looks up rows of a CSV or JSON table loaded once from disk.
+//
+// A CSV file has a header row; each row becomes an object of its columns.
+// A JSON file holds either an object of rows by key or an array of objects.
+type Table struct {
+	key  *unifiedxform.Expression
+	rows map[string]interface{}
+}
+
+// LoadTable loads the table of cfg.
+func LoadTable(cfg TableConfig) (*Table, error) {
+	if cfg.File == "" {
+		return nil, fmt.Errorf("table file is required")
+	}
+	key, err := unifiedxform.NewExpression(cfg.Key, cfg.Functions...)
+	if err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(cfg.File))
+	if err != nil {
+		return nil, fmt.Errorf("read table: %w", err)
+	}
+	var rows map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(cfg.File)); ext {
+	case ".csv":
+		rows, err = csvRows(data, cfg.KeyColumn)
+	case ".json":
+		rows, err = jsonRows(data, cfg.KeyColumn)
+	default:
+		err = fmt.Errorf("unsupported table format %q (must be .csv or .json)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", cfg.File, err)
+	}
+	return &Table{key: key, rows: rows}, nil
+}
+
+func csvRows(data []byte, keyColumn string) (map[string]interface{}, error) {
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	header := records[0]
+	keyIdx := 0
+	if keyColumn != "" {
+		keyIdx = -1
+		for i, name := range header {
+			if name == keyColumn {
+				keyIdx = i
+			}
+		}
+		if keyIdx < 0 {
+			return nil, fmt.Errorf("key column %q not found", keyColumn)
+		}
+	}
+
+	rows := make(map[string]interface{}, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows[record[keyIdx]] = row
+	}
+	return rows, nil
+}
+
+func jsonRows(data []byte, keyColumn string) (map[string]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		return doc, nil
+	case []interface{}:
+		if keyColumn == "" {
+			keyColumn = "id"
+		}
+		rows := make(map[string]interface{}, len(doc))
+		for i, item := range doc {
+			row, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("row %d is not an object", i)
+			}
+			key, ok := row[keyColumn]
+			if !ok {
+				return nil, fmt.Errorf("row %d has no %q field", i, keyColumn)
+			}
+			rows[keyString(key)] = row
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("must hold an object or an array of objects")
+	}
+}
+
+// Lookup implements Lookup. It returns the row of the key, or nil.
+func (t *Table) Lookup(_ context.Context, input map[string]interface{}, evt source.Event) (interface{}, error) {
+	key, err := t.key.Eval(input, evt)
+	if err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+	return t.rows[keyString(key)], nil
+}
+
+// keyString formats a key; whole numbers have no decimals, so the number
+// 42 finds the row "42".
+func keyString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+	}
+	return fmt.Sprint(v)
+}
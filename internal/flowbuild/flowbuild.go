@@ -0,0 +1,341 @@
+// Package flowbuild assembles the stages of a flow pipeline from its
+// definition. It is shared by the flow binaries, which only differ in how
+// they build sources, sinks and interceptors.
+package flowbuild
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lsm/fiso/internal/config"
+	"github.com/lsm/fiso/internal/dedupe"
+	"github.com/lsm/fiso/internal/enrich"
+	"github.com/lsm/fiso/internal/pipeline"
+	"github.com/lsm/fiso/internal/schema"
+	"github.com/lsm/fiso/internal/sink"
+	"github.com/lsm/fiso/internal/transform/celext"
+	unifiedxform "github.com/lsm/fiso/internal/transform/unified"
+)
+
+// SinkFunc builds the sink sc of a flow.
+type SinkFunc func(sc config.SinkConfig) (sink.Sink, error)
+
+// Stages sets the optional stages of flowDef on cfg: source decoding,
+// dedupe, filter, validation, enrich, batch, aggregate, ordering and
+// CloudEvents overrides. The dedupe store is closed if a later stage fails.
+func Stages(flowDef *config.FlowDefinition, cfg *pipeline.Config) (retErr error) {
+	lookups := flowDef.Lookups
+
+	// Decode wire-format source payloads (optional)
+	if raw, ok := flowDef.Source.Config["schema"].(map[string]interface{}); ok {
+		decoder, err := Decoder(raw)
+		if err != nil {
+			return fmt.Errorf("source schema: %w", err)
+		}
+		cfg.Decoder = decoder
+	}
+
+	// Acknowledge events already delivered (optional)
+	if d := flowDef.Dedupe; d != nil {
+		deduper, err := Dedupe(d, lookups)
+		if err != nil {
+			return fmt.Errorf("dedupe: %w", err)
+		}
+		cfg.Dedupe = deduper
+		defer func() {
+			if retErr != nil {
+				_ = deduper.Close()
+				cfg.Dedupe = nil
+			}
+		}()
+	}
+
+	// Drop events before transform (optional)
+	if flowDef.Filter != nil {
+		filter, err := unifiedxform.NewFilter(flowDef.Filter.Expr, celext.WithLookups(lookups))
+		if err != nil {
+			return fmt.Errorf("filter: %w", err)
+		}
+		cfg.Filter = filter
+		cfg.LogFiltered = flowDef.Filter.Log
+	}
+
+	// JSON Schema validation before and after transform (optional)
+	if flowDef.Validation != nil {
+		validation, err := Validation(flowDef.Validation)
+		if err != nil {
+			return fmt.Errorf("validate: %w", err)
+		}
+		cfg.Validation = validation
+	}
+
+	// Merge looked-up data into events before transform (optional)
+	if len(flowDef.Enrich) > 0 {
+		enrichers, err := Enrichers(flowDef.Enrich, lookups)
+		if err != nil {
+			return err
+		}
+		cfg.Enrichers = enrichers
+	}
+
+	// Deliver in batches (optional)
+	if b := flowDef.Batch; b != nil {
+		batch, err := Batch(b)
+		if err != nil {
+			return fmt.Errorf("batch: %w", err)
+		}
+		cfg.Batch = batch
+	}
+
+	// Deliver one event per key and window (optional)
+	if a := flowDef.Aggregate; a != nil {
+		aggregate, err := Aggregate(a, lookups)
+		if err != nil {
+			return fmt.Errorf("aggregate: %w", err)
+		}
+		cfg.Aggregate = aggregate
+	}
+
+	// Process events of different keys in parallel (optional)
+	if o := flowDef.Ordering; o != nil {
+		ordering, err := Ordering(o, lookups)
+		if err != nil {
+			return fmt.Errorf("ordering: %w", err)
+		}
+		cfg.Ordering = ordering
+	}
+
+	// Apply CloudEvents overrides from config
+	if ce := flowDef.CloudEvents; ce != nil {
+		cfg.CloudEvents = &pipeline.CloudEventsOverrides{
+			ID:              ce.ID,
+			Type:            ce.Type,
+			Source:          ce.Source,
+			Subject:         ce.Subject,
+			Data:            ce.Data,
+			DataContentType: ce.DataContentType,
+			DataSchema:      ce.DataSchema,
+		}
+	}
+	return nil
+}
+
+// Decoder builds the decoder of wire-format source payloads from the schema
+// section of a source config.
+func Decoder(raw map[string]interface{}) (schema.Codec, error) {
+	cfg, err := schema.DecoderConfigFromMap(raw)
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewDecoder(cfg)
+}
+
+// Transformer builds the unified transformer of tc, with the lookup tables
+// of its flow.
+func Transformer(tc *config.TransformConfig, lookups map[string]map[string]string) (*unifiedxform.Transformer, error) {
+	steps := []unifiedxform.Step{{Fields: tc.Fields, OmitIf: tc.OmitIf}}
+	if len(tc.Steps) > 0 {
+		steps = make([]unifiedxform.Step, len(tc.Steps))
+		for i, s := range tc.Steps {
+			steps[i] = unifiedxform.Step{Let: letBindings(s.Let), Fields: s.Fields, OmitIf: s.OmitIf}
+		}
+	}
+	return unifiedxform.NewStepTransformer(steps,
+		unifiedxform.WithLet(letBindings(tc.Let)),
+		unifiedxform.WithHeaders(tc.Headers),
+		unifiedxform.WithFunctions(celext.WithLookups(lookups)),
+	)
+}
+
+func letBindings(let config.Bindings) []unifiedxform.Binding {
+	bindings := make([]unifiedxform.Binding, len(let))
+	for i, b := range let {
+		bindings[i] = unifiedxform.Binding{Name: b.Name, Expr: b.Expr}
+	}
+	return bindings
+}
+
+// Router builds the routes of flowDef with their transforms, and their sinks
+// with buildSink.
+func Router(flowDef *config.FlowDefinition, buildSink SinkFunc) (*pipeline.Router, error) {
+	routes := make([]pipeline.Route, 0, len(flowDef.Routes))
+	for _, rc := range flowDef.Routes {
+		route := pipeline.Route{Name: rc.Name, When: rc.When}
+		if rc.Transform != nil {
+			tr, err := Transformer(rc.Transform, flowDef.Lookups)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: unified transformer: %w", rc.Name, err)
+			}
+			route.Transformer = tr
+		}
+		sk, err := buildSink(rc.Sink)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", rc.Name, err)
+		}
+		route.Sink = sk
+		routes = append(routes, route)
+	}
+	return pipeline.NewRouter(flowDef.RouteMode, routes, celext.WithLookups(flowDef.Lookups))
+}
+
+// Validation compiles the validate stage of a flow.
+func Validation(v *config.ValidationConfig) (*pipeline.Validation, error) {
+	validation := &pipeline.Validation{OnFailure: v.OnFailure}
+	for _, stage := range []struct {
+		name string
+		ref  *config.SchemaRef
+		dst  *schema.Validator
+	}{
+		{"before", v.Before, &validation.Before},
+		{"after", v.After, &validation.After},
+	} {
+		if stage.ref == nil {
+			continue
+		}
+		vc := schema.ValidatorConfig{
+			SchemaFile:  stage.ref.SchemaFile,
+			RegistryURL: stage.ref.RegistryURL,
+			Subject:     stage.ref.Subject,
+		}
+		if stage.ref.Schema != nil {
+			vc.Schema = stage.ref.Schema
+		}
+		if stage.ref.CacheTTL != "" {
+			ttl, err := time.ParseDuration(stage.ref.CacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cacheTTL: %w", stage.name, err)
+			}
+			vc.CacheTTL = ttl
+		}
+		validator, err := schema.NewValidator(vc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", stage.name, err)
+		}
+		*stage.dst = validator
+	}
+	return validation, nil
+}
+
+// Dedupe opens the dedupe store of a flow.
+func Dedupe(d *config.DedupeConfig, lookups map[string]map[string]string) (*pipeline.Dedupe, error) {
+	dc := dedupe.Config{CacheSize: d.CacheSize, Path: d.Path, FileKeys: d.FileKeys}
+	if d.Window != "" {
+		window, err := time.ParseDuration(d.Window)
+		if err != nil {
+			return nil, fmt.Errorf("window: %w", err)
+		}
+		dc.Window = window
+	}
+	store, err := dedupe.Open(dc)
+	if err != nil {
+		return nil, err
+	}
+	dd, err := pipeline.NewDedupe(d.Key, store, celext.WithLookups(lookups))
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	return dd, nil
+}
+
+// Batch builds the batch stage of a flow.
+func Batch(b *config.BatchConfig) (*pipeline.Batch, error) {
+	batch := &pipeline.Batch{MaxEvents: b.MaxEvents, MaxBytes: b.MaxBytes}
+	if b.MaxWait != "" {
+		maxWait, err := time.ParseDuration(b.MaxWait)
+		if err != nil {
+			return nil, fmt.Errorf("maxWait: %w", err)
+		}
+		batch.MaxWait = maxWait
+	}
+	return batch, nil
+}
+
+// Aggregate compiles the aggregate stage of a flow. Debounce is a session
+// window that delivers the latest event.
+func Aggregate(a *config.AggregateConfig, lookups map[string]map[string]string) (*pipeline.Aggregate, error) {
+	spec := pipeline.AggregateSpec{
+		Key:       a.Key,
+		Window:    a.Window,
+		Fields:    a.Fields,
+		MaxKeys:   a.MaxKeys,
+		MaxEvents: a.MaxEvents,
+		Functions: []celext.Option{celext.WithLookups(lookups)},
+	}
+	size := a.Size
+	if a.Debounce != "" {
+		spec.Window, size = pipeline.WindowSession, a.Debounce
+	}
+	var err error
+	if spec.Size, err = time.ParseDuration(size); err != nil {
+		return nil, fmt.Errorf("size: %w", err)
+	}
+	if a.MaxDuration != "" {
+		if spec.MaxDuration, err = time.ParseDuration(a.MaxDuration); err != nil {
+			return nil, fmt.Errorf("maxDuration: %w", err)
+		}
+	}
+	return pipeline.NewAggregate(spec)
+}
+
+// Ordering builds the keyed executor of a flow.
+func Ordering(o *config.OrderingConfig, lookups map[string]map[string]string) (*pipeline.Ordering, error) {
+	return pipeline.NewOrdering(o.Key, o.MaxInFlight, celext.WithLookups(lookups))
+}
+
+// Enrichers builds the enrich stage of a flow.
+func Enrichers(ecs []config.EnrichConfig, lookups map[string]map[string]string) ([]pipeline.Enricher, error) {
+	functions := []celext.Option{celext.WithLookups(lookups)}
+	enrichers := make([]pipeline.Enricher, 0, len(ecs))
+	for _, ec := range ecs {
+		var timeout time.Duration
+		if ec.Timeout != "" {
+			d, err := time.ParseDuration(ec.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("enrich %s: timeout: %w", ec.As, err)
+			}
+			timeout = d
+		}
+
+		var lookup enrich.Lookup
+		switch {
+		case ec.Link != nil:
+			lc := enrich.LinkConfig{
+				Addr:      ec.Link.Addr,
+				Target:    ec.Link.Target,
+				Method:    ec.Link.Method,
+				Path:      ec.Link.Path,
+				Body:      ec.Link.Body,
+				CacheSize: ec.Link.CacheSize,
+				Functions: functions,
+			}
+			if ec.Link.CacheTTL != "" {
+				ttl, err := time.ParseDuration(ec.Link.CacheTTL)
+				if err != nil {
+					return nil, fmt.Errorf("enrich %s: cacheTTL: %w", ec.As, err)
+				}
+				lc.CacheTTL = ttl
+			}
+			link, err := enrich.NewLink(lc)
+			if err != nil {
+				return nil, fmt.Errorf("enrich %s: %w", ec.As, err)
+			}
+			lookup = link
+		case ec.Table != nil:
+			table, err := enrich.LoadTable(enrich.TableConfig{
+				File:      ec.Table.File,
+				Key:       ec.Table.Key,
+				KeyColumn: ec.Table.KeyColumn,
+				Functions: functions,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("enrich %s: %w", ec.As, err)
+			}
+			lookup = table
+		default:
+			return nil, fmt.Errorf("enrich %s: link or table is required", ec.As)
+		}
+		enrichers = append(enrichers, enrich.New(ec.As, lookup, timeout))
+	}
+	return enrichers, nil
+}
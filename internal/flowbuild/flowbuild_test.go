@@ -0,0 +1,124 @@
+package flowbuild
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/config"
+	"github.com/lsm/fiso/internal/pipeline"
+	"github.com/lsm/fiso/internal/sink"
+)
+
+type nopSink struct{}
+
+func (nopSink) Deliver(context.Context, []byte, map[string]string) error { return nil }
+func (nopSink) Close() error                                             { return nil }
+
+func TestStages_SetsConfiguredStages(t *testing.T) {
+	flowDef := &config.FlowDefinition{
+		Dedupe:      &config.DedupeConfig{Key: "data.id"},
+		Filter:      &config.FilterConfig{Expr: "data.ok", Log: true},
+		Batch:       &config.BatchConfig{MaxEvents: 10, MaxWait: "2s"},
+		Ordering:    &config.OrderingConfig{Key: "data.id"},
+		CloudEvents: &config.CloudEventsConfig{Type: `"order.created"`},
+	}
+	var cfg pipeline.Config
+	if err := Stages(flowDef, &cfg); err != nil {
+		t.Fatalf("Stages: %v", err)
+	}
+	defer func() { _ = cfg.Dedupe.Close() }()
+
+	if cfg.Dedupe == nil || cfg.Filter == nil || cfg.Ordering == nil {
+		t.Fatalf("expected dedupe, filter and ordering, got %+v", cfg)
+	}
+	if !cfg.LogFiltered {
+		t.Error("expected LogFiltered")
+	}
+	if cfg.Batch == nil || cfg.Batch.MaxEvents != 10 || cfg.Batch.MaxWait != 2*time.Second {
+		t.Errorf("unexpected batch %+v", cfg.Batch)
+	}
+	if cfg.CloudEvents == nil || cfg.CloudEvents.Type != `"order.created"` {
+		t.Errorf("unexpected cloudevents overrides %+v", cfg.CloudEvents)
+	}
+	if cfg.Aggregate != nil || cfg.Validation != nil || cfg.Enrichers != nil || cfg.Decoder != nil {
+		t.Errorf("unexpected stages %+v", cfg)
+	}
+}
+
+func TestStages_FailedStageClosesDedupe(t *testing.T) {
+	flowDef := &config.FlowDefinition{
+		Dedupe: &config.DedupeConfig{Key: "data.id", Path: filepath.Join(t.TempDir(), "dedupe.db")},
+		Batch:  &config.BatchConfig{MaxWait: "soon"},
+	}
+	var cfg pipeline.Config
+	err := Stages(flowDef, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "batch: maxWait") {
+		t.Fatalf("expected batch maxWait error, got %v", err)
+	}
+	if cfg.Dedupe != nil {
+		t.Error("expected dedupe to be cleared")
+	}
+}
+
+func TestAggregate_InvalidDurations(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  config.AggregateConfig
+		want string
+	}{
+		{"size", config.AggregateConfig{Key: "data.id", Size: "1 minute"}, "size:"},
+		{"debounce", config.AggregateConfig{Key: "data.id", Debounce: "later"}, "size:"},
+		{"maxDuration", config.AggregateConfig{Key: "data.id", Debounce: "5s", MaxDuration: "forever"}, "maxDuration:"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Aggregate(&tc.cfg, nil)
+			if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+				t.Fatalf("expected %q error, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRouter_BuildsRouteSinks(t *testing.T) {
+	flowDef := &config.FlowDefinition{
+		Routes: []config.RouteConfig{
+			{Name: "a", When: "data.a", Sink: config.SinkConfig{Type: "http"}},
+			{Name: "b", Transform: &config.TransformConfig{Fields: map[string]string{"id": "data.id"}}, Sink: config.SinkConfig{Type: "kafka"}},
+		},
+	}
+	var types []string
+	router, err := Router(flowDef, func(sc config.SinkConfig) (sink.Sink, error) {
+		types = append(types, sc.Type)
+		return nopSink{}, nil
+	})
+	if err != nil {
+		t.Fatalf("Router: %v", err)
+	}
+	defer func() { _ = router.Close() }()
+	if strings.Join(types, ",") != "http,kafka" {
+		t.Errorf("expected sinks http,kafka, got %v", types)
+	}
+}
+
+func TestRouter_SinkError(t *testing.T) {
+	flowDef := &config.FlowDefinition{
+		Routes: []config.RouteConfig{{Name: "a", Sink: config.SinkConfig{Type: "http"}}},
+	}
+	_, err := Router(flowDef, func(config.SinkConfig) (sink.Sink, error) {
+		return nil, errors.New("no url")
+	})
+	if err == nil || err.Error() != "route a: no url" {
+		t.Fatalf("expected route error, got %v", err)
+	}
+}
+
+func TestEnrichers_RequiresLookup(t *testing.T) {
+	_, err := Enrichers([]config.EnrichConfig{{As: "customer"}}, nil)
+	if err == nil || err.Error() != "enrich customer: link or table is required" {
+		t.Fatalf("expected lookup error, got %v", err)
+	}
+}
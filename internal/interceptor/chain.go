@@ -43,3 +43,8 @@ func (c *Chain) Close() error {
 func (c *Chain) Len() int {
 	return len(c.interceptors)
 }
+
+// Interceptors returns the interceptors in the chain, in execution order.
+func (c *Chain) Interceptors() []Interceptor {
+	return c.interceptors
+}
@@ -39,6 +39,53 @@ func (p *PooledPublisher) Publish(ctx context.Context, topic string, key, value
 	return nil
 }
 
+// Message is a record to publish with PublishBatch.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+	// Partition is used by the manual partitioner and ignored otherwise.
+	Partition int32
+}
+
+// Result is the outcome of publishing one Message.
+type Result struct {
+	Partition int32
+	Offset    int64
+	Err       error
+}
+
+// PublishBatch sends messages and waits until every record is acknowledged
+// or has failed. Results are in the order of msgs.
+func (p *PooledPublisher) PublishBatch(ctx context.Context, msgs []Message) []Result {
+	records := make([]*kgo.Record, len(msgs))
+	for i, m := range msgs {
+		r := &kgo.Record{Topic: m.Topic, Key: m.Key, Value: m.Value, Partition: m.Partition}
+		for k, v := range m.Headers {
+			r.Headers = append(r.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+		}
+		records[i] = r
+	}
+
+	produced := p.client.ProduceSync(ctx, records...)
+	results := make([]Result, len(msgs))
+	for i := range results {
+		if i >= len(produced) {
+			results[i].Err = fmt.Errorf("kafka publish to %s: no result", msgs[i].Topic)
+			continue
+		}
+		pr := produced[i]
+		if pr.Err != nil {
+			results[i].Err = fmt.Errorf("kafka publish to %s: %w", msgs[i].Topic, pr.Err)
+			continue
+		}
+		results[i].Partition = pr.Record.Partition
+		results[i].Offset = pr.Record.Offset
+	}
+	return results
+}
+
 // Close is a no-op for pooled publishers; the pool manages client lifecycle.
 func (p *PooledPublisher) Close() error {
 	return nil
@@ -61,9 +108,20 @@ func NewPublisherPool(registry *Registry) *PublisherPool {
 
 // Get returns a publisher for the named cluster, creating the client if needed.
 func (p *PublisherPool) Get(clusterName string) (*PooledPublisher, error) {
+	return p.GetWithProducer(clusterName, ProducerConfig{})
+}
+
+// GetWithProducer returns a publisher for the named cluster using the given
+// producer settings. Targets with equal settings share a client.
+func (p *PublisherPool) GetWithProducer(clusterName string, pc ProducerConfig) (*PooledPublisher, error) {
+	key := clusterName
+	if k := pc.key(); k != "" {
+		key += "|" + k
+	}
+
 	// Fast path: check if client already exists
 	p.mu.RLock()
-	client, exists := p.clients[clusterName]
+	client, exists := p.clients[key]
 	p.mu.RUnlock()
 
 	if exists {
@@ -75,7 +133,7 @@ func (p *PublisherPool) Get(clusterName string) (*PooledPublisher, error) {
 	defer p.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if client, exists = p.clients[clusterName]; exists {
+	if client, exists = p.clients[key]; exists {
 		return &PooledPublisher{client: client, name: clusterName}, nil
 	}
 
@@ -88,13 +146,18 @@ func (p *PublisherPool) Get(clusterName string) (*PooledPublisher, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cluster %q options: %w", clusterName, err)
 	}
+	producerOpts, err := pc.Options()
+	if err != nil {
+		return nil, fmt.Errorf("cluster %q producer: %w", clusterName, err)
+	}
+	opts = append(opts, producerOpts...)
 
 	client, err = kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("cluster %q client: %w", clusterName, err)
 	}
 
-	p.clients[clusterName] = client
+	p.clients[key] = client
 	return &PooledPublisher{client: client, name: clusterName}, nil
 }
 
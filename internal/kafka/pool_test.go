@@ -180,3 +180,87 @@ func TestPooledPublisher_PublishNoHeaders(t *testing.T) {
 		t.Errorf("expected no headers, got %d", len(mock.records[0].Headers))
 	}
 }
+
+// offsetProducer assigns consecutive offsets and fails records whose value
+// is "fail".
+type offsetProducer struct {
+	next int64
+}
+
+func (o *offsetProducer) ProduceSync(_ context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	results := make(kgo.ProduceResults, len(rs))
+	for i, r := range rs {
+		if string(r.Value) == "fail" {
+			results[i] = kgo.ProduceResult{Record: r, Err: fmt.Errorf("record too large")}
+			continue
+		}
+		r.Offset = o.next
+		o.next++
+		results[i] = kgo.ProduceResult{Record: r}
+	}
+	return results
+}
+
+func (o *offsetProducer) Close() {}
+
+func TestPooledPublisher_PublishBatch(t *testing.T) {
+	pub := &PooledPublisher{client: &offsetProducer{next: 10}, name: "test"}
+
+	results := pub.PublishBatch(context.Background(), []Message{
+		{Topic: "t", Value: []byte("a"), Partition: 2, Headers: map[string]string{"h": "v"}},
+		{Topic: "t", Value: []byte("fail")},
+		{Topic: "t", Value: []byte("b")},
+	})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Partition != 2 || results[0].Offset != 10 {
+		t.Errorf("unexpected first result %+v", results[0])
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "record too large") {
+		t.Errorf("expected second record to fail, got %+v", results[1])
+	}
+	if results[2].Err != nil || results[2].Offset != 11 {
+		t.Errorf("unexpected third result %+v", results[2])
+	}
+}
+
+func TestPooledPublisher_PublishBatchMissingResults(t *testing.T) {
+	pub := &PooledPublisher{client: &mockProducer{}, name: "test"}
+
+	results := pub.PublishBatch(context.Background(), []Message{{Topic: "t", Value: []byte("a")}})
+	if results[0].Err == nil {
+		t.Error("expected error when the client returns no result")
+	}
+}
+
+func TestPublisherPool_GetWithProducer(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register("test", &ClusterConfig{Brokers: []string{"localhost:9092"}})
+	pool := NewPublisherPool(registry)
+	defer func() { _ = pool.Close() }()
+
+	gzip := ProducerConfig{Compression: "gzip", RequiredAcks: "1"}
+	a, err := pool.GetWithProducer("test", gzip)
+	if err != nil {
+		t.Fatalf("GetWithProducer() error = %v", err)
+	}
+	b, err := pool.GetWithProducer("test", gzip)
+	if err != nil {
+		t.Fatalf("GetWithProducer() error = %v", err)
+	}
+	c, err := pool.Get("test")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if a.client != b.client {
+		t.Error("expected equal producer settings to share a client")
+	}
+	if a.client == c.client {
+		t.Error("expected different producer settings to use separate clients")
+	}
+
+	if _, err := pool.GetWithProducer("test", ProducerConfig{Compression: "brotli"}); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
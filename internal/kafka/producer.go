@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Partitioner names accepted by ProducerConfig.
+const (
+	PartitionerMurmur2    = "murmur2"    // Kafka-compatible key hashing; unkeyed records are sticky (default)
+	PartitionerSticky     = "sticky"     // Ignore keys and fill one partition's batch at a time
+	PartitionerRoundRobin = "roundrobin" // Spread records evenly, ignoring keys
+	PartitionerManual     = "manual"     // Use Message.Partition
+)
+
+// ProducerConfig holds producer settings that require a dedicated client.
+// The zero value uses the client defaults (murmur2, acks=all, idempotent
+// writes, no compression override).
+type ProducerConfig struct {
+	RequiredAcks  string // "all" (default), "1" or "0"
+	Compression   string // none, gzip, snappy, lz4, zstd
+	Linger        time.Duration
+	BatchMaxBytes int
+	Partitioner   string
+}
+
+// Options returns the kgo options for the producer settings.
+func (c ProducerConfig) Options() ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+
+	switch c.RequiredAcks {
+	case "", "all", "-1":
+	case "1":
+		// Idempotent writes require acks=all.
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()), kgo.DisableIdempotentWrite())
+	case "0":
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()), kgo.DisableIdempotentWrite())
+	default:
+		return nil, fmt.Errorf("unsupported requiredAcks %q (valid: all, 1, 0)", c.RequiredAcks)
+	}
+
+	switch strings.ToLower(c.Compression) {
+	case "":
+	case "none":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.NoCompression()))
+	case "gzip":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.GzipCompression()))
+	case "snappy":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.SnappyCompression()))
+	case "lz4":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.Lz4Compression()))
+	case "zstd":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.ZstdCompression()))
+	default:
+		return nil, fmt.Errorf("unsupported compression %q (valid: none, gzip, snappy, lz4, zstd)", c.Compression)
+	}
+
+	if c.Linger > 0 {
+		opts = append(opts, kgo.ProducerLinger(c.Linger))
+	}
+	if c.BatchMaxBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(int32(c.BatchMaxBytes)))
+	}
+
+	switch c.Partitioner {
+	case "", PartitionerMurmur2:
+		opts = append(opts, kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)))
+	case PartitionerSticky:
+		opts = append(opts, kgo.RecordPartitioner(kgo.StickyPartitioner()))
+	case PartitionerRoundRobin:
+		opts = append(opts, kgo.RecordPartitioner(kgo.RoundRobinPartitioner()))
+	case PartitionerManual:
+		opts = append(opts, kgo.RecordPartitioner(kgo.ManualPartitioner()))
+	default:
+		return nil, fmt.Errorf("unsupported partitioner %q (valid: murmur2, sticky, roundrobin, manual)", c.Partitioner)
+	}
+
+	return opts, nil
+}
+
+// key identifies clients with equivalent producer settings in the pool.
+// Settings that spell out the defaults share the key of the zero value.
+func (c ProducerConfig) key() string {
+	if c.RequiredAcks == "all" || c.RequiredAcks == "-1" {
+		c.RequiredAcks = ""
+	}
+	if c.Partitioner == PartitionerMurmur2 {
+		c.Partitioner = ""
+	}
+	c.Compression = strings.ToLower(c.Compression)
+	c.Linger = max(c.Linger, 0)
+	c.BatchMaxBytes = max(c.BatchMaxBytes, 0)
+	if c == (ProducerConfig{}) {
+		return ""
+	}
+	return strings.Join([]string{
+		c.RequiredAcks, c.Compression, c.Linger.String(),
+		strconv.Itoa(c.BatchMaxBytes), c.Partitioner,
+	}, "|")
+}
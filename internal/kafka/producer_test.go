@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProducerConfig_Options(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ProducerConfig
+		wantOpts int
+		wantErr  string
+	}{
+		{"defaults", ProducerConfig{}, 1, ""},
+		{"leader acks", ProducerConfig{RequiredAcks: "1"}, 3, ""},
+		{"no acks", ProducerConfig{RequiredAcks: "0"}, 3, ""},
+		{"all acks", ProducerConfig{RequiredAcks: "all"}, 1, ""},
+		{"tuned", ProducerConfig{Compression: "zstd", Linger: 5 * time.Millisecond, BatchMaxBytes: 1 << 20, Partitioner: PartitionerManual}, 4, ""},
+		{"bad acks", ProducerConfig{RequiredAcks: "2"}, 0, "requiredAcks"},
+		{"bad compression", ProducerConfig{Compression: "brotli"}, 0, "compression"},
+		{"bad partitioner", ProducerConfig{Partitioner: "hash"}, 0, "partitioner"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := tt.cfg.Options()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(opts) != tt.wantOpts {
+				t.Errorf("expected %d options, got %d", tt.wantOpts, len(opts))
+			}
+		})
+	}
+}
+
+func TestProducerConfig_Key(t *testing.T) {
+	if (ProducerConfig{}).key() != "" {
+		t.Error("expected empty key for default settings")
+	}
+	a := ProducerConfig{Compression: "GZIP", Linger: time.Millisecond}
+	b := ProducerConfig{Compression: "gzip", Linger: time.Millisecond}
+	if a.key() != b.key() {
+		t.Errorf("expected equal keys, got %q and %q", a.key(), b.key())
+	}
+	defaults := ProducerConfig{RequiredAcks: "all", Partitioner: PartitionerMurmur2}
+	if defaults.key() != "" {
+		t.Errorf("expected explicit defaults to share the default key, got %q", defaults.key())
+	}
+	if (ProducerConfig{RequiredAcks: "-1", Compression: "gzip"}).key() != (ProducerConfig{Compression: "gzip"}).key() {
+		t.Error("expected acks -1 and the default acks to share a key")
+	}
+}
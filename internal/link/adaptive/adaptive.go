@@ -0,0 +1,282 @@
+// Package adaptive implements adaptive concurrency limiting for link
+// targets. Instead of a fixed rate, each target gets a concurrency limit that
+// shrinks when upstream latency rises or requests are dropped (429, 503,
+// timeouts) and grows while the upstream is healthy.
+package adaptive
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+// ErrLimitExceeded is returned when the target already has as many requests
+// in flight as its current limit allows.
+var ErrLimitExceeded = errors.New("adaptive concurrency limit exceeded")
+
+// Algorithm selects how the limit reacts to samples.
+type Algorithm string
+
+const (
+	// AIMD grows the limit by one while healthy and multiplies it by
+	// BackoffRatio when a request is dropped or slower than LatencyThreshold.
+	AIMD Algorithm = "aimd"
+	// Gradient compares each sample against a long-term average latency and
+	// scales the limit by the ratio, similar to TCP Vegas.
+	Gradient Algorithm = "gradient"
+)
+
+// Config holds adaptive limiter settings.
+type Config struct {
+	Algorithm    Algorithm
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// BackoffRatio is the multiplicative decrease applied by AIMD (0-1).
+	BackoffRatio float64
+	// LatencyThreshold is the latency above which AIMD treats a sample as
+	// overload. Zero disables latency-based backoff.
+	LatencyThreshold time.Duration
+	// Tolerance is how much the gradient algorithm lets latency exceed the
+	// long-term average before shrinking the limit (e.g. 1.5 = 50%).
+	Tolerance float64
+	// Smoothing weights each new gradient limit against the current one (0-1).
+	Smoothing float64
+}
+
+// DefaultConfig returns sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:    AIMD,
+		InitialLimit: 20,
+		MinLimit:     1,
+		MaxLimit:     200,
+		BackoffRatio: 0.9,
+		Tolerance:    1.5,
+		Smoothing:    0.2,
+	}
+}
+
+// Status describes the current state of a limiter.
+type Status struct {
+	Algorithm string `json:"algorithm"`
+	Limit     int    `json:"limit"`
+	InFlight  int    `json:"inFlight"`
+	MinLimit  int    `json:"minLimit"`
+	MaxLimit  int    `json:"maxLimit"`
+}
+
+// Limiter tracks the concurrency limit and in-flight requests of a target.
+type Limiter struct {
+	mu       sync.Mutex
+	cfg      Config
+	limit    float64
+	inflight int
+	longRTT  float64 // exponentially weighted average latency in ns (gradient)
+	observe  func(limit int)
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithObserver registers fn to be called with the new limit whenever it
+// changes.
+func WithObserver(fn func(limit int)) Option {
+	return func(l *Limiter) {
+		l.observe = fn
+	}
+}
+
+// New creates a limiter. Zero-valued settings fall back to DefaultConfig.
+func New(cfg Config, opts ...Option) *Limiter {
+	def := DefaultConfig()
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = def.Algorithm
+	}
+	if cfg.MinLimit < 1 {
+		cfg.MinLimit = def.MinLimit
+	}
+	if cfg.MaxLimit < 1 {
+		cfg.MaxLimit = def.MaxLimit
+	}
+	if cfg.MaxLimit < cfg.MinLimit {
+		cfg.MaxLimit = cfg.MinLimit
+	}
+	if cfg.InitialLimit < 1 {
+		cfg.InitialLimit = def.InitialLimit
+	}
+	if cfg.BackoffRatio <= 0 || cfg.BackoffRatio >= 1 {
+		cfg.BackoffRatio = def.BackoffRatio
+	}
+	if cfg.Tolerance < 1 {
+		cfg.Tolerance = def.Tolerance
+	}
+	if cfg.Smoothing <= 0 || cfg.Smoothing > 1 {
+		cfg.Smoothing = def.Smoothing
+	}
+	l := &Limiter{cfg: cfg}
+	l.limit = l.clamp(float64(cfg.InitialLimit))
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.observe != nil {
+		l.observe(int(l.limit))
+	}
+	return l
+}
+
+// FromLinkConfig returns DefaultConfig overridden by any values set in a
+// target's adaptive concurrency configuration.
+func FromLinkConfig(ac link.AdaptiveConcurrencyConfig) Config {
+	cfg := DefaultConfig()
+	if ac.Algorithm != "" {
+		cfg.Algorithm = Algorithm(ac.Algorithm)
+	}
+	if ac.InitialLimit > 0 {
+		cfg.InitialLimit = ac.InitialLimit
+	}
+	if ac.MinLimit > 0 {
+		cfg.MinLimit = ac.MinLimit
+	}
+	if ac.MaxLimit > 0 {
+		cfg.MaxLimit = ac.MaxLimit
+	}
+	if ac.BackoffRatio > 0 {
+		cfg.BackoffRatio = ac.BackoffRatio
+	}
+	if d, err := time.ParseDuration(ac.LatencyThreshold); err == nil {
+		cfg.LatencyThreshold = d
+	}
+	if ac.Tolerance > 0 {
+		cfg.Tolerance = ac.Tolerance
+	}
+	if ac.Smoothing > 0 {
+		cfg.Smoothing = ac.Smoothing
+	}
+	return cfg
+}
+
+// FromConfig builds a limiter for every target with adaptive concurrency
+// enabled. When metrics is non-nil, the limit gauge is kept up to date.
+func FromConfig(targets []link.LinkTarget, metrics *link.Metrics) map[string]*Limiter {
+	limiters := make(map[string]*Limiter)
+	for _, t := range targets {
+		if t.AdaptiveConcurrency == nil || !t.AdaptiveConcurrency.Enabled {
+			continue
+		}
+		var opts []Option
+		if metrics != nil {
+			name := t.Name
+			opts = append(opts, WithObserver(func(limit int) {
+				metrics.AdaptiveLimit.WithLabelValues(name).Set(float64(limit))
+			}))
+		}
+		limiters[t.Name] = New(FromLinkConfig(*t.AdaptiveConcurrency), opts...)
+	}
+	return limiters
+}
+
+// Acquire admits a request if fewer than the current limit are in flight.
+// On success the returned release function must be called exactly once when
+// the request completes.
+func (l *Limiter) Acquire() (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= int(l.limit) {
+		return nil, ErrLimitExceeded
+	}
+	l.inflight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inflight--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// Sample feeds the latency of one upstream attempt into the limiter.
+// dropped reports that the upstream shed or failed the request (429, 503,
+// timeout, connection error), which always shrinks the limit.
+func (l *Limiter) Sample(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	prev := int(l.limit)
+	switch l.cfg.Algorithm {
+	case Gradient:
+		l.sampleGradient(rtt, dropped)
+	default:
+		l.sampleAIMD(rtt, dropped)
+	}
+	next := int(l.limit)
+	observe := l.observe
+	l.mu.Unlock()
+
+	if observe != nil && next != prev {
+		observe(next)
+	}
+}
+
+// sampleAIMD must be called with l.mu held.
+func (l *Limiter) sampleAIMD(rtt time.Duration, dropped bool) {
+	overloaded := dropped || (l.cfg.LatencyThreshold > 0 && rtt > l.cfg.LatencyThreshold)
+	switch {
+	case overloaded:
+		l.limit = l.clamp(l.limit * l.cfg.BackoffRatio)
+	case float64(l.inflight)*2 >= l.limit:
+		// Only grow while the current limit is actually being used.
+		l.limit = l.clamp(l.limit + 1)
+	}
+}
+
+// sampleGradient must be called with l.mu held.
+func (l *Limiter) sampleGradient(rtt time.Duration, dropped bool) {
+	short := float64(rtt)
+	if short <= 0 {
+		short = 1
+	}
+	if l.longRTT == 0 {
+		l.longRTT = short
+	}
+
+	gradient := 0.5
+	if !dropped {
+		gradient = math.Max(0.5, math.Min(1.0, l.cfg.Tolerance*l.longRTT/short))
+		// Track the long-term latency slowly so sustained changes shift the
+		// baseline while short spikes still register as a gradient.
+		l.longRTT = l.longRTT*0.95 + short*0.05
+	}
+
+	// Allow a small queue so the limit can probe for more capacity.
+	queue := math.Sqrt(l.limit)
+	next := l.limit*gradient + queue
+	l.limit = l.clamp(l.limit*(1-l.cfg.Smoothing) + next*l.cfg.Smoothing)
+}
+
+func (l *Limiter) clamp(v float64) float64 {
+	return math.Max(float64(l.cfg.MinLimit), math.Min(float64(l.cfg.MaxLimit), v))
+}
+
+// Limit returns the current concurrency limit.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Status returns the current limit and in-flight count.
+func (l *Limiter) Status() Status {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Status{
+		Algorithm: string(l.cfg.Algorithm),
+		Limit:     int(l.limit),
+		InFlight:  l.inflight,
+		MinLimit:  l.cfg.MinLimit,
+		MaxLimit:  l.cfg.MaxLimit,
+	}
+}
@@ -0,0 +1,135 @@
+package adaptive
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+func TestAcquire_RejectsOverLimit(t *testing.T) {
+	l := New(Config{InitialLimit: 2, MinLimit: 1, MaxLimit: 10})
+
+	r1, err := l.Acquire()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2, err := l.Acquire()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := l.Acquire(); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+
+	r1()
+	r1() // release is idempotent
+	if st := l.Status(); st.InFlight != 1 {
+		t.Fatalf("expected 1 in flight, got %d", st.InFlight)
+	}
+	r2()
+}
+
+func TestAIMD_BacksOffOnDrop(t *testing.T) {
+	l := New(Config{Algorithm: AIMD, InitialLimit: 10, MinLimit: 2, MaxLimit: 100, BackoffRatio: 0.5})
+
+	l.Sample(10*time.Millisecond, true)
+	if got := l.Limit(); got != 5 {
+		t.Fatalf("expected limit 5 after drop, got %d", got)
+	}
+	for i := 0; i < 5; i++ {
+		l.Sample(10*time.Millisecond, true)
+	}
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("expected limit clamped to 2, got %d", got)
+	}
+}
+
+func TestAIMD_BacksOffOnLatency(t *testing.T) {
+	l := New(Config{Algorithm: AIMD, InitialLimit: 10, BackoffRatio: 0.5, LatencyThreshold: 100 * time.Millisecond})
+
+	l.Sample(200*time.Millisecond, false)
+	if got := l.Limit(); got != 5 {
+		t.Fatalf("expected limit 5 after slow sample, got %d", got)
+	}
+}
+
+func TestAIMD_GrowsOnlyWhenUtilized(t *testing.T) {
+	l := New(Config{Algorithm: AIMD, InitialLimit: 4, MaxLimit: 6})
+
+	// Idle: no requests in flight, the limit stays put.
+	l.Sample(time.Millisecond, false)
+	if got := l.Limit(); got != 4 {
+		t.Fatalf("expected idle limit 4, got %d", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		release, _ := l.Acquire()
+		defer release()
+	}
+	for i := 0; i < 5; i++ {
+		l.Sample(time.Millisecond, false)
+	}
+	if got := l.Limit(); got != 6 {
+		t.Fatalf("expected limit to grow to max 6, got %d", got)
+	}
+}
+
+func TestGradient_ShrinksWhenLatencyRises(t *testing.T) {
+	l := New(Config{Algorithm: Gradient, InitialLimit: 50, MaxLimit: 100, Tolerance: 1.5, Smoothing: 0.5})
+
+	for i := 0; i < 5; i++ {
+		l.Sample(10*time.Millisecond, false)
+	}
+	healthy := l.Limit()
+	if healthy < 50 {
+		t.Fatalf("expected healthy limit >= 50, got %d", healthy)
+	}
+
+	for i := 0; i < 10; i++ {
+		l.Sample(100*time.Millisecond, false)
+	}
+	if got := l.Limit(); got >= healthy {
+		t.Fatalf("expected limit below %d after latency rise, got %d", healthy, got)
+	}
+}
+
+func TestGradient_ShrinksOnDrop(t *testing.T) {
+	l := New(Config{Algorithm: Gradient, InitialLimit: 100, MaxLimit: 100, Smoothing: 1})
+
+	l.Sample(10*time.Millisecond, true)
+	if got := l.Limit(); got != 60 {
+		t.Fatalf("expected limit 60 (100*0.5 + sqrt(100)), got %d", got)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	limiters := FromConfig([]link.LinkTarget{
+		{Name: "adaptive", AdaptiveConcurrency: &link.AdaptiveConcurrencyConfig{
+			Enabled: true, Algorithm: "aimd", InitialLimit: 8, BackoffRatio: 0.5, LatencyThreshold: "250ms",
+		}},
+		{Name: "disabled", AdaptiveConcurrency: &link.AdaptiveConcurrencyConfig{InitialLimit: 8}},
+		{Name: "static"},
+	}, metrics)
+
+	if len(limiters) != 1 {
+		t.Fatalf("expected 1 limiter, got %d", len(limiters))
+	}
+	l := limiters["adaptive"]
+	if l.cfg.LatencyThreshold != 250*time.Millisecond {
+		t.Fatalf("expected 250ms latency threshold, got %s", l.cfg.LatencyThreshold)
+	}
+	if got := testutil.ToFloat64(metrics.AdaptiveLimit.WithLabelValues("adaptive")); got != 8 {
+		t.Fatalf("expected initial limit gauge 8, got %v", got)
+	}
+
+	l.Sample(time.Millisecond, true)
+	if got := testutil.ToFloat64(metrics.AdaptiveLimit.WithLabelValues("adaptive")); got != 4 {
+		t.Fatalf("expected limit gauge 4 after drop, got %v", got)
+	}
+}
@@ -0,0 +1,293 @@
+// Package admin provides the Fiso-Link runtime admin API. It exposes the
+// effective configuration and live resilience state of each target, and
+// allows operators to force circuit breakers and toggle fault injection.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
+	"github.com/lsm/fiso/internal/link/bulkhead"
+	"github.com/lsm/fiso/internal/link/circuitbreaker"
+	"github.com/lsm/fiso/internal/link/fault"
+	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
+	"github.com/lsm/fiso/internal/link/ratelimit"
+	"github.com/lsm/fiso/internal/link/retry"
+)
+
+// BreakerStatus is the live state of a target's circuit breaker.
+type BreakerStatus struct {
+	State            string `json:"state"`
+	Mode             string `json:"mode"`
+	Forced           bool   `json:"forced"`
+	Failures         int    `json:"failures"`
+	Successes        int    `json:"successes"`
+	FailureThreshold int    `json:"failureThreshold"`
+	SuccessThreshold int    `json:"successThreshold"`
+	ResetTimeout     string `json:"resetTimeout"`
+}
+
+// RetryStatus is the effective retry policy of a target.
+type RetryStatus struct {
+	MaxAttempts     int     `json:"maxAttempts"`
+	InitialInterval string  `json:"initialInterval"`
+	MaxInterval     string  `json:"maxInterval"`
+	Jitter          float64 `json:"jitter"`
+}
+
+// TargetStatus is the effective configuration and runtime state of a target.
+type TargetStatus struct {
+	Name           string              `json:"name"`
+	Protocol       string              `json:"protocol"`
+	Host           string              `json:"host,omitempty"`
+	Port           int                 `json:"port,omitempty"`
+	BasePath       string              `json:"basePath,omitempty"`
+	AllowedPaths   []string            `json:"allowedPaths,omitempty"`
+	AuthType       string              `json:"authType,omitempty"`
+	Retry          RetryStatus         `json:"retry"`
+	CircuitBreaker *BreakerStatus      `json:"circuitBreaker,omitempty"`
+	RateLimit      *ratelimit.Status   `json:"rateLimit,omitempty"`
+	Bulkhead       *bulkhead.Status    `json:"bulkhead,omitempty"`
+	Adaptive       *adaptive.Status    `json:"adaptiveConcurrency,omitempty"`
+	Interceptors   map[string][]string `json:"interceptors,omitempty"`
+	FaultsEnabled  bool                `json:"faultsEnabled"`
+}
+
+// Config configures the admin handler. All fields except Token and Targets
+// are optional.
+type Config struct {
+	Token        string
+	Targets      *link.TargetStore
+	Breakers     map[string]*circuitbreaker.Breaker
+	Bulkheads    map[string]*bulkhead.Bulkhead
+	Adaptive     map[string]*adaptive.Limiter
+	RateLimiter  *ratelimit.Limiter
+	Interceptors *linkinterceptor.Registry
+	Faults       *fault.Injector
+	Logger       *slog.Logger
+}
+
+// Handler serves the admin API under /admin/.
+type Handler struct {
+	token        []byte
+	targets      *link.TargetStore
+	breakers     map[string]*circuitbreaker.Breaker
+	bulkheads    map[string]*bulkhead.Bulkhead
+	adaptive     map[string]*adaptive.Limiter
+	rateLimiter  *ratelimit.Limiter
+	interceptors *linkinterceptor.Registry
+	faults       *fault.Injector
+	logger       *slog.Logger
+	mux          *http.ServeMux
+}
+
+// NewHandler creates an admin API handler. Routes:
+//   - GET  /admin/targets                 — all targets
+//   - GET  /admin/targets/{name}          — a single target
+//   - POST /admin/targets/{name}/breaker  — body {"action": "open"|"close"|"reset"}
+//   - GET  /admin/faults, PUT /admin/faults/{target} — see fault.Injector.Handler
+func NewHandler(cfg Config) *Handler {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	h := &Handler{
+		token:        []byte(cfg.Token),
+		targets:      cfg.Targets,
+		breakers:     cfg.Breakers,
+		bulkheads:    cfg.Bulkheads,
+		adaptive:     cfg.Adaptive,
+		rateLimiter:  cfg.RateLimiter,
+		interceptors: cfg.Interceptors,
+		faults:       cfg.Faults,
+		logger:       cfg.Logger,
+		mux:          http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /admin/targets", h.handleList)
+	h.mux.HandleFunc("GET /admin/targets/{name}", h.handleGet)
+	h.mux.HandleFunc("POST /admin/targets/{name}/breaker", h.handleBreaker)
+	if h.faults != nil {
+		fh := h.faults.Handler()
+		h.mux.Handle("/admin/faults", fh)
+		h.mux.Handle("/admin/faults/", fh)
+	}
+	return h
+}
+
+// ServeHTTP authenticates the request and dispatches it to the admin routes.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="fiso-link-admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if len(h.token) == 0 {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), h.token) == 1
+}
+
+// Status returns the status of every target, sorted by name.
+func (h *Handler) Status() []TargetStatus {
+	names := h.targets.Names()
+	sort.Strings(names)
+	out := make([]TargetStatus, 0, len(names))
+	for _, name := range names {
+		if t := h.targets.Get(name); t != nil {
+			out = append(out, h.targetStatus(t))
+		}
+	}
+	return out
+}
+
+func (h *Handler) targetStatus(t *link.LinkTarget) TargetStatus {
+	st := TargetStatus{
+		Name:          t.Name,
+		Protocol:      t.Protocol,
+		Host:          t.Host,
+		Port:          t.Port,
+		BasePath:      t.BasePath,
+		AllowedPaths:  t.AllowedPaths,
+		AuthType:      t.Auth.Type,
+		FaultsEnabled: h.faults != nil && h.faults.Enabled(t.Name),
+	}
+	rc := retry.FromLinkConfig(t.Retry)
+	st.Retry = RetryStatus{
+		MaxAttempts:     rc.MaxAttempts,
+		InitialInterval: rc.InitialInterval.String(),
+		MaxInterval:     rc.MaxInterval.String(),
+		Jitter:          rc.Jitter,
+	}
+	if b, ok := h.breakers[t.Name]; ok {
+		failures, successes := b.Counts()
+		cfg := b.Config()
+		st.CircuitBreaker = &BreakerStatus{
+			State:            b.State().String(),
+			Mode:             string(cfg.Mode),
+			Forced:           b.Forced(),
+			Failures:         failures,
+			Successes:        successes,
+			FailureThreshold: cfg.FailureThreshold,
+			SuccessThreshold: cfg.SuccessThreshold,
+			ResetTimeout:     cfg.ResetTimeout.String(),
+		}
+	}
+	if h.rateLimiter != nil {
+		if rl, ok := h.rateLimiter.Status(t.Name); ok {
+			st.RateLimit = &rl
+		}
+	}
+	if b, ok := h.bulkheads[t.Name]; ok {
+		bs := b.Status()
+		st.Bulkhead = &bs
+	}
+	if l, ok := h.adaptive[t.Name]; ok {
+		as := l.Status()
+		st.Adaptive = &as
+	}
+	if h.interceptors != nil {
+		for phase, modules := range h.interceptors.Modules(t.Name) {
+			if st.Interceptors == nil {
+				st.Interceptors = make(map[string][]string)
+			}
+			st.Interceptors[string(phase)] = modules
+		}
+	}
+	return st
+}
+
+func (h *Handler) handleList(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.Status())
+}
+
+func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
+	t := h.targets.Get(r.PathValue("name"))
+	if t == nil {
+		http.Error(w, fmt.Sprintf("target %q not found", r.PathValue("name")), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.targetStatus(t))
+}
+
+func (h *Handler) handleBreaker(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	b, ok := h.breakers[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("no circuit breaker configured for target %q", name), http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	switch body.Action {
+	case "open":
+		b.Force(circuitbreaker.Open)
+	case "close":
+		b.Force(circuitbreaker.Closed)
+	case "reset":
+		b.Reset()
+	default:
+		http.Error(w, `action must be one of: open, close, reset`, http.StatusBadRequest)
+		return
+	}
+	h.logger.Info("circuit breaker changed via admin API", "target", name, "action", body.Action, "state", b.State().String())
+
+	t := h.targets.Get(name)
+	if t == nil {
+		t = &link.LinkTarget{Name: name}
+	}
+	writeJSON(w, http.StatusOK, h.targetStatus(t))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) // best-effort response body
+}
+
+// LoadToken reads the admin token from a file or environment variable.
+func LoadToken(ref *link.SecretRef) (string, error) {
+	if ref == nil {
+		return "", fmt.Errorf("no token reference configured")
+	}
+	if ref.FilePath != "" {
+		data, err := os.ReadFile(filepath.Clean(ref.FilePath))
+		if err != nil {
+			return "", fmt.Errorf("read admin token file %s: %w", ref.FilePath, err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("admin token file %s is empty", ref.FilePath)
+		}
+		return token, nil
+	}
+	if ref.EnvVar != "" {
+		token := os.Getenv(ref.EnvVar)
+		if token == "" {
+			return "", fmt.Errorf("env var %s is empty", ref.EnvVar)
+		}
+		return token, nil
+	}
+	return "", fmt.Errorf("no file path or env var configured")
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/circuitbreaker"
+	"github.com/lsm/fiso/internal/link/fault"
+	"github.com/lsm/fiso/internal/link/ratelimit"
+)
+
+const testToken = "s3cret"
+
+func newTestHandler(t *testing.T) (*Handler, *circuitbreaker.Breaker, *fault.Injector) {
+	t.Helper()
+	breaker := circuitbreaker.New(circuitbreaker.Config{FailureThreshold: 3, SuccessThreshold: 1, ResetTimeout: 30 * time.Second})
+	limiter := ratelimit.New()
+	limiter.Set("crm", 10, 20)
+	faults := fault.New()
+	faults.Set("crm", false, []fault.Rule{{Percentage: 10, AbortStatus: 503}})
+
+	h := NewHandler(Config{
+		Token: testToken,
+		Targets: link.NewTargetStore([]link.LinkTarget{
+			{Name: "crm", Protocol: "https", Host: "api.example.com", Retry: link.RetryConfig{MaxAttempts: 5}},
+			{Name: "billing", Protocol: "http", Host: "billing"},
+		}),
+		Breakers:    map[string]*circuitbreaker.Breaker{"crm": breaker},
+		RateLimiter: limiter,
+		Faults:      faults,
+	})
+	return h, breaker, faults
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_RequiresToken(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	for _, auth := range []string{"", "Bearer wrong", testToken} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/targets", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("auth %q: expected 401, got %d", auth, w.Code)
+		}
+	}
+}
+
+func TestHandler_EmptyTokenRejectsAll(t *testing.T) {
+	h := NewHandler(Config{Targets: link.NewTargetStore(nil)})
+	req := httptest.NewRequest(http.MethodGet, "/admin/targets", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with no configured token, got %d", w.Code)
+	}
+}
+
+func TestHandler_ListTargets(t *testing.T) {
+	h, breaker, _ := newTestHandler(t)
+	_, _ = breaker.Allow()
+	breaker.RecordFailure()
+
+	w := do(h, http.MethodGet, "/admin/targets", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var statuses []TargetStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "billing" || statuses[1].Name != "crm" {
+		t.Fatalf("expected sorted targets, got %+v", statuses)
+	}
+
+	billing, crm := statuses[0], statuses[1]
+	if billing.CircuitBreaker != nil || billing.RateLimit != nil {
+		t.Errorf("expected no breaker or limiter for billing, got %+v", billing)
+	}
+	if billing.Retry.MaxAttempts != 3 {
+		t.Errorf("expected default retry attempts for billing, got %d", billing.Retry.MaxAttempts)
+	}
+	if crm.CircuitBreaker == nil || crm.CircuitBreaker.State != "closed" || crm.CircuitBreaker.Failures != 1 {
+		t.Errorf("unexpected crm breaker status: %+v", crm.CircuitBreaker)
+	}
+	if crm.RateLimit == nil || crm.RateLimit.Burst != 20 {
+		t.Errorf("unexpected crm rate limit: %+v", crm.RateLimit)
+	}
+	if crm.Retry.MaxAttempts != 5 {
+		t.Errorf("expected crm retry attempts 5, got %d", crm.Retry.MaxAttempts)
+	}
+}
+
+func TestHandler_GetTarget(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	w := do(h, http.MethodGet, "/admin/targets/crm", "")
+	var st TargetStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Name != "crm" || st.Host != "api.example.com" {
+		t.Errorf("unexpected target: %+v", st)
+	}
+
+	if w := do(h, http.MethodGet, "/admin/targets/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing target, got %d", w.Code)
+	}
+}
+
+func TestHandler_ForceBreaker(t *testing.T) {
+	h, breaker, _ := newTestHandler(t)
+
+	w := do(h, http.MethodPost, "/admin/targets/crm/breaker", `{"action":"open"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if breaker.State() != circuitbreaker.Open || !breaker.Forced() {
+		t.Fatalf("expected forced open breaker, got %s", breaker.State())
+	}
+
+	do(h, http.MethodPost, "/admin/targets/crm/breaker", `{"action":"close"}`)
+	if breaker.State() != circuitbreaker.Closed || !breaker.Forced() {
+		t.Fatalf("expected forced closed breaker, got %s", breaker.State())
+	}
+
+	do(h, http.MethodPost, "/admin/targets/crm/breaker", `{"action":"reset"}`)
+	if breaker.Forced() {
+		t.Fatal("expected reset to release forced state")
+	}
+
+	if w := do(h, http.MethodPost, "/admin/targets/crm/breaker", `{"action":"explode"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown action, got %d", w.Code)
+	}
+	if w := do(h, http.MethodPost, "/admin/targets/billing/breaker", `{"action":"open"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for target without breaker, got %d", w.Code)
+	}
+}
+
+func TestHandler_Faults(t *testing.T) {
+	h, _, faults := newTestHandler(t)
+
+	if w := do(h, http.MethodPut, "/admin/faults/crm", `{"enabled":true}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !faults.Enabled("crm") {
+		t.Fatal("expected faults enabled via admin API")
+	}
+
+	var st TargetStatus
+	_ = json.Unmarshal(do(h, http.MethodGet, "/admin/targets/crm", "").Body.Bytes(), &st)
+	if !st.FaultsEnabled {
+		t.Error("expected target status to report faults enabled")
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("  from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if tok, err := LoadToken(&link.SecretRef{FilePath: path}); err != nil || tok != "from-file" {
+		t.Errorf("file token: got %q, %v", tok, err)
+	}
+
+	t.Setenv("TEST_ADMIN_TOKEN", "from-env")
+	if tok, err := LoadToken(&link.SecretRef{EnvVar: "TEST_ADMIN_TOKEN"}); err != nil || tok != "from-env" {
+		t.Errorf("env token: got %q, %v", tok, err)
+	}
+
+	if _, err := LoadToken(nil); err == nil {
+		t.Error("expected error for nil ref")
+	}
+	if _, err := LoadToken(&link.SecretRef{EnvVar: "TEST_ADMIN_TOKEN_UNSET"}); err == nil {
+		t.Error("expected error for empty env var")
+	}
+	empty := filepath.Join(dir, "empty")
+	_ = os.WriteFile(empty, nil, 0o600)
+	if _, err := LoadToken(&link.SecretRef{FilePath: empty}); err == nil {
+		t.Error("expected error for empty token file")
+	}
+}
@@ -0,0 +1,203 @@
+// Package bulkhead limits the number of concurrent requests per link target
+// so that a single slow upstream cannot exhaust the sidecar's goroutines and
+// connections and starve other targets.
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+var (
+	// ErrQueueFull is returned when all slots are in use and the wait queue
+	// is full.
+	ErrQueueFull = errors.New("bulkhead queue full")
+	// ErrQueueTimeout is returned when a queued request did not obtain a slot
+	// within the queue timeout.
+	ErrQueueTimeout = errors.New("bulkhead queue timeout")
+)
+
+// Config holds bulkhead settings.
+type Config struct {
+	// MaxConcurrent is the number of requests allowed in flight at once.
+	MaxConcurrent int
+	// MaxQueue is the number of requests allowed to wait for a slot.
+	// Zero rejects requests as soon as all slots are in use.
+	MaxQueue int
+	// QueueTimeout bounds how long a request waits in the queue. Zero waits
+	// until the request context is done.
+	QueueTimeout time.Duration
+}
+
+// Status describes the current occupancy of a bulkhead.
+type Status struct {
+	Active        int `json:"active"`
+	Queued        int `json:"queued"`
+	MaxConcurrent int `json:"maxConcurrent"`
+	MaxQueue      int `json:"maxQueue"`
+}
+
+// Bulkhead is a semaphore with a bounded wait queue.
+type Bulkhead struct {
+	cfg   Config
+	slots chan struct{}
+
+	mu      sync.Mutex
+	queued  int
+	observe func(active, queued int)
+}
+
+// Option configures a Bulkhead.
+type Option func(*Bulkhead)
+
+// WithObserver registers fn to be called with the current number of active
+// and queued requests whenever either changes.
+func WithObserver(fn func(active, queued int)) Option {
+	return func(b *Bulkhead) {
+		b.observe = fn
+	}
+}
+
+// New creates a bulkhead. MaxConcurrent values below 1 are treated as 1.
+func New(cfg Config, opts ...Option) *Bulkhead {
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.MaxQueue < 0 {
+		cfg.MaxQueue = 0
+	}
+	b := &Bulkhead{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxConcurrent),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// FromLinkConfig converts a target's bulkhead configuration. Unparseable
+// durations fall back to waiting until the request context is done;
+// link.Config.Validate reports them at load time.
+func FromLinkConfig(bc link.BulkheadConfig) Config {
+	cfg := Config{
+		MaxConcurrent: bc.MaxConcurrentRequests,
+		MaxQueue:      bc.MaxQueue,
+	}
+	if d, err := time.ParseDuration(bc.QueueTimeout); err == nil {
+		cfg.QueueTimeout = d
+	}
+	return cfg
+}
+
+// FromConfig builds a bulkhead for every target with maxConcurrentRequests
+// set. When metrics is non-nil, the in-flight and queue depth gauges are kept
+// up to date.
+func FromConfig(targets []link.LinkTarget, metrics *link.Metrics) map[string]*Bulkhead {
+	bulkheads := make(map[string]*Bulkhead)
+	for _, t := range targets {
+		if t.Bulkhead.MaxConcurrentRequests <= 0 {
+			continue
+		}
+		var opts []Option
+		if metrics != nil {
+			name := t.Name
+			opts = append(opts, WithObserver(func(active, queued int) {
+				metrics.BulkheadActive.WithLabelValues(name).Set(float64(active))
+				metrics.BulkheadQueueDepth.WithLabelValues(name).Set(float64(queued))
+			}))
+		}
+		bulkheads[t.Name] = New(FromLinkConfig(t.Bulkhead), opts...)
+	}
+	return bulkheads
+}
+
+// Acquire obtains a slot, waiting in the queue if all slots are in use. On
+// success the returned release function must be called exactly once when the
+// request completes. Returns ErrQueueFull, ErrQueueTimeout or the context
+// error when no slot was obtained.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.slots <- struct{}{}:
+		b.notify()
+		return b.releaseFunc(), nil
+	default:
+	}
+
+	b.mu.Lock()
+	if b.queued >= b.cfg.MaxQueue {
+		b.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	b.queued++
+	b.mu.Unlock()
+	b.notify()
+
+	defer func() {
+		b.mu.Lock()
+		b.queued--
+		b.mu.Unlock()
+		b.notify()
+	}()
+
+	var timeout <-chan time.Time
+	if b.cfg.QueueTimeout > 0 {
+		timer := time.NewTimer(b.cfg.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return b.releaseFunc(), nil
+	case <-timeout:
+		return nil, ErrQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-b.slots
+			b.notify()
+		})
+	}
+}
+
+func (b *Bulkhead) notify() {
+	if b.observe == nil {
+		return
+	}
+	st := b.Status()
+	b.observe(st.Active, st.Queued)
+}
+
+// Status returns the current occupancy.
+func (b *Bulkhead) Status() Status {
+	b.mu.Lock()
+	queued := b.queued
+	b.mu.Unlock()
+	return Status{
+		Active:        len(b.slots),
+		Queued:        queued,
+		MaxConcurrent: b.cfg.MaxConcurrent,
+		MaxQueue:      b.cfg.MaxQueue,
+	}
+}
+
+// RetryAfter returns the number of seconds clients should wait before
+// retrying a rejected request.
+func (b *Bulkhead) RetryAfter() int {
+	secs := int(b.cfg.QueueTimeout.Round(time.Second) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
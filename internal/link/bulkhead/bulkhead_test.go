@@ -0,0 +1,183 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+func TestAcquire_WithinLimit(t *testing.T) {
+	b := New(Config{MaxConcurrent: 2})
+
+	r1, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2, err := b.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st := b.Status(); st.Active != 2 {
+		t.Fatalf("expected 2 active, got %d", st.Active)
+	}
+
+	r1()
+	r1() // release is idempotent
+	r2()
+	if st := b.Status(); st.Active != 0 {
+		t.Fatalf("expected 0 active, got %d", st.Active)
+	}
+}
+
+func TestAcquire_NoQueueRejectsImmediately(t *testing.T) {
+	b := New(Config{MaxConcurrent: 1})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	if _, err := b.Acquire(context.Background()); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestAcquire_QueuedRequestGetsSlot(t *testing.T) {
+	b := New(Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second})
+	release, _ := b.Acquire(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		r, err := b.Acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+
+	waitFor(t, func() bool { return b.Status().Queued == 1 })
+
+	// The queue is full now.
+	if _, err := b.Acquire(context.Background()); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("expected queued request to succeed, got %v", err)
+	}
+	if st := b.Status(); st.Queued != 0 || st.Active != 0 {
+		t.Fatalf("expected empty bulkhead, got %+v", st)
+	}
+}
+
+func TestAcquire_QueueTimeout(t *testing.T) {
+	b := New(Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	if _, err := b.Acquire(context.Background()); !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected ErrQueueTimeout, got %v", err)
+	}
+	if st := b.Status(); st.Queued != 0 {
+		t.Fatalf("expected queue to drain, got %d", st.Queued)
+	}
+}
+
+func TestAcquire_ContextCancelled(t *testing.T) {
+	b := New(Config{MaxConcurrent: 1, MaxQueue: 1})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAcquire_ConcurrencyNeverExceedsLimit(t *testing.T) {
+	b := New(Config{MaxConcurrent: 3, MaxQueue: 100})
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := b.Acquire(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			active++
+			if active > peak {
+				peak = active
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			release()
+		}()
+	}
+	wg.Wait()
+	if peak > 3 {
+		t.Fatalf("expected at most 3 concurrent requests, got %d", peak)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := New(Config{MaxConcurrent: 1}).RetryAfter(); got != 1 {
+		t.Errorf("expected 1s minimum, got %d", got)
+	}
+	if got := New(Config{MaxConcurrent: 1, QueueTimeout: 5 * time.Second}).RetryAfter(); got != 5 {
+		t.Errorf("expected 5s, got %d", got)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	bulkheads := FromConfig([]link.LinkTarget{
+		{Name: "limited", Bulkhead: link.BulkheadConfig{MaxConcurrentRequests: 2, MaxQueue: 4, QueueTimeout: "250ms"}},
+		{Name: "unlimited"},
+	}, metrics)
+
+	if _, ok := bulkheads["unlimited"]; ok {
+		t.Fatal("expected no bulkhead for target without maxConcurrentRequests")
+	}
+	b, ok := bulkheads["limited"]
+	if !ok {
+		t.Fatal("expected bulkhead for limited target")
+	}
+	if b.cfg.QueueTimeout != 250*time.Millisecond {
+		t.Fatalf("expected 250ms queue timeout, got %s", b.cfg.QueueTimeout)
+	}
+
+	release, _ := b.Acquire(context.Background())
+	if got := testutil.ToFloat64(metrics.BulkheadActive.WithLabelValues("limited")); got != 1 {
+		t.Fatalf("expected active gauge 1, got %v", got)
+	}
+	release()
+	if got := testutil.ToFloat64(metrics.BulkheadActive.WithLabelValues("limited")); got != 0 {
+		t.Fatalf("expected active gauge 0, got %v", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
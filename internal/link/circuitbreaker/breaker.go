@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/lsm/fiso/internal/link"
 )
 
 // State represents the circuit breaker state.
@@ -31,33 +33,121 @@ func (s State) String() string {
 // ErrCircuitOpen is returned when the circuit breaker is open.
 var ErrCircuitOpen = errors.New("circuit breaker is open")
 
+// Mode selects how the breaker decides to trip from Closed to Open.
+type Mode string
+
+const (
+	// ModeConsecutive trips after FailureThreshold consecutive failures.
+	ModeConsecutive Mode = "consecutive"
+	// ModeCount trips when the failure rate over the last WindowSize calls
+	// reaches FailureRateThreshold.
+	ModeCount Mode = "count"
+	// ModeTime trips when the failure rate over the last WindowDuration
+	// reaches FailureRateThreshold.
+	ModeTime Mode = "time"
+)
+
+// ErrTooManyProbes is returned when the breaker is half-open and the
+// maximum number of concurrent probe requests is already in flight.
+var ErrTooManyProbes = errors.New("circuit breaker is half-open and probe limit reached")
+
 // Config holds circuit breaker configuration.
 type Config struct {
 	FailureThreshold int
 	SuccessThreshold int
 	ResetTimeout     time.Duration
+
+	// Mode defaults to ModeConsecutive when empty.
+	Mode Mode
+	// WindowSize is the number of calls tracked in ModeCount.
+	WindowSize int
+	// WindowDuration is the span of time tracked in ModeTime.
+	WindowDuration time.Duration
+	// MinimumRequests is the number of calls the window must contain
+	// before the failure rate is evaluated.
+	MinimumRequests int
+	// FailureRateThreshold is the failure percentage (0-100) at which a
+	// windowed breaker trips.
+	FailureRateThreshold float64
+	// SlowCallDuration, when set, records successful calls that take at
+	// least this long as failures.
+	SlowCallDuration time.Duration
+	// MaxHalfOpenRequests caps concurrent probes while half-open.
+	// Zero means unlimited.
+	MaxHalfOpenRequests int
+	// FailureStatusCodes lists the HTTP status codes that count as
+	// failures. Empty means any error reported by the caller.
+	FailureStatusCodes []StatusRange
 }
 
 // DefaultConfig returns sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		FailureThreshold: 5,
-		SuccessThreshold: 3,
-		ResetTimeout:     30 * time.Second,
+		FailureThreshold:     5,
+		SuccessThreshold:     3,
+		ResetTimeout:         30 * time.Second,
+		Mode:                 ModeConsecutive,
+		WindowSize:           100,
+		WindowDuration:       60 * time.Second,
+		MinimumRequests:      10,
+		FailureRateThreshold: 50,
 	}
 }
 
+// FromLinkConfig returns DefaultConfig overridden by any values set in a
+// target's circuit breaker configuration. Unparseable durations and status
+// codes keep their defaults; link.Config.Validate reports them at load time.
+func FromLinkConfig(cb link.CircuitBreakerConfig) Config {
+	cfg := DefaultConfig()
+	if cb.FailureThreshold > 0 {
+		cfg.FailureThreshold = cb.FailureThreshold
+	}
+	if cb.SuccessThreshold > 0 {
+		cfg.SuccessThreshold = cb.SuccessThreshold
+	}
+	if d, err := time.ParseDuration(cb.ResetTimeout); err == nil {
+		cfg.ResetTimeout = d
+	}
+	if cb.Mode != "" {
+		cfg.Mode = Mode(cb.Mode)
+	}
+	if cb.WindowSize > 0 {
+		cfg.WindowSize = cb.WindowSize
+	}
+	if d, err := time.ParseDuration(cb.WindowDuration); err == nil {
+		cfg.WindowDuration = d
+	}
+	if cb.MinimumRequests > 0 {
+		cfg.MinimumRequests = cb.MinimumRequests
+	}
+	if cb.FailureRateThreshold > 0 {
+		cfg.FailureRateThreshold = cb.FailureRateThreshold
+	}
+	if d, err := time.ParseDuration(cb.SlowCallDuration); err == nil {
+		cfg.SlowCallDuration = d
+	}
+	if cb.MaxHalfOpenRequests > 0 {
+		cfg.MaxHalfOpenRequests = cb.MaxHalfOpenRequests
+	}
+	if ranges, err := ParseStatusCodes(cb.FailureStatusCodes); err == nil && len(ranges) > 0 {
+		cfg.FailureStatusCodes = ranges
+	}
+	return cfg
+}
+
 // Breaker implements the circuit breaker pattern with three states.
 type Breaker struct {
-	mu               sync.Mutex
-	state            State
-	failures         int
-	successes        int
-	failureThreshold int
-	successThreshold int
-	resetTimeout     time.Duration
-	lastFailure      time.Time
-	clock            func() time.Time
+	mu          sync.Mutex
+	cfg         Config
+	state       State
+	failures    int
+	successes   int
+	probes      int
+	generation  uint64 // incremented on every state change
+	window      window
+	lastFailure time.Time
+	forced      bool
+	clock       func() time.Time
 }
 
 // Option configures a Breaker.
@@ -72,12 +162,19 @@ func WithClock(clock func() time.Time) Option {
 
 // New creates a new circuit breaker.
 func New(cfg Config, opts ...Option) *Breaker {
+	if cfg.Mode == "" {
+		cfg.Mode = ModeConsecutive
+	}
 	b := &Breaker{
-		state:            Closed,
-		failureThreshold: cfg.FailureThreshold,
-		successThreshold: cfg.SuccessThreshold,
-		resetTimeout:     cfg.ResetTimeout,
-		clock:            time.Now,
+		cfg:   cfg,
+		state: Closed,
+		clock: time.Now,
+	}
+	switch cfg.Mode {
+	case ModeCount:
+		b.window = newCountWindow(cfg.WindowSize)
+	case ModeTime:
+		b.window = newTimeWindow(cfg.WindowDuration)
 	}
 	for _, opt := range opts {
 		opt(b)
@@ -85,63 +182,201 @@ func New(cfg Config, opts ...Option) *Breaker {
 	return b
 }
 
+// Ticket identifies a request admitted by Allow.
+type Ticket struct {
+	probe      bool   // holds a half-open probe slot
+	generation uint64 // of the breaker state the request was admitted in
+}
+
 // Allow checks whether the request is allowed. Returns ErrCircuitOpen if
-// the circuit is open and the reset timeout has not elapsed.
-func (b *Breaker) Allow() error {
+// the circuit is open and the reset timeout has not elapsed, or
+// ErrTooManyProbes if the circuit is half-open and MaxHalfOpenRequests
+// probes are already in flight. A nil return must be followed by exactly
+// one call to Record or Cancel with the returned Ticket.
+func (b *Breaker) Allow() (Ticket, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.forced {
+		if b.state == Open {
+			return Ticket{}, ErrCircuitOpen
+		}
+		return Ticket{generation: b.generation}, nil
+	}
+
 	switch b.state {
-	case Closed:
-		return nil
 	case Open:
-		if b.clock().Sub(b.lastFailure) >= b.resetTimeout {
-			b.state = HalfOpen
-			b.successes = 0
-			return nil
+		if b.clock().Sub(b.lastFailure) < b.cfg.ResetTimeout {
+			return Ticket{}, ErrCircuitOpen
 		}
-		return ErrCircuitOpen
+		b.state = HalfOpen
+		b.generation++
+		b.successes = 0
+		b.probes = 0
+		fallthrough
 	case HalfOpen:
-		return nil
+		if b.cfg.MaxHalfOpenRequests > 0 && b.probes >= b.cfg.MaxHalfOpenRequests {
+			return Ticket{}, ErrTooManyProbes
+		}
+		b.probes++
+		return Ticket{probe: true, generation: b.generation}, nil
 	default:
-		return nil
+		return Ticket{generation: b.generation}, nil
+	}
+}
+
+// Cancel releases a request admitted by Allow without recording an outcome,
+// e.g. when the request is rejected locally before reaching upstream.
+func (b *Breaker) Cancel(t Ticket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.release(t)
+}
+
+// Record records the outcome of a request admitted by Allow along with how
+// long it took. Successful calls slower than SlowCallDuration are recorded
+// as failures. The outcome of a request admitted before the breaker last
+// changed state is ignored, so that, for example, a request admitted while
+// Closed does not count as a half-open probe.
+func (b *Breaker) Record(t Ticket, success bool, elapsed time.Duration) {
+	if success && b.cfg.SlowCallDuration > 0 && elapsed >= b.cfg.SlowCallDuration {
+		success = false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.release(t) {
+		return
+	}
+	if success {
+		b.recordSuccess()
+	} else {
+		b.recordFailure()
+	}
+}
+
+// IsFailure reports whether a request outcome counts as a failure. When
+// FailureStatusCodes is configured and a response was received
+// (statusCode > 0), only matching codes are failures; otherwise any non-nil
+// err is a failure.
+func (b *Breaker) IsFailure(statusCode int, err error) bool {
+	if len(b.cfg.FailureStatusCodes) == 0 || statusCode == 0 {
+		return err != nil
+	}
+	for _, r := range b.cfg.FailureStatusCodes {
+		if r.Contains(statusCode) {
+			return true
+		}
 	}
+	return false
 }
 
-// RecordSuccess records a successful request.
+// RecordSuccess records a successful request that was not admitted by Allow.
 func (b *Breaker) RecordSuccess() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.recordSuccess()
+}
+
+func (b *Breaker) recordSuccess() {
+	if b.forced {
+		return
+	}
 
 	switch b.state {
 	case HalfOpen:
 		b.successes++
-		if b.successes >= b.successThreshold {
-			b.state = Closed
-			b.failures = 0
-			b.successes = 0
+		if b.successes >= b.cfg.SuccessThreshold {
+			b.toClosed()
 		}
 	case Closed:
+		if b.window != nil {
+			b.window.record(b.clock(), false)
+			b.syncWindowCounts()
+			return
+		}
 		b.failures = 0
 	}
 }
 
-// RecordFailure records a failed request.
+// RecordFailure records a failed request that was not admitted by Allow.
 func (b *Breaker) RecordFailure() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.recordFailure()
+}
+
+func (b *Breaker) recordFailure() {
+	if b.forced {
+		return
+	}
 
 	switch b.state {
 	case Closed:
+		if b.window != nil {
+			now := b.clock()
+			b.window.record(now, true)
+			b.syncWindowCounts()
+			if b.shouldTripWindow(now) {
+				b.toOpen()
+			}
+			return
+		}
 		b.failures++
-		if b.failures >= b.failureThreshold {
-			b.state = Open
-			b.lastFailure = b.clock()
+		if b.failures >= b.cfg.FailureThreshold {
+			b.toOpen()
 		}
 	case HalfOpen:
-		b.state = Open
-		b.lastFailure = b.clock()
-		b.successes = 0
+		b.toOpen()
+	}
+}
+
+// shouldTripWindow evaluates the failure rate of a windowed breaker.
+// Must be called with b.mu held.
+func (b *Breaker) shouldTripWindow(now time.Time) bool {
+	total, failures := b.window.counts(now)
+	if total == 0 || total < b.cfg.MinimumRequests {
+		return false
+	}
+	return float64(failures)*100/float64(total) >= b.cfg.FailureRateThreshold
+}
+
+// syncWindowCounts mirrors the window totals into the counters reported by
+// Counts. Must be called with b.mu held.
+func (b *Breaker) syncWindowCounts() {
+	total, failures := b.window.counts(b.clock())
+	b.failures = failures
+	b.successes = total - failures
+}
+
+// release ends the admission of t, freeing its half-open probe slot, and
+// reports whether t was admitted in the current state. Must be called with
+// b.mu held.
+func (b *Breaker) release(t Ticket) bool {
+	if t.generation != b.generation {
+		return false
+	}
+	if t.probe && b.probes > 0 {
+		b.probes--
+	}
+	return true
+}
+
+func (b *Breaker) toOpen() {
+	b.state = Open
+	b.generation++
+	b.lastFailure = b.clock()
+	b.successes = 0
+	b.probes = 0
+}
+
+func (b *Breaker) toClosed() {
+	b.state = Closed
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	if b.window != nil {
+		b.window.reset()
 	}
 }
 
@@ -158,3 +393,40 @@ func (b *Breaker) Counts() (failures, successes int) {
 	defer b.mu.Unlock()
 	return b.failures, b.successes
 }
+
+// Config returns the breaker's configuration.
+func (b *Breaker) Config() Config {
+	return b.cfg
+}
+
+// Force pins the breaker in the given state until Reset is called. A forced
+// Open breaker rejects every request; a forced Closed breaker allows every
+// request and ignores recorded outcomes.
+func (b *Breaker) Force(s State) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s == Open {
+		b.toOpen()
+	} else {
+		b.toClosed()
+		b.state = s
+	}
+	b.forced = true
+	b.failures = 0
+}
+
+// Forced reports whether the breaker state is pinned by Force.
+func (b *Breaker) Forced() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.forced
+}
+
+// Reset returns the breaker to Closed, clears its counts and releases any
+// state pinned by Force.
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.toClosed()
+	b.forced = false
+}
@@ -1,8 +1,11 @@
 package circuitbreaker
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/lsm/fiso/internal/link"
 )
 
 func TestNew_DefaultState(t *testing.T) {
@@ -15,7 +18,7 @@ func TestNew_DefaultState(t *testing.T) {
 func TestAllow_ClosedAlwaysAllows(t *testing.T) {
 	b := New(DefaultConfig())
 	for i := 0; i < 10; i++ {
-		if err := b.Allow(); err != nil {
+		if _, err := b.Allow(); err != nil {
 			t.Fatalf("expected nil error, got %v", err)
 		}
 	}
@@ -27,7 +30,7 @@ func TestTransition_ClosedToOpen(t *testing.T) {
 
 	// Record failures up to threshold
 	for i := 0; i < 3; i++ {
-		_ = b.Allow()
+		_, _ = b.Allow()
 		b.RecordFailure()
 	}
 
@@ -40,10 +43,10 @@ func TestAllow_OpenReturnsError(t *testing.T) {
 	cfg := Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 1 * time.Hour}
 	b := New(cfg)
 
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
-	err := b.Allow()
+	_, err := b.Allow()
 	if err != ErrCircuitOpen {
 		t.Fatalf("expected ErrCircuitOpen, got %v", err)
 	}
@@ -55,7 +58,7 @@ func TestTransition_OpenToHalfOpen(t *testing.T) {
 	cfg := Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 5 * time.Second}
 	b := New(cfg, WithClock(clock))
 
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
 	if b.State() != Open {
@@ -65,7 +68,7 @@ func TestTransition_OpenToHalfOpen(t *testing.T) {
 	// Advance clock past reset timeout
 	now = now.Add(6 * time.Second)
 
-	err := b.Allow()
+	_, err := b.Allow()
 	if err != nil {
 		t.Fatalf("expected nil after reset timeout, got %v", err)
 	}
@@ -81,12 +84,12 @@ func TestTransition_HalfOpenToClosed(t *testing.T) {
 	b := New(cfg, WithClock(clock))
 
 	// Trip to Open
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
 	// Advance past reset
 	now = now.Add(6 * time.Second)
-	_ = b.Allow() // transitions to HalfOpen
+	_, _ = b.Allow() // transitions to HalfOpen
 
 	// Record successes up to threshold
 	b.RecordSuccess()
@@ -106,12 +109,12 @@ func TestTransition_HalfOpenToOpen(t *testing.T) {
 	b := New(cfg, WithClock(clock))
 
 	// Trip to Open
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
 	// Advance past reset
 	now = now.Add(6 * time.Second)
-	_ = b.Allow() // transitions to HalfOpen
+	_, _ = b.Allow() // transitions to HalfOpen
 
 	// Single failure in HalfOpen should trip back to Open
 	b.RecordFailure()
@@ -125,9 +128,9 @@ func TestRecordSuccess_ClosedResetsFailures(t *testing.T) {
 	b := New(cfg)
 
 	// Accumulate some failures (below threshold)
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
 	failures, _ := b.Counts()
@@ -149,13 +152,13 @@ func TestOpenDoesNotTransition_BeforeTimeout(t *testing.T) {
 	cfg := Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 10 * time.Second}
 	b := New(cfg, WithClock(clock))
 
-	_ = b.Allow()
+	_, _ = b.Allow()
 	b.RecordFailure()
 
 	// Advance only 5 seconds (less than 10s timeout)
 	now = now.Add(5 * time.Second)
 
-	err := b.Allow()
+	_, err := b.Allow()
 	if err != ErrCircuitOpen {
 		t.Fatalf("expected ErrCircuitOpen before timeout, got %v", err)
 	}
@@ -190,7 +193,7 @@ func TestConcurrentAccess(t *testing.T) {
 		go func() {
 			defer func() { done <- struct{}{} }()
 			for j := 0; j < 100; j++ {
-				_ = b.Allow()
+				_, _ = b.Allow()
 				b.RecordFailure()
 				b.RecordSuccess()
 				_ = b.State()
@@ -202,3 +205,316 @@ func TestConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestForce_OpenRejectsUntilReset(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	b := New(Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: time.Second}, WithClock(clock))
+
+	b.Force(Open)
+	if !b.Forced() {
+		t.Fatal("expected breaker to be forced")
+	}
+	now = now.Add(time.Hour)
+	if _, err := b.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("expected forced open breaker to reject after reset timeout, got %v", err)
+	}
+	b.RecordSuccess()
+	if b.State() != Open {
+		t.Fatalf("expected forced state to ignore outcomes, got %s", b.State())
+	}
+
+	b.Reset()
+	if b.Forced() || b.State() != Closed {
+		t.Fatalf("expected unforced Closed after reset, got %s (forced=%v)", b.State(), b.Forced())
+	}
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("expected allow after reset, got %v", err)
+	}
+}
+
+func TestForce_ClosedIgnoresFailures(t *testing.T) {
+	b := New(Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: time.Hour})
+	b.Force(Closed)
+	for i := 0; i < 5; i++ {
+		if _, err := b.Allow(); err != nil {
+			t.Fatalf("expected allow, got %v", err)
+		}
+		b.RecordFailure()
+	}
+	if b.State() != Closed {
+		t.Fatalf("expected forced Closed, got %s", b.State())
+	}
+	if f, _ := b.Counts(); f != 0 {
+		t.Fatalf("expected failures not counted, got %d", f)
+	}
+}
+
+func TestConfig_ReturnsSettings(t *testing.T) {
+	cfg := Config{FailureThreshold: 7, SuccessThreshold: 2, ResetTimeout: 5 * time.Second}
+	got := New(cfg).Config()
+	if got.FailureThreshold != 7 || got.SuccessThreshold != 2 || got.ResetTimeout != 5*time.Second {
+		t.Fatalf("expected %+v, got %+v", cfg, got)
+	}
+	if got.Mode != ModeConsecutive {
+		t.Fatalf("expected default mode %q, got %q", ModeConsecutive, got.Mode)
+	}
+}
+
+func TestCountWindow_TripsOnFailureRate(t *testing.T) {
+	cfg := Config{
+		SuccessThreshold:     1,
+		ResetTimeout:         time.Hour,
+		Mode:                 ModeCount,
+		WindowSize:           10,
+		MinimumRequests:      4,
+		FailureRateThreshold: 50,
+	}
+	b := New(cfg)
+
+	// Alternating outcomes never produce consecutive failures but reach 50%.
+	outcomes := []bool{true, false, true}
+	for _, ok := range outcomes {
+		ticket, _ := b.Allow()
+		b.Record(ticket, ok, 0)
+	}
+	if b.State() != Closed {
+		t.Fatalf("expected Closed below minimumRequests, got %s", b.State())
+	}
+
+	_, _ = b.Allow()
+	b.RecordFailure()
+	if b.State() != Open {
+		t.Fatalf("expected Open at 50%% failure rate, got %s", b.State())
+	}
+}
+
+func TestCountWindow_EvictsOldOutcomes(t *testing.T) {
+	cfg := Config{
+		SuccessThreshold:     1,
+		ResetTimeout:         time.Hour,
+		Mode:                 ModeCount,
+		WindowSize:           4,
+		MinimumRequests:      4,
+		FailureRateThreshold: 75,
+	}
+	b := New(cfg)
+
+	for i := 0; i < 2; i++ {
+		b.RecordFailure()
+	}
+	for i := 0; i < 4; i++ {
+		b.RecordSuccess()
+	}
+	// The window now holds only successes; two failures give 50%.
+	for i := 0; i < 2; i++ {
+		b.RecordFailure()
+	}
+	if b.State() != Closed {
+		t.Fatalf("expected Closed at 50%% failure rate, got %s", b.State())
+	}
+	if failures, successes := b.Counts(); failures != 2 || successes != 2 {
+		t.Fatalf("expected counts 2/2, got %d/%d", failures, successes)
+	}
+}
+
+func TestTimeWindow_ExpiresOldBuckets(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+	cfg := Config{
+		SuccessThreshold:     1,
+		ResetTimeout:         time.Hour,
+		Mode:                 ModeTime,
+		WindowDuration:       10 * time.Second,
+		MinimumRequests:      3,
+		FailureRateThreshold: 100,
+	}
+	b := New(cfg, WithClock(clock))
+
+	b.RecordFailure()
+	b.RecordFailure()
+
+	// Both failures fall out of the window before the third arrives.
+	now = now.Add(11 * time.Second)
+	b.RecordFailure()
+	if b.State() != Closed {
+		t.Fatalf("expected Closed after window expiry, got %s", b.State())
+	}
+
+	now = now.Add(time.Second)
+	b.RecordFailure()
+	b.RecordFailure()
+	if b.State() != Open {
+		t.Fatalf("expected Open with 3 failures in window, got %s", b.State())
+	}
+}
+
+func TestRecord_SlowCallCountsAsFailure(t *testing.T) {
+	cfg := Config{FailureThreshold: 2, SuccessThreshold: 1, ResetTimeout: time.Hour, SlowCallDuration: 100 * time.Millisecond}
+	b := New(cfg)
+
+	for _, elapsed := range []time.Duration{50 * time.Millisecond, 150 * time.Millisecond} {
+		ticket, _ := b.Allow()
+		b.Record(ticket, true, elapsed)
+	}
+	if b.State() != Closed {
+		t.Fatalf("expected Closed after one slow call, got %s", b.State())
+	}
+	ticket, _ := b.Allow()
+	b.Record(ticket, true, 100*time.Millisecond)
+	if b.State() != Open {
+		t.Fatalf("expected Open after two slow calls, got %s", b.State())
+	}
+}
+
+func TestHalfOpen_ProbeLimit(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	cfg := Config{FailureThreshold: 1, SuccessThreshold: 2, ResetTimeout: time.Second, MaxHalfOpenRequests: 1}
+	b := New(cfg, WithClock(clock))
+
+	_, _ = b.Allow()
+	b.RecordFailure()
+	now = now.Add(2 * time.Second)
+
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected first probe to be allowed, got %v", err)
+	}
+	if _, err := b.Allow(); err != ErrTooManyProbes {
+		t.Fatalf("expected ErrTooManyProbes, got %v", err)
+	}
+
+	// Cancel frees the slot without counting as a success.
+	b.Cancel(probe)
+	if probe, err = b.Allow(); err != nil {
+		t.Fatalf("expected probe after Cancel, got %v", err)
+	}
+	b.Record(probe, true, 0)
+	if b.State() != HalfOpen {
+		t.Fatalf("expected HalfOpen after one success, got %s", b.State())
+	}
+	if probe, err = b.Allow(); err != nil {
+		t.Fatalf("expected probe after success, got %v", err)
+	}
+	b.Record(probe, true, 0)
+	if b.State() != Closed {
+		t.Fatalf("expected Closed, got %s", b.State())
+	}
+}
+
+func TestHalfOpen_RequestAdmittedWhileClosedIsNotAProbe(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	cfg := Config{FailureThreshold: 1, SuccessThreshold: 2, ResetTimeout: time.Second, MaxHalfOpenRequests: 1}
+	b := New(cfg, WithClock(clock))
+
+	// A slow request is admitted while Closed, then the breaker opens and
+	// moves to HalfOpen, where a probe takes the only slot.
+	slow, _ := b.Allow()
+	failed, _ := b.Allow()
+	b.Record(failed, false, 0)
+	now = now.Add(2 * time.Second)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a probe, got %v", err)
+	}
+
+	// The slow request finishing frees no slot and counts as no probe.
+	b.Record(slow, true, 0)
+	if _, err := b.Allow(); err != ErrTooManyProbes {
+		t.Fatalf("expected ErrTooManyProbes, got %v", err)
+	}
+	b.Cancel(slow)
+	if _, err := b.Allow(); err != ErrTooManyProbes {
+		t.Fatalf("expected ErrTooManyProbes after a stale Cancel, got %v", err)
+	}
+	if _, successes := b.Counts(); successes != 0 {
+		t.Errorf("expected no probe successes, got %d", successes)
+	}
+
+	b.Record(probe, false, 0)
+	if b.State() != Open {
+		t.Fatalf("expected Open after the probe failed, got %s", b.State())
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	errUpstream := errors.New("upstream error")
+
+	b := New(DefaultConfig())
+	if !b.IsFailure(503, errUpstream) || b.IsFailure(200, nil) {
+		t.Fatal("expected default breaker to follow the caller's error")
+	}
+
+	codes, err := ParseStatusCodes([]string{"5xx", "429"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.FailureStatusCodes = codes
+	b = New(cfg)
+
+	tests := []struct {
+		status int
+		err    error
+		want   bool
+	}{
+		{status: 503, err: errUpstream, want: true},
+		{status: 429, err: nil, want: true},
+		{status: 404, err: errUpstream, want: false},
+		{status: 200, err: nil, want: false},
+		{status: 0, err: errUpstream, want: true},
+	}
+	for _, tt := range tests {
+		if got := b.IsFailure(tt.status, tt.err); got != tt.want {
+			t.Errorf("IsFailure(%d, %v) = %v, want %v", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseStatusCodes(t *testing.T) {
+	got, err := ParseStatusCodes([]string{"503", "4xx", "500-504"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []StatusRange{{503, 503}, {400, 499}, {500, 504}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("range %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	for _, bad := range []string{"abc", "600", "504-500", "6xx"} {
+		if _, err := ParseStatusCodes([]string{bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestFromLinkConfig(t *testing.T) {
+	cfg := FromLinkConfig(link.CircuitBreakerConfig{
+		Enabled:              true,
+		FailureThreshold:     4,
+		ResetTimeout:         "10s",
+		Mode:                 "count",
+		WindowSize:           20,
+		MinimumRequests:      5,
+		FailureRateThreshold: 25,
+		SlowCallDuration:     "2s",
+		MaxHalfOpenRequests:  3,
+		FailureStatusCodes:   []string{"5xx"},
+	})
+	if cfg.FailureThreshold != 4 || cfg.SuccessThreshold != 3 || cfg.ResetTimeout != 10*time.Second {
+		t.Fatalf("unexpected thresholds: %+v", cfg)
+	}
+	if cfg.Mode != ModeCount || cfg.WindowSize != 20 || cfg.MinimumRequests != 5 || cfg.FailureRateThreshold != 25 {
+		t.Fatalf("unexpected window settings: %+v", cfg)
+	}
+	if cfg.SlowCallDuration != 2*time.Second || cfg.MaxHalfOpenRequests != 3 {
+		t.Fatalf("unexpected slow-call/probe settings: %+v", cfg)
+	}
+	if len(cfg.FailureStatusCodes) != 1 || cfg.FailureStatusCodes[0] != (StatusRange{500, 599}) {
+		t.Fatalf("unexpected status codes: %+v", cfg.FailureStatusCodes)
+	}
+}
@@ -0,0 +1,160 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// window tracks recent call outcomes for failure-rate evaluation.
+type window interface {
+	record(now time.Time, failed bool)
+	counts(now time.Time) (total, failures int)
+	reset()
+}
+
+// countWindow keeps the outcomes of the last N calls in a ring buffer.
+type countWindow struct {
+	outcomes []bool
+	next     int
+	filled   int
+	failures int
+}
+
+func newCountWindow(size int) *countWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &countWindow{outcomes: make([]bool, size)}
+}
+
+func (w *countWindow) record(_ time.Time, failed bool) {
+	if w.filled == len(w.outcomes) {
+		if w.outcomes[w.next] {
+			w.failures--
+		}
+	} else {
+		w.filled++
+	}
+	w.outcomes[w.next] = failed
+	if failed {
+		w.failures++
+	}
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+func (w *countWindow) counts(time.Time) (int, int) {
+	return w.filled, w.failures
+}
+
+func (w *countWindow) reset() {
+	w.next, w.filled, w.failures = 0, 0, 0
+}
+
+// timeWindowBuckets is the number of buckets a time window is split into.
+const timeWindowBuckets = 10
+
+type bucket struct {
+	epoch    int64
+	total    int
+	failures int
+}
+
+// timeWindow aggregates outcomes into fixed-width buckets covering the
+// last window duration.
+type timeWindow struct {
+	width   time.Duration
+	buckets []bucket
+}
+
+func newTimeWindow(d time.Duration) *timeWindow {
+	if d <= 0 {
+		d = time.Minute
+	}
+	width := d / timeWindowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &timeWindow{width: width, buckets: make([]bucket, timeWindowBuckets)}
+}
+
+func (w *timeWindow) record(now time.Time, failed bool) {
+	epoch := now.UnixNano() / int64(w.width)
+	b := &w.buckets[epoch%int64(len(w.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *timeWindow) counts(now time.Time) (total, failures int) {
+	epoch := now.UnixNano() / int64(w.width)
+	oldest := epoch - int64(len(w.buckets)) + 1
+	for _, b := range w.buckets {
+		if b.epoch >= oldest && b.epoch <= epoch {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
+
+// StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether code falls within the range.
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// ParseStatusCodes parses status code specs such as "503", "5xx" or
+// "500-504" into ranges.
+func ParseStatusCodes(specs []string) ([]StatusRange, error) {
+	ranges := make([]StatusRange, 0, len(specs))
+	for _, spec := range specs {
+		r, err := parseStatusRange(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func parseStatusRange(spec string) (StatusRange, error) {
+	lower := strings.ToLower(spec)
+	if len(lower) == 3 && strings.HasSuffix(lower, "xx") && lower[0] >= '1' && lower[0] <= '5' {
+		base := int(lower[0]-'0') * 100
+		return StatusRange{Min: base, Max: base + 99}, nil
+	}
+	if lo, hi, ok := strings.Cut(spec, "-"); ok {
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || !validStatus(from) || !validStatus(to) || from > to {
+			return StatusRange{}, fmt.Errorf("invalid status code range %q", spec)
+		}
+		return StatusRange{Min: from, Max: to}, nil
+	}
+	code, err := strconv.Atoi(spec)
+	if err != nil || !validStatus(code) {
+		return StatusRange{}, fmt.Errorf("invalid status code %q (use e.g. 503, 5xx or 500-504)", spec)
+	}
+	return StatusRange{Min: code, Max: code}, nil
+}
+
+func validStatus(code int) bool {
+	return code >= 100 && code <= 599
+}
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,9 +29,118 @@ type LinkTarget struct {
 	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker"`
 	Retry          RetryConfig          `yaml:"retry"`
 	RateLimit      RateLimitConfig      `yaml:"rateLimit"`
+	Bulkhead       BulkheadConfig       `yaml:"bulkhead,omitempty"`
 	AllowedPaths   []string             `yaml:"allowedPaths"`
-	Kafka          *KafkaConfig         `yaml:"kafka,omitempty"` // Kafka-specific settings
-	Interceptors   []InterceptorConfig  `yaml:"interceptors"`    // Interceptor chain configuration
+	Kafka          *KafkaConfig         `yaml:"kafka,omitempty"`  // Kafka-specific settings
+	Interceptors   []InterceptorConfig  `yaml:"interceptors"`     // Interceptor chain configuration
+	Faults         *FaultsConfig        `yaml:"faults,omitempty"` // Fault injection for resilience testing
+
+	// AdaptiveConcurrency adjusts a concurrency limit from observed latency.
+	AdaptiveConcurrency *AdaptiveConcurrencyConfig `yaml:"adaptiveConcurrency,omitempty"`
+	// Headers manipulates upstream request and response headers.
+	Headers *HeadersConfig `yaml:"headers,omitempty"`
+	// Rewrite rewrites the upstream path, query and method.
+	Rewrite *RewriteConfig `yaml:"rewrite,omitempty"`
+	// Validation validates request bodies before they are forwarded.
+	Validation *ValidationConfig `yaml:"validation,omitempty"`
+}
+
+// ValidationConfig defines request body validation for a target. Requests
+// are checked after rewrites, so schemas describe the upstream API. A
+// request matched by several rules must satisfy all of them.
+type ValidationConfig struct {
+	Rules []ValidationRule `yaml:"rules,omitempty"`
+	// OpenAPI is the path to an OpenAPI 3 document (YAML or JSON). The
+	// application/json request body schema of the matching operation is
+	// enforced.
+	OpenAPI string `yaml:"openapi,omitempty"`
+}
+
+// ValidationRule validates the JSON body of requests matching Match (route
+// syntax, e.g. "POST /customers"). Exactly one of Schema or SchemaFile must
+// be set.
+type ValidationRule struct {
+	Match      string                 `yaml:"match"`
+	Schema     map[string]interface{} `yaml:"schema,omitempty"`     // Inline JSON Schema
+	SchemaFile string                 `yaml:"schemaFile,omitempty"` // Path to a JSON Schema file
+}
+
+var validHTTPMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+var validQueryActions = map[string]bool{"add": true, "set": true, "remove": true, "rename": true}
+
+// RewriteConfig defines rewrite rules for a target. Rules run in order after
+// allowedPaths is checked, each on the output of the previous rule.
+type RewriteConfig struct {
+	Rules []RewriteRule `yaml:"rules"`
+}
+
+// RewriteRule rewrites requests whose path matches Match (allowedPaths
+// syntax; empty matches everything). StripPrefix runs before Regex.
+type RewriteRule struct {
+	Match       string         `yaml:"match,omitempty"`
+	StripPrefix string         `yaml:"stripPrefix,omitempty"`
+	Regex       string         `yaml:"regex,omitempty"`   // e.g. "^/customers/([^/]+)$"
+	Replace     string         `yaml:"replace,omitempty"` // e.g. "/v3/accounts/$1"
+	Method      string         `yaml:"method,omitempty"`  // Method override
+	Query       []QueryRewrite `yaml:"query,omitempty"`
+}
+
+// QueryRewrite adds, sets, removes or renames a query parameter.
+type QueryRewrite struct {
+	Action string `yaml:"action"` // add, set, remove, rename
+	Name   string `yaml:"name"`
+	To     string `yaml:"to,omitempty"`
+	Value  string `yaml:"value,omitempty"`
+}
+
+var validHeaderActions = map[string]bool{"add": true, "set": true, "remove": true, "rename": true}
+
+// HeadersConfig defines header rules for a target. Request rules run on the
+// upstream request after the default deny-list (hop-by-hop and internal
+// headers) is applied; response rules run on the upstream response.
+type HeadersConfig struct {
+	Request  []HeaderRule `yaml:"request,omitempty"`
+	Response []HeaderRule `yaml:"response,omitempty"`
+	Deny     []string     `yaml:"deny,omitempty"`  // Extra headers stripped from upstream requests; "*" wildcards allowed
+	Allow    []string     `yaml:"allow,omitempty"` // Headers exempt from the deny-list
+}
+
+// HeaderRule is a single header operation. Name may contain "*" wildcards
+// for remove.
+type HeaderRule struct {
+	Action    string             `yaml:"action"` // add, set, remove, rename
+	Name      string             `yaml:"name"`
+	To        string             `yaml:"to,omitempty"` // New name for rename
+	Value     string             `yaml:"value,omitempty"`
+	ValueFrom *HeaderValueSource `yaml:"valueFrom,omitempty"`
+}
+
+// HeaderValueSource computes a header value at request time. Exactly one
+// field must be set.
+type HeaderValueSource struct {
+	Env       string     `yaml:"env,omitempty"`
+	SecretRef *SecretRef `yaml:"secretRef,omitempty"`
+	CEL       string     `yaml:"cel,omitempty"` // Over request (and response for response rules)
+}
+
+// FaultsConfig defines fault injection for a target. Faults are applied to
+// each upstream attempt, so retries and the circuit breaker observe them.
+// They require the admin API, which toggles them.
+type FaultsConfig struct {
+	Enabled bool        `yaml:"enabled"` // Initial state; can be toggled at runtime
+	Rules   []FaultRule `yaml:"rules"`
+}
+
+// FaultRule injects a fault into a percentage of requests matching Path.
+type FaultRule struct {
+	Path        string  `yaml:"path,omitempty"`        // Path pattern (same syntax as allowedPaths); empty matches all
+	Percentage  float64 `yaml:"percentage"`            // Share of matching requests affected, 0-100
+	Delay       string  `yaml:"delay,omitempty"`       // Added latency, e.g. "500ms"
+	AbortStatus int     `yaml:"abortStatus,omitempty"` // Respond with this status instead of calling upstream
+	Reset       bool    `yaml:"reset,omitempty"`       // Fail the attempt as a connection reset
 }
 
 // InterceptorConfig defines a single interceptor in the chain.
@@ -64,11 +177,75 @@ type VaultRef struct {
 
 // KafkaConfig defines Kafka-specific settings for a target.
 type KafkaConfig struct {
-	Cluster      string            `yaml:"cluster,omitempty"`      // Reference to named cluster in kafka.clusters
-	Topic        string            `yaml:"topic"`                  // Required: Kafka topic
-	Key          KeyStrategy       `yaml:"key"`                    // Optional: Key generation strategy
-	Headers      map[string]string `yaml:"headers,omitempty"`      // Optional: Static Kafka headers
-	RequiredAcks string            `yaml:"requiredAcks,omitempty"` // Optional: "all", "1" (default)
+	Cluster         string            `yaml:"cluster,omitempty"`         // Reference to named cluster in kafka.clusters
+	Topic           string            `yaml:"topic"`                     // Required: Kafka topic
+	Key             KeyStrategy       `yaml:"key"`                       // Optional: Key generation strategy
+	Headers         map[string]string `yaml:"headers,omitempty"`         // Optional: Static Kafka headers
+	RequiredAcks    string            `yaml:"requiredAcks,omitempty"`    // Optional: "all" (default), "1", "0"
+	Partitioner     string            `yaml:"partitioner,omitempty"`     // Optional: murmur2 (default), sticky, roundrobin, manual
+	PartitionHeader string            `yaml:"partitionHeader,omitempty"` // Optional: Partition header for manual (default X-Kafka-Partition)
+	Compression     string            `yaml:"compression,omitempty"`     // Optional: none, gzip, snappy, lz4, zstd
+	Linger          string            `yaml:"linger,omitempty"`          // Optional: Time to wait for a batch to fill, e.g. "5ms"
+	BatchMaxBytes   int               `yaml:"batchMaxBytes,omitempty"`   // Optional: Maximum bytes per record batch
+
+	// CloudEvents wraps each published payload in a CloudEvent.
+	CloudEvents *KafkaCloudEventsConfig `yaml:"cloudevents,omitempty"`
+	// Schema validates payloads against a schema registry subject.
+	Schema *KafkaSchemaConfig `yaml:"schema,omitempty"`
+}
+
+var validCloudEventsModes = map[string]bool{"structured": true, "binary": true}
+
+// KafkaCloudEventsConfig defines the CloudEvent envelope of Kafka publishes.
+// Subject and ID are CEL expressions over `data` (the JSON payload),
+// `headers` (lower-cased request headers) and `target`.
+type KafkaCloudEventsConfig struct {
+	Type    string `yaml:"type,omitempty"`    // CloudEvent type (default: fiso.event)
+	Source  string `yaml:"source,omitempty"`  // CloudEvent source (default: fiso-link/{target})
+	Subject string `yaml:"subject,omitempty"` // CEL expression for the subject (optional)
+	ID      string `yaml:"id,omitempty"`      // CEL expression for the ID (default: random UUID)
+	Mode    string `yaml:"mode,omitempty"`    // structured (default) or binary (ce_* Kafka headers)
+}
+
+// KafkaSchemaConfig validates Kafka payloads against the latest schema of a
+// schema registry subject.
+type KafkaSchemaConfig struct {
+	RegistryURL string `yaml:"registryUrl"`        // Confluent-compatible schema registry
+	Subject     string `yaml:"subject"`            // e.g. "orders-value"
+	CacheTTL    string `yaml:"cacheTTL,omitempty"` // How long the latest schema is cached (default: 5m)
+}
+
+// DefaultPartitionHeader carries the partition for the manual partitioner.
+const DefaultPartitionHeader = "X-Kafka-Partition"
+
+// ProducerConfig returns the producer settings for the target. Targets
+// with equal settings share a Kafka client.
+func (k *KafkaConfig) ProducerConfig() (kafka.ProducerConfig, error) {
+	if k == nil {
+		return kafka.ProducerConfig{}, nil
+	}
+	pc := kafka.ProducerConfig{
+		RequiredAcks:  k.RequiredAcks,
+		Compression:   k.Compression,
+		BatchMaxBytes: k.BatchMaxBytes,
+		Partitioner:   k.Partitioner,
+	}
+	if k.Linger != "" {
+		d, err := time.ParseDuration(k.Linger)
+		if err != nil {
+			return kafka.ProducerConfig{}, fmt.Errorf("linger %q is not a valid duration", k.Linger)
+		}
+		pc.Linger = d
+	}
+	return pc, nil
+}
+
+// PartitionHeaderName returns the header read by the manual partitioner.
+func (k *KafkaConfig) PartitionHeaderName() string {
+	if k == nil || k.PartitionHeader == "" {
+		return DefaultPartitionHeader
+	}
+	return k.PartitionHeader
 }
 
 // KeyStrategy defines how to generate Kafka message keys.
@@ -78,12 +255,23 @@ type KeyStrategy struct {
 	Value string `yaml:"value"` // For "static" type
 }
 
+var validBreakerModes = map[string]bool{"consecutive": true, "count": true, "time": true}
+
 // CircuitBreakerConfig holds circuit breaker settings.
 type CircuitBreakerConfig struct {
 	Enabled          bool   `yaml:"enabled"`
 	FailureThreshold int    `yaml:"failureThreshold"`
 	SuccessThreshold int    `yaml:"successThreshold"`
 	ResetTimeout     string `yaml:"resetTimeout"` // e.g., "30s"
+
+	Mode                 string   `yaml:"mode,omitempty"`                 // consecutive (default), count, time
+	WindowSize           int      `yaml:"windowSize,omitempty"`           // Calls tracked in count mode
+	WindowDuration       string   `yaml:"windowDuration,omitempty"`       // Time tracked in time mode, e.g. "60s"
+	MinimumRequests      int      `yaml:"minimumRequests,omitempty"`      // Calls required before the failure rate is evaluated
+	FailureRateThreshold float64  `yaml:"failureRateThreshold,omitempty"` // Failure percentage that trips the breaker, 0-100
+	SlowCallDuration     string   `yaml:"slowCallDuration,omitempty"`     // Successful calls at least this slow count as failures
+	MaxHalfOpenRequests  int      `yaml:"maxHalfOpenRequests,omitempty"`  // Concurrent probes while half-open (0 = unlimited)
+	FailureStatusCodes   []string `yaml:"failureStatusCodes,omitempty"`   // e.g. ["5xx", "429"]; default: any failed request
 }
 
 // UnmarshalYAML implements custom unmarshaling for CircuitBreakerConfig.
@@ -100,6 +288,14 @@ func (c *CircuitBreakerConfig) UnmarshalYAML(value *yaml.Node) error {
 	c.FailureThreshold = 0
 	c.SuccessThreshold = 0
 	c.ResetTimeout = ""
+	c.Mode = ""
+	c.WindowSize = 0
+	c.WindowDuration = ""
+	c.MinimumRequests = 0
+	c.FailureRateThreshold = 0
+	c.SlowCallDuration = ""
+	c.MaxHalfOpenRequests = 0
+	c.FailureStatusCodes = nil
 
 	// Parse each field
 	if v, ok := raw["enabled"]; ok {
@@ -124,19 +320,177 @@ func (c *CircuitBreakerConfig) UnmarshalYAML(value *yaml.Node) error {
 		}
 	}
 	if v, ok := raw["resetTimeout"]; ok {
+		c.ResetTimeout = durationString(v)
+	}
+	if v, ok := raw["mode"]; ok {
+		if s, ok := v.(string); ok {
+			c.Mode = s
+		}
+	}
+	if v, ok := raw["windowSize"]; ok {
+		c.WindowSize = intValue(v)
+	}
+	if v, ok := raw["windowDuration"]; ok {
+		c.WindowDuration = durationString(v)
+	}
+	if v, ok := raw["minimumRequests"]; ok {
+		c.MinimumRequests = intValue(v)
+	}
+	if v, ok := raw["failureRateThreshold"]; ok {
 		switch tv := v.(type) {
-		case string:
-			c.ResetTimeout = tv
 		case int:
-			c.ResetTimeout = fmt.Sprintf("%dms", tv)
+			c.FailureRateThreshold = float64(tv)
 		case float64:
-			c.ResetTimeout = fmt.Sprintf("%.0fms", tv)
+			c.FailureRateThreshold = tv
+		}
+	}
+	if v, ok := raw["slowCallDuration"]; ok {
+		c.SlowCallDuration = durationString(v)
+	}
+	if v, ok := raw["maxHalfOpenRequests"]; ok {
+		c.MaxHalfOpenRequests = intValue(v)
+	}
+	if v, ok := raw["failureStatusCodes"]; ok {
+		if list, ok := v.([]interface{}); ok {
+			for _, item := range list {
+				c.FailureStatusCodes = append(c.FailureStatusCodes, fmt.Sprintf("%v", item))
+			}
 		}
 	}
 
 	return nil
 }
 
+// durationString converts a YAML duration value to a duration string.
+// Bare numbers are interpreted as milliseconds.
+func durationString(v interface{}) string {
+	switch tv := v.(type) {
+	case string:
+		return tv
+	case int:
+		return fmt.Sprintf("%dms", tv)
+	case float64:
+		return fmt.Sprintf("%.0fms", tv)
+	}
+	return ""
+}
+
+// validateHeaderRule checks a single header rule.
+func validateHeaderRule(prefix string, hr HeaderRule) []error {
+	var errs []error
+	if !validHeaderActions[hr.Action] {
+		errs = append(errs, fmt.Errorf("%s: action %q is not valid (must be one of: add, set, remove, rename)", prefix, hr.Action))
+	}
+	if hr.Name == "" {
+		errs = append(errs, fmt.Errorf("%s: name is required", prefix))
+	}
+	sources := 0
+	if hr.Value != "" {
+		sources++
+	}
+	if vf := hr.ValueFrom; vf != nil {
+		for _, set := range []bool{vf.Env != "", vf.SecretRef != nil, vf.CEL != ""} {
+			if set {
+				sources++
+			}
+		}
+	}
+	switch hr.Action {
+	case "add", "set":
+		if sources != 1 {
+			errs = append(errs, fmt.Errorf("%s: exactly one of value, valueFrom.env, valueFrom.secretRef or valueFrom.cel is required", prefix))
+		}
+	case "rename":
+		if hr.To == "" {
+			errs = append(errs, fmt.Errorf("%s: to is required for rename", prefix))
+		}
+		fallthrough
+	case "remove":
+		if sources != 0 {
+			errs = append(errs, fmt.Errorf("%s: value is not allowed for %s", prefix, hr.Action))
+		}
+	}
+	return errs
+}
+
+// validateRoute checks a route pattern such as "GET,POST /api/*".
+func validateRoute(prefix, route string) []error {
+	var errs []error
+	methods, pattern := ParseRoute(route)
+	if !strings.HasPrefix(pattern, "/") {
+		errs = append(errs, fmt.Errorf("%s: %q must be a path starting with /, optionally prefixed by methods (e.g. \"GET /api/*\")", prefix, route))
+	} else if _, err := path.Match(pattern, ""); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %q is not a valid pattern: %w", prefix, route, err))
+	}
+	for _, m := range methods {
+		if m != "*" && !validHTTPMethods[m] {
+			errs = append(errs, fmt.Errorf("%s: method %q is not valid", prefix, m))
+		}
+	}
+	return errs
+}
+
+// validateRewriteRule checks a single rewrite rule.
+func validateRewriteRule(prefix string, rr RewriteRule) []error {
+	var errs []error
+	if rr.Regex != "" {
+		if _, err := regexp.Compile(rr.Regex); err != nil {
+			errs = append(errs, fmt.Errorf("%s: regex %q is not valid: %w", prefix, rr.Regex, err))
+		}
+	} else if rr.Replace != "" {
+		errs = append(errs, fmt.Errorf("%s: replace requires regex", prefix))
+	}
+	if rr.Method != "" && !validHTTPMethods[strings.ToUpper(rr.Method)] {
+		errs = append(errs, fmt.Errorf("%s: method %q is not valid", prefix, rr.Method))
+	}
+	for k, q := range rr.Query {
+		if !validQueryActions[q.Action] {
+			errs = append(errs, fmt.Errorf("%s: query[%d]: action %q is not valid (must be one of: add, set, remove, rename)", prefix, k, q.Action))
+		}
+		if q.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: query[%d]: name is required", prefix, k))
+		}
+		if q.Action == "rename" && q.To == "" {
+			errs = append(errs, fmt.Errorf("%s: query[%d]: to is required for rename", prefix, k))
+		}
+	}
+	return errs
+}
+
+// statusSpecPattern matches "503", "5xx" and "500-504" style status specs.
+var statusSpecPattern = regexp.MustCompile(`^(\d{3}|[1-5][xX]{2}|\d{3}\s*-\s*\d{3})$`)
+
+// validStatusSpec reports whether spec is a valid failure status code spec.
+func validStatusSpec(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if !statusSpecPattern.MatchString(spec) {
+		return false
+	}
+	prev := 0
+	for _, part := range strings.Split(spec, "-") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			continue // "5xx"
+		}
+		if n < 100 || n > 599 || n < prev {
+			return false
+		}
+		prev = n
+	}
+	return true
+}
+
+// intValue converts a numeric YAML value to an int, returning 0 otherwise.
+func intValue(v interface{}) int {
+	switch tv := v.(type) {
+	case int:
+		return tv
+	case float64:
+		return int(tv)
+	}
+	return 0
+}
+
 // RetryConfig holds retry settings.
 type RetryConfig struct {
 	MaxAttempts     int     `yaml:"maxAttempts"`
@@ -144,6 +498,9 @@ type RetryConfig struct {
 	InitialInterval string  `yaml:"initialInterval"` // e.g., "200ms"
 	MaxInterval     string  `yaml:"maxInterval"`     // e.g., "30s"
 	Jitter          float64 `yaml:"jitter"`
+	// RetryableMethods limits retries to these HTTP methods; empty retries
+	// every method.
+	RetryableMethods []string `yaml:"retryableMethods,omitempty"`
 }
 
 // UnmarshalYAML implements custom unmarshaling for RetryConfig.
@@ -161,6 +518,7 @@ func (r *RetryConfig) UnmarshalYAML(value *yaml.Node) error {
 	r.InitialInterval = ""
 	r.MaxInterval = ""
 	r.Jitter = 0
+	r.RetryableMethods = nil
 
 	// Parse each field
 	if v, ok := raw["maxAttempts"]; ok {
@@ -204,6 +562,13 @@ func (r *RetryConfig) UnmarshalYAML(value *yaml.Node) error {
 			r.Jitter = float64(tv)
 		}
 	}
+	if v, ok := raw["retryableMethods"].([]interface{}); ok {
+		for _, m := range v {
+			if s, ok := m.(string); ok {
+				r.RetryableMethods = append(r.RetryableMethods, s)
+			}
+		}
+	}
 
 	return nil
 }
@@ -252,12 +617,47 @@ func (r *RateLimitConfig) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// BulkheadConfig limits concurrent requests to a target. Requests beyond
+// MaxConcurrentRequests wait in a queue of MaxQueue entries for at most
+// QueueTimeout; when the queue is full they are rejected with 503.
+type BulkheadConfig struct {
+	MaxConcurrentRequests int    `yaml:"maxConcurrentRequests"` // 0 = unlimited
+	MaxQueue              int    `yaml:"maxQueue"`
+	QueueTimeout          string `yaml:"queueTimeout,omitempty"` // e.g. "500ms"; empty waits for the request context
+}
+
+var validAdaptiveAlgorithms = map[string]bool{"aimd": true, "gradient": true}
+
+// AdaptiveConcurrencyConfig enables an adaptive concurrency limit for a
+// target. The limit shrinks when upstream latency rises or requests are shed
+// (429, 503, timeouts) and grows while the upstream is healthy. Requests over
+// the limit are rejected with 503.
+type AdaptiveConcurrencyConfig struct {
+	Enabled          bool    `yaml:"enabled"`
+	Algorithm        string  `yaml:"algorithm,omitempty"`        // aimd (default) or gradient
+	InitialLimit     int     `yaml:"initialLimit,omitempty"`     // default 20
+	MinLimit         int     `yaml:"minLimit,omitempty"`         // default 1
+	MaxLimit         int     `yaml:"maxLimit,omitempty"`         // default 200
+	BackoffRatio     float64 `yaml:"backoffRatio,omitempty"`     // aimd: multiplicative decrease, default 0.9
+	LatencyThreshold string  `yaml:"latencyThreshold,omitempty"` // aimd: slower samples count as overload
+	Tolerance        float64 `yaml:"tolerance,omitempty"`        // gradient: allowed latency growth, default 1.5
+	Smoothing        float64 `yaml:"smoothing,omitempty"`        // gradient: weight of each new limit, default 0.2
+}
+
 // Config is the top-level Fiso-Link configuration.
 type Config struct {
 	ListenAddr  string                  `yaml:"listenAddr"`
 	MetricsAddr string                  `yaml:"metricsAddr"`
 	Targets     []LinkTarget            `yaml:"targets"`
 	Kafka       kafka.KafkaGlobalConfig `yaml:"kafka,omitempty"`
+	Admin       AdminConfig             `yaml:"admin,omitempty"`
+}
+
+// AdminConfig enables the runtime admin API on the metrics port.
+// Requests must carry the token as "Authorization: Bearer <token>".
+type AdminConfig struct {
+	Enabled  bool       `yaml:"enabled"`
+	TokenRef *SecretRef `yaml:"tokenRef,omitempty"` // Required when enabled
 }
 
 // LoadConfig reads Fiso-Link configuration from a YAML file.
@@ -342,6 +742,34 @@ func (c *Config) Validate() error {
 					errs = append(errs, fmt.Errorf("%s: key type static requires value parameter", prefix))
 				}
 			}
+			if t.Kafka != nil {
+				if pc, err := t.Kafka.ProducerConfig(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: kafka.%w", prefix, err))
+				} else if pc.Linger < 0 {
+					errs = append(errs, fmt.Errorf("%s: kafka.linger must not be negative", prefix))
+				} else if _, err := pc.Options(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: kafka: %w", prefix, err))
+				}
+				if t.Kafka.BatchMaxBytes < 0 {
+					errs = append(errs, fmt.Errorf("%s: kafka.batchMaxBytes must not be negative", prefix))
+				}
+				if ce := t.Kafka.CloudEvents; ce != nil && ce.Mode != "" && !validCloudEventsModes[ce.Mode] {
+					errs = append(errs, fmt.Errorf("%s: kafka.cloudevents.mode %q is not valid (must be one of: structured, binary)", prefix, ce.Mode))
+				}
+				if sc := t.Kafka.Schema; sc != nil {
+					if sc.RegistryURL == "" {
+						errs = append(errs, fmt.Errorf("%s: kafka.schema.registryUrl is required", prefix))
+					}
+					if sc.Subject == "" {
+						errs = append(errs, fmt.Errorf("%s: kafka.schema.subject is required", prefix))
+					}
+					if sc.CacheTTL != "" {
+						if _, err := time.ParseDuration(sc.CacheTTL); err != nil {
+							errs = append(errs, fmt.Errorf("%s: kafka.schema.cacheTTL %q is not a valid duration", prefix, sc.CacheTTL))
+						}
+					}
+				}
+			}
 		}
 
 		if t.CircuitBreaker.ResetTimeout != "" {
@@ -349,6 +777,30 @@ func (c *Config) Validate() error {
 				errs = append(errs, fmt.Errorf("%s: circuitBreaker.resetTimeout %q is not a valid duration", prefix, t.CircuitBreaker.ResetTimeout))
 			}
 		}
+		if cb := t.CircuitBreaker; cb.Mode != "" && !validBreakerModes[cb.Mode] {
+			errs = append(errs, fmt.Errorf("%s: circuitBreaker.mode %q is not valid (must be one of: consecutive, count, time)", prefix, cb.Mode))
+		}
+		if t.CircuitBreaker.WindowDuration != "" {
+			if _, err := time.ParseDuration(t.CircuitBreaker.WindowDuration); err != nil {
+				errs = append(errs, fmt.Errorf("%s: circuitBreaker.windowDuration %q is not a valid duration", prefix, t.CircuitBreaker.WindowDuration))
+			}
+		}
+		if t.CircuitBreaker.SlowCallDuration != "" {
+			if _, err := time.ParseDuration(t.CircuitBreaker.SlowCallDuration); err != nil {
+				errs = append(errs, fmt.Errorf("%s: circuitBreaker.slowCallDuration %q is not a valid duration", prefix, t.CircuitBreaker.SlowCallDuration))
+			}
+		}
+		if r := t.CircuitBreaker.FailureRateThreshold; r < 0 || r > 100 {
+			errs = append(errs, fmt.Errorf("%s: circuitBreaker.failureRateThreshold must be between 0 and 100, got %g", prefix, r))
+		}
+		if t.CircuitBreaker.WindowSize < 0 || t.CircuitBreaker.MinimumRequests < 0 || t.CircuitBreaker.MaxHalfOpenRequests < 0 {
+			errs = append(errs, fmt.Errorf("%s: circuitBreaker.windowSize, minimumRequests and maxHalfOpenRequests must be >= 0", prefix))
+		}
+		for _, code := range t.CircuitBreaker.FailureStatusCodes {
+			if !validStatusSpec(code) {
+				errs = append(errs, fmt.Errorf("%s: circuitBreaker.failureStatusCodes entry %q is not valid (use e.g. 503, 5xx or 500-504)", prefix, code))
+			}
+		}
 		if t.Retry.InitialInterval != "" {
 			if _, err := time.ParseDuration(t.Retry.InitialInterval); err != nil {
 				errs = append(errs, fmt.Errorf("%s: retry.initialInterval %q is not a valid duration", prefix, t.Retry.InitialInterval))
@@ -363,6 +815,11 @@ func (c *Config) Validate() error {
 		if t.Retry.Jitter < 0 || t.Retry.Jitter > 1.0 {
 			errs = append(errs, fmt.Errorf("%s: retry.jitter must be between 0.0 and 1.0, got %f", prefix, t.Retry.Jitter))
 		}
+		for _, m := range t.Retry.RetryableMethods {
+			if !validHTTPMethods[strings.ToUpper(m)] {
+				errs = append(errs, fmt.Errorf("%s: retry.retryableMethods entry %q is not a valid method", prefix, m))
+			}
+		}
 
 		if t.RateLimit.RequestsPerSecond < 0 {
 			errs = append(errs, fmt.Errorf("%s: rateLimit.requestsPerSecond must be >= 0", prefix))
@@ -371,6 +828,106 @@ func (c *Config) Validate() error {
 			errs = append(errs, fmt.Errorf("%s: rateLimit.burst must be >= 0", prefix))
 		}
 
+		if t.Bulkhead.MaxConcurrentRequests < 0 {
+			errs = append(errs, fmt.Errorf("%s: bulkhead.maxConcurrentRequests must be >= 0", prefix))
+		}
+		if t.Bulkhead.MaxQueue < 0 {
+			errs = append(errs, fmt.Errorf("%s: bulkhead.maxQueue must be >= 0", prefix))
+		}
+		if t.Bulkhead.QueueTimeout != "" {
+			if _, err := time.ParseDuration(t.Bulkhead.QueueTimeout); err != nil {
+				errs = append(errs, fmt.Errorf("%s: bulkhead.queueTimeout %q is not a valid duration", prefix, t.Bulkhead.QueueTimeout))
+			}
+		}
+
+		if ac := t.AdaptiveConcurrency; ac != nil {
+			if ac.Algorithm != "" && !validAdaptiveAlgorithms[ac.Algorithm] {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.algorithm %q is not valid (must be one of: aimd, gradient)", prefix, ac.Algorithm))
+			}
+			if ac.InitialLimit < 0 || ac.MinLimit < 0 || ac.MaxLimit < 0 {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency limits must be >= 0", prefix))
+			}
+			if ac.MinLimit > 0 && ac.MaxLimit > 0 && ac.MinLimit > ac.MaxLimit {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.minLimit must not exceed maxLimit", prefix))
+			}
+			if ac.BackoffRatio < 0 || ac.BackoffRatio >= 1 {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.backoffRatio must be between 0 and 1, got %g", prefix, ac.BackoffRatio))
+			}
+			if ac.Smoothing < 0 || ac.Smoothing > 1 {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.smoothing must be between 0 and 1, got %g", prefix, ac.Smoothing))
+			}
+			if ac.Tolerance != 0 && ac.Tolerance < 1 {
+				errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.tolerance must be >= 1, got %g", prefix, ac.Tolerance))
+			}
+			if ac.LatencyThreshold != "" {
+				if _, err := time.ParseDuration(ac.LatencyThreshold); err != nil {
+					errs = append(errs, fmt.Errorf("%s: adaptiveConcurrency.latencyThreshold %q is not a valid duration", prefix, ac.LatencyThreshold))
+				}
+			}
+		}
+
+		if t.Headers != nil {
+			for j, hr := range t.Headers.Request {
+				errs = append(errs, validateHeaderRule(fmt.Sprintf("%s: headers.request[%d]", prefix, j), hr)...)
+			}
+			for j, hr := range t.Headers.Response {
+				errs = append(errs, validateHeaderRule(fmt.Sprintf("%s: headers.response[%d]", prefix, j), hr)...)
+			}
+		}
+
+		if t.Rewrite != nil {
+			for j, rr := range t.Rewrite.Rules {
+				errs = append(errs, validateRewriteRule(fmt.Sprintf("%s: rewrite.rules[%d]", prefix, j), rr)...)
+			}
+		}
+
+		for j, route := range t.AllowedPaths {
+			errs = append(errs, validateRoute(fmt.Sprintf("%s: allowedPaths[%d]", prefix, j), route)...)
+		}
+
+		if v := t.Validation; v != nil {
+			for j, vr := range v.Rules {
+				vrPrefix := fmt.Sprintf("%s: validation.rules[%d]", prefix, j)
+				if vr.Match == "" {
+					errs = append(errs, fmt.Errorf("%s: match is required", vrPrefix))
+				} else {
+					errs = append(errs, validateRoute(vrPrefix+": match", vr.Match)...)
+				}
+				if (vr.Schema == nil) == (vr.SchemaFile == "") {
+					errs = append(errs, fmt.Errorf("%s: exactly one of schema or schemaFile is required", vrPrefix))
+				}
+			}
+			if len(v.Rules) == 0 && v.OpenAPI == "" {
+				errs = append(errs, fmt.Errorf("%s: validation requires rules or openapi", prefix))
+			}
+		}
+
+		if t.Faults != nil {
+			if !c.Admin.Enabled {
+				errs = append(errs, fmt.Errorf("%s: faults require admin.enabled, which toggles them", prefix))
+			}
+			for j, fr := range t.Faults.Rules {
+				frPrefix := fmt.Sprintf("%s: faults.rules[%d]", prefix, j)
+				if fr.Percentage < 0 || fr.Percentage > 100 {
+					errs = append(errs, fmt.Errorf("%s: percentage must be between 0 and 100, got %g", frPrefix, fr.Percentage))
+				}
+				if fr.Delay != "" {
+					if _, err := time.ParseDuration(fr.Delay); err != nil {
+						errs = append(errs, fmt.Errorf("%s: delay %q is not a valid duration", frPrefix, fr.Delay))
+					}
+				}
+				if fr.AbortStatus != 0 && (fr.AbortStatus < 200 || fr.AbortStatus > 599) {
+					errs = append(errs, fmt.Errorf("%s: abortStatus must be between 200 and 599, got %d", frPrefix, fr.AbortStatus))
+				}
+				if fr.AbortStatus != 0 && fr.Reset {
+					errs = append(errs, fmt.Errorf("%s: abortStatus and reset are mutually exclusive", frPrefix))
+				}
+				if fr.Delay == "" && fr.AbortStatus == 0 && !fr.Reset {
+					errs = append(errs, fmt.Errorf("%s: one of delay, abortStatus or reset is required", frPrefix))
+				}
+			}
+		}
+
 		// Validate interceptors
 		for j, ic := range t.Interceptors {
 			icPrefix := fmt.Sprintf("%s: interceptors[%d]", prefix, j)
@@ -385,6 +942,10 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Admin.Enabled && (c.Admin.TokenRef == nil || (c.Admin.TokenRef.FilePath == "" && c.Admin.TokenRef.EnvVar == "")) {
+		errs = append(errs, fmt.Errorf("admin: tokenRef with filePath or envVar is required when admin is enabled"))
+	}
+
 	// Validate Kafka clusters
 	if err := c.Kafka.Validate(); err != nil {
 		errs = append(errs, fmt.Errorf("kafka: %w", err))
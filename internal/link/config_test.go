@@ -183,6 +183,174 @@ func TestConfig_Validate(t *testing.T) {
 			}}},
 			wantErr: "resetTimeout",
 		},
+		{
+			name: "invalid circuit breaker mode",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				CircuitBreaker: CircuitBreakerConfig{Mode: "rolling"},
+			}}},
+			wantErr: "circuitBreaker.mode",
+		},
+		{
+			name: "invalid slow call duration",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				CircuitBreaker: CircuitBreakerConfig{SlowCallDuration: "slow"},
+			}}},
+			wantErr: "slowCallDuration",
+		},
+		{
+			name: "failure rate out of range",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				CircuitBreaker: CircuitBreakerConfig{FailureRateThreshold: 150},
+			}}},
+			wantErr: "failureRateThreshold",
+		},
+		{
+			name: "invalid failure status code",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				CircuitBreaker: CircuitBreakerConfig{FailureStatusCodes: []string{"5xx", "504-500"}},
+			}}},
+			wantErr: "failureStatusCodes entry \"504-500\"",
+		},
+		{
+			name: "invalid bulkhead queue timeout",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Bulkhead: BulkheadConfig{MaxConcurrentRequests: 10, QueueTimeout: "soon"},
+			}}},
+			wantErr: "bulkhead.queueTimeout",
+		},
+		{
+			name: "negative bulkhead queue",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Bulkhead: BulkheadConfig{MaxQueue: -1},
+			}}},
+			wantErr: "bulkhead.maxQueue",
+		},
+		{
+			name: "invalid adaptive algorithm",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AdaptiveConcurrency: &AdaptiveConcurrencyConfig{Enabled: true, Algorithm: "vegas"},
+			}}},
+			wantErr: "adaptiveConcurrency.algorithm",
+		},
+		{
+			name: "adaptive min above max",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AdaptiveConcurrency: &AdaptiveConcurrencyConfig{Enabled: true, MinLimit: 10, MaxLimit: 5},
+			}}},
+			wantErr: "minLimit must not exceed maxLimit",
+		},
+		{
+			name: "invalid header action",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Headers: &HeadersConfig{Request: []HeaderRule{{Action: "append", Name: "X-A", Value: "1"}}},
+			}}},
+			wantErr: "headers.request[0]: action \"append\" is not valid",
+		},
+		{
+			name: "header set without value",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Headers: &HeadersConfig{Response: []HeaderRule{{Action: "set", Name: "X-A"}}},
+			}}},
+			wantErr: "headers.response[0]: exactly one of value",
+		},
+		{
+			name: "header rename without target",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Headers: &HeadersConfig{Request: []HeaderRule{{Action: "rename", Name: "X-A"}}},
+			}}},
+			wantErr: "to is required for rename",
+		},
+		{
+			name: "invalid rewrite regex",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Rewrite: &RewriteConfig{Rules: []RewriteRule{{Regex: "(", Replace: "/x"}}},
+			}}},
+			wantErr: "rewrite.rules[0]: regex",
+		},
+		{
+			name: "invalid rewrite method",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Rewrite: &RewriteConfig{Rules: []RewriteRule{{Method: "FETCH"}}},
+			}}},
+			wantErr: "method \"FETCH\" is not valid",
+		},
+		{
+			name: "rewrite query rename without target",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Rewrite: &RewriteConfig{Rules: []RewriteRule{{Query: []QueryRewrite{{Action: "rename", Name: "q"}}}}},
+			}}},
+			wantErr: "query[0]: to is required for rename",
+		},
+		{
+			name: "invalid allowedPaths method",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AllowedPaths: []string{"GET /ok/*", "FETCH /api/*"},
+			}}},
+			wantErr: "allowedPaths[1]: method \"FETCH\" is not valid",
+		},
+		{
+			name: "allowedPaths with invalid method",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AllowedPaths: []string{"GTE /api/*"},
+			}}},
+			wantErr: "allowedPaths[0]: method \"GTE\" is not valid",
+		},
+		{
+			name: "allowedPaths without path",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AllowedPaths: []string{"GET"},
+			}}},
+			wantErr: "allowedPaths[0]: \"GET\" must be a path starting with /",
+		},
+		{
+			name: "allowedPaths without leading slash",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				AllowedPaths: []string{"GET api/*"},
+			}}},
+			wantErr: "allowedPaths[0]: \"GET api/*\" must be a path starting with /",
+		},
+		{
+			name: "validation rule without schema",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Validation: &ValidationConfig{Rules: []ValidationRule{{Match: "POST /customers"}}},
+			}}},
+			wantErr: "validation.rules[0]: exactly one of schema or schemaFile",
+		},
+		{
+			name: "validation rule without match",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Validation: &ValidationConfig{Rules: []ValidationRule{{SchemaFile: "customer.json"}}},
+			}}},
+			wantErr: "validation.rules[0]: match is required",
+		},
+		{
+			name: "empty validation",
+			cfg: Config{Targets: []LinkTarget{{
+				Name: "svc", Host: "api.example.com",
+				Validation: &ValidationConfig{},
+			}}},
+			wantErr: "validation requires rules or openapi",
+		},
 		{
 			name: "invalid initial interval",
 			cfg: Config{Targets: []LinkTarget{{
@@ -543,6 +711,185 @@ func TestConfig_Validate_KafkaProtocol(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "kafka with producer settings",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:       "main",
+						Topic:         "events",
+						RequiredAcks:  "1",
+						Partitioner:   "manual",
+						Compression:   "zstd",
+						Linger:        "5ms",
+						BatchMaxBytes: 1048576,
+					},
+				}},
+			},
+		},
+		{
+			name: "kafka with invalid requiredAcks",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:      "main",
+						Topic:        "events",
+						RequiredAcks: "2",
+					},
+				}},
+			},
+			wantErr: "unsupported requiredAcks",
+		},
+		{
+			name: "kafka with invalid partitioner",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:     "main",
+						Topic:       "events",
+						Partitioner: "hash",
+					},
+				}},
+			},
+			wantErr: "unsupported partitioner",
+		},
+		{
+			name: "kafka with invalid compression",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:     "main",
+						Topic:       "events",
+						Compression: "brotli",
+					},
+				}},
+			},
+			wantErr: "unsupported compression",
+		},
+		{
+			name: "kafka with invalid linger",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster: "main",
+						Topic:   "events",
+						Linger:  "soon",
+					},
+				}},
+			},
+			wantErr: "kafka.linger",
+		},
+		{
+			name: "kafka with negative batchMaxBytes",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:       "main",
+						Topic:         "events",
+						BatchMaxBytes: -1,
+					},
+				}},
+			},
+			wantErr: "batchMaxBytes must not be negative",
+		},
+		{
+			name: "kafka with cloudevents and schema",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:     "main",
+						Topic:       "events",
+						CloudEvents: &KafkaCloudEventsConfig{Type: "order.created", Mode: "binary"},
+						Schema:      &KafkaSchemaConfig{RegistryURL: "http://registry:8081", Subject: "events-value", CacheTTL: "1m"},
+					},
+				}},
+			},
+		},
+		{
+			name: "kafka with invalid cloudevents mode",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster:     "main",
+						Topic:       "events",
+						CloudEvents: &KafkaCloudEventsConfig{Mode: "inline"},
+					},
+				}},
+			},
+			wantErr: "kafka.cloudevents.mode",
+		},
+		{
+			name: "kafka schema without registry",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster: "main",
+						Topic:   "events",
+						Schema:  &KafkaSchemaConfig{Subject: "events-value"},
+					},
+				}},
+			},
+			wantErr: "kafka.schema.registryUrl is required",
+		},
+		{
+			name: "kafka schema without subject",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster: "main",
+						Topic:   "events",
+						Schema:  &KafkaSchemaConfig{RegistryURL: "http://registry:8081"},
+					},
+				}},
+			},
+			wantErr: "kafka.schema.subject is required",
+		},
+		{
+			name: "kafka schema with invalid cacheTTL",
+			cfg: Config{
+				Kafka: kafkaGlobal,
+				Targets: []LinkTarget{{
+					Name:     "kafka-target",
+					Protocol: "kafka",
+					Kafka: &KafkaConfig{
+						Cluster: "main",
+						Topic:   "events",
+						Schema:  &KafkaSchemaConfig{RegistryURL: "http://registry:8081", Subject: "s", CacheTTL: "later"},
+					},
+				}},
+			},
+			wantErr: "kafka.schema.cacheTTL",
+		},
 		{
 			name: "kafka with invalid key type",
 			cfg: Config{
@@ -814,6 +1161,36 @@ targets:
 	}
 }
 
+func TestRetryConfig_UnmarshalYAML_RetryableMethods(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yaml")
+	data := `
+targets:
+  - name: svc
+    host: api.example.com
+    retry:
+      maxAttempts: 3
+      retryableMethods: [GET, put]
+`
+	if err := os.WriteFile(cfgFile, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := cfg.Targets[0].Retry.RetryableMethods; len(got) != 2 || got[0] != "GET" || got[1] != "put" {
+		t.Errorf("expected retryable methods [GET put], got %v", got)
+	}
+
+	cfg.Targets[0].Retry.RetryableMethods = []string{"FETCH"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "retry.retryableMethods") {
+		t.Errorf("expected retryableMethods validation error, got %v", err)
+	}
+}
+
 func TestRetryConfig_UnmarshalYAML_NonStringBackoff(t *testing.T) {
 	dir := t.TempDir()
 	cfgFile := filepath.Join(dir, "config.yaml")
@@ -1210,3 +1587,115 @@ func TestValidate_InvalidPort(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestValidate_Faults(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    FaultRule
+		wantErr string
+	}{
+		{name: "valid delay", rule: FaultRule{Path: "/api/**", Percentage: 50, Delay: "200ms"}},
+		{name: "valid abort", rule: FaultRule{Percentage: 10, AbortStatus: 503}},
+		{name: "valid reset", rule: FaultRule{Percentage: 1, Reset: true}},
+		{name: "percentage too high", rule: FaultRule{Percentage: 101, Reset: true}, wantErr: "percentage must be between 0 and 100"},
+		{name: "invalid delay", rule: FaultRule{Percentage: 5, Delay: "soon"}, wantErr: "delay \"soon\" is not a valid duration"},
+		{name: "invalid abort status", rule: FaultRule{Percentage: 5, AbortStatus: 42}, wantErr: "abortStatus must be between 200 and 599"},
+		{name: "abort and reset", rule: FaultRule{Percentage: 5, AbortStatus: 500, Reset: true}, wantErr: "mutually exclusive"},
+		{name: "no fault", rule: FaultRule{Percentage: 5}, wantErr: "one of delay, abortStatus or reset is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				Targets: []LinkTarget{{
+					Name: "svc", Host: "api.example.com",
+					Faults: &FaultsConfig{Enabled: true, Rules: []FaultRule{tt.rule}},
+				}},
+				Admin: AdminConfig{Enabled: true, TokenRef: &SecretRef{EnvVar: "FISO_LINK_ADMIN_TOKEN"}},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_FaultsRequireAdmin(t *testing.T) {
+	cfg := Config{Targets: []LinkTarget{{
+		Name: "svc", Host: "api.example.com",
+		Faults: &FaultsConfig{Rules: []FaultRule{{Percentage: 10, AbortStatus: 503}}},
+	}}}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "faults require admin.enabled") {
+		t.Fatalf("expected admin error, got %v", err)
+	}
+}
+
+func TestValidate_Admin(t *testing.T) {
+	cfg := Config{Admin: AdminConfig{Enabled: true}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tokenRef") {
+		t.Fatalf("expected tokenRef error, got %v", err)
+	}
+	cfg.Admin.TokenRef = &SecretRef{EnvVar: "FISO_LINK_ADMIN_TOKEN"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCircuitBreakerConfig_UnmarshalYAML_WindowFields(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yaml")
+	data := `
+targets:
+  - name: svc
+    host: api.example.com
+    circuitBreaker:
+      enabled: true
+      mode: time
+      windowDuration: 30s
+      windowSize: 50
+      minimumRequests: 20
+      failureRateThreshold: 40
+      slowCallDuration: 2000
+      maxHalfOpenRequests: 2
+      failureStatusCodes: [5xx, 429, "500-504"]
+`
+	if err := os.WriteFile(cfgFile, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cb := cfg.Targets[0].CircuitBreaker
+	if cb.Mode != "time" || cb.WindowDuration != "30s" || cb.WindowSize != 50 {
+		t.Errorf("unexpected window settings: %+v", cb)
+	}
+	if cb.MinimumRequests != 20 || cb.FailureRateThreshold != 40 || cb.MaxHalfOpenRequests != 2 {
+		t.Errorf("unexpected thresholds: %+v", cb)
+	}
+	if cb.SlowCallDuration != "2000ms" {
+		t.Errorf("expected slowCallDuration \"2000ms\", got %q", cb.SlowCallDuration)
+	}
+	want := []string{"5xx", "429", "500-504"}
+	if len(cb.FailureStatusCodes) != len(want) {
+		t.Fatalf("expected status codes %v, got %v", want, cb.FailureStatusCodes)
+	}
+	for i := range want {
+		if cb.FailureStatusCodes[i] != want[i] {
+			t.Errorf("status code %d: expected %q, got %q", i, want[i], cb.FailureStatusCodes[i])
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package envelope prepares payloads published through Kafka link targets:
+// it validates them against a schema registry subject and wraps them in
+// CloudEvents, so applications get the same envelope as events published by
+// fiso-flow and link/async.
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"github.com/google/uuid"
+
+	"github.com/lsm/fiso/internal/kafka"
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/schema"
+)
+
+// Content modes of the CloudEvents Kafka protocol binding.
+const (
+	ModeStructured = "structured"
+	ModeBinary     = "binary"
+)
+
+// ValidationError reports a payload that does not conform to the schema of
+// the configured subject.
+type ValidationError struct {
+	Subject string
+	Err     error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("payload does not match schema subject %s: %v", e.Subject, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+type events struct {
+	typ     string
+	source  string
+	mode    string
+	subject cel.Program
+	id      cel.Program
+}
+
+// Policy is the compiled CloudEvents and schema configuration of a Kafka
+// target.
+type Policy struct {
+	target string
+	events *events
+	schema *schema.LatestCodec
+}
+
+// Compile builds the policy of a Kafka target. A target without CloudEvents
+// or schema configuration yields a nil policy, which publishes payloads
+// unchanged.
+func Compile(target string, cfg *link.KafkaConfig) (*Policy, error) {
+	return compile(target, cfg, map[string]schema.Registry{})
+}
+
+// FromConfig compiles a policy for every Kafka target that needs one.
+// Targets using the same registry URL share a registry client.
+func FromConfig(targets []link.LinkTarget) (map[string]*Policy, error) {
+	registries := map[string]schema.Registry{}
+	policies := make(map[string]*Policy)
+	for _, t := range targets {
+		p, err := compile(t.Name, t.Kafka, registries)
+		if err != nil {
+			return nil, fmt.Errorf("target %q kafka: %w", t.Name, err)
+		}
+		if p != nil {
+			policies[t.Name] = p
+		}
+	}
+	return policies, nil
+}
+
+func compile(target string, cfg *link.KafkaConfig, registries map[string]schema.Registry) (*Policy, error) {
+	if cfg == nil || (cfg.CloudEvents == nil && cfg.Schema == nil) {
+		return nil, nil
+	}
+	p := &Policy{target: target}
+
+	if ce := cfg.CloudEvents; ce != nil {
+		e := &events{typ: ce.Type, source: ce.Source, mode: ce.Mode}
+		if e.typ == "" {
+			e.typ = "fiso.event"
+		}
+		if e.source == "" {
+			e.source = "fiso-link/" + target
+		}
+		if e.mode == "" {
+			e.mode = ModeStructured
+		}
+		var err error
+		if e.subject, err = compileCEL(ce.Subject); err != nil {
+			return nil, fmt.Errorf("cloudevents.subject: %w", err)
+		}
+		if e.id, err = compileCEL(ce.ID); err != nil {
+			return nil, fmt.Errorf("cloudevents.id: %w", err)
+		}
+		p.events = e
+	}
+
+	if sc := cfg.Schema; sc != nil {
+		var ttl time.Duration
+		if sc.CacheTTL != "" {
+			d, err := time.ParseDuration(sc.CacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("schema.cacheTTL %q is not a valid duration", sc.CacheTTL)
+			}
+			ttl = d
+		}
+		reg, ok := registries[sc.RegistryURL]
+		if !ok {
+			r, err := schema.NewConfluentRegistry(sc.RegistryURL)
+			if err != nil {
+				return nil, fmt.Errorf("schema: %w", err)
+			}
+			reg = r
+			registries[sc.RegistryURL] = reg
+		}
+		p.schema = schema.NewLatestCodec(reg, sc.Subject, ttl)
+	}
+	return p, nil
+}
+
+func compileCEL(expr string) (cel.Program, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	env, err := cel.NewEnv(
+		cel.Variable("data", cel.DynType),
+		cel.Variable("headers", cel.DynType),
+		cel.Variable("target", cel.StringType),
+		ext.Strings(),
+		ext.Encoders(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile cel %q: %w", expr, issues.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("cel program %q: %w", expr, err)
+	}
+	return prg, nil
+}
+
+// Validate checks payload against the latest schema of the configured
+// subject. Non-conforming payloads yield a *ValidationError; other errors
+// mean the schema could not be resolved. A nil policy accepts every payload.
+func (p *Policy) Validate(ctx context.Context, payload []byte) error {
+	if p == nil || p.schema == nil {
+		return nil
+	}
+	codec, err := p.schema.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("resolve schema: %w", err)
+	}
+	if err := codec.Validate(payload); err != nil {
+		return &ValidationError{Subject: p.schema.Subject(), Err: err}
+	}
+	return nil
+}
+
+// Wrap turns msg into a CloudEvent. Structured mode replaces the value with
+// a JSON CloudEvent; binary mode keeps the value and adds ce_* headers.
+// headers are the request headers with lower-cased names, visible to CEL
+// expressions as `headers`. A nil policy leaves msg unchanged.
+func (p *Policy) Wrap(msg *kafka.Message, headers map[string]string) error {
+	if p == nil || p.events == nil {
+		return nil
+	}
+	e := p.events
+
+	var data interface{}
+	isJSON := json.Unmarshal(msg.Value, &data) == nil
+	vars := map[string]interface{}{"data": data, "headers": headers, "target": p.target}
+
+	id, err := eval(e.id, vars)
+	if err != nil {
+		return fmt.Errorf("cloudevents id: %w", err)
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+	subject, err := eval(e.subject, vars)
+	if err != nil {
+		return fmt.Errorf("cloudevents subject: %w", err)
+	}
+	contentType := headers["content-type"]
+	if contentType == "" {
+		contentType = cloudevents.ApplicationJSON
+	}
+	now := time.Now().UTC()
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	for k := range msg.Headers {
+		if strings.EqualFold(k, "content-type") {
+			delete(msg.Headers, k)
+		}
+	}
+
+	if e.mode == ModeBinary {
+		msg.Headers["ce_specversion"] = cloudevents.VersionV1
+		msg.Headers["ce_id"] = id
+		msg.Headers["ce_source"] = e.source
+		msg.Headers["ce_type"] = e.typ
+		msg.Headers["ce_time"] = now.Format(time.RFC3339Nano)
+		if subject != "" {
+			msg.Headers["ce_subject"] = subject
+		}
+		msg.Headers["content-type"] = contentType
+		return nil
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetSource(e.source)
+	event.SetType(e.typ)
+	event.SetTime(now)
+	if subject != "" {
+		event.SetSubject(subject)
+	}
+	var payload interface{} = msg.Value
+	if isJSON && strings.Contains(contentType, "json") {
+		payload = json.RawMessage(msg.Value)
+	}
+	if err := event.SetData(contentType, payload); err != nil {
+		return fmt.Errorf("set event data: %w", err)
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal cloudevent: %w", err)
+	}
+	msg.Value = value
+	msg.Headers["content-type"] = "application/cloudevents+json"
+	return nil
+}
+
+func eval(prg cel.Program, vars map[string]interface{}) (string, error) {
+	if prg == nil {
+		return "", nil
+	}
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		return "", err
+	}
+	if out.Type() == types.StringType {
+		return out.Value().(string), nil
+	}
+	return fmt.Sprintf("%v", out.Value()), nil
+}
@@ -0,0 +1,204 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/kafka"
+	"github.com/lsm/fiso/internal/link"
+)
+
+func TestCompile_Nil(t *testing.T) {
+	for _, cfg := range []*link.KafkaConfig{nil, {Topic: "orders"}} {
+		p, err := Compile("orders", cfg)
+		if err != nil || p != nil {
+			t.Fatalf("expected nil policy, got %v, %v", p, err)
+		}
+	}
+
+	var p *Policy
+	msg := &kafka.Message{Value: []byte(`{"id":1}`)}
+	if err := p.Wrap(msg, nil); err != nil || string(msg.Value) != `{"id":1}` {
+		t.Errorf("nil policy should leave the message unchanged, got %s, %v", msg.Value, err)
+	}
+	if err := p.Validate(context.Background(), []byte(`{}`)); err != nil {
+		t.Errorf("nil policy should accept payloads, got %v", err)
+	}
+}
+
+func TestCompile_InvalidCEL(t *testing.T) {
+	_, err := Compile("orders", &link.KafkaConfig{CloudEvents: &link.KafkaCloudEventsConfig{Subject: "data.("}})
+	if err == nil || !strings.Contains(err.Error(), "cloudevents.subject") {
+		t.Errorf("expected subject compile error, got %v", err)
+	}
+}
+
+func TestWrap_Structured(t *testing.T) {
+	p, err := Compile("orders", &link.KafkaConfig{CloudEvents: &link.KafkaCloudEventsConfig{
+		Type:    "order.created",
+		Subject: "data.orderId",
+		ID:      `headers["x-request-id"]`,
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	msg := &kafka.Message{
+		Value:   []byte(`{"orderId":"o-1"}`),
+		Headers: map[string]string{"Content-Type": "application/json", "X-Request-ID": "req-1"},
+	}
+	if err := p.Wrap(msg, map[string]string{"x-request-id": "req-1"}); err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+
+	var ce map[string]interface{}
+	if err := json.Unmarshal(msg.Value, &ce); err != nil {
+		t.Fatalf("value is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "req-1",
+		"type":        "order.created",
+		"source":      "fiso-link/orders",
+		"subject":     "o-1",
+	}
+	for k, v := range want {
+		if ce[k] != v {
+			t.Errorf("%s = %v, want %v", k, ce[k], v)
+		}
+	}
+	if data, _ := ce["data"].(map[string]interface{}); data["orderId"] != "o-1" {
+		t.Errorf("unexpected data %v", ce["data"])
+	}
+	if msg.Headers["content-type"] != "application/cloudevents+json" {
+		t.Errorf("content-type = %q", msg.Headers["content-type"])
+	}
+	if _, ok := msg.Headers["Content-Type"]; ok {
+		t.Error("request Content-Type should be replaced")
+	}
+}
+
+func TestWrap_Binary(t *testing.T) {
+	p, err := Compile("orders", &link.KafkaConfig{CloudEvents: &link.KafkaCloudEventsConfig{
+		Source:  "orders-service",
+		Subject: `target + "/" + string(data.id)`,
+		Mode:    ModeBinary,
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	msg := &kafka.Message{Value: []byte(`{"id":7}`)}
+	if err := p.Wrap(msg, map[string]string{}); err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if string(msg.Value) != `{"id":7}` {
+		t.Errorf("binary mode should keep the value, got %s", msg.Value)
+	}
+	want := map[string]string{
+		"ce_specversion": "1.0",
+		"ce_source":      "orders-service",
+		"ce_type":        "fiso.event",
+		"ce_subject":     "orders/7",
+		"content-type":   "application/json",
+	}
+	for k, v := range want {
+		if msg.Headers[k] != v {
+			t.Errorf("%s = %q, want %q", k, msg.Headers[k], v)
+		}
+	}
+	if msg.Headers["ce_id"] == "" || msg.Headers["ce_time"] == "" {
+		t.Errorf("expected generated ce_id and ce_time, got %v", msg.Headers)
+	}
+}
+
+func TestWrap_SubjectError(t *testing.T) {
+	p, err := Compile("orders", &link.KafkaConfig{CloudEvents: &link.KafkaCloudEventsConfig{Subject: "data.missing"}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if err := p.Wrap(&kafka.Message{Value: []byte(`{}`)}, nil); err == nil {
+		t.Error("expected error for missing subject field")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/subjects/orders-value/versions/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         3,
+			"subject":    "orders-value",
+			"schemaType": "JSON",
+			"schema":     `{"type":"object","required":["orderId"]}`,
+		})
+	}))
+	defer srv.Close()
+
+	p, err := Compile("orders", &link.KafkaConfig{Schema: &link.KafkaSchemaConfig{
+		RegistryURL: srv.URL,
+		Subject:     "orders-value",
+	}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if err := p.Validate(context.Background(), []byte(`{"orderId":"o-1"}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = p.Validate(context.Background(), []byte(`{"id":1}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Subject != "orders-value" {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the schema to be cached, got %d registry calls", calls)
+	}
+}
+
+func TestValidate_RegistryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p, err := Compile("orders", &link.KafkaConfig{Schema: &link.KafkaSchemaConfig{RegistryURL: srv.URL, Subject: "orders-value"}})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	err = p.Validate(context.Background(), []byte(`{}`))
+	var verr *ValidationError
+	if err == nil || errors.As(err, &verr) {
+		t.Errorf("expected registry error, got %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	policies, err := FromConfig([]link.LinkTarget{
+		{Name: "plain", Protocol: "kafka", Kafka: &link.KafkaConfig{Topic: "a"}},
+		{Name: "wrapped", Protocol: "kafka", Kafka: &link.KafkaConfig{Topic: "b", CloudEvents: &link.KafkaCloudEventsConfig{}}},
+		{Name: "http", Protocol: "http"},
+	})
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+	if len(policies) != 1 || policies["wrapped"] == nil {
+		t.Errorf("expected only the wrapped target, got %v", policies)
+	}
+
+	_, err = FromConfig([]link.LinkTarget{
+		{Name: "bad", Kafka: &link.KafkaConfig{CloudEvents: &link.KafkaCloudEventsConfig{ID: "("}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), `target "bad"`) {
+		t.Errorf("expected target error, got %v", err)
+	}
+}
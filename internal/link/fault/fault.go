@@ -0,0 +1,226 @@
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+// ErrConnectionReset is returned in place of an upstream call when a reset
+// fault is injected. It is treated as a transient transport error.
+var ErrConnectionReset = errors.New("fault injected: connection reset by peer")
+
+// ErrUnknownTarget is returned when toggling faults for a target that has
+// no fault rules configured.
+var ErrUnknownTarget = errors.New("no faults configured for target")
+
+// Rule injects a fault into a percentage of requests whose path matches Path.
+type Rule struct {
+	Path        string        `json:"path,omitempty"` // empty matches every path
+	Percentage  float64       `json:"percentage"`     // 0-100
+	Delay       time.Duration `json:"delay,omitempty"`
+	AbortStatus int           `json:"abortStatus,omitempty"`
+	Reset       bool          `json:"reset,omitempty"`
+}
+
+// Decision describes the faults to apply to a single upstream attempt.
+type Decision struct {
+	Delay       time.Duration
+	AbortStatus int
+	Reset       bool
+}
+
+// Wait blocks for the decision's delay or until ctx is done.
+func (d Decision) Wait(ctx context.Context) error {
+	if d.Delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d.Delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// TargetStatus is the runtime fault state of a single target.
+type TargetStatus struct {
+	Target  string `json:"target"`
+	Enabled bool   `json:"enabled"`
+	Rules   []Rule `json:"rules"`
+}
+
+type targetFaults struct {
+	enabled bool
+	rules   []Rule
+}
+
+// Injector decides which faults to inject for each proxied request.
+// Faults can be enabled or disabled per target at runtime.
+type Injector struct {
+	mu      sync.RWMutex
+	targets map[string]*targetFaults
+	rand    func() float64
+}
+
+// Option configures an Injector.
+type Option func(*Injector)
+
+// WithRand sets the random source used for percentage sampling. fn must
+// return values in [0, 1).
+func WithRand(fn func() float64) Option {
+	return func(i *Injector) {
+		i.rand = fn
+	}
+}
+
+// New creates an Injector with no targets configured.
+func New(opts ...Option) *Injector {
+	i := &Injector{
+		targets: make(map[string]*targetFaults),
+		rand:    rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// FromConfig builds an Injector from link target configuration. Targets
+// without fault rules are skipped. Durations are assumed to be validated.
+func FromConfig(targets []link.LinkTarget, opts ...Option) *Injector {
+	i := New(opts...)
+	for _, t := range targets {
+		if t.Faults == nil || len(t.Faults.Rules) == 0 {
+			continue
+		}
+		rules := make([]Rule, 0, len(t.Faults.Rules))
+		for _, fr := range t.Faults.Rules {
+			r := Rule{
+				Path:        fr.Path,
+				Percentage:  fr.Percentage,
+				AbortStatus: fr.AbortStatus,
+				Reset:       fr.Reset,
+			}
+			if d, err := time.ParseDuration(fr.Delay); err == nil {
+				r.Delay = d
+			}
+			rules = append(rules, r)
+		}
+		i.Set(t.Name, t.Faults.Enabled, rules)
+	}
+	return i
+}
+
+// Set configures the fault rules for a target.
+func (i *Injector) Set(target string, enabled bool, rules []Rule) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.targets[target] = &targetFaults{enabled: enabled, rules: rules}
+}
+
+// SetEnabled toggles fault injection for a target. Returns ErrUnknownTarget
+// if no rules are configured for it.
+func (i *Injector) SetEnabled(target string, enabled bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	tf, ok := i.targets[target]
+	if !ok {
+		return ErrUnknownTarget
+	}
+	tf.enabled = enabled
+	return nil
+}
+
+// Enabled reports whether fault injection is active for a target.
+func (i *Injector) Enabled(target string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	tf, ok := i.targets[target]
+	return ok && tf.enabled
+}
+
+// Evaluate samples the target's rules for reqPath and returns the faults to
+// inject. Each matching rule is sampled independently; the first sampled
+// delay and the first sampled abort or reset win.
+func (i *Injector) Evaluate(target, reqPath string) Decision {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var d Decision
+	tf, ok := i.targets[target]
+	if !ok || !tf.enabled {
+		return d
+	}
+	for _, r := range tf.rules {
+		if r.Path != "" && !link.MatchPath(r.Path, reqPath) {
+			continue
+		}
+		if i.rand()*100 >= r.Percentage {
+			continue
+		}
+		if d.Delay == 0 && r.Delay > 0 {
+			d.Delay = r.Delay
+		}
+		if d.AbortStatus == 0 && !d.Reset {
+			d.AbortStatus = r.AbortStatus
+			d.Reset = r.Reset
+		}
+	}
+	return d
+}
+
+// Status returns the fault state of all configured targets, sorted by name.
+func (i *Injector) Status() []TargetStatus {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	out := make([]TargetStatus, 0, len(i.targets))
+	for name, tf := range i.targets {
+		rules := make([]Rule, len(tf.rules))
+		copy(rules, tf.rules)
+		out = append(out, TargetStatus{Target: name, Enabled: tf.enabled, Rules: rules})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Target < out[b].Target })
+	return out
+}
+
+// Handler returns an http.Handler exposing the runtime fault toggles:
+//   - GET /admin/faults            — list targets and their rules
+//   - PUT /admin/faults/{target}   — body {"enabled": bool}
+func (i *Injector) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/faults", i.handleList)
+	mux.HandleFunc("PUT /admin/faults/{target}", i.handleToggle)
+	return mux
+}
+
+func (i *Injector) handleList(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(i.Status()) // best-effort response body
+}
+
+func (i *Injector) handleToggle(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+		http.Error(w, `body must be {"enabled": true|false}`, http.StatusBadRequest)
+		return
+	}
+	target := r.PathValue("target")
+	if err := i.SetEnabled(target, *body.Enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"target": target, "enabled": *body.Enabled}) // best-effort response body
+}
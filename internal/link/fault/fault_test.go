@@ -0,0 +1,170 @@
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+func fixedRand(v float64) Option {
+	return WithRand(func() float64 { return v })
+}
+
+func TestEvaluate_NoTarget(t *testing.T) {
+	i := New()
+	if d := i.Evaluate("svc", "/x"); d != (Decision{}) {
+		t.Fatalf("expected empty decision, got %+v", d)
+	}
+}
+
+func TestEvaluate_Disabled(t *testing.T) {
+	i := New(fixedRand(0))
+	i.Set("svc", false, []Rule{{Percentage: 100, AbortStatus: 503}})
+	if d := i.Evaluate("svc", "/x"); d != (Decision{}) {
+		t.Fatalf("expected empty decision when disabled, got %+v", d)
+	}
+}
+
+func TestEvaluate_Percentage(t *testing.T) {
+	rule := []Rule{{Percentage: 25, AbortStatus: 503}}
+
+	hit := New(fixedRand(0.24))
+	hit.Set("svc", true, rule)
+	if d := hit.Evaluate("svc", "/x"); d.AbortStatus != 503 {
+		t.Errorf("expected abort at 24%%, got %+v", d)
+	}
+
+	miss := New(fixedRand(0.25))
+	miss.Set("svc", true, rule)
+	if d := miss.Evaluate("svc", "/x"); d.AbortStatus != 0 {
+		t.Errorf("expected no abort at 25%%, got %+v", d)
+	}
+}
+
+func TestEvaluate_PathMatching(t *testing.T) {
+	i := New(fixedRand(0))
+	i.Set("svc", true, []Rule{
+		{Path: "/orders/**", Percentage: 100, Delay: time.Second},
+		{Path: "/orders/*/items", Percentage: 100, Reset: true},
+	})
+
+	d := i.Evaluate("svc", "/orders/1/items")
+	if d.Delay != time.Second || !d.Reset {
+		t.Errorf("expected delay and reset, got %+v", d)
+	}
+	d = i.Evaluate("svc", "/orders/1")
+	if d.Delay != time.Second || d.Reset {
+		t.Errorf("expected delay only, got %+v", d)
+	}
+	d = i.Evaluate("svc", "/customers")
+	if d != (Decision{}) {
+		t.Errorf("expected no fault for unmatched path, got %+v", d)
+	}
+}
+
+func TestEvaluate_FirstAbortWins(t *testing.T) {
+	i := New(fixedRand(0))
+	i.Set("svc", true, []Rule{
+		{Percentage: 100, AbortStatus: 503},
+		{Percentage: 100, Reset: true},
+	})
+	d := i.Evaluate("svc", "/")
+	if d.AbortStatus != 503 || d.Reset {
+		t.Errorf("expected first abort to win, got %+v", d)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	i := FromConfig([]link.LinkTarget{
+		{Name: "plain"},
+		{Name: "svc", Faults: &link.FaultsConfig{Enabled: true, Rules: []link.FaultRule{
+			{Path: "/a", Percentage: 10, Delay: "250ms"},
+			{Percentage: 5, AbortStatus: 502},
+		}}},
+	})
+
+	status := i.Status()
+	if len(status) != 1 || status[0].Target != "svc" {
+		t.Fatalf("expected only svc configured, got %+v", status)
+	}
+	if !status[0].Enabled {
+		t.Error("expected svc enabled")
+	}
+	if got := status[0].Rules[0].Delay; got != 250*time.Millisecond {
+		t.Errorf("expected 250ms delay, got %v", got)
+	}
+	if got := status[0].Rules[1].AbortStatus; got != 502 {
+		t.Errorf("expected abort 502, got %d", got)
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	i := New()
+	if err := i.SetEnabled("svc", true); err != ErrUnknownTarget {
+		t.Fatalf("expected ErrUnknownTarget, got %v", err)
+	}
+	i.Set("svc", false, []Rule{{Percentage: 100, Reset: true}})
+	if err := i.SetEnabled("svc", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !i.Enabled("svc") {
+		t.Error("expected svc enabled")
+	}
+}
+
+func TestDecision_Wait(t *testing.T) {
+	if err := (Decision{}).Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (Decision{Delay: time.Millisecond}).Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (Decision{Delay: time.Hour}).Wait(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	i := New()
+	i.Set("svc", false, []Rule{{Percentage: 100, Reset: true}})
+	h := i.Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/faults/svc", strings.NewReader(`{"enabled":true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !i.Enabled("svc") {
+		t.Error("expected svc enabled after toggle")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/faults", nil))
+	var status []TargetStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if len(status) != 1 || !status[0].Enabled {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/faults/unknown", strings.NewReader(`{"enabled":true}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown target, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/faults/svc", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing enabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,328 @@
+// Package headers applies per-target header manipulation rules to upstream
+// requests and responses in Fiso-Link. Every policy also strips a default
+// deny-list of hop-by-hop and internal headers so they do not leak to third
+// parties.
+package headers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+// DefaultDeny lists headers stripped from upstream requests unless allowed
+// explicitly: hop-by-hop headers (RFC 9110 §7.6.1) and Fiso/internal headers.
+// Entries may end in "*" to match a prefix.
+var DefaultDeny = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Fiso-*",
+	"X-Fiso-*",
+	"X-Internal-*",
+}
+
+// hopByHop lists headers stripped from responses before they are returned to
+// the application.
+var hopByHop = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Request describes the application request that CEL value expressions can
+// reference as the `request` variable.
+type Request struct {
+	Method  string
+	Path    string
+	Query   map[string]string
+	Headers map[string]string
+	Target  string
+}
+
+func (r Request) vars() map[string]interface{} {
+	return map[string]interface{}{
+		"method":  r.Method,
+		"path":    r.Path,
+		"query":   r.Query,
+		"headers": r.Headers,
+		"target":  r.Target,
+	}
+}
+
+// NewRequest captures the CEL-visible fields of an incoming request. Header
+// names are lower-cased; only the first value of each header is kept.
+func NewRequest(r *http.Request, target, proxyPath string) Request {
+	req := Request{
+		Method:  r.Method,
+		Path:    proxyPath,
+		Query:   make(map[string]string),
+		Headers: make(map[string]string),
+		Target:  target,
+	}
+	for k, vv := range r.URL.Query() {
+		if len(vv) > 0 {
+			req.Query[k] = vv[0]
+		}
+	}
+	for k, vv := range r.Header {
+		if len(vv) > 0 {
+			req.Headers[strings.ToLower(k)] = vv[0]
+		}
+	}
+	return req
+}
+
+type rule struct {
+	action string
+	name   string
+	to     string
+	value  string
+	env    string
+	secret *link.SecretRef
+	prg    cel.Program
+}
+
+// Policy is the compiled header configuration of a target.
+type Policy struct {
+	request  []rule
+	response []rule
+	deny     []string
+	allow    []string
+}
+
+// Compile builds a policy from a target's header configuration. A nil
+// configuration yields a policy that only applies the default deny-list.
+func Compile(cfg *link.HeadersConfig) (*Policy, error) {
+	p := &Policy{deny: DefaultDeny}
+	if cfg == nil {
+		return p, nil
+	}
+	p.deny = append(append([]string{}, DefaultDeny...), cfg.Deny...)
+	p.allow = cfg.Allow
+
+	var err error
+	if p.request, err = compileRules(cfg.Request, false); err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	if p.response, err = compileRules(cfg.Response, true); err != nil {
+		return nil, fmt.Errorf("response: %w", err)
+	}
+	return p, nil
+}
+
+// FromConfig compiles a policy for every target. Targets without header
+// rules get the default policy.
+func FromConfig(targets []link.LinkTarget) (map[string]*Policy, error) {
+	policies := make(map[string]*Policy, len(targets))
+	for _, t := range targets {
+		p, err := Compile(t.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("target %q headers: %w", t.Name, err)
+		}
+		policies[t.Name] = p
+	}
+	return policies, nil
+}
+
+func compileRules(specs []link.HeaderRule, response bool) ([]rule, error) {
+	rules := make([]rule, 0, len(specs))
+	for i, s := range specs {
+		r := rule{action: s.Action, name: s.Name, to: s.To, value: s.Value}
+		if vf := s.ValueFrom; vf != nil {
+			r.env = vf.Env
+			r.secret = vf.SecretRef
+			if vf.CEL != "" {
+				prg, err := compileCEL(vf.CEL, response)
+				if err != nil {
+					return nil, fmt.Errorf("rule %d: %w", i, err)
+				}
+				r.prg = prg
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func compileCEL(expr string, response bool) (cel.Program, error) {
+	opts := []cel.EnvOption{
+		cel.Variable("request", cel.DynType),
+		ext.Strings(),
+		ext.Encoders(),
+	}
+	if response {
+		opts = append(opts, cel.Variable("response", cel.DynType))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile cel %q: %w", expr, issues.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("cel program %q: %w", expr, err)
+	}
+	return prg, nil
+}
+
+// ApplyRequest strips denied headers from h and applies the request rules.
+// A nil policy applies only the default deny-list.
+func (p *Policy) ApplyRequest(h http.Header, req Request) error {
+	if p == nil {
+		p = &Policy{deny: DefaultDeny}
+	}
+	stripConnectionTokens(h)
+	for name := range h {
+		if matchAny(p.deny, name) && !matchAny(p.allow, name) {
+			h.Del(name)
+		}
+	}
+	vars := map[string]interface{}{"request": req.vars()}
+	return applyRules(h, p.request, vars)
+}
+
+// ApplyResponse strips hop-by-hop headers from h and applies the response
+// rules. status is the upstream status code.
+func (p *Policy) ApplyResponse(h http.Header, req Request, status int) error {
+	stripConnectionTokens(h)
+	for _, name := range hopByHop {
+		h.Del(name)
+	}
+	if p == nil {
+		return nil
+	}
+	respHeaders := make(map[string]string, len(h))
+	for k, vv := range h {
+		if len(vv) > 0 {
+			respHeaders[strings.ToLower(k)] = vv[0]
+		}
+	}
+	vars := map[string]interface{}{
+		"request":  req.vars(),
+		"response": map[string]interface{}{"status": status, "headers": respHeaders},
+	}
+	return applyRules(h, p.response, vars)
+}
+
+func applyRules(h http.Header, rules []rule, vars map[string]interface{}) error {
+	for _, r := range rules {
+		switch r.action {
+		case "remove":
+			for name := range h {
+				if matchName(r.name, name) {
+					h.Del(name)
+				}
+			}
+		case "rename":
+			if vv, ok := h[http.CanonicalHeaderKey(r.name)]; ok {
+				h.Del(r.name)
+				h[http.CanonicalHeaderKey(r.to)] = vv
+			}
+		case "add", "set":
+			v, err := r.resolve(vars)
+			if err != nil {
+				return fmt.Errorf("header %s: %w", r.name, err)
+			}
+			if r.action == "add" {
+				h.Add(r.name, v)
+			} else {
+				h.Set(r.name, v)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the value of an add/set rule.
+func (r rule) resolve(vars map[string]interface{}) (string, error) {
+	switch {
+	case r.prg != nil:
+		out, _, err := r.prg.Eval(vars)
+		if err != nil {
+			return "", fmt.Errorf("evaluate cel: %w", err)
+		}
+		if out.Type() == types.StringType {
+			return out.Value().(string), nil
+		}
+		return fmt.Sprintf("%v", out.Value()), nil
+	case r.env != "":
+		return os.Getenv(r.env), nil
+	case r.secret != nil:
+		return readSecret(r.secret)
+	default:
+		return r.value, nil
+	}
+}
+
+// readSecret reads a header value from a file or environment variable. Like
+// auth.SecretProvider, it reads on every request so rotated secrets apply
+// without a restart.
+func readSecret(ref *link.SecretRef) (string, error) {
+	if ref.FilePath != "" {
+		data, err := os.ReadFile(filepath.Clean(ref.FilePath))
+		if err != nil {
+			return "", fmt.Errorf("read secret file %s: %w", ref.FilePath, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if ref.EnvVar != "" {
+		return os.Getenv(ref.EnvVar), nil
+	}
+	return "", fmt.Errorf("secretRef has no filePath or envVar")
+}
+
+// stripConnectionTokens removes headers named in the Connection header, which
+// are hop-by-hop by definition.
+func stripConnectionTokens(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				h.Del(token)
+			}
+		}
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchName(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchName matches a header name case-insensitively against a pattern that
+// may contain "*" wildcards.
+func matchName(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	if !strings.Contains(pattern, "*") {
+		return pattern == name
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
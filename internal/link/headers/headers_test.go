@@ -0,0 +1,161 @@
+package headers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+func TestApplyRequest_DefaultDenyList(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "keep-alive, X-Custom-Hop")
+	h.Set("X-Custom-Hop", "1")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("Proxy-Authorization", "Basic abc")
+	h.Set("X-Internal-Tenant", "acme")
+	h.Set("X-Fiso-Debug", "true")
+	h.Set("Content-Type", "application/json")
+
+	var p *Policy
+	if err := p.ApplyRequest(h, Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"Connection", "X-Custom-Hop", "Keep-Alive", "Proxy-Authorization", "X-Internal-Tenant", "X-Fiso-Debug"} {
+		if h.Get(name) != "" {
+			t.Errorf("expected %s to be stripped", name)
+		}
+	}
+	if h.Get("Content-Type") != "application/json" {
+		t.Error("expected Content-Type to be kept")
+	}
+}
+
+func TestApplyRequest_AllowAndDeny(t *testing.T) {
+	p, err := Compile(&link.HeadersConfig{
+		Deny:  []string{"X-Debug-*"},
+		Allow: []string{"X-Internal-Tenant"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h := http.Header{}
+	h.Set("X-Internal-Tenant", "acme")
+	h.Set("X-Internal-Secret", "s3cr3t")
+	h.Set("X-Debug-Trace", "on")
+	if err := p.ApplyRequest(h, Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if h.Get("X-Internal-Tenant") != "acme" {
+		t.Error("expected allowed header to be kept")
+	}
+	if h.Get("X-Internal-Secret") != "" || h.Get("X-Debug-Trace") != "" {
+		t.Error("expected denied headers to be stripped")
+	}
+}
+
+func TestApplyRequest_Rules(t *testing.T) {
+	t.Setenv("HEADERS_TEST_REGION", "eu-west-1")
+	secretFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(secretFile, []byte("k-123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Compile(&link.HeadersConfig{Request: []link.HeaderRule{
+		{Action: "set", Name: "X-Client", Value: "fiso"},
+		{Action: "add", Name: "Accept", Value: "text/plain"},
+		{Action: "set", Name: "X-Region", ValueFrom: &link.HeaderValueSource{Env: "HEADERS_TEST_REGION"}},
+		{Action: "set", Name: "X-Api-Key", ValueFrom: &link.HeaderValueSource{SecretRef: &link.SecretRef{FilePath: secretFile}}},
+		{Action: "set", Name: "X-Route", ValueFrom: &link.HeaderValueSource{CEL: `request.method + " " + request.path + "?id=" + request.query["id"]`}},
+		{Action: "rename", Name: "X-User", To: "X-Customer-Id"},
+		{Action: "remove", Name: "X-Debug*"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := httptest.NewRequest("POST", "/link/crm/orders?id=42", nil)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("X-User", "u-1")
+	r.Header.Set("X-Debug-A", "1")
+	r.Header.Set("X-Debugging", "1")
+	req := NewRequest(r, "crm", "/orders")
+
+	if err := p.ApplyRequest(r.Header, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]string{
+		"X-Client":      "fiso",
+		"X-Region":      "eu-west-1",
+		"X-Api-Key":     "k-123",
+		"X-Route":       "POST /orders?id=42",
+		"X-Customer-Id": "u-1",
+		"X-User":        "",
+		"X-Debug-A":     "",
+		"X-Debugging":   "",
+	}
+	for name, want := range tests {
+		if got := r.Header.Get(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if got := r.Header.Values("Accept"); len(got) != 2 {
+		t.Errorf("expected 2 Accept values, got %v", got)
+	}
+}
+
+func TestApplyResponse(t *testing.T) {
+	p, err := Compile(&link.HeadersConfig{Response: []link.HeaderRule{
+		{Action: "remove", Name: "Server"},
+		{Action: "set", Name: "X-Upstream-Status", ValueFrom: &link.HeaderValueSource{CEL: `string(response.status) + ":" + response.headers["content-type"]`}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h := http.Header{}
+	h.Set("Server", "nginx")
+	h.Set("Content-Type", "application/json")
+	h.Set("Transfer-Encoding", "chunked")
+	if err := p.ApplyResponse(h, Request{}, 201); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if h.Get("Server") != "" || h.Get("Transfer-Encoding") != "" {
+		t.Errorf("expected Server and Transfer-Encoding to be stripped, got %v", h)
+	}
+	if got := h.Get("X-Upstream-Status"); got != "201:application/json" {
+		t.Errorf("expected X-Upstream-Status %q, got %q", "201:application/json", got)
+	}
+}
+
+func TestCompile_InvalidCEL(t *testing.T) {
+	_, err := FromConfig([]link.LinkTarget{{
+		Name: "crm",
+		Headers: &link.HeadersConfig{Request: []link.HeaderRule{
+			{Action: "set", Name: "X-Bad", ValueFrom: &link.HeaderValueSource{CEL: "request.("}},
+		}},
+	}})
+	if err == nil {
+		t.Fatal("expected compile error")
+	}
+}
+
+func TestApplyRequest_MissingSecretFile(t *testing.T) {
+	p, err := Compile(&link.HeadersConfig{Request: []link.HeaderRule{
+		{Action: "set", Name: "X-Key", ValueFrom: &link.HeaderValueSource{SecretRef: &link.SecretRef{FilePath: "/nonexistent/key"}}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.ApplyRequest(http.Header{}, Request{}); err == nil {
+		t.Fatal("expected error for missing secret file")
+	}
+}
@@ -220,6 +220,29 @@ func (r *Registry) GetChains(targetName string) *TargetChains {
 	return r.chains[targetName]
 }
 
+// Modules returns the module names of a target's interceptors per phase, in
+// execution order. Phases without interceptors are omitted.
+func (r *Registry) Modules(targetName string) map[Phase][]string {
+	chains := r.GetChains(targetName)
+	if chains == nil {
+		return nil
+	}
+	out := make(map[Phase][]string)
+	for phase, chain := range map[Phase]*interceptor.Chain{PhaseOutbound: chains.Outbound, PhaseInbound: chains.Inbound} {
+		if chain == nil || chain.Len() == 0 {
+			continue
+		}
+		for _, ic := range chain.Interceptors() {
+			name := "unknown"
+			if w, ok := ic.(*InterceptorWrapper); ok {
+				name = w.Module()
+			}
+			out[phase] = append(out[phase], name)
+		}
+	}
+	return out
+}
+
 // ProcessOutbound runs the outbound interceptor chain for a target.
 // Returns the modified request, or an error. If no chain is configured,
 // returns the original request unchanged.
@@ -266,6 +289,11 @@ type InterceptorWrapper struct {
 	metrics  MetricsRecorder
 }
 
+// Module returns the module path of the wrapped interceptor.
+func (w *InterceptorWrapper) Module() string {
+	return w.module
+}
+
 // Process invokes the wrapped interceptor with metrics recording.
 func (w *InterceptorWrapper) Process(ctx context.Context, req *interceptor.Request) (*interceptor.Request, error) {
 	start := time.Now()
@@ -0,0 +1,62 @@
+package link
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath reports whether reqPath matches pattern. Patterns use path.Match
+// syntax, with an additional "/**" suffix that matches the prefix itself and
+// anything nested beneath it (e.g. /api/v2/** matches /api/v2/a/b).
+func MatchPath(pattern, reqPath string) bool {
+	matched, err := path.Match(pattern, reqPath)
+	if err == nil && matched {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/**") {
+		prefix := strings.TrimSuffix(pattern, "/**")
+		if strings.HasPrefix(reqPath, prefix+"/") || reqPath == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRoute splits a route pattern into its methods and path pattern. A
+// route is a path pattern optionally preceded by a comma-separated list of
+// methods, e.g. "GET /api/v2/customers/*" or "GET,HEAD /status". Methods are
+// upper-cased; an empty list matches any method.
+func ParseRoute(route string) (methods []string, pattern string) {
+	route = strings.TrimSpace(route)
+	if strings.HasPrefix(route, "/") {
+		return nil, route
+	}
+	spec, rest, ok := strings.Cut(route, " ")
+	if !ok {
+		return nil, route
+	}
+	for _, m := range strings.Split(spec, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			methods = append(methods, strings.ToUpper(m))
+		}
+	}
+	return methods, strings.TrimSpace(rest)
+}
+
+// MatchRoute reports whether a request matches a route pattern (see
+// ParseRoute). Path-only routes match every method.
+func MatchRoute(route, method, reqPath string) bool {
+	methods, pattern := ParseRoute(route)
+	if !MatchPath(pattern, reqPath) {
+		return false
+	}
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
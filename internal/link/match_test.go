@@ -0,0 +1,45 @@
+package link
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		route   string
+		methods []string
+		pattern string
+	}{
+		{"/api/v2/**", nil, "/api/v2/**"},
+		{"GET /api/v2/customers/*", []string{"GET"}, "/api/v2/customers/*"},
+		{"get,Head  /status", []string{"GET", "HEAD"}, "/status"},
+		{"* /any", []string{"*"}, "/any"},
+	}
+	for _, tt := range tests {
+		methods, pattern := ParseRoute(tt.route)
+		if !reflect.DeepEqual(methods, tt.methods) || pattern != tt.pattern {
+			t.Errorf("ParseRoute(%q) = %v, %q; want %v, %q", tt.route, methods, pattern, tt.methods, tt.pattern)
+		}
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		route, method, path string
+		want                bool
+	}{
+		{"/api/v2/**", "DELETE", "/api/v2/a/b", true},
+		{"GET /api/v2/customers/*", "GET", "/api/v2/customers/42", true},
+		{"GET /api/v2/customers/*", "get", "/api/v2/customers/42", true},
+		{"GET /api/v2/customers/*", "POST", "/api/v2/customers/42", false},
+		{"GET,POST /api/v2/customers/*", "POST", "/api/v2/customers/42", true},
+		{"GET /api/v2/customers/*", "GET", "/api/v2/orders/42", false},
+		{"* /health", "PUT", "/health", true},
+	}
+	for _, tt := range tests {
+		if got := MatchRoute(tt.route, tt.method, tt.path); got != tt.want {
+			t.Errorf("MatchRoute(%q, %q, %q) = %v, want %v", tt.route, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,17 @@ type Metrics struct {
 	RetriesTotal     *prometheus.CounterVec
 	AuthRefreshTotal *prometheus.CounterVec
 	RateLimitedTotal *prometheus.CounterVec
+	// FaultsInjectedTotal counts injected faults by type (delay, abort, reset).
+	FaultsInjectedTotal *prometheus.CounterVec
+	// Bulkhead metrics
+	BulkheadActive        *prometheus.GaugeVec
+	BulkheadQueueDepth    *prometheus.GaugeVec
+	BulkheadRejectedTotal *prometheus.CounterVec
+	// Adaptive concurrency metrics
+	AdaptiveLimit         *prometheus.GaugeVec
+	AdaptiveRejectedTotal *prometheus.CounterVec
+	// ValidationRejectedTotal counts requests rejected by request validation.
+	ValidationRejectedTotal *prometheus.CounterVec
 	// Interceptor metrics
 	InterceptorInvocations *prometheus.CounterVec
 	InterceptorDuration    *prometheus.HistogramVec
@@ -48,6 +59,34 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Name: "fiso_link_rate_limited_total",
 			Help: "Total requests rejected by rate limiting.",
 		}, []string{"target"}),
+		FaultsInjectedTotal: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "fiso_link_faults_injected_total",
+			Help: "Total faults injected by type.",
+		}, []string{"target", "type"}),
+		BulkheadActive: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fiso_link_bulkhead_active_requests",
+			Help: "Requests currently holding a bulkhead slot.",
+		}, []string{"target"}),
+		BulkheadQueueDepth: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fiso_link_bulkhead_queue_depth",
+			Help: "Requests currently waiting for a bulkhead slot.",
+		}, []string{"target"}),
+		BulkheadRejectedTotal: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "fiso_link_bulkhead_rejected_total",
+			Help: "Total requests rejected by the bulkhead by reason (queue_full, queue_timeout).",
+		}, []string{"target", "reason"}),
+		AdaptiveLimit: f.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "fiso_link_adaptive_concurrency_limit",
+			Help: "Current adaptive concurrency limit per target.",
+		}, []string{"target"}),
+		AdaptiveRejectedTotal: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "fiso_link_adaptive_rejected_total",
+			Help: "Total requests rejected by the adaptive concurrency limit.",
+		}, []string{"target"}),
+		ValidationRejectedTotal: f.NewCounterVec(prometheus.CounterOpts{
+			Name: "fiso_link_validation_rejected_total",
+			Help: "Total requests rejected by request validation.",
+		}, []string{"target"}),
 		// Interceptor metrics
 		InterceptorInvocations: f.NewCounterVec(prometheus.CounterOpts{
 			Name: "fiso_link_interceptor_invocations_total",
@@ -0,0 +1,370 @@
+// Package openapi reads the parts of OpenAPI 3 documents that Fiso-Link
+// uses: servers, operations, request body schemas and security schemes.
+// Documents are kept as generic YAML/JSON values; only local "$ref"
+// pointers are resolved.
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Methods lists the operation keys of a path item, in document order.
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Document is a parsed OpenAPI 3 document.
+type Document struct {
+	raw map[string]interface{}
+}
+
+// Operation is a single method on a path.
+type Operation struct {
+	Method      string // Upper-case HTTP method
+	Path        string // Path template, e.g. /customers/{id}
+	OperationID string
+	Summary     string
+	Parameters  []Parameter
+	// RequestBody is the requestBody object, or nil. Schema references
+	// inside it are not resolved.
+	RequestBody map[string]interface{}
+	// Security lists the names of the security schemes the operation
+	// accepts, falling back to the document-level requirement.
+	Security []string
+	// Extensions holds the operation's "x-" properties.
+	Extensions map[string]interface{}
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Name     string
+	In       string // path, query, header, cookie
+	Required bool
+	Schema   map[string]interface{}
+}
+
+// SecurityScheme is an entry of components.securitySchemes.
+type SecurityScheme struct {
+	Type   string // http, apiKey, oauth2, openIdConnect, mutualTLS
+	Scheme string // For http: bearer, basic, ...
+	In     string // For apiKey: header, query, cookie
+	Name   string // For apiKey: header or parameter name
+}
+
+// Load reads an OpenAPI document from a YAML or JSON file.
+func Load(file string) (*Document, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses an OpenAPI document from YAML or JSON.
+func Parse(data []byte) (*Document, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if paths, _ := raw["paths"].(map[string]interface{}); len(paths) == 0 {
+		return nil, fmt.Errorf("document has no paths")
+	}
+	return &Document{raw: raw}, nil
+}
+
+// Title returns info.title.
+func (d *Document) Title() string {
+	info, _ := d.raw["info"].(map[string]interface{})
+	title, _ := info["title"].(string)
+	return title
+}
+
+// Servers returns the server URLs with variables replaced by their default
+// values.
+func (d *Document) Servers() []string {
+	servers, _ := d.raw["servers"].([]interface{})
+	var urls []string
+	for _, s := range servers {
+		sm, _ := s.(map[string]interface{})
+		u, _ := sm["url"].(string)
+		if u == "" {
+			continue
+		}
+		vars, _ := sm["variables"].(map[string]interface{})
+		for name, v := range vars {
+			vm, _ := v.(map[string]interface{})
+			if def, ok := vm["default"]; ok {
+				u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(def))
+			}
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// SecuritySchemes returns components.securitySchemes by name.
+func (d *Document) SecuritySchemes() (map[string]SecurityScheme, error) {
+	components, _ := d.raw["components"].(map[string]interface{})
+	schemes, _ := components["securitySchemes"].(map[string]interface{})
+	out := make(map[string]SecurityScheme, len(schemes))
+	for name, s := range schemes {
+		resolved, err := d.deref(s)
+		if err != nil {
+			return nil, fmt.Errorf("security scheme %s: %w", name, err)
+		}
+		sm, _ := resolved.(map[string]interface{})
+		ss := SecurityScheme{}
+		ss.Type, _ = sm["type"].(string)
+		ss.Scheme, _ = sm["scheme"].(string)
+		ss.In, _ = sm["in"].(string)
+		ss.Name, _ = sm["name"].(string)
+		out[name] = ss
+	}
+	return out, nil
+}
+
+// Schemas returns components.schemas by name. References between schemas
+// are left in place.
+func (d *Document) Schemas() map[string]interface{} {
+	components, _ := d.raw["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	return schemas
+}
+
+// Operations returns every operation, sorted by path and then method in
+// document order.
+func (d *Document) Operations() ([]Operation, error) {
+	paths, _ := d.raw["paths"].(map[string]interface{})
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	global := securityNames(d.raw["security"])
+	var ops []Operation
+	for _, p := range keys {
+		item, err := d.deref(paths[p])
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", p, err)
+		}
+		itemMap, _ := item.(map[string]interface{})
+		shared, err := d.parameters(itemMap["parameters"])
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", p, err)
+		}
+		for _, m := range Methods {
+			raw, ok := itemMap[m].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			op := Operation{Method: strings.ToUpper(m), Path: p, Security: global, Extensions: map[string]interface{}{}}
+			op.OperationID, _ = raw["operationId"].(string)
+			op.Summary, _ = raw["summary"].(string)
+			if sec, ok := raw["security"]; ok {
+				op.Security = securityNames(sec)
+			}
+			for k, v := range raw {
+				if strings.HasPrefix(k, "x-") {
+					op.Extensions[k] = v
+				}
+			}
+			params, err := d.parameters(raw["parameters"])
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", op.Method, p, err)
+			}
+			op.Parameters = mergeParameters(shared, params)
+			if rb, ok := raw["requestBody"]; ok {
+				body, err := d.deref(rb)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s requestBody: %w", op.Method, p, err)
+				}
+				op.RequestBody, _ = body.(map[string]interface{})
+			}
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+func (d *Document) parameters(v interface{}) ([]Parameter, error) {
+	list, _ := v.([]interface{})
+	var params []Parameter
+	for _, item := range list {
+		resolved, err := d.deref(item)
+		if err != nil {
+			return nil, err
+		}
+		pm, _ := resolved.(map[string]interface{})
+		p := Parameter{}
+		p.Name, _ = pm["name"].(string)
+		p.In, _ = pm["in"].(string)
+		p.Required, _ = pm["required"].(bool)
+		p.Schema, _ = pm["schema"].(map[string]interface{})
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// mergeParameters overrides path-level parameters with operation-level
+// parameters of the same name and location.
+func mergeParameters(shared, own []Parameter) []Parameter {
+	out := append([]Parameter(nil), own...)
+	for _, s := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == s.Name && o.In == s.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func securityNames(v interface{}) []string {
+	reqs, _ := v.([]interface{})
+	var names []string
+	for _, r := range reqs {
+		rm, _ := r.(map[string]interface{})
+		for name := range rm {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONSchema returns the unresolved schema of the application/json (or
+// "+json") media type of the operation's request body, or nil.
+func (op Operation) JSONSchema() interface{} {
+	content, _ := op.RequestBody["content"].(map[string]interface{})
+	if media, ok := content["application/json"].(map[string]interface{}); ok {
+		return media["schema"]
+	}
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	for _, ct := range types {
+		if media, ok := content[ct].(map[string]interface{}); ok && strings.HasSuffix(ct, "+json") {
+			return media["schema"]
+		}
+	}
+	return nil
+}
+
+// BodyRequired reports whether the request body is marked required.
+func (op Operation) BodyRequired() bool {
+	required, _ := op.RequestBody["required"].(bool)
+	return required
+}
+
+// PathPattern converts a path template such as /customers/{id} to an
+// allowedPaths pattern (/customers/*) and reports the number of templated
+// segments.
+func PathPattern(template string) (string, int) {
+	segments := strings.Split(template, "/")
+	wildcards := 0
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = "*"
+			wildcards++
+		}
+	}
+	return strings.Join(segments, "/"), wildcards
+}
+
+// maxRefDepth bounds $ref resolution so recursive schemas terminate.
+// References nested deeper are replaced by the empty (accept-all) schema.
+const maxRefDepth = 32
+
+// Resolve returns v with local "$ref" pointers (#/components/...) replaced
+// by the referenced values.
+func (d *Document) Resolve(v interface{}) (interface{}, error) {
+	return d.resolve(v, 0)
+}
+
+func (d *Document) resolve(v interface{}, depth int) (interface{}, error) {
+	if depth > maxRefDepth {
+		return map[string]interface{}{}, nil
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := val["$ref"].(string); ok {
+			target, err := d.Lookup(ref)
+			if err != nil {
+				return nil, err
+			}
+			return d.resolve(target, depth+1)
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			r, err := d.resolve(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			r, err := d.resolve(item, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// deref follows "$ref" pointers at the top level of v only.
+func (d *Document) deref(v interface{}) (interface{}, error) {
+	for depth := 0; depth <= maxRefDepth; depth++ {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v, nil
+		}
+		target, err := d.Lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		v = target
+	}
+	return nil, fmt.Errorf("$ref chain exceeds %d levels", maxRefDepth)
+}
+
+// Lookup returns the value a local "$ref" points to.
+func (d *Document) Lookup(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+	var cur interface{} = d.raw
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+	return cur, nil
+}
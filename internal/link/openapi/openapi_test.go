@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSpec = `openapi: 3.0.3
+info: {title: CRM API, version: "2"}
+servers:
+  - url: https://{region}.api.example.com/v2
+    variables:
+      region: {default: eu}
+security:
+  - bearerAuth: []
+paths:
+  /customers/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string}}
+    get:
+      operationId: getCustomer
+      parameters:
+        - $ref: '#/components/parameters/Fields'
+    patch:
+      security: []
+      x-fiso-retryable: true
+      requestBody:
+        $ref: '#/components/requestBodies/CustomerPatch'
+  /customers:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/vnd.crm+json:
+            schema: {$ref: '#/components/schemas/Customer'}
+components:
+  parameters:
+    Fields: {name: fields, in: query, schema: {type: string}}
+  requestBodies:
+    CustomerPatch:
+      content:
+        application/json:
+          schema: {$ref: '#/components/schemas/Customer'}
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+  schemas:
+    Customer:
+      type: object
+      properties:
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Customer'}
+`
+
+func TestParse_DocumentFields(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Title() != "CRM API" {
+		t.Errorf("expected title, got %q", doc.Title())
+	}
+	if got := doc.Servers(); !reflect.DeepEqual(got, []string{"https://eu.api.example.com/v2"}) {
+		t.Errorf("unexpected servers %v", got)
+	}
+	schemes, err := doc.SecuritySchemes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schemes["apiKey"] != (SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}) {
+		t.Errorf("unexpected apiKey scheme %+v", schemes["apiKey"])
+	}
+	if _, ok := doc.Schemas()["Customer"]; !ok {
+		t.Error("expected Customer schema")
+	}
+}
+
+func TestOperations(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ops, err := doc.Operations()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ops) != 3 {
+		t.Fatalf("expected 3 operations, got %d", len(ops))
+	}
+
+	post, get, patch := ops[0], ops[1], ops[2]
+	if post.Method != "POST" || post.Path != "/customers" || !post.BodyRequired() {
+		t.Errorf("unexpected POST operation %+v", post)
+	}
+	if ref, _ := post.JSONSchema().(map[string]interface{})["$ref"].(string); ref != "#/components/schemas/Customer" {
+		t.Errorf("expected +json schema reference, got %v", post.JSONSchema())
+	}
+
+	if get.OperationID != "getCustomer" || len(get.Parameters) != 2 {
+		t.Errorf("expected own and path-level parameters, got %+v", get.Parameters)
+	}
+	if !reflect.DeepEqual(get.Security, []string{"bearerAuth"}) {
+		t.Errorf("expected document security, got %v", get.Security)
+	}
+
+	if patch.Security != nil || patch.Extensions["x-fiso-retryable"] != true {
+		t.Errorf("unexpected PATCH operation %+v", patch)
+	}
+	if patch.BodyRequired() || patch.JSONSchema() == nil {
+		t.Errorf("expected optional JSON body from requestBody $ref")
+	}
+}
+
+func TestResolve_Recursive(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resolved, err := doc.Resolve(map[string]interface{}{"$ref": "#/components/schemas/Customer"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	props := resolved.(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := props["parent"].(map[string]interface{})["properties"]; !ok {
+		t.Error("expected nested reference to be resolved")
+	}
+	if _, err := doc.Resolve(map[string]interface{}{"$ref": "other.yaml#/Customer"}); err == nil {
+		t.Error("expected error for remote reference")
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	pattern, wildcards := PathPattern("/customers/{id}/orders/{orderId}")
+	if pattern != "/customers/*/orders/*" || wildcards != 2 {
+		t.Errorf("unexpected pattern %q (%d wildcards)", pattern, wildcards)
+	}
+}
+
+func TestParse_NoPaths(t *testing.T) {
+	if _, err := Parse([]byte("openapi: 3.0.0\n")); err == nil {
+		t.Fatal("expected error for document without paths")
+	}
+}
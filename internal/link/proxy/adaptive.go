@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
+)
+
+// acquireAdaptive admits the request under the target's adaptive concurrency
+// limit. When the limit is reached it writes a 503 with Retry-After and
+// returns false. A nil limiter always admits the request.
+func acquireAdaptive(w http.ResponseWriter, l *adaptive.Limiter, target string, metrics *link.Metrics) (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	release, err := l.Acquire()
+	if err == nil {
+		return release, true
+	}
+	if metrics != nil {
+		metrics.AdaptiveRejectedTotal.WithLabelValues(target).Inc()
+	}
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "service unavailable (concurrency limit reached)", http.StatusServiceUnavailable)
+	return nil, false
+}
+
+// isDropped reports whether an upstream attempt indicates overload: the
+// request failed outright or the upstream shed it.
+func isDropped(resp *http.Response, err error) bool {
+	if err != nil || resp == nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/bulkhead"
+)
+
+// acquireBulkhead obtains a bulkhead slot for the target. When the bulkhead
+// rejects the request it writes a 503 with Retry-After and returns false.
+// A nil bulkhead always admits the request.
+func acquireBulkhead(w http.ResponseWriter, r *http.Request, b *bulkhead.Bulkhead, target string, metrics *link.Metrics) (func(), bool) {
+	if b == nil {
+		return func() {}, true
+	}
+	release, err := b.Acquire(r.Context())
+	if err == nil {
+		return release, true
+	}
+
+	if metrics != nil {
+		switch {
+		case errors.Is(err, bulkhead.ErrQueueFull):
+			metrics.BulkheadRejectedTotal.WithLabelValues(target, "queue_full").Inc()
+		case errors.Is(err, bulkhead.ErrQueueTimeout):
+			metrics.BulkheadRejectedTotal.WithLabelValues(target, "queue_timeout").Inc()
+		}
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(b.RetryAfter()))
+	http.Error(w, "service unavailable (too many concurrent requests)", http.StatusServiceUnavailable)
+	return nil, false
+}
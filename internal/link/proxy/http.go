@@ -8,7 +8,6 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
-	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -18,12 +17,19 @@ import (
 	"github.com/lsm/fiso/internal/interceptor"
 	"github.com/lsm/fiso/internal/kafka"
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
 	"github.com/lsm/fiso/internal/link/auth"
+	"github.com/lsm/fiso/internal/link/bulkhead"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
 	"github.com/lsm/fiso/internal/link/discovery"
+	"github.com/lsm/fiso/internal/link/envelope"
+	"github.com/lsm/fiso/internal/link/fault"
+	linkheaders "github.com/lsm/fiso/internal/link/headers"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/ratelimit"
 	"github.com/lsm/fiso/internal/link/retry"
+	"github.com/lsm/fiso/internal/link/rewrite"
+	"github.com/lsm/fiso/internal/link/validation"
 	"github.com/lsm/fiso/internal/tracing"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/trace"
@@ -34,6 +40,11 @@ import (
 type Handler struct {
 	targets      *link.TargetStore
 	breakers     map[string]*circuitbreaker.Breaker
+	bulkheads    map[string]*bulkhead.Bulkhead
+	adaptive     map[string]*adaptive.Limiter
+	headers      map[string]*linkheaders.Policy
+	rewriters    map[string]*rewrite.Rewriter
+	validators   map[string]*validation.Validator
 	rateLimiter  *ratelimit.Limiter
 	auth         auth.Provider
 	resolver     discovery.Resolver
@@ -43,12 +54,19 @@ type Handler struct {
 	kafkaHandler *KafkaHandler // Optional: For Kafka targets
 	tracer       trace.Tracer
 	interceptors *linkinterceptor.Registry // Interceptor registry
+	faults       *fault.Injector           // Optional: fault injection
 }
 
 // Config configures the proxy handler.
 type Config struct {
 	Targets        *link.TargetStore
 	Breakers       map[string]*circuitbreaker.Breaker
+	Bulkheads      map[string]*bulkhead.Bulkhead    // Optional: per-target concurrency limits
+	Adaptive       map[string]*adaptive.Limiter     // Optional: per-target adaptive concurrency limits
+	Headers        map[string]*linkheaders.Policy   // Optional: per-target header rules; the default deny-list always applies
+	Rewriters      map[string]*rewrite.Rewriter     // Optional: per-target path, query and method rewrites
+	Validators     map[string]*validation.Validator // Optional: per-target request body validation
+	Envelopes      map[string]*envelope.Policy      // Optional: per-target CloudEvents wrapping and schema validation for Kafka targets
 	RateLimiter    *ratelimit.Limiter
 	Auth           auth.Provider
 	Resolver       discovery.Resolver
@@ -58,6 +76,7 @@ type Config struct {
 	KafkaRegistry  *kafka.Registry           // Named Kafka cluster registry
 	KafkaPool      *kafka.PublisherPool      // Kafka publisher connection pool
 	Interceptors   *linkinterceptor.Registry // Interceptor registry
+	Faults         *fault.Injector           // Optional: fault injection for resilience testing
 }
 
 // NewHandler creates a new HTTP proxy handler.
@@ -75,6 +94,11 @@ func NewHandler(cfg Config) *Handler {
 	h := &Handler{
 		targets:     cfg.Targets,
 		breakers:    cfg.Breakers,
+		bulkheads:   cfg.Bulkheads,
+		adaptive:    cfg.Adaptive,
+		headers:     cfg.Headers,
+		rewriters:   cfg.Rewriters,
+		validators:  cfg.Validators,
 		rateLimiter: cfg.RateLimiter,
 		auth:        cfg.Auth,
 		resolver:    cfg.Resolver,
@@ -86,6 +110,7 @@ func NewHandler(cfg Config) *Handler {
 		logger:       cfg.Logger,
 		tracer:       noop.NewTracerProvider().Tracer("proxy-handler"),
 		interceptors: cfg.Interceptors,
+		faults:       cfg.Faults,
 	}
 
 	// Initialize Kafka handler if pool or publisher provided
@@ -110,6 +135,11 @@ func NewHandler(cfg Config) *Handler {
 			cfg.Logger,
 		)
 	}
+	if h.kafkaHandler != nil {
+		h.kafkaHandler.SetBulkheads(cfg.Bulkheads)
+		h.kafkaHandler.SetAdaptive(cfg.Adaptive)
+		h.kafkaHandler.SetEnvelopes(cfg.Envelopes)
+	}
 
 	return h
 }
@@ -180,22 +210,44 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	// Check allowed paths
-	if !h.isPathAllowed(target, proxyPath) {
+	if !h.isPathAllowed(target, r.Method, proxyPath) {
 		http.Error(w, "path not allowed", http.StatusForbidden)
 		return
 	}
 
+	// Apply rewrite rules
+	rewritten := h.rewriters[targetName].Apply(r.Method, proxyPath, r.URL.Query())
+	if rewritten.Applied > 0 {
+		span.SetAttributes(
+			tracing.RewriteOriginalPathAttr(proxyPath),
+			tracing.RewriteOriginalMethodAttr(r.Method),
+			tracing.RewriteRulesAttr(rewritten.Applied),
+			tracing.HTTPMethodAttr(rewritten.Method),
+		)
+	}
+
 	// Check circuit breaker
-	if breaker, ok := h.breakers[targetName]; ok {
-		if err := breaker.Allow(); err != nil {
+	breaker := h.breakers[targetName]
+	var ticket circuitbreaker.Ticket
+	if breaker != nil {
+		var err error
+		if ticket, err = breaker.Allow(); err != nil {
 			if h.metrics != nil {
-				h.metrics.CircuitState.WithLabelValues(targetName).Set(float64(circuitbreaker.Open))
+				h.metrics.CircuitState.WithLabelValues(targetName).Set(float64(breaker.State()))
 			}
 			w.Header().Set("Retry-After", "30")
 			http.Error(w, "service unavailable (circuit open)", http.StatusServiceUnavailable)
 			return
 		}
 	}
+	// Requests rejected locally before reaching upstream release their
+	// half-open probe slot without counting towards the breaker.
+	breakerRecorded := false
+	defer func() {
+		if breaker != nil && !breakerRecorded {
+			breaker.Cancel(ticket)
+		}
+	}()
 
 	// Check rate limit
 	if h.rateLimiter != nil && !h.rateLimiter.Allow(targetName) {
@@ -207,6 +259,21 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Acquire a bulkhead slot
+	release, ok := acquireBulkhead(w, r, h.bulkheads[targetName], targetName, h.metrics)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Check adaptive concurrency limit
+	limiter := h.adaptive[targetName]
+	releaseLimit, ok := acquireAdaptive(w, limiter, targetName, h.metrics)
+	if !ok {
+		return
+	}
+	defer releaseLimit()
+
 	// Resolve host
 	resolvedHost, err := h.resolver.Resolve(ctx, target.Host)
 	if err != nil {
@@ -266,6 +333,20 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Validate the request body before it can reach upstream
+	if !validateRequest(w, h.validators[targetName], targetName, rewritten.Method, rewritten.Path, requestBody, h.metrics) {
+		return
+	}
+
+	// Apply header rules and the default deny-list
+	headerPolicy := h.headers[targetName]
+	headerReq := linkheaders.NewRequest(r, targetName, proxyPath)
+	if err := headerPolicy.ApplyRequest(r.Header, headerReq); err != nil {
+		h.logger.Error("request header rule error", "target", targetName, "error", err)
+		http.Error(w, "header rule error", http.StatusInternalServerError)
+		return
+	}
+
 	// Build upstream URL
 	scheme := target.Protocol
 	if scheme == "" {
@@ -277,20 +358,25 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		upstreamHost = fmt.Sprintf("%s:%d", resolvedHost, target.Port)
 	}
 
-	upstreamPath := joinUpstreamPath(target.BasePath, proxyPath)
+	upstreamPath := joinUpstreamPath(target.BasePath, rewritten.Path)
 	upstreamURL := fmt.Sprintf("%s://%s%s", scheme, upstreamHost, upstreamPath)
-	if r.URL.RawQuery != "" {
-		upstreamURL += "?" + r.URL.RawQuery
+	rawQuery := r.URL.RawQuery
+	if rewritten.Applied > 0 {
+		rawQuery = rewritten.Query.Encode()
+	}
+	if rawQuery != "" {
+		upstreamURL += "?" + rawQuery
 	}
 
 	span.SetAttributes(tracing.HTTPTargetAttr(upstreamURL))
 
 	// Execute with retry
 	var resp *http.Response
-	retryCfg := h.buildRetryConfig(target)
+	retryCfg := h.buildRetryConfig(target, rewritten.Method)
+	upstreamStart := time.Now()
 
 	retryErr := retry.Do(ctx, retryCfg, func() error {
-		req, reqErr := http.NewRequestWithContext(ctx, r.Method, upstreamURL, bytes.NewReader(requestBody))
+		req, reqErr := http.NewRequestWithContext(ctx, rewritten.Method, upstreamURL, bytes.NewReader(requestBody))
 		if reqErr != nil {
 			return retry.Permanent(reqErr)
 		}
@@ -325,7 +411,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var doErr error
-		resp, doErr = h.client.Do(req)
+		attemptStart := time.Now()
+		resp, doErr = h.doUpstream(ctx, req, targetName, proxyPath)
+		if limiter != nil {
+			limiter.Sample(time.Since(attemptStart), isDropped(resp, doErr))
+		}
 		if doErr != nil {
 			return doErr
 		}
@@ -354,17 +444,27 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Record circuit breaker outcome
-	if breaker, ok := h.breakers[targetName]; ok {
-		if retryErr != nil {
-			breaker.RecordFailure()
-		} else {
-			breaker.RecordSuccess()
+	if breaker != nil {
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
 		}
+		breaker.Record(ticket, !breaker.IsFailure(statusCode, retryErr), time.Since(upstreamStart))
+		breakerRecorded = true
 		if h.metrics != nil {
 			h.metrics.CircuitState.WithLabelValues(targetName).Set(float64(breaker.State()))
 		}
 	}
 
+	if resp != nil {
+		if err := headerPolicy.ApplyResponse(resp.Header, headerReq, resp.StatusCode); err != nil {
+			_ = resp.Body.Close()
+			h.logger.Error("response header rule error", "target", targetName, "error", err)
+			http.Error(w, "header rule error", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if retryErr != nil {
 		tracing.SetSpanError(span, retryErr)
 		if resp != nil {
@@ -442,6 +542,43 @@ func (h *Handler) copyResponseWithInterceptors(ctx context.Context, w http.Respo
 	_, _ = w.Write(responseBody)
 }
 
+// doUpstream performs a single upstream attempt, applying any configured
+// faults first. Aborts produce a synthetic response and resets fail with
+// fault.ErrConnectionReset, so retry and circuit breaker logic treat them
+// exactly like real upstream behaviour.
+func (h *Handler) doUpstream(ctx context.Context, req *http.Request, targetName, proxyPath string) (*http.Response, error) {
+	if h.faults == nil {
+		return h.client.Do(req)
+	}
+	d := h.faults.Evaluate(targetName, proxyPath)
+	if d.Delay > 0 {
+		h.recordFault(targetName, "delay")
+		if err := d.Wait(ctx); err != nil {
+			return nil, retry.Permanent(err)
+		}
+	}
+	switch {
+	case d.Reset:
+		h.recordFault(targetName, "reset")
+		return nil, fault.ErrConnectionReset
+	case d.AbortStatus != 0:
+		h.recordFault(targetName, "abort")
+		body := fmt.Sprintf("fault injected: %d %s\n", d.AbortStatus, http.StatusText(d.AbortStatus))
+		return &http.Response{
+			StatusCode: d.AbortStatus,
+			Header:     http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}
+	return h.client.Do(req)
+}
+
+func (h *Handler) recordFault(targetName, kind string) {
+	if h.metrics != nil {
+		h.metrics.FaultsInjectedTotal.WithLabelValues(targetName, kind).Inc()
+	}
+}
+
 func (h *Handler) copyResponse(w http.ResponseWriter, resp *http.Response) {
 	defer func() { _ = resp.Body.Close() }()
 	for k, vv := range resp.Header {
@@ -453,39 +590,31 @@ func (h *Handler) copyResponse(w http.ResponseWriter, resp *http.Response) {
 	_, _ = io.Copy(w, resp.Body)
 }
 
-func (h *Handler) isPathAllowed(target *link.LinkTarget, reqPath string) bool {
+func (h *Handler) isPathAllowed(target *link.LinkTarget, method, reqPath string) bool {
 	if len(target.AllowedPaths) == 0 {
 		return true
 	}
-	for _, pattern := range target.AllowedPaths {
-		matched, err := path.Match(pattern, reqPath)
-		if err == nil && matched {
+	for _, route := range target.AllowedPaths {
+		if link.MatchRoute(route, method, reqPath) {
 			return true
 		}
-		// Support ** suffix: /api/v2/** matches /api/v2/anything/nested
-		if strings.HasSuffix(pattern, "/**") {
-			prefix := strings.TrimSuffix(pattern, "/**")
-			if strings.HasPrefix(reqPath, prefix+"/") || reqPath == prefix {
-				return true
-			}
-		}
 	}
 	return false
 }
 
-func (h *Handler) buildRetryConfig(target *link.LinkTarget) retry.Config {
-	cfg := retry.DefaultConfig()
-	if target.Retry.MaxAttempts > 0 {
-		cfg.MaxAttempts = target.Retry.MaxAttempts
-	}
-	if target.Retry.Jitter > 0 {
-		cfg.Jitter = target.Retry.Jitter
-	}
-	if d, err := time.ParseDuration(target.Retry.InitialInterval); err == nil {
-		cfg.InitialInterval = d
-	}
-	if d, err := time.ParseDuration(target.Retry.MaxInterval); err == nil {
-		cfg.MaxInterval = d
+func (h *Handler) buildRetryConfig(target *link.LinkTarget, method string) retry.Config {
+	cfg := retry.FromLinkConfig(target.Retry)
+	if methods := target.Retry.RetryableMethods; len(methods) > 0 {
+		retryable := false
+		for _, m := range methods {
+			if strings.EqualFold(m, method) {
+				retryable = true
+				break
+			}
+		}
+		if !retryable {
+			cfg.MaxAttempts = 1
+		}
 	}
 	return cfg
 }
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,16 +11,24 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
 	"github.com/lsm/fiso/internal/link/auth"
+	"github.com/lsm/fiso/internal/link/bulkhead"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
 	"github.com/lsm/fiso/internal/link/discovery"
+	"github.com/lsm/fiso/internal/link/fault"
+	linkheaders "github.com/lsm/fiso/internal/link/headers"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/ratelimit"
+	"github.com/lsm/fiso/internal/link/rewrite"
+	"github.com/lsm/fiso/internal/link/validation"
 )
 
 func setupProxy(t *testing.T, upstream *httptest.Server, targets []link.LinkTarget, breakers map[string]*circuitbreaker.Breaker, authProvider auth.Provider) *Handler {
@@ -132,7 +141,7 @@ func TestProxy_CircuitBreakerOpen(t *testing.T) {
 	breaker := circuitbreaker.New(circuitbreaker.Config{
 		FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 1000000000,
 	})
-	_ = breaker.Allow()
+	_, _ = breaker.Allow()
 	breaker.RecordFailure()
 
 	handler := setupProxy(t, nil, []link.LinkTarget{
@@ -375,6 +384,67 @@ func TestProxy_CircuitBreakerRecordsSuccess(t *testing.T) {
 	}
 }
 
+func TestProxy_CircuitBreakerFailureStatusCodes(t *testing.T) {
+	status := http.StatusNotFound
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	cfg := circuitbreaker.FromLinkConfig(link.CircuitBreakerConfig{
+		FailureThreshold:   1,
+		ResetTimeout:       "1h",
+		FailureStatusCodes: []string{"503"},
+	})
+	breaker := circuitbreaker.New(cfg)
+	handler := setupProxy(t, upstream, []link.LinkTarget{
+		{Name: "svc", Protocol: "http", Host: host, Retry: link.RetryConfig{MaxAttempts: 1}},
+	}, map[string]*circuitbreaker.Breaker{"svc": breaker}, nil)
+
+	// 404 is not a configured failure status.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/test", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if breaker.State() != circuitbreaker.Closed {
+		t.Fatalf("expected Closed after 404, got %s", breaker.State())
+	}
+
+	status = http.StatusServiceUnavailable
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/test", nil))
+	if breaker.State() != circuitbreaker.Open {
+		t.Fatalf("expected Open after 503, got %s", breaker.State())
+	}
+}
+
+func TestProxy_CircuitBreakerSlowCall(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	breaker := circuitbreaker.New(circuitbreaker.Config{
+		FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: time.Hour, SlowCallDuration: 10 * time.Millisecond,
+	})
+	handler := setupProxy(t, upstream, []link.LinkTarget{
+		{Name: "svc", Protocol: "http", Host: host},
+	}, map[string]*circuitbreaker.Breaker{"svc": breaker}, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/test", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if breaker.State() != circuitbreaker.Open {
+		t.Fatalf("expected slow call to open the breaker, got %s", breaker.State())
+	}
+}
+
 func TestProxy_RetryConfigCustom(t *testing.T) {
 	calls := 0
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -402,6 +472,38 @@ func TestProxy_RetryConfigCustom(t *testing.T) {
 	}
 }
 
+func TestProxy_RetryableMethods(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	handler := setupProxy(t, upstream, []link.LinkTarget{
+		{Name: "svc", Protocol: "http", Host: host, Retry: link.RetryConfig{
+			MaxAttempts: 3, InitialInterval: "1ms", MaxInterval: "5ms", RetryableMethods: []string{"GET", "put"},
+		}},
+	}, nil, nil)
+
+	tests := []struct {
+		method string
+		calls  int
+	}{
+		{"POST", 1},
+		{"GET", 3},
+		{"PUT", 3},
+	}
+	for _, tt := range tests {
+		calls = 0
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/link/svc/test", nil))
+		if calls != tt.calls {
+			t.Errorf("%s: expected %d upstream calls, got %d", tt.method, tt.calls, calls)
+		}
+	}
+}
+
 func TestProxy_NoMetrics(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -463,6 +565,193 @@ func TestProxy_PathAllowExactMatch(t *testing.T) {
 	}
 }
 
+func TestProxy_BulkheadFull(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	targets := []link.LinkTarget{{
+		Name: "svc", Protocol: "http", Host: host,
+		Bulkhead: link.BulkheadConfig{MaxConcurrentRequests: 1, QueueTimeout: "2s"},
+	}}
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	handler := NewHandler(Config{
+		Targets:   link.NewTargetStore(targets),
+		Bulkheads: bulkhead.FromConfig(targets, metrics),
+		Resolver:  &discovery.StaticResolver{},
+		Metrics:   metrics,
+	})
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/slow", nil))
+		done <- w.Code
+	}()
+	<-entered
+
+	// The only slot is taken and there is no queue.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/fast", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After: 2, got %q", got)
+	}
+	if got := testutil.ToFloat64(metrics.BulkheadRejectedTotal.WithLabelValues("svc", "queue_full")); got != 1 {
+		t.Errorf("expected 1 queue_full rejection, got %v", got)
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("expected 200 for in-flight request, got %d", code)
+	}
+}
+
+func TestProxy_AdaptiveLimitShrinksOn429(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	targets := []link.LinkTarget{{
+		Name: "svc", Protocol: "http", Host: host,
+		Retry: link.RetryConfig{MaxAttempts: 1},
+		AdaptiveConcurrency: &link.AdaptiveConcurrencyConfig{
+			Enabled: true, InitialLimit: 10, MinLimit: 1, BackoffRatio: 0.5,
+		},
+	}}
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	limiters := adaptive.FromConfig(targets, metrics)
+	handler := NewHandler(Config{
+		Targets:  link.NewTargetStore(targets),
+		Adaptive: limiters,
+		Resolver: &discovery.StaticResolver{},
+		Metrics:  metrics,
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/test", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected upstream 429 to be forwarded, got %d", w.Code)
+	}
+	if got := limiters["svc"].Limit(); got != 5 {
+		t.Fatalf("expected limit 5 after 429, got %d", got)
+	}
+	if got := testutil.ToFloat64(metrics.AdaptiveLimit.WithLabelValues("svc")); got != 5 {
+		t.Fatalf("expected limit gauge 5, got %v", got)
+	}
+	if st := limiters["svc"].Status(); st.InFlight != 0 {
+		t.Fatalf("expected slot to be released, got %d in flight", st.InFlight)
+	}
+}
+
+func TestProxy_HeaderRules(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Server", "upstream/1.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	targets := []link.LinkTarget{{
+		Name: "svc", Protocol: "http", Host: host,
+		Headers: &link.HeadersConfig{
+			Request:  []link.HeaderRule{{Action: "set", Name: "X-Client", Value: "fiso"}},
+			Response: []link.HeaderRule{{Action: "remove", Name: "Server"}},
+		},
+	}}
+	policies, err := linkheaders.FromConfig(targets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := NewHandler(Config{
+		Targets:  link.NewTargetStore(targets),
+		Headers:  policies,
+		Resolver: &discovery.StaticResolver{},
+		Metrics:  link.NewMetrics(prometheus.NewRegistry()),
+	})
+
+	req := httptest.NewRequest("GET", "/link/svc/test", nil)
+	req.Header.Set("X-Internal-Tenant", "acme")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Get("X-Client") != "fiso" {
+		t.Errorf("expected X-Client to be set, got %q", got.Get("X-Client"))
+	}
+	if got.Get("X-Internal-Tenant") != "" {
+		t.Error("expected internal header to be stripped")
+	}
+	if got.Get("Fiso-Correlation-Id") == "" {
+		t.Error("expected correlation ID to survive the deny-list")
+	}
+	if w.Header().Get("Server") != "" {
+		t.Error("expected Server response header to be removed")
+	}
+}
+
+func TestProxy_Rewrite(t *testing.T) {
+	var gotMethod, gotURI string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotURI = r.Method, r.URL.RequestURI()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	targets := []link.LinkTarget{{
+		Name: "crm", Protocol: "http", Host: host, BasePath: "/api",
+		AllowedPaths: []string{"/customers/*"},
+		Rewrite: &link.RewriteConfig{Rules: []link.RewriteRule{{
+			Regex: "^/customers/(.+)$", Replace: "/v3/accounts/$1", Method: "PATCH",
+			Query: []link.QueryRewrite{{Action: "rename", Name: "q", To: "fields"}},
+		}}},
+	}}
+	rewriters, err := rewrite.FromConfig(targets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := NewHandler(Config{
+		Targets:   link.NewTargetStore(targets),
+		Rewriters: rewriters,
+		Resolver:  &discovery.StaticResolver{},
+		Metrics:   link.NewMetrics(prometheus.NewRegistry()),
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/link/crm/customers/123?q=name", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if gotMethod != "PATCH" {
+		t.Errorf("expected PATCH upstream, got %s", gotMethod)
+	}
+	if gotURI != "/api/v3/accounts/123?fields=name" {
+		t.Errorf("expected rewritten URI, got %s", gotURI)
+	}
+
+	// allowedPaths is checked against the original path.
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/crm/v3/accounts/123", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for path outside allowedPaths, got %d", w.Code)
+	}
+}
+
 func TestProxy_RateLimited(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -635,6 +924,92 @@ func TestProxy_PathMatchError(t *testing.T) {
 	}
 }
 
+func TestProxy_PathAllowedMethod(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	handler := setupProxy(t, upstream, []link.LinkTarget{
+		{Name: "svc", Protocol: "http", Host: host, AllowedPaths: []string{"GET /api/v2/customers/*", "POST,PUT /api/v2/orders/**"}},
+	}, nil, nil)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/link/svc/api/v2/customers/42", http.StatusOK},
+		{"DELETE", "/link/svc/api/v2/customers/42", http.StatusForbidden},
+		{"PUT", "/link/svc/api/v2/orders/7/lines", http.StatusOK},
+		{"GET", "/link/svc/api/v2/orders/7", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+	}
+}
+
+func TestProxy_RequestValidation(t *testing.T) {
+	var upstreamCalls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	targets := []link.LinkTarget{{
+		Name: "crm", Protocol: "http", Host: host,
+		Validation: &link.ValidationConfig{Rules: []link.ValidationRule{{
+			Match:  "POST /customers",
+			Schema: map[string]interface{}{"type": "object", "required": []interface{}{"name"}},
+		}}},
+	}}
+	validators, err := validation.FromConfig(targets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	handler := NewHandler(Config{
+		Targets:    link.NewTargetStore(targets),
+		Validators: validators,
+		Resolver:   &discovery.StaticResolver{},
+		Metrics:    metrics,
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/link/crm/customers", strings.NewReader(`{"email":"a@b"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected problem+json content type, got %q", ct)
+	}
+	var p problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Status != http.StatusBadRequest || p.Type != problemTypeValidation || len(p.Errors) != 1 {
+		t.Errorf("unexpected problem: %+v", p)
+	}
+	if upstreamCalls != 0 {
+		t.Errorf("expected invalid request not to reach upstream, got %d calls", upstreamCalls)
+	}
+	if got := testutil.ToFloat64(metrics.ValidationRejectedTotal.WithLabelValues("crm")); got != 1 {
+		t.Errorf("expected 1 validation rejection, got %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/link/crm/customers", strings.NewReader(`{"name":"Ada"}`)))
+	if w.Code != http.StatusCreated || upstreamCalls != 1 {
+		t.Errorf("expected valid request to be forwarded, got %d with %d calls", w.Code, upstreamCalls)
+	}
+}
+
 func TestProxy_PathAllowedPrefixMatch(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -995,3 +1370,77 @@ func TestHasExplicitPort(t *testing.T) {
 		t.Fatal("did not expect port for bare host")
 	}
 }
+
+func TestProxy_FaultAbortIsRetried(t *testing.T) {
+	var calls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	faults := fault.New(fault.WithRand(func() float64 { return 0 }))
+	faults.Set("svc", true, []fault.Rule{{Path: "/api/**", Percentage: 100, AbortStatus: http.StatusServiceUnavailable}})
+
+	breaker := circuitbreaker.New(circuitbreaker.Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: time.Hour})
+	handler := NewHandler(Config{
+		Targets:  link.NewTargetStore([]link.LinkTarget{{Name: "svc", Protocol: "http", Host: strings.TrimPrefix(upstream.URL, "http://"), Retry: link.RetryConfig{MaxAttempts: 2, InitialInterval: "1ms"}}}),
+		Breakers: map[string]*circuitbreaker.Breaker{"svc": breaker},
+		Metrics:  link.NewMetrics(prometheus.NewRegistry()),
+		Faults:   faults,
+	})
+
+	// Unmatched paths reach upstream.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/health", nil))
+	if w.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("expected upstream call for unmatched path, got %d (%d calls)", w.Code, calls)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/api/orders", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected injected 503, got %d", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("expected upstream not to be called, got %d calls", calls)
+	}
+	if breaker.State() != circuitbreaker.Open {
+		t.Errorf("expected breaker to open on injected failures, got %s", breaker.State())
+	}
+}
+
+func TestProxy_FaultResetAndDelay(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	faults := fault.New(fault.WithRand(func() float64 { return 0 }))
+	faults.Set("svc", true, []fault.Rule{{Percentage: 100, Delay: 20 * time.Millisecond, Reset: true}})
+
+	handler := NewHandler(Config{
+		Targets: link.NewTargetStore([]link.LinkTarget{{Name: "svc", Protocol: "http", Host: strings.TrimPrefix(upstream.URL, "http://"), Retry: link.RetryConfig{MaxAttempts: 1}}}),
+		Faults:  faults,
+	})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/x", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for injected reset, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected injected delay, request took %v", elapsed)
+	}
+
+	if err := faults.SetEnabled("svc", false); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/link/svc/x", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with faults disabled, got %d", w.Code)
+	}
+}
@@ -1,12 +1,16 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +19,10 @@ import (
 	"github.com/lsm/fiso/internal/interceptor"
 	"github.com/lsm/fiso/internal/kafka"
 	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/adaptive"
+	"github.com/lsm/fiso/internal/link/bulkhead"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
+	"github.com/lsm/fiso/internal/link/envelope"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/ratelimit"
 )
@@ -26,6 +33,9 @@ type KafkaHandler struct {
 	pool         *kafka.PublisherPool // Publisher pool for per-cluster publishing
 	targets      *link.TargetStore
 	breakers     map[string]*circuitbreaker.Breaker
+	bulkheads    map[string]*bulkhead.Bulkhead
+	adaptive     map[string]*adaptive.Limiter
+	envelopes    map[string]*envelope.Policy
 	rateLimiter  *ratelimit.Limiter
 	metrics      *link.Metrics
 	logger       *slog.Logger
@@ -92,17 +102,34 @@ func NewKafkaHandlerWithInterceptors(pool *kafka.PublisherPool, targets *link.Ta
 	}
 }
 
+// SetBulkheads sets the per-target concurrency limits.
+func (h *KafkaHandler) SetBulkheads(bulkheads map[string]*bulkhead.Bulkhead) {
+	h.bulkheads = bulkheads
+}
+
+// SetAdaptive sets the per-target adaptive concurrency limiters.
+func (h *KafkaHandler) SetAdaptive(limiters map[string]*adaptive.Limiter) {
+	h.adaptive = limiters
+}
+
+// SetEnvelopes sets the per-target CloudEvents and schema policies.
+func (h *KafkaHandler) SetEnvelopes(policies map[string]*envelope.Policy) {
+	h.envelopes = policies
+}
+
 // ServeHTTP handles Kafka publish requests.
 // Route: POST /link/{targetName}
 // Body: JSON payload to publish to Kafka
+// Route: POST /link/{targetName}/batch
+// Body: JSON array or NDJSON of payloads, each published as one record
 func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Parse target name from URL: /link/{targetName}
-	targetName := r.URL.Path[len("/link/"):]
+	// Parse target name from URL: /link/{targetName}[/batch]
+	targetName, batch := strings.CutSuffix(r.URL.Path[len("/link/"):], "/batch")
 	if targetName == "" {
 		http.Error(w, "target name required", http.StatusBadRequest)
 		return
@@ -121,15 +148,26 @@ func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check circuit breaker
-	if breaker, ok := h.breakers[target.Name]; ok {
-		if err := breaker.Allow(); err != nil {
+	breaker := h.breakers[target.Name]
+	var ticket circuitbreaker.Ticket
+	if breaker != nil {
+		var err error
+		if ticket, err = breaker.Allow(); err != nil {
 			if h.metrics != nil {
-				h.metrics.CircuitState.WithLabelValues(target.Name).Set(float64(circuitbreaker.Open))
+				h.metrics.CircuitState.WithLabelValues(target.Name).Set(float64(breaker.State()))
 			}
 			http.Error(w, "circuit breaker open", http.StatusServiceUnavailable)
 			return
 		}
 	}
+	// Requests rejected before publishing release their half-open probe
+	// slot without counting towards the breaker.
+	breakerRecorded := false
+	defer func() {
+		if breaker != nil && !breakerRecorded {
+			breaker.Cancel(ticket)
+		}
+	}()
 
 	// Check rate limit
 	if h.rateLimiter != nil {
@@ -142,6 +180,21 @@ func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Acquire a bulkhead slot
+	release, ok := acquireBulkhead(w, r, h.bulkheads[target.Name], target.Name, h.metrics)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Check adaptive concurrency limit
+	limiter := h.adaptive[target.Name]
+	releaseLimit, ok := acquireAdaptive(w, limiter, target.Name, h.metrics)
+	if !ok {
+		return
+	}
+	defer releaseLimit()
+
 	// Read request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -156,43 +209,219 @@ func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Track start time for latency logging
 	start := time.Now()
 
-	// Build headers map for interceptor processing
-	httpHeaders := make(map[string]string)
-	for k, v := range r.Header {
-		if len(v) > 0 {
-			httpHeaders[k] = v[0]
+	payloads := [][]byte{body}
+	if batch {
+		payloads, err = splitBatch(body)
+		if err != nil {
+			if h.metrics != nil {
+				h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "400", "kafka").Inc()
+			}
+			http.Error(w, fmt.Sprintf("invalid batch: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	partition, err := partitionFor(target, r.Header)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "400", "kafka").Inc()
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msgs := make([]kafka.Message, len(payloads))
+	for i, payload := range payloads {
+		header := r.Header
+		if batch {
+			// Interceptors may rewrite headers per record.
+			header = r.Header.Clone()
+		}
+		msg, status, err := h.buildMessage(r.Context(), target, payload, header)
+		if err != nil {
+			if h.metrics != nil {
+				h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", strconv.Itoa(status), "kafka").Inc()
+			}
+			if batch {
+				err = fmt.Errorf("record %d: %w", i, err)
+			}
+			var verr *envelope.ValidationError
+			if errors.As(err, &verr) {
+				writeSchemaProblem(w, target.Name, verr, err, h.metrics)
+				return
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		msg.Partition = partition
+		msgs[i] = msg
+	}
+
+	// Extract correlation ID from headers
+	corrID := correlation.ExtractOrGenerate(msgs[0].Headers)
+
+	// Publish to Kafka with retry
+	maxRetries := 3
+	if target.Retry.MaxAttempts > 0 {
+		maxRetries = target.Retry.MaxAttempts
+	}
+
+	// Get publisher for this target's cluster
+	publisher, err := h.getPublisher(target)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "500", "kafka").Inc()
+		}
+		http.Error(w, fmt.Sprintf("get publisher: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Only records that failed are retried, so a partially published batch
+	// does not produce duplicates.
+	results := make([]kafka.Result, len(msgs))
+	pending := make([]int, len(msgs))
+	for i := range pending {
+		pending[i] = i
+	}
+	publishStart := time.Now()
+	for attempt := 0; attempt < maxRetries && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt) * 100 * time.Millisecond
+			select {
+			case <-time.After(backoff):
+			case <-r.Context().Done():
+			}
+			if r.Context().Err() != nil {
+				break
+			}
+		}
+
+		attemptMsgs := make([]kafka.Message, len(pending))
+		for j, idx := range pending {
+			attemptMsgs[j] = msgs[idx]
+		}
+
+		// Use request context with timeout for safety
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		attemptStart := time.Now()
+		attemptResults := publishMessages(ctx, publisher, attemptMsgs)
+		cancel()
+
+		var failed []int
+		for j, idx := range pending {
+			results[idx] = attemptResults[j]
+			if attemptResults[j].Err != nil {
+				failed = append(failed, idx)
+			}
+		}
+		if limiter != nil {
+			limiter.Sample(time.Since(attemptStart), len(failed) > 0)
+		}
+		pending = failed
+	}
+
+	published := len(msgs) - len(pending)
+	if breaker != nil {
+		// A batch is one call to the breaker. It fails when the share of
+		// its records that failed reaches the failure rate threshold.
+		failedPct := float64(len(pending)) * 100 / float64(len(msgs))
+		failed := len(pending) > 0 && failedPct >= breaker.Config().FailureRateThreshold
+		breaker.Record(ticket, !failed, time.Since(publishStart))
+		breakerRecorded = true
+	}
+
+	if batch {
+		h.writeBatchResponse(w, target.Name, msgs[0].Topic, results, published)
+		h.logger.Info("kafka batch publish completed",
+			"correlation_id", corrID.Value,
+			"target", targetName,
+			"topic", msgs[0].Topic,
+			"records", len(msgs),
+			"failed", len(pending),
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return
+	}
+
+	result := results[0]
+	if result.Err != nil {
+		if h.metrics != nil {
+			h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "502", "kafka").Inc()
 		}
+		http.Error(w, fmt.Sprintf("kafka publish: %v", result.Err), http.StatusBadGateway)
+		return
+	}
+
+	if h.metrics != nil {
+		h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "200", "kafka").Inc()
 	}
+	// Log successful Kafka publish
+	h.logger.Info("kafka publish completed",
+		"correlation_id", corrID.Value,
+		"target", targetName,
+		"topic", msgs[0].Topic,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+	w.WriteHeader(http.StatusOK)
+	if result.Offset < 0 {
+		_, _ = fmt.Fprintf(w, `{"status":"published","topic":"%s"}`, msgs[0].Topic)
+		return
+	}
+	_, _ = fmt.Fprintf(w, `{"status":"published","topic":"%s","partition":%d,"offset":%d}`,
+		msgs[0].Topic, result.Partition, result.Offset)
+}
 
+// buildMessage runs the outbound interceptors on one payload and builds the
+// Kafka message with its headers and key. On error it returns the HTTP status
+// to respond with.
+func (h *KafkaHandler) buildMessage(ctx context.Context, target *link.LinkTarget, body []byte, header http.Header) (kafka.Message, int, error) {
 	// Run outbound interceptors (before publishing to Kafka)
 	if h.interceptors != nil && len(body) > 0 {
+		httpHeaders := make(map[string]string)
+		for k, v := range header {
+			if len(v) > 0 {
+				httpHeaders[k] = v[0]
+			}
+		}
 		icReq := &interceptor.Request{
 			Payload:   body,
 			Headers:   httpHeaders,
 			Direction: interceptor.Outbound,
 		}
 
-		icResult, icErr := h.interceptors.ProcessOutbound(r.Context(), targetName, icReq)
+		icResult, icErr := h.interceptors.ProcessOutbound(ctx, target.Name, icReq)
 		if icErr != nil {
-			h.logger.Error("outbound interceptor error", "target", targetName, "error", icErr)
-			if h.metrics != nil {
-				h.metrics.RequestsTotal.WithLabelValues(targetName, "POST", "500", "kafka").Inc()
-			}
-			http.Error(w, "interceptor error", http.StatusInternalServerError)
-			return
+			h.logger.Error("outbound interceptor error", "target", target.Name, "error", icErr)
+			return kafka.Message{}, http.StatusInternalServerError, errors.New("interceptor error")
 		}
 
 		body = icResult.Payload
 		// Update headers from interceptor result
 		for k, v := range icResult.Headers {
-			r.Header.Set(k, v)
+			header.Set(k, v)
 		}
 	}
 
+	policy := h.envelopes[target.Name]
+	if err := policy.Validate(ctx, body); err != nil {
+		var verr *envelope.ValidationError
+		if errors.As(err, &verr) {
+			return kafka.Message{}, http.StatusBadRequest, err
+		}
+		return kafka.Message{}, http.StatusBadGateway, fmt.Errorf("schema registry: %w", err)
+	}
+
+	// The partition header only selects the partition for manual
+	// partitioning and is not forwarded.
+	skip := ""
+	if target.Kafka != nil && target.Kafka.Partitioner == kafka.PartitionerManual {
+		skip = http.CanonicalHeaderKey(target.Kafka.PartitionHeaderName())
+	}
+
 	// Build Kafka headers from HTTP headers + static headers
 	kafkaHeaders := make(map[string]string)
-	for k, v := range r.Header {
-		if len(v) > 0 {
+	for k, v := range header {
+		if len(v) > 0 && k != skip {
 			// Normalize well-known header names to their conventional casing
 			// since http.Header canonicalizes them (e.g., X-Request-Id instead of X-Request-ID)
 			normalizedKey := normalizeHeaderKey(k)
@@ -206,28 +435,14 @@ func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Extract correlation ID from headers
-	corrID := correlation.ExtractOrGenerate(kafkaHeaders)
-
 	// Generate key
 	keyStrategy := link.KeyStrategy{}
 	if target.Kafka != nil {
 		keyStrategy = target.Kafka.Key
 	}
-	key, err := h.generateKey(keyStrategy, body, r.Header)
+	key, err := h.generateKey(keyStrategy, body, header)
 	if err != nil {
-		if h.metrics != nil {
-			h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "400", "kafka").Inc()
-		}
-		http.Error(w, fmt.Sprintf("key generation: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	// Publish to Kafka with retry
-	var publishErr error
-	maxRetries := 3
-	if target.Retry.MaxAttempts > 0 {
-		maxRetries = target.Retry.MaxAttempts
+		return kafka.Message{}, http.StatusBadRequest, fmt.Errorf("key generation: %w", err)
 	}
 
 	// Get topic
@@ -236,61 +451,142 @@ func (h *KafkaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		topic = target.Kafka.Topic
 	}
 
-	// Get publisher for this target's cluster
-	publisher, err := h.getPublisher(target)
-	if err != nil {
-		if h.metrics != nil {
-			h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "500", "kafka").Inc()
+	msg := kafka.Message{Topic: topic, Key: key, Value: body, Headers: kafkaHeaders}
+	if policy != nil {
+		lower := make(map[string]string, len(header))
+		for k, v := range header {
+			if len(v) > 0 {
+				lower[strings.ToLower(k)] = v[0]
+			}
+		}
+		if err := policy.Wrap(&msg, lower); err != nil {
+			return kafka.Message{}, http.StatusBadRequest, err
 		}
-		http.Error(w, fmt.Sprintf("get publisher: %v", err), http.StatusInternalServerError)
-		return
 	}
+	return msg, 0, nil
+}
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Use request context with timeout for safety
-		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-		publishErr = publisher.Publish(ctx, topic, key, body, kafkaHeaders)
-		cancel()
-		if publishErr == nil {
-			// Success
-			if breaker, ok := h.breakers[target.Name]; ok {
-				breaker.RecordSuccess()
+// partitionFor returns the partition requested through the partition header
+// when the target uses the manual partitioner, and 0 otherwise.
+func partitionFor(target *link.LinkTarget, header http.Header) (int32, error) {
+	if target.Kafka == nil || target.Kafka.Partitioner != kafka.PartitionerManual {
+		return 0, nil
+	}
+	name := target.Kafka.PartitionHeaderName()
+	value := header.Get(name)
+	if value == "" {
+		return 0, fmt.Errorf("header %q is required for manual partitioning", name)
+	}
+	partition, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || partition < 0 {
+		return 0, fmt.Errorf("header %q: invalid partition %q", name, value)
+	}
+	return int32(partition), nil
+}
+
+// recordPublisher is implemented by publishers that report the partition
+// and offset of every record, such as kafka.PooledPublisher.
+type recordPublisher interface {
+	PublishBatch(ctx context.Context, msgs []kafka.Message) []kafka.Result
+}
+
+// publishMessages publishes msgs and returns a result per message. Publishers
+// that cannot report offsets publish one message at a time and return -1 for
+// the partition and offset.
+func publishMessages(ctx context.Context, publisher dlq.Publisher, msgs []kafka.Message) []kafka.Result {
+	if rp, ok := publisher.(recordPublisher); ok {
+		return rp.PublishBatch(ctx, msgs)
+	}
+	results := make([]kafka.Result, len(msgs))
+	for i, m := range msgs {
+		results[i] = kafka.Result{
+			Partition: -1,
+			Offset:    -1,
+			Err:       publisher.Publish(ctx, m.Topic, m.Key, m.Value, m.Headers),
+		}
+	}
+	return results
+}
+
+// splitBatch splits a batch body into record payloads. The body is either a
+// JSON array, whose elements are the records, or newline-delimited JSON.
+func splitBatch(body []byte) ([][]byte, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("no records")
+	}
+
+	var records [][]byte
+	if trimmed[0] == '[' {
+		var elems []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elems); err != nil {
+			return nil, fmt.Errorf("parse JSON array: %w", err)
+		}
+		for _, e := range elems {
+			records = append(records, []byte(e))
+		}
+	} else {
+		for i, line := range bytes.Split(trimmed, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
 			}
-			if h.metrics != nil {
-				h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "200", "kafka").Inc()
+			if !json.Valid(line) {
+				return nil, fmt.Errorf("line %d is not valid JSON", i+1)
 			}
-			// Log successful Kafka publish
-			h.logger.Info("kafka publish completed",
-				"correlation_id", corrID.Value,
-				"target", targetName,
-				"topic", topic,
-				"latency_ms", time.Since(start).Milliseconds(),
-			)
-			w.WriteHeader(http.StatusOK)
-			_, _ = fmt.Fprintf(w, `{"status":"published","topic":"%s"}`, topic)
-			return
+			records = append(records, line)
 		}
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no records")
+	}
+	return records, nil
+}
 
-		// Retry on error
-		if attempt < maxRetries-1 {
-			backoff := time.Duration(attempt+1) * 100 * time.Millisecond
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				publishErr = ctx.Err()
-				break
-			}
+// batchRecord is the outcome of one record in a batch response.
+type batchRecord struct {
+	Index     int    `json:"index"`
+	Partition *int32 `json:"partition,omitempty"`
+	Offset    *int64 `json:"offset,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// writeBatchResponse reports per-record results. The status is 200 when every
+// record was published, 207 when some failed and 502 when all failed.
+func (h *KafkaHandler) writeBatchResponse(w http.ResponseWriter, targetName, topic string, results []kafka.Result, published int) {
+	records := make([]batchRecord, len(results))
+	for i, res := range results {
+		records[i].Index = i
+		if res.Err != nil {
+			records[i].Error = res.Err.Error()
+			continue
+		}
+		if res.Offset >= 0 {
+			partition, offset := res.Partition, res.Offset
+			records[i].Partition = &partition
+			records[i].Offset = &offset
 		}
 	}
 
-	// All retries failed
-	if breaker, ok := h.breakers[target.Name]; ok {
-		breaker.RecordFailure()
+	status := http.StatusOK
+	switch {
+	case published == 0:
+		status = http.StatusBadGateway
+	case published < len(results):
+		status = http.StatusMultiStatus
 	}
 	if h.metrics != nil {
-		h.metrics.RequestsTotal.WithLabelValues(target.Name, "POST", "502", "kafka").Inc()
+		h.metrics.RequestsTotal.WithLabelValues(targetName, "POST", strconv.Itoa(status), "kafka").Inc()
 	}
-	http.Error(w, fmt.Sprintf("kafka publish: %v", publishErr), http.StatusBadGateway)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Topic     string        `json:"topic"`
+		Published int           `json:"published"`
+		Failed    int           `json:"failed"`
+		Records   []batchRecord `json:"records"`
+	}{topic, published, len(results) - published, records})
 }
 
 // getPublisher returns the appropriate publisher for the target.
@@ -304,7 +600,11 @@ func (h *KafkaHandler) getPublisher(target *link.LinkTarget) (dlq.Publisher, err
 		if target.Kafka != nil && target.Kafka.Cluster != "" {
 			clusterName = target.Kafka.Cluster
 		}
-		return h.pool.Get(clusterName)
+		pc, err := target.Kafka.ProducerConfig()
+		if err != nil {
+			return nil, err
+		}
+		return h.pool.GetWithProducer(clusterName, pc)
 	}
 
 	// Fallback to single publisher
@@ -3,6 +3,7 @@ package proxy
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/lsm/fiso/internal/kafka"
 	"github.com/lsm/fiso/internal/link"
 	"github.com/lsm/fiso/internal/link/circuitbreaker"
+	"github.com/lsm/fiso/internal/link/envelope"
 	linkinterceptor "github.com/lsm/fiso/internal/link/interceptor"
 	"github.com/lsm/fiso/internal/link/ratelimit"
 )
@@ -953,3 +955,373 @@ func TestKafkaHandler_GetPublisherError(t *testing.T) {
 		t.Errorf("expected get publisher error message, got: %s", w.Body.String())
 	}
 }
+
+// recordingPublisher implements recordPublisher, assigning consecutive
+// offsets and failing records whose value matches fail until it has been
+// retried failUntil times.
+type recordingPublisher struct {
+	mockPublisher
+	fail      string
+	failUntil int
+	calls     int
+	msgs      []kafka.Message
+	next      int64
+}
+
+func (p *recordingPublisher) PublishBatch(_ context.Context, msgs []kafka.Message) []kafka.Result {
+	p.calls++
+	results := make([]kafka.Result, len(msgs))
+	for i, m := range msgs {
+		if p.fail != "" && string(m.Value) == p.fail && (p.failUntil == 0 || p.calls <= p.failUntil) {
+			results[i].Err = fmt.Errorf("broker unavailable")
+			continue
+		}
+		p.msgs = append(p.msgs, m)
+		results[i] = kafka.Result{Partition: m.Partition, Offset: p.next}
+		p.next++
+	}
+	return results
+}
+
+func TestKafkaHandler_ReportsPartitionAndOffset(t *testing.T) {
+	publisher := &recordingPublisher{next: 41}
+	store := link.NewTargetStore([]link.LinkTarget{
+		{Name: "orders", Protocol: "kafka", Kafka: &link.KafkaConfig{Topic: "orders"}},
+	})
+	handler := NewKafkaHandler(publisher, store, nil, nil, nil, nil)
+
+	req := httptest.NewRequest("POST", "/link/orders", strings.NewReader(`{"id":1}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	want := `{"status":"published","topic":"orders","partition":0,"offset":41}`
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("got %d %s, want 200 %s", w.Code, w.Body.String(), want)
+	}
+}
+
+func TestKafkaHandler_ManualPartition(t *testing.T) {
+	store := link.NewTargetStore([]link.LinkTarget{
+		{Name: "orders", Protocol: "kafka", Kafka: &link.KafkaConfig{
+			Topic:           "orders",
+			Partitioner:     kafka.PartitionerManual,
+			PartitionHeader: "X-Partition",
+		}},
+	})
+
+	tests := []struct {
+		name          string
+		header        string
+		wantStatus    int
+		wantPartition int32
+	}{
+		{"header sets partition", "3", http.StatusOK, 3},
+		{"missing header", "", http.StatusBadRequest, 0},
+		{"invalid header", "abc", http.StatusBadRequest, 0},
+		{"negative partition", "-1", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			handler := NewKafkaHandler(publisher, store, nil, nil, nil, nil)
+
+			req := httptest.NewRequest("POST", "/link/orders", strings.NewReader(`{"id":1}`))
+			if tt.header != "" {
+				req.Header.Set("X-Partition", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if len(publisher.msgs) != 1 || publisher.msgs[0].Partition != tt.wantPartition {
+				t.Fatalf("unexpected messages %+v", publisher.msgs)
+			}
+			if _, ok := publisher.msgs[0].Headers["X-Partition"]; ok {
+				t.Error("partition header should not be forwarded to Kafka")
+			}
+		})
+	}
+}
+
+func TestKafkaHandler_Batch(t *testing.T) {
+	store := link.NewTargetStore([]link.LinkTarget{
+		{
+			Name:     "orders",
+			Protocol: "kafka",
+			Kafka: &link.KafkaConfig{
+				Topic: "orders",
+				Key:   link.KeyStrategy{Type: "payload", Field: "id"},
+			},
+			Retry: link.RetryConfig{MaxAttempts: 1},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		fail       string
+		wantStatus int
+		wantBody   string
+		wantKeys   []string
+	}{
+		{
+			name:       "JSON array",
+			body:       `[{"id":1},{"id":2}]`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"topic":"orders","published":2,"failed":0,"records":[{"index":0,"partition":0,"offset":0},{"index":1,"partition":0,"offset":1}]}`,
+			wantKeys:   []string{"1", "2"},
+		},
+		{
+			name:       "NDJSON",
+			body:       "{\"id\":\"a\"}\n\n{\"id\":\"b\"}\n",
+			wantStatus: http.StatusOK,
+			wantKeys:   []string{"a", "b"},
+		},
+		{
+			name:       "partial failure",
+			body:       `[{"id":1},{"id":2}]`,
+			fail:       `{"id":2}`,
+			wantStatus: http.StatusMultiStatus,
+			wantBody:   `{"topic":"orders","published":1,"failed":1,"records":[{"index":0,"partition":0,"offset":0},{"index":1,"error":"broker unavailable"}]}`,
+			wantKeys:   []string{"1"},
+		},
+		{
+			name:       "all failed",
+			body:       `[{"id":2}]`,
+			fail:       `{"id":2}`,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "empty batch",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid NDJSON line",
+			body:       "{\"id\":1}\nnot json\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "key error names the record",
+			body:       `[{"id":1},{"other":2}]`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "record 1: key generation: field \"id\" not found in payload\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{fail: tt.fail}
+			handler := NewKafkaHandler(publisher, store, nil, nil, nil, nil)
+
+			req := httptest.NewRequest("POST", "/link/orders/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != strings.TrimSpace(tt.wantBody) {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+			var keys []string
+			for _, m := range publisher.msgs {
+				keys = append(keys, string(m.Key))
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestKafkaHandler_BatchRetriesFailedRecordsOnly(t *testing.T) {
+	publisher := &recordingPublisher{fail: `{"id":2}`, failUntil: 1}
+	store := link.NewTargetStore([]link.LinkTarget{
+		{
+			Name:     "orders",
+			Protocol: "kafka",
+			Kafka:    &link.KafkaConfig{Topic: "orders"},
+			Retry:    link.RetryConfig{MaxAttempts: 2},
+		},
+	})
+	breakers := map[string]*circuitbreaker.Breaker{"orders": circuitbreaker.New(circuitbreaker.Config{})}
+	handler := NewKafkaHandler(publisher, store, breakers, nil, nil, nil)
+
+	req := httptest.NewRequest("POST", "/link/orders/batch", strings.NewReader(`[{"id":1},{"id":2}]`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if publisher.calls != 2 || len(publisher.msgs) != 2 {
+		t.Errorf("expected 2 calls publishing 2 records once each, got %d calls and %d records", publisher.calls, len(publisher.msgs))
+	}
+}
+
+func TestKafkaHandler_BatchBreakerFailureRate(t *testing.T) {
+	store := link.NewTargetStore([]link.LinkTarget{
+		{
+			Name:     "orders",
+			Protocol: "kafka",
+			Kafka:    &link.KafkaConfig{Topic: "orders"},
+			Retry:    link.RetryConfig{MaxAttempts: 1},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		body      string
+		wantState circuitbreaker.State
+	}{
+		{"below the threshold", `[{"id":1},{"id":2},{"id":3}]`, circuitbreaker.Closed},
+		{"at the threshold", `[{"id":1},{"id":2}]`, circuitbreaker.Open},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := circuitbreaker.New(circuitbreaker.Config{FailureThreshold: 1, FailureRateThreshold: 50, ResetTimeout: time.Minute})
+			publisher := &recordingPublisher{fail: `{"id":2}`}
+			handler := NewKafkaHandler(publisher, store, map[string]*circuitbreaker.Breaker{"orders": breaker}, nil, nil, nil)
+
+			req := httptest.NewRequest("POST", "/link/orders/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusMultiStatus {
+				t.Fatalf("status = %d, want 207: %s", w.Code, w.Body.String())
+			}
+			if got := breaker.State(); got != tt.wantState {
+				t.Errorf("breaker state = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestKafkaHandler_BatchWithLegacyPublisher(t *testing.T) {
+	var published int
+	publisher := &mockPublisher{
+		publishFunc: func(ctx context.Context, topic string, key, value []byte, headers map[string]string) error {
+			published++
+			return nil
+		},
+	}
+	store := link.NewTargetStore([]link.LinkTarget{
+		{Name: "orders", Protocol: "kafka", Kafka: &link.KafkaConfig{Topic: "orders"}},
+	})
+	handler := NewKafkaHandler(publisher, store, nil, nil, nil, nil)
+
+	req := httptest.NewRequest("POST", "/link/orders/batch", strings.NewReader(`[1,2,3]`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	want := `{"topic":"orders","published":3,"failed":0,"records":[{"index":0},{"index":1},{"index":2}]}`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("got %d %s, want 200 %s", w.Code, w.Body.String(), want)
+	}
+	if published != 3 {
+		t.Errorf("expected 3 published records, got %d", published)
+	}
+}
+
+func TestKafkaHandler_CloudEvents(t *testing.T) {
+	target := link.LinkTarget{
+		Name:     "orders",
+		Protocol: "kafka",
+		Kafka: &link.KafkaConfig{
+			Topic:       "orders",
+			CloudEvents: &link.KafkaCloudEventsConfig{Type: "order.created", Subject: "data.orderId"},
+		},
+	}
+	policies, err := envelope.FromConfig([]link.LinkTarget{target})
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+	publisher := &recordingPublisher{}
+	handler := NewKafkaHandler(publisher, link.NewTargetStore([]link.LinkTarget{target}), nil, nil, nil, nil)
+	handler.SetEnvelopes(policies)
+
+	req := httptest.NewRequest("POST", "/link/orders", strings.NewReader(`{"orderId":"o-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	msg := publisher.msgs[0]
+	var ce map[string]interface{}
+	if err := json.Unmarshal(msg.Value, &ce); err != nil {
+		t.Fatalf("value is not a JSON CloudEvent: %v", err)
+	}
+	if ce["type"] != "order.created" || ce["subject"] != "o-1" || ce["source"] != "fiso-link/orders" {
+		t.Errorf("unexpected CloudEvent %v", ce)
+	}
+	if msg.Headers["content-type"] != "application/cloudevents+json" {
+		t.Errorf("content-type = %q", msg.Headers["content-type"])
+	}
+
+	// A subject that cannot be evaluated rejects the request.
+	req = httptest.NewRequest("POST", "/link/orders", strings.NewReader(`{"id":1}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestKafkaHandler_SchemaValidation(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         1,
+			"schemaType": "JSON",
+			"schema":     `{"required":["orderId"]}`,
+		})
+	}))
+	defer registry.Close()
+
+	target := link.LinkTarget{
+		Name:     "orders",
+		Protocol: "kafka",
+		Kafka: &link.KafkaConfig{
+			Topic:  "orders",
+			Schema: &link.KafkaSchemaConfig{RegistryURL: registry.URL, Subject: "orders-value"},
+		},
+	}
+	policies, err := envelope.FromConfig([]link.LinkTarget{target})
+	if err != nil {
+		t.Fatalf("FromConfig() error = %v", err)
+	}
+	metrics := link.NewMetrics(prometheus.NewRegistry())
+	publisher := &recordingPublisher{}
+	handler := NewKafkaHandler(publisher, link.NewTargetStore([]link.LinkTarget{target}), nil, nil, metrics, nil)
+	handler.SetEnvelopes(policies)
+
+	req := httptest.NewRequest("POST", "/link/orders/batch", strings.NewReader(`[{"orderId":"o-1"},{"id":2}]`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("content type = %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), problemTypeSchema) || !strings.Contains(w.Body.String(), "record 1") {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+	if len(publisher.msgs) != 0 {
+		t.Errorf("expected nothing to be published, got %d records", len(publisher.msgs))
+	}
+
+	req = httptest.NewRequest("POST", "/link/orders", strings.NewReader(`{"orderId":"o-1"}`))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/envelope"
+	"github.com/lsm/fiso/internal/link/validation"
+)
+
+// problemTypeValidation identifies request validation failures in problem
+// details responses.
+const problemTypeValidation = "urn:fiso:problem:request-validation"
+
+// problemTypeSchema identifies Kafka payloads rejected by schema registry
+// validation.
+const problemTypeSchema = "urn:fiso:problem:schema-validation"
+
+// problem is an RFC 9457 problem details body.
+type problem struct {
+	Type   string   `json:"type"`
+	Title  string   `json:"title"`
+	Status int      `json:"status"`
+	Detail string   `json:"detail,omitempty"`
+	Target string   `json:"target,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, p problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// validateRequest checks body against the target's validator. When the
+// request is rejected it writes a 400 problem details response and returns
+// false. A nil validator accepts every request.
+func validateRequest(w http.ResponseWriter, v *validation.Validator, target, method, reqPath string, body []byte, metrics *link.Metrics) bool {
+	err := v.Validate(method, reqPath, body)
+	if err == nil {
+		return true
+	}
+
+	if metrics != nil {
+		metrics.ValidationRejectedTotal.WithLabelValues(target).Inc()
+	}
+	p := problem{
+		Type:   problemTypeValidation,
+		Title:  "Request validation failed",
+		Status: http.StatusBadRequest,
+		Target: target,
+	}
+	var verr *validation.Error
+	if errors.As(err, &verr) {
+		p.Detail = "request body does not match the schema for " + verr.Route
+		p.Errors = verr.Violations
+	} else {
+		p.Detail = err.Error()
+	}
+	writeProblem(w, p)
+	return false
+}
+
+// writeSchemaProblem writes the 400 problem details response for a Kafka
+// payload that does not match its registry schema. err is verr, possibly
+// wrapped with the batch record index.
+func writeSchemaProblem(w http.ResponseWriter, target string, verr *envelope.ValidationError, err error, metrics *link.Metrics) {
+	if metrics != nil {
+		metrics.ValidationRejectedTotal.WithLabelValues(target).Inc()
+	}
+	writeProblem(w, problem{
+		Type:   problemTypeSchema,
+		Title:  "Schema validation failed",
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+		Target: target,
+		Errors: []string{verr.Err.Error()},
+	})
+}
@@ -1,11 +1,20 @@
 package ratelimit
 
 import (
+	"sort"
 	"sync"
 
 	"golang.org/x/time/rate"
 )
 
+// Status describes the current state of a target's token bucket.
+type Status struct {
+	Target string  `json:"target"`
+	Limit  float64 `json:"limit"`  // tokens per second
+	Burst  int     `json:"burst"`  // bucket capacity
+	Tokens float64 `json:"tokens"` // tokens currently available
+}
+
 // Limiter provides per-target rate limiting using token bucket algorithm.
 type Limiter struct {
 	mu       sync.RWMutex
@@ -50,3 +59,40 @@ func (l *Limiter) Allow(target string) bool {
 	}
 	return lim.Allow()
 }
+
+// Status returns the bucket state for a target. The second return value is
+// false if no rate limit is configured for the target.
+func (l *Limiter) Status(target string) (Status, bool) {
+	l.mu.RLock()
+	lim, ok := l.limiters[target]
+	l.mu.RUnlock()
+
+	if !ok {
+		return Status{}, false
+	}
+	return Status{
+		Target: target,
+		Limit:  float64(lim.Limit()),
+		Burst:  lim.Burst(),
+		Tokens: lim.Tokens(),
+	}, true
+}
+
+// Statuses returns the bucket state of every rate-limited target, sorted by name.
+func (l *Limiter) Statuses() []Status {
+	l.mu.RLock()
+	names := make([]string, 0, len(l.limiters))
+	for name := range l.limiters {
+		names = append(names, name)
+	}
+	l.mu.RUnlock()
+	sort.Strings(names)
+
+	out := make([]Status, 0, len(names))
+	for _, name := range names {
+		if st, ok := l.Status(name); ok {
+			out = append(out, st)
+		}
+	}
+	return out
+}
@@ -160,3 +160,32 @@ func TestLimiter_NegativeRPS(t *testing.T) {
 		}
 	}
 }
+
+func TestLimiter_Status(t *testing.T) {
+	l := New()
+	if _, ok := l.Status("svc"); ok {
+		t.Fatal("expected no status for unconfigured target")
+	}
+
+	l.Set("svc", 0.001, 4)
+	l.Set("other", 5, 5)
+	for i := 0; i < 3; i++ {
+		l.Allow("svc")
+	}
+
+	st, ok := l.Status("svc")
+	if !ok {
+		t.Fatal("expected status for svc")
+	}
+	if st.Burst != 4 || st.Limit != 0.001 {
+		t.Errorf("unexpected bucket settings: %+v", st)
+	}
+	if st.Tokens < 0.9 || st.Tokens > 1.1 {
+		t.Errorf("expected ~1 token remaining, got %f", st.Tokens)
+	}
+
+	all := l.Statuses()
+	if len(all) != 2 || all[0].Target != "other" || all[1].Target != "svc" {
+		t.Errorf("expected sorted statuses for both targets, got %+v", all)
+	}
+}
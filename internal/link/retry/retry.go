@@ -6,6 +6,8 @@ import (
 	"math"
 	"math/rand/v2"
 	"time"
+
+	"github.com/lsm/fiso/internal/link"
 )
 
 // Config holds retry configuration.
@@ -26,6 +28,25 @@ func DefaultConfig() Config {
 	}
 }
 
+// FromLinkConfig returns DefaultConfig overridden by any values set in a
+// target's retry configuration. Unparseable durations keep their defaults.
+func FromLinkConfig(rc link.RetryConfig) Config {
+	cfg := DefaultConfig()
+	if rc.MaxAttempts > 0 {
+		cfg.MaxAttempts = rc.MaxAttempts
+	}
+	if rc.Jitter > 0 {
+		cfg.Jitter = rc.Jitter
+	}
+	if d, err := time.ParseDuration(rc.InitialInterval); err == nil {
+		cfg.InitialInterval = d
+	}
+	if d, err := time.ParseDuration(rc.MaxInterval); err == nil {
+		cfg.MaxInterval = d
+	}
+	return cfg
+}
+
 // PermanentError wraps an error that should not be retried.
 type PermanentError struct {
 	Err error
@@ -0,0 +1,136 @@
+// Package rewrite applies per-target path, query and method rewrite rules
+// in Fiso-Link, so applications can call stable local paths while the
+// provider's API shape changes underneath.
+package rewrite
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+// Result is the outcome of applying a target's rewrite rules.
+type Result struct {
+	Method string
+	Path   string
+	Query  url.Values
+	// Applied is the number of rules that changed the path or method, or
+	// have query rewrites. A regex that did not match changes nothing.
+	Applied int
+}
+
+type rule struct {
+	match       string
+	stripPrefix string
+	regex       *regexp.Regexp
+	replace     string
+	method      string
+	query       []link.QueryRewrite
+}
+
+// Rewriter is the compiled rewrite configuration of a target.
+type Rewriter struct {
+	rules []rule
+}
+
+// Compile builds a rewriter from a target's rewrite rules. A nil or empty
+// configuration yields a nil rewriter, which leaves requests unchanged.
+func Compile(cfg *link.RewriteConfig) (*Rewriter, error) {
+	if cfg == nil || len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	rw := &Rewriter{rules: make([]rule, 0, len(cfg.Rules))}
+	for i, rc := range cfg.Rules {
+		r := rule{
+			match:       rc.Match,
+			stripPrefix: rc.StripPrefix,
+			replace:     rc.Replace,
+			method:      strings.ToUpper(rc.Method),
+			query:       rc.Query,
+		}
+		if rc.Regex != "" {
+			re, err := regexp.Compile(rc.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: compile regex %q: %w", i, rc.Regex, err)
+			}
+			r.regex = re
+		}
+		rw.rules = append(rw.rules, r)
+	}
+	return rw, nil
+}
+
+// FromConfig compiles the rewriter of every target with rewrite rules.
+func FromConfig(targets []link.LinkTarget) (map[string]*Rewriter, error) {
+	rewriters := make(map[string]*Rewriter)
+	for _, t := range targets {
+		rw, err := Compile(t.Rewrite)
+		if err != nil {
+			return nil, fmt.Errorf("target %q rewrite: %w", t.Name, err)
+		}
+		if rw != nil {
+			rewriters[t.Name] = rw
+		}
+	}
+	return rewriters, nil
+}
+
+// Apply rewrites the request method, path and query. Rules run in order and
+// each sees the output of the previous one; a rule applies only if its Match
+// pattern (allowedPaths syntax) matches the current path. query is not
+// modified. A nil rewriter returns the inputs unchanged.
+func (rw *Rewriter) Apply(method, reqPath string, query url.Values) Result {
+	res := Result{Method: method, Path: reqPath, Query: cloneValues(query)}
+	if rw == nil {
+		return res
+	}
+	for _, r := range rw.rules {
+		if r.match != "" && !link.MatchPath(r.match, res.Path) {
+			continue
+		}
+		path, method := res.Path, res.Method
+		if r.stripPrefix != "" && strings.HasPrefix(res.Path, r.stripPrefix) {
+			res.Path = "/" + strings.TrimLeft(strings.TrimPrefix(res.Path, r.stripPrefix), "/")
+		}
+		if r.regex != nil {
+			res.Path = r.regex.ReplaceAllString(res.Path, r.replace)
+		}
+		if r.method != "" {
+			res.Method = r.method
+		}
+		for _, q := range r.query {
+			applyQuery(res.Query, q)
+		}
+		if res.Path != path || res.Method != method || len(r.query) > 0 {
+			res.Applied++
+		}
+	}
+	return res
+}
+
+func applyQuery(v url.Values, q link.QueryRewrite) {
+	switch q.Action {
+	case "add":
+		v.Add(q.Name, q.Value)
+	case "set":
+		v.Set(q.Name, q.Value)
+	case "remove":
+		v.Del(q.Name)
+	case "rename":
+		if vals, ok := v[q.Name]; ok {
+			v.Del(q.Name)
+			v[q.To] = vals
+		}
+	}
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vv := range v {
+		out[k] = append([]string(nil), vv...)
+	}
+	return out
+}
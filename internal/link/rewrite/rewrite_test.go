@@ -0,0 +1,114 @@
+package rewrite
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+func TestApply_NilRewriter(t *testing.T) {
+	var rw *Rewriter
+	q := url.Values{"a": {"1"}}
+	res := rw.Apply("GET", "/customers/1", q)
+	if res.Method != "GET" || res.Path != "/customers/1" || res.Query.Get("a") != "1" || res.Applied != 0 {
+		t.Fatalf("expected unchanged request, got %+v", res)
+	}
+}
+
+func TestApply_RegexAndMethod(t *testing.T) {
+	rw, err := Compile(&link.RewriteConfig{Rules: []link.RewriteRule{
+		{Match: "/customers/*", Regex: "^/customers/([^/]+)$", Replace: "/v3/accounts/$1"},
+		{Match: "/customers/*/archive", Method: "delete", Regex: "^/customers/([^/]+)/archive$", Replace: "/v3/accounts/$1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := rw.Apply("GET", "/customers/123", nil)
+	if res.Path != "/v3/accounts/123" || res.Method != "GET" || res.Applied != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res = rw.Apply("POST", "/customers/123/archive", nil)
+	if res.Path != "/v3/accounts/123" || res.Method != "DELETE" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestApply_RegexNoMatchNotApplied(t *testing.T) {
+	rw, err := Compile(&link.RewriteConfig{Rules: []link.RewriteRule{
+		{Regex: "^/customers/([^/]+)$", Replace: "/v3/accounts/$1"},
+		{Regex: "^/orders/([^/]+)$", Replace: "/v2/orders/$1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res := rw.Apply("GET", "/orders/7", nil); res.Path != "/v2/orders/7" || res.Applied != 1 {
+		t.Fatalf("expected only the orders rule to apply, got %+v", res)
+	}
+	if res := rw.Apply("GET", "/invoices/7", nil); res.Path != "/invoices/7" || res.Applied != 0 {
+		t.Fatalf("expected no rule to apply, got %+v", res)
+	}
+}
+
+func TestApply_StripPrefixThenRegex(t *testing.T) {
+	rw, err := Compile(&link.RewriteConfig{Rules: []link.RewriteRule{
+		{StripPrefix: "/legacy", Regex: "^/orders", Replace: "/api/orders"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res := rw.Apply("GET", "/legacy/orders/9", nil); res.Path != "/api/orders/9" {
+		t.Fatalf("expected /api/orders/9, got %s", res.Path)
+	}
+	if res := rw.Apply("GET", "/legacy", nil); res.Path != "/" {
+		t.Fatalf("expected /, got %s", res.Path)
+	}
+}
+
+func TestApply_Query(t *testing.T) {
+	rw, err := Compile(&link.RewriteConfig{Rules: []link.RewriteRule{{
+		Query: []link.QueryRewrite{
+			{Action: "add", Name: "api-version", Value: "2024-01"},
+			{Action: "set", Name: "limit", Value: "50"},
+			{Action: "remove", Name: "debug"},
+			{Action: "rename", Name: "q", To: "search"},
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := url.Values{"limit": {"10"}, "debug": {"1"}, "q": {"acme"}}
+	res := rw.Apply("GET", "/search", in)
+	want := "api-version=2024-01&limit=50&search=acme"
+	if got := res.Query.Encode(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if in.Get("debug") != "1" {
+		t.Fatal("expected input query to be left untouched")
+	}
+}
+
+func TestApply_MatchSkipsRule(t *testing.T) {
+	rw, err := Compile(&link.RewriteConfig{Rules: []link.RewriteRule{
+		{Match: "/orders/**", Method: "PUT"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res := rw.Apply("POST", "/customers/1", nil); res.Method != "POST" || res.Applied != 0 {
+		t.Fatalf("expected rule to be skipped, got %+v", res)
+	}
+}
+
+func TestFromConfig_InvalidRegex(t *testing.T) {
+	_, err := FromConfig([]link.LinkTarget{{
+		Name:    "crm",
+		Rewrite: &link.RewriteConfig{Rules: []link.RewriteRule{{Regex: "("}}},
+	}})
+	if err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
@@ -0,0 +1,191 @@
+// Package validation checks request bodies against JSON Schemas or OpenAPI
+// operations before Fiso-Link forwards them, so malformed payloads are
+// rejected locally instead of reaching (and being billed by) the upstream.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lsm/fiso/internal/link"
+	"github.com/lsm/fiso/internal/link/openapi"
+	"github.com/lsm/fiso/internal/schema"
+)
+
+// Error reports why a request body was rejected.
+type Error struct {
+	// Route is the rule or OpenAPI operation that rejected the request,
+	// e.g. "POST /customers".
+	Route      string
+	Violations []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request does not match %s: %s", e.Route, strings.Join(e.Violations, "; "))
+}
+
+type check struct {
+	route    string
+	codec    *schema.JSONCodec
+	required bool
+	// wildcards counts templated path segments; OpenAPI operations with
+	// fewer wildcards take precedence (/customers/me over /customers/{id}).
+	wildcards int
+}
+
+// Validator is the compiled validation configuration of a target.
+type Validator struct {
+	rules   []check
+	openapi []check
+}
+
+// Compile builds a validator from a target's validation configuration.
+// Schema files and the OpenAPI document are read once, here. A nil
+// configuration yields a nil validator, which accepts every request.
+func Compile(cfg *link.ValidationConfig) (*Validator, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	v := &Validator{}
+	for i, rc := range cfg.Rules {
+		raw, err := ruleSchema(rc)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		codec, err := schema.NewJSONCodec(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		v.rules = append(v.rules, check{route: rc.Match, codec: codec, required: true})
+	}
+	if cfg.OpenAPI != "" {
+		checks, err := loadOpenAPI(cfg.OpenAPI)
+		if err != nil {
+			return nil, fmt.Errorf("openapi %s: %w", cfg.OpenAPI, err)
+		}
+		v.openapi = checks
+	}
+	return v, nil
+}
+
+// FromConfig compiles the validator of every target with validation
+// configured.
+func FromConfig(targets []link.LinkTarget) (map[string]*Validator, error) {
+	validators := make(map[string]*Validator)
+	for _, t := range targets {
+		v, err := Compile(t.Validation)
+		if err != nil {
+			return nil, fmt.Errorf("target %q validation: %w", t.Name, err)
+		}
+		if v != nil {
+			validators[t.Name] = v
+		}
+	}
+	return validators, nil
+}
+
+// Validate checks body against every rule matching the request and against
+// the best matching OpenAPI operation. It returns an *Error when the body is
+// rejected. A nil validator accepts every request.
+func (v *Validator) Validate(method, reqPath string, body []byte) error {
+	if v == nil {
+		return nil
+	}
+	for _, c := range v.rules {
+		if link.MatchRoute(c.route, method, reqPath) {
+			if err := c.validate(body); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range v.openapi {
+		if link.MatchRoute(c.route, method, reqPath) {
+			// Operations are sorted by specificity; only the first match applies.
+			return c.validate(body)
+		}
+	}
+	return nil
+}
+
+func (c check) validate(body []byte) error {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		if c.required {
+			return &Error{Route: c.route, Violations: []string{"request body is required"}}
+		}
+		return nil
+	}
+	if c.codec == nil {
+		return nil
+	}
+	if err := c.codec.Validate(body); err != nil {
+		var verr *schema.ValidationError
+		if errors.As(err, &verr) {
+			return &Error{Route: c.route, Violations: verr.Strings()}
+		}
+		return &Error{Route: c.route, Violations: []string{err.Error()}}
+	}
+	return nil
+}
+
+func ruleSchema(rc link.ValidationRule) ([]byte, error) {
+	if rc.SchemaFile != "" {
+		data, err := os.ReadFile(filepath.Clean(rc.SchemaFile))
+		if err != nil {
+			return nil, fmt.Errorf("read schema: %w", err)
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse schema %s: %w", rc.SchemaFile, err)
+		}
+		return json.Marshal(doc)
+	}
+	return json.Marshal(rc.Schema)
+}
+
+// loadOpenAPI returns a check for every operation in an OpenAPI 3 document,
+// most specific path first. Operations without a JSON request body are
+// included so they still take precedence over less specific templates.
+func loadOpenAPI(file string) ([]check, error) {
+	doc, err := openapi.Load(file)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := doc.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]check, 0, len(ops))
+	for _, op := range ops {
+		pattern, wildcards := openapi.PathPattern(op.Path)
+		c := check{route: op.Method + " " + pattern, wildcards: wildcards, required: op.BodyRequired()}
+		if s := op.JSONSchema(); s != nil {
+			resolved, err := doc.Resolve(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s schema: %w", c.route, err)
+			}
+			raw, err := json.Marshal(resolved)
+			if err != nil {
+				return nil, fmt.Errorf("%s schema: %w", c.route, err)
+			}
+			if c.codec, err = schema.NewJSONCodec(string(raw)); err != nil {
+				return nil, fmt.Errorf("%s schema: %w", c.route, err)
+			}
+		}
+		checks = append(checks, c)
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].wildcards != checks[j].wildcards {
+			return checks[i].wildcards < checks[j].wildcards
+		}
+		return checks[i].route < checks[j].route
+	})
+	return checks, nil
+}
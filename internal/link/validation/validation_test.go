@@ -0,0 +1,180 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/link"
+)
+
+const testSpec = `openapi: 3.0.3
+info: {title: CRM, version: "1"}
+paths:
+  /customers:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Customer'
+  /customers/{id}:
+    patch:
+      requestBody:
+        $ref: '#/components/requestBodies/CustomerPatch'
+    get: {}
+  /customers/me:
+    patch:
+      requestBody:
+        content:
+          application/merge-patch+json:
+            schema:
+              type: object
+              required: [nickname]
+components:
+  requestBodies:
+    CustomerPatch:
+      content:
+        application/json:
+          schema:
+            type: object
+            properties:
+              email: {type: string}
+  schemas:
+    Customer:
+      type: object
+      required: [name, email]
+      properties:
+        name: {type: string}
+        email: {type: string}
+        parent: {$ref: '#/components/schemas/Customer'}
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestValidate_Rules(t *testing.T) {
+	schemaFile := writeFile(t, "order.json", `{"type":"object","required":["sku"]}`)
+	v, err := Compile(&link.ValidationConfig{Rules: []link.ValidationRule{
+		{Match: "POST /customers", Schema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"name"},
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{"type": "string"},
+			},
+		}},
+		{Match: "POST,PUT /orders/**", SchemaFile: schemaFile},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name, method, path, body string
+		wantErr                  string
+	}{
+		{"valid", "POST", "/customers", `{"name":"Ada"}`, ""},
+		{"missing field", "POST", "/customers", `{"email":"a@b"}`, "(root): missing property 'name'"},
+		{"wrong type", "POST", "/customers", `{"name":1}`, "/name: got number, want string"},
+		{"empty body", "POST", "/customers", ``, "request body is required"},
+		{"other method", "GET", "/customers", ``, ""},
+		{"schema file", "PUT", "/orders/1", `{}`, "missing property 'sku'"},
+		{"unmatched path", "POST", "/invoices", `{}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.method, tt.path, []byte(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if !strings.Contains(strings.Join(verr.Violations, "; "), tt.wantErr) {
+				t.Errorf("violations %v do not contain %q", verr.Violations, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_OpenAPI(t *testing.T) {
+	v, err := Compile(&link.ValidationConfig{OpenAPI: writeFile(t, "crm.yaml", testSpec)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name, method, path, body string
+		wantRoute                string
+	}{
+		{"valid create", "POST", "/customers", `{"name":"Ada","email":"a@b"}`, ""},
+		{"create via $ref", "POST", "/customers", `{"name":"Ada"}`, "POST /customers"},
+		{"nested property", "POST", "/customers", `{"name":"Ada","email":"a@b","parent":{"name":1,"email":"c@d"}}`, "POST /customers"},
+		{"required body", "POST", "/customers", ``, "POST /customers"},
+		{"requestBody $ref", "PATCH", "/customers/42", `{"email":1}`, "PATCH /customers/*"},
+		{"optional body", "PATCH", "/customers/42", ``, ""},
+		{"literal path wins", "PATCH", "/customers/me", `{"email":1}`, "PATCH /customers/me"},
+		{"no request body", "GET", "/customers/42", `{"anything":true}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.method, tt.path, []byte(tt.body))
+			if tt.wantRoute == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var verr *Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			if verr.Route != tt.wantRoute {
+				t.Errorf("expected route %q, got %q", tt.wantRoute, verr.Route)
+			}
+		})
+	}
+}
+
+func TestValidate_NilValidator(t *testing.T) {
+	var v *Validator
+	if err := v.Validate("POST", "/x", []byte("not json")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFromConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *link.ValidationConfig
+	}{
+		{"missing schema file", &link.ValidationConfig{Rules: []link.ValidationRule{{Match: "POST /x", SchemaFile: "/nonexistent.json"}}}},
+		{"missing spec", &link.ValidationConfig{OpenAPI: "/nonexistent.yaml"}},
+		{"remote ref", &link.ValidationConfig{OpenAPI: writeFile(t, "remote.yaml", `paths:
+  /x:
+    post:
+      requestBody: {$ref: 'https://example.com/body.yaml'}
+`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromConfig([]link.LinkTarget{{Name: "crm", Validation: tt.cfg}})
+			if err == nil || !strings.Contains(err.Error(), `target "crm" validation`) {
+				t.Fatalf("expected target validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package lru provides a least recently used cache of expiring entries.
+package lru
+
+import (
+	"container/list"
+	"time"
+)
+
+// Cache holds up to a fixed number of keys with their value and expiry,
+// evicting the least recently used key when it is full. It is not safe for
+// concurrent use.
+type Cache struct {
+	size    int
+	order   *list.List // front: most recently used
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// New creates a Cache of size keys.
+func New(size int) *Cache {
+	return &Cache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the value of key if it is held and not expired at now. An
+// expired key is removed.
+func (c *Cache) Get(key string, now time.Time) (interface{}, bool) {
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !now.Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Put adds or refreshes key, evicting the least recently used key when the
+// cache is full.
+func (c *Cache) Put(key string, value interface{}, expires time.Time) {
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Len returns the number of keys held, including expired ones not yet
+// removed.
+func (c *Cache) Len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,48 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Unix(1000, 0)
+	expires := now.Add(time.Minute)
+	c := New(2)
+	c.Put("a", 1, expires)
+	c.Put("b", 2, expires)
+	if _, ok := c.Get("a", now); !ok {
+		t.Fatal("expected a to be held")
+	}
+	c.Put("c", 3, expires)
+
+	if _, ok := c.Get("b", now); ok {
+		t.Error("expected b, the least recently used key, to be evicted")
+	}
+	if v, ok := c.Get("a", now); !ok || v != 1 {
+		t.Errorf("Get(a) = %v, %v", v, ok)
+	}
+	if v, ok := c.Get("c", now); !ok || v != 3 {
+		t.Errorf("Get(c) = %v, %v", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New(2)
+	c.Put("a", 1, now.Add(time.Second))
+	c.Put("a", 2, now.Add(time.Minute))
+
+	if v, ok := c.Get("a", now.Add(2*time.Second)); !ok || v != 2 {
+		t.Errorf("expected the refreshed value, got %v, %v", v, ok)
+	}
+	if _, ok := c.Get("a", now.Add(time.Minute)); ok {
+		t.Error("expected a to expire")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected the expired key to be removed, Len() = %d", c.Len())
+	}
+}
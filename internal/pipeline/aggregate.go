@@ -0,0 +1,532 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// Aggregation windows.
+const (
+	WindowTumbling = "tumbling" // fixed windows of Size, aligned to multiples of Size
+	WindowSession  = "session"  // closes once its key had no events for Size
+)
+
+// Aggregate defaults.
+const (
+	DefaultAggregateMaxKeys   = 10000
+	DefaultAggregateMaxEvents = 1000
+)
+
+// Headers of aggregated events.
+const (
+	HeaderWindowStart = "fiso-window-start"
+	HeaderWindowEnd   = "fiso-window-end"
+	HeaderWindowCount = "fiso-window-count"
+)
+
+// AggregateSpec configures an Aggregate. Key and the arguments of reducers
+// are CEL expressions with the variables of CloudEvents overrides,
+// evaluated against the transformed event.
+type AggregateSpec struct {
+	Key         string
+	Window      string            // WindowTumbling (default) or WindowSession
+	Size        time.Duration     // Length of tumbling windows, or the gap that closes a session
+	MaxDuration time.Duration     // Optional: sessions close at most this long after their first event
+	Fields      map[string]string // Reducers of the output fields; empty: the output is the latest event
+	MaxKeys     int               // Open windows (default: DefaultAggregateMaxKeys)
+	MaxEvents   int               // Events per window (default: DefaultAggregateMaxEvents)
+	Functions   []celext.Option   // Optional: configures the fiso CEL functions
+}
+
+// Aggregate collapses the events of each key into windows and delivers one
+// event per window when it closes. Windows use processing time. State is
+// bounded: a window that reaches MaxEvents closes early, and when MaxKeys
+// windows are open, the one due first closes to make room for a new key.
+//
+// The fields of the delivered event are reducers over the events of the
+// window: last(expr), count(), sum(expr) and collect(expr).
+type Aggregate struct {
+	spec     AggregateSpec
+	key      cel.Program
+	reducers []reducer
+	now      func() time.Time
+}
+
+type reducer struct {
+	field string
+	kind  string      // last, count, sum or collect
+	arg   cel.Program // nil for count
+}
+
+var reducerPattern = regexp.MustCompile(`^\s*(last|count|sum|collect)\s*\((.*)\)\s*$`)
+
+// NewAggregate compiles the key and reducers of spec.
+func NewAggregate(spec AggregateSpec) (*Aggregate, error) {
+	switch spec.Window {
+	case "":
+		spec.Window = WindowTumbling
+	case WindowTumbling, WindowSession:
+	default:
+		return nil, fmt.Errorf("unknown window %q", spec.Window)
+	}
+	if spec.Key == "" {
+		return nil, errors.New("key is required")
+	}
+	if spec.Size <= 0 {
+		return nil, errors.New("size must be positive")
+	}
+	if spec.MaxKeys <= 0 {
+		spec.MaxKeys = DefaultAggregateMaxKeys
+	}
+	if spec.MaxEvents <= 0 {
+		spec.MaxEvents = DefaultAggregateMaxEvents
+	}
+
+	env, err := newOverrideEnv(spec.Functions...)
+	if err != nil {
+		return nil, err
+	}
+	compile := func(expr string) (cel.Program, error) {
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("compile %q: %w", expr, issues.Err())
+		}
+		return env.Program(ast)
+	}
+
+	a := &Aggregate{spec: spec, now: time.Now}
+	if a.key, err = compile(spec.Key); err != nil {
+		return nil, fmt.Errorf("key: %w", err)
+	}
+
+	fields := make([]string, 0, len(spec.Fields))
+	for field := range spec.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		r, err := parseReducer(field, spec.Fields[field], compile)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field, err)
+		}
+		a.reducers = append(a.reducers, r)
+	}
+	return a, nil
+}
+
+func parseReducer(field, expr string, compile func(string) (cel.Program, error)) (reducer, error) {
+	m := reducerPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return reducer{}, fmt.Errorf("%q is not last(expr), count(), sum(expr) or collect(expr)", expr)
+	}
+	r := reducer{field: field, kind: m[1]}
+	arg := m[2]
+	if r.kind == "count" {
+		if arg != "" {
+			return reducer{}, errors.New("count() takes no argument")
+		}
+		return r, nil
+	}
+	if arg == "" {
+		return reducer{}, fmt.Errorf("%s() requires an expression", r.kind)
+	}
+	prg, err := compile(arg)
+	if err != nil {
+		return reducer{}, err
+	}
+	r.arg = prg
+	return r, nil
+}
+
+// eval returns the key of an event and the arguments of its reducers.
+func (a *Aggregate) eval(payload []byte, evt source.Event) (string, []interface{}, error) {
+	var parsed map[string]interface{}
+	_ = json.Unmarshal(payload, &parsed)
+	vars := celVars(parsed, evt)
+
+	out, _, err := a.key.Eval(vars)
+	if err != nil {
+		return "", nil, fmt.Errorf("aggregate key: %w", err)
+	}
+	var key string
+	switch v := toNative(out).(type) {
+	case string:
+		key = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("aggregate key: %w", err)
+		}
+		key = string(b)
+	}
+
+	args := make([]interface{}, len(a.reducers))
+	for i, r := range a.reducers {
+		if r.arg == nil {
+			continue
+		}
+		out, _, err := r.arg.Eval(vars)
+		if err != nil {
+			return "", nil, fmt.Errorf("aggregate field %s: %w", r.field, err)
+		}
+		args[i] = toNative(out)
+		if r.kind == "sum" {
+			if _, ok := number(args[i]); !ok && args[i] != nil {
+				return "", nil, fmt.Errorf("aggregate field %s: sum of %T", r.field, args[i])
+			}
+		}
+	}
+	return key, args, nil
+}
+
+// number returns v as a float64 if it is a number.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// window holds the events of one key.
+type window struct {
+	key        string
+	seq        uint64 // order in which windows were opened
+	start      time.Time
+	end        time.Time // when the window closes
+	deadline   time.Time // sessions: latest end; zero: none
+	count      int
+	values     []interface{} // per reducer
+	last       source.Event
+	staged     *stagedEvent
+	held       []position
+	dedupeKeys []string
+}
+
+type position struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+func eventPosition(evt source.Event) position {
+	return position{evt.Topic, evt.Partition, evt.Offset}
+}
+
+// reduce adds the reducer arguments of an event to w.
+func (a *Aggregate) reduce(w *window, args []interface{}) {
+	w.count++
+	for i, r := range a.reducers {
+		switch r.kind {
+		case "last":
+			w.values[i] = args[i]
+		case "count":
+			w.values[i] = int64(w.count)
+		case "sum":
+			w.values[i] = add(w.values[i], args[i])
+		case "collect":
+			list, _ := w.values[i].([]interface{})
+			w.values[i] = append(list, args[i])
+		}
+	}
+}
+
+// add sums numbers, keeping integer sums as integers. nil counts as zero.
+func add(sum, v interface{}) interface{} {
+	if v == nil {
+		if sum == nil {
+			return int64(0)
+		}
+		return sum
+	}
+	if sum == nil {
+		sum = int64(0)
+	}
+	if x, ok := sum.(int64); ok {
+		if y, ok := v.(int64); ok {
+			return x + y
+		}
+	}
+	x, _ := number(sum)
+	y, _ := number(v)
+	return x + y
+}
+
+// output returns the payload delivered for w.
+func (a *Aggregate) output(w *window) ([]byte, error) {
+	if len(a.reducers) == 0 {
+		return w.staged.payload, nil
+	}
+	out := make(map[string]interface{}, len(a.reducers))
+	for i, r := range a.reducers {
+		out[r.field] = w.values[i]
+	}
+	return json.Marshal(out)
+}
+
+// aggregator holds the open windows of a pipeline. It is the
+// source.RetainingHandler of sources that acknowledge batches, so events
+// are acknowledged once the window they belong to was delivered. Events of
+// other sources are acknowledged once they are added to a window, and the
+// open windows are delivered when the pipeline stops: they are delivered at
+// most once, as a crash or a failed delivery loses them.
+type aggregator struct {
+	p   *Pipeline
+	agg *Aggregate
+	// ctx is used for flushes not tied to a source call.
+	ctx  context.Context
+	wake chan struct{}
+
+	mu      sync.Mutex
+	windows map[string]*window
+	closed  []*window // closed early, waiting for the next flush
+	held    map[position]int
+	opened  uint64 // windows opened so far
+}
+
+func newAggregator(ctx context.Context, p *Pipeline, agg *Aggregate) *aggregator {
+	return &aggregator{
+		p:       p,
+		agg:     agg,
+		ctx:     context.WithoutCancel(ctx),
+		wake:    make(chan struct{}, 1),
+		windows: make(map[string]*window),
+		held:    make(map[position]int),
+	}
+}
+
+// Add implements source.BatchHandler.
+func (a *aggregator) Add(ctx context.Context, evt source.Event) (bool, error) {
+	st, err := a.p.stage(ctx, evt, eventCorrelationID(evt))
+	if st == nil {
+		return false, a.p.reportFailure(evt, err)
+	}
+	key, args, err := a.agg.eval(st.payload, evt)
+	if err != nil {
+		a.p.settle(st.dedupeKeys, false)
+		return false, a.p.reportFailure(evt, a.p.handleFailure(ctx, evt, "AGGREGATE_FAILED", err))
+	}
+
+	a.mu.Lock()
+	full := a.addLocked(key, args, evt, st, a.agg.now())
+	a.mu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	return full, nil
+}
+
+// addLocked adds an event to the window of key and reports whether a
+// window closed early.
+func (a *aggregator) addLocked(key string, args []interface{}, evt source.Event, st *stagedEvent, now time.Time) bool {
+	spec := a.agg.spec
+	w, ok := a.windows[key]
+	if !ok {
+		if len(a.windows) >= spec.MaxKeys {
+			a.evictLocked()
+		}
+		a.opened++
+		w = &window{key: key, seq: a.opened, start: now, values: make([]interface{}, len(a.agg.reducers))}
+		if spec.Window == WindowTumbling {
+			w.end = now.Truncate(spec.Size).Add(spec.Size)
+		} else if spec.MaxDuration > 0 {
+			w.deadline = now.Add(spec.MaxDuration)
+		}
+		a.windows[key] = w
+	}
+
+	a.agg.reduce(w, args)
+	w.last, w.staged = evt, st
+	w.dedupeKeys = append(w.dedupeKeys, st.dedupeKeys...)
+	pos := eventPosition(evt)
+	w.held = append(w.held, pos)
+	a.held[pos]++
+
+	if spec.Window == WindowSession {
+		w.end = now.Add(spec.Size)
+		if !w.deadline.IsZero() && w.deadline.Before(w.end) {
+			w.end = w.deadline
+		}
+	}
+	if w.count >= spec.MaxEvents {
+		delete(a.windows, key)
+		a.closed = append(a.closed, w)
+	}
+	return len(a.closed) > 0
+}
+
+// evictLocked closes the window that is due first, or the oldest of the
+// windows due first.
+func (a *aggregator) evictLocked() {
+	var first *window
+	for _, w := range a.windows {
+		if first == nil || w.end.Before(first.end) || (w.end.Equal(first.end) && w.seq < first.seq) {
+			first = w
+		}
+	}
+	if first != nil {
+		delete(a.windows, first.key)
+		a.closed = append(a.closed, first)
+	}
+}
+
+// Due implements source.BatchHandler.
+func (a *aggregator) Due() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.closed) > 0 {
+		return a.agg.now()
+	}
+	var due time.Time
+	for _, w := range a.windows {
+		if due.IsZero() || w.end.Before(due) {
+			due = w.end
+		}
+	}
+	return due
+}
+
+// Retains implements source.RetainingHandler.
+func (a *aggregator) Retains(evt source.Event) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.held[eventPosition(evt)] > 0
+}
+
+// Flush implements source.BatchHandler. It delivers the windows that
+// closed.
+func (a *aggregator) Flush(ctx context.Context) error {
+	return a.flush(ctx, a.take(false))
+}
+
+func (a *aggregator) flush(ctx context.Context, windows []*window) error {
+	var errs []error
+	for _, w := range windows {
+		if err := a.deliver(ctx, w); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	if err == nil {
+		return nil
+	}
+	a.p.logger.Error("aggregate delivery failed", "flow", a.p.config.FlowName, "error", err)
+	if a.p.config.PropagateErrors {
+		return err
+	}
+	return nil
+}
+
+// take removes and returns the closed windows, or all windows.
+func (a *aggregator) take(all bool) []*window {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.agg.now()
+	windows := a.closed
+	a.closed = nil
+	for key, w := range a.windows {
+		if all || !now.Before(w.end) {
+			windows = append(windows, w)
+			delete(a.windows, key)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].seq < windows[j].seq })
+	return windows
+}
+
+// deliver delivers the event of w. Its failure is handled like that of
+// the latest event of the window, with the aggregated payload.
+func (a *aggregator) deliver(ctx context.Context, w *window) error {
+	defer a.release(w)
+
+	evt := w.last
+	payload, err := a.agg.output(w)
+	if err != nil {
+		a.p.settle(w.dedupeKeys, false)
+		return a.p.handleFailure(ctx, evt, "AGGREGATE_FAILED", err)
+	}
+	evt.Value = payload
+
+	st := *w.staged
+	st.payload = payload
+	st.dedupeKeys = w.dedupeKeys
+	st.headers = make(map[string]string, len(w.staged.headers)+3)
+	for k, v := range w.staged.headers {
+		st.headers[k] = v
+	}
+	st.headers[HeaderWindowStart] = w.start.UTC().Format(time.RFC3339Nano)
+	st.headers[HeaderWindowEnd] = w.end.UTC().Format(time.RFC3339Nano)
+	st.headers[HeaderWindowCount] = fmt.Sprint(w.count)
+
+	return a.p.deliver(ctx, evt, &st, time.Now())
+}
+
+// release stops retaining the events of a delivered window.
+func (a *aggregator) release(w *window) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, pos := range w.held {
+		if a.held[pos]--; a.held[pos] <= 0 {
+			delete(a.held, pos)
+		}
+	}
+}
+
+// run delivers windows as they close, for sources that do not acknowledge
+// batches. Once ctx is done, it delivers the open windows and returns.
+func (a *aggregator) run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := time.Hour
+		if due := a.Due(); !due.IsZero() {
+			wait = due.Sub(a.agg.now())
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			_ = a.flush(a.ctx, a.take(true))
+			return
+		case <-a.wake:
+		case <-timer.C:
+			_ = a.Flush(a.ctx)
+		}
+	}
+}
+
+// start runs the source for an aggregating pipeline.
+func (a *aggregator) start(ctx context.Context) error {
+	if bs, ok := a.p.source.(source.BatchSource); ok {
+		return bs.StartBatch(ctx, a)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.run(runCtx)
+	}()
+	err := a.p.source.Start(ctx, func(ctx context.Context, evt source.Event) error {
+		_, err := a.Add(ctx, evt)
+		return err
+	})
+	cancel()
+	<-done
+	return err
+}
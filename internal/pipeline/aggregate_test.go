@@ -0,0 +1,262 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/source"
+)
+
+func mustAdd(t *testing.T, a *aggregator, offset int64, value string) bool {
+	t.Helper()
+	full, err := a.Add(context.Background(), source.Event{Topic: "orders", Offset: offset, Value: []byte(value)})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	return full
+}
+
+// deliveredData returns the CloudEvent data of the events received by sk.
+func deliveredData(t *testing.T, sk *mockSink) []map[string]interface{} {
+	t.Helper()
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+	var out []map[string]interface{}
+	for _, m := range sk.received {
+		var ce struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(m.event, &ce); err != nil {
+			t.Fatalf("unmarshal delivered event: %v", err)
+		}
+		out = append(out, ce.Data)
+	}
+	return out
+}
+
+func TestAggregator_Tumbling(t *testing.T) {
+	now := time.Unix(1000, 0)
+	sk := &mockSink{}
+	a := newTestAggregator(t, AggregateSpec{
+		Key:  "data.user",
+		Size: time.Minute,
+		Fields: map[string]string{
+			"user":   "last(data.user)",
+			"orders": "count()",
+			"total":  "sum(data.qty)",
+			"items":  "collect(data.item)",
+		},
+	}, sk, &mockPublisher{}, &now)
+
+	mustAdd(t, a, 1, `{"user":"a","qty":2,"item":"x"}`)
+	mustAdd(t, a, 2, `{"user":"b","qty":1,"item":"y"}`)
+	now = now.Add(10 * time.Second)
+	mustAdd(t, a, 3, `{"user":"a","qty":3,"item":"z"}`)
+
+	end := time.Unix(1020, 0) // the next multiple of a minute
+	if due := a.Due(); !due.Equal(end) {
+		t.Errorf("Due() = %v, want %v", due, end)
+	}
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if sk.count() != 0 {
+		t.Fatalf("expected no delivery before the window ends, got %d", sk.count())
+	}
+	if !a.Retains(source.Event{Topic: "orders", Offset: 1}) {
+		t.Error("expected offset 1 to be retained while its window is open")
+	}
+
+	now = end
+	if err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	data := deliveredData(t, sk)
+	if len(data) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(data))
+	}
+	byUser := map[string]map[string]interface{}{}
+	for _, d := range data {
+		byUser[d["user"].(string)] = d
+	}
+	if got := byUser["a"]; got["orders"] != float64(2) || got["total"] != float64(5) || len(got["items"].([]interface{})) != 2 {
+		t.Errorf("window a = %v", got)
+	}
+	if got := byUser["b"]; got["orders"] != float64(1) || got["total"] != float64(1) {
+		t.Errorf("window b = %v", got)
+	}
+	if h := sk.received[0].headers; h[HeaderWindowEnd] != "1970-01-01T00:17:00Z" || h[HeaderWindowCount] == "" {
+		t.Errorf("window headers = %v", h)
+	}
+	if a.Retains(source.Event{Topic: "orders", Offset: 1}) || !a.Due().IsZero() {
+		t.Error("expected nothing retained after the windows were delivered")
+	}
+}
+
+func TestAggregator_Debounce(t *testing.T) {
+	now := time.Unix(1000, 0)
+	sk := &mockSink{}
+	a := newTestAggregator(t, AggregateSpec{
+		Key:         "data.id",
+		Window:      WindowSession,
+		Size:        5 * time.Second,
+		MaxDuration: 12 * time.Second,
+	}, sk, &mockPublisher{}, &now)
+
+	mustAdd(t, a, 1, `{"id":"a","v":1}`)
+	now = now.Add(3 * time.Second)
+	mustAdd(t, a, 2, `{"id":"a","v":2}`)
+
+	now = now.Add(4 * time.Second) // 7s: 4s of quiet
+	_ = a.Flush(context.Background())
+	if sk.count() != 0 {
+		t.Fatalf("expected no delivery before 5s of quiet, got %d", sk.count())
+	}
+	now = now.Add(time.Second)
+	_ = a.Flush(context.Background())
+	data := deliveredData(t, sk)
+	if len(data) != 1 || data[0]["v"] != float64(2) {
+		t.Fatalf("expected the latest event, got %v", data)
+	}
+
+	// A steady stream is emitted once the session reaches MaxDuration.
+	start := now
+	for i := int64(0); i < 5; i++ {
+		mustAdd(t, a, 10+i, `{"id":"a","v":3}`)
+		now = now.Add(3 * time.Second)
+	}
+	if due := a.Due(); !due.Equal(start.Add(12 * time.Second)) {
+		t.Errorf("Due() = %v, want %v", due, start.Add(12*time.Second))
+	}
+}
+
+func TestAggregator_Bounds(t *testing.T) {
+	now := time.Unix(1000, 0)
+	sk := &mockSink{}
+	a := newTestAggregator(t, AggregateSpec{
+		Key:       "data.id",
+		Size:      time.Hour,
+		MaxKeys:   2,
+		MaxEvents: 2,
+		Fields:    map[string]string{"n": "count()"},
+	}, sk, &mockPublisher{}, &now)
+
+	if mustAdd(t, a, 1, `{"id":"a"}`) {
+		t.Error("expected room for the first event")
+	}
+	if !mustAdd(t, a, 2, `{"id":"a"}`) {
+		t.Error("expected the window to close at MaxEvents")
+	}
+	_ = a.Flush(context.Background())
+	if sk.count() != 1 {
+		t.Fatalf("expected the full window to be delivered, got %d", sk.count())
+	}
+
+	mustAdd(t, a, 3, `{"id":"b"}`)
+	mustAdd(t, a, 4, `{"id":"c"}`)
+	if !mustAdd(t, a, 5, `{"id":"d"}`) {
+		t.Error("expected a window to close at MaxKeys")
+	}
+	_ = a.Flush(context.Background())
+	if sk.count() != 2 {
+		t.Fatalf("expected the evicted window to be delivered, got %d", sk.count())
+	}
+	if a.Retains(source.Event{Topic: "orders", Offset: 3}) || !a.Retains(source.Event{Topic: "orders", Offset: 4}) {
+		t.Error("expected only the evicted window to be released")
+	}
+}
+
+func TestAggregator_Failures(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"key error", `{"qty":1}`},
+		{"sum of a string", `{"id":"a","qty":"one"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			pub := &mockPublisher{}
+			a := newTestAggregator(t, AggregateSpec{
+				Key:    "data.id",
+				Size:   time.Minute,
+				Fields: map[string]string{"total": "sum(data.qty)"},
+			}, &mockSink{}, pub, &now)
+
+			mustAdd(t, a, 1, tt.value)
+			if pub.count() != 1 {
+				t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+			}
+			if code := pub.published[0].headers["fiso-error-code"]; code != "AGGREGATE_FAILED" {
+				t.Errorf("expected AGGREGATE_FAILED, got %s", code)
+			}
+			if !a.Due().IsZero() {
+				t.Error("expected no open window")
+			}
+		})
+	}
+}
+
+func TestPipeline_Aggregate_DeliversOpenWindowsOnStop(t *testing.T) {
+	agg, err := NewAggregate(AggregateSpec{Key: "data.id", Size: time.Hour, Fields: map[string]string{"n": "count()"}})
+	if err != nil {
+		t.Fatalf("NewAggregate: %v", err)
+	}
+	sk := &mockSink{}
+	runEvents(t, Config{FlowName: "orders", Aggregate: agg}, sk, &mockPublisher{},
+		`{"id":"a"}`, `{"id":"a"}`, `{"id":"b"}`)
+
+	data := deliveredData(t, sk)
+	if len(data) != 2 || data[0]["n"] != float64(2) || data[1]["n"] != float64(1) {
+		t.Errorf("delivered = %v", data)
+	}
+}
+
+func TestPipeline_Aggregate_BatchSource(t *testing.T) {
+	agg, err := NewAggregate(AggregateSpec{Key: "data.id", Size: time.Hour, MaxEvents: 2})
+	if err != nil {
+		t.Fatalf("NewAggregate: %v", err)
+	}
+	src := &mockBatchSource{mockSource: mockSource{events: []source.Event{
+		{Value: []byte(`{"id":"a","v":1}`), Offset: 1},
+		{Value: []byte(`{"id":"a","v":2}`), Offset: 2},
+		{Value: []byte(`{"id":"b","v":3}`), Offset: 3},
+	}}}
+	sk := &mockSink{}
+	p := New(Config{FlowName: "orders", Aggregate: agg}, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Window a filled up; window b is still open and retained.
+	data := deliveredData(t, sk)
+	if len(data) != 1 || data[0]["v"] != float64(2) {
+		t.Errorf("delivered = %v", data)
+	}
+}
+
+func TestNewAggregate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec AggregateSpec
+	}{
+		{"no key", AggregateSpec{Size: time.Second}},
+		{"no size", AggregateSpec{Key: "data.id"}},
+		{"unknown window", AggregateSpec{Key: "data.id", Size: time.Second, Window: "sliding"}},
+		{"invalid key", AggregateSpec{Key: "data.id +", Size: time.Second}},
+		{"unknown reducer", AggregateSpec{Key: "data.id", Size: time.Second, Fields: map[string]string{"x": "avg(data.v)"}}},
+		{"count argument", AggregateSpec{Key: "data.id", Size: time.Second, Fields: map[string]string{"x": "count(data.v)"}}},
+		{"missing argument", AggregateSpec{Key: "data.id", Size: time.Second, Fields: map[string]string{"x": "sum()"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAggregate(tt.spec); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lsm/fiso/internal/sink"
+	"github.com/lsm/fiso/internal/source"
+)
+
+// Batch defaults.
+const (
+	DefaultBatchMaxEvents = 100
+	DefaultBatchMaxWait   = time.Second
+)
+
+// Batch groups events for delivery. A batch is flushed once it holds
+// MaxEvents events or MaxBytes bytes of events, or MaxWait after its first
+// event was added. Sinks implementing sink.BatchSink receive the batch in
+// one call; other sinks receive its events one at a time.
+type Batch struct {
+	MaxEvents int           // default: 100
+	MaxBytes  int           // 0: no limit
+	MaxWait   time.Duration // default: 1s
+}
+
+// batchEntry is a prepared event waiting in a batch.
+type batchEntry struct {
+	evt        source.Event
+	msg        sink.Message
+	dedupeKeys []string
+	// result receives the outcome of delivering the event; nil for events
+	// from a source.BatchSource, which learns it from Flush.
+	result chan error
+	// ctx is that of the handler call waiting for result, if any.
+	ctx context.Context
+}
+
+// batcher accumulates prepared events. It is the source.BatchHandler of
+// sources that acknowledge batches, and buffers events of other sources
+// on behalf of their handler calls, flushing on a timer.
+type batcher struct {
+	p      *Pipeline
+	config Batch
+	// ctx is used for flushes not tied to a source call: timer flushes
+	// and flushes of batches filled by concurrent requests.
+	ctx context.Context
+
+	mu      sync.Mutex
+	entries []batchEntry
+	size    int
+	due     time.Time
+}
+
+func newBatcher(ctx context.Context, p *Pipeline, cfg Batch) *batcher {
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultBatchMaxEvents
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = DefaultBatchMaxWait
+	}
+	return &batcher{p: p, config: cfg, ctx: context.WithoutCancel(ctx)}
+}
+
+// Add implements source.BatchHandler.
+func (b *batcher) Add(ctx context.Context, evt source.Event) (bool, error) {
+	msg, dedupeKeys, err := b.p.prepare(ctx, evt, eventCorrelationID(evt))
+	if msg == nil {
+		return false, b.p.reportFailure(evt, err)
+	}
+	full, _ := b.add(batchEntry{evt: evt, msg: *msg, dedupeKeys: dedupeKeys})
+	return full, nil
+}
+
+// Due implements source.BatchHandler.
+func (b *batcher) Due() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.due
+}
+
+// Flush implements source.BatchHandler.
+func (b *batcher) Flush(ctx context.Context) error {
+	err := b.p.deliverBatch(ctx, b.take())
+	if err == nil {
+		return nil
+	}
+	b.p.logger.Error("batch delivery failed", "flow", b.p.config.FlowName, "error", err)
+	if b.p.config.PropagateErrors {
+		return err
+	}
+	return nil
+}
+
+// submit adds evt to the batch and waits until the batch is delivered. It
+// serves sources that call their handler concurrently and reply to each
+// event, such as HTTP.
+func (b *batcher) submit(ctx context.Context, evt source.Event) error {
+	msg, dedupeKeys, err := b.p.prepare(ctx, evt, eventCorrelationID(evt))
+	if msg == nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	full, first := b.add(batchEntry{evt: evt, msg: *msg, dedupeKeys: dedupeKeys, result: result, ctx: ctx})
+	switch {
+	case full:
+		_ = b.p.deliverBatch(b.ctx, b.take())
+	case first:
+		time.AfterFunc(b.config.MaxWait, b.flushDue)
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flushDue flushes the batch if its MaxWait has passed. Timers of batches
+// that filled up before their MaxWait find a newer batch, or none, and
+// leave it alone.
+func (b *batcher) flushDue() {
+	b.mu.Lock()
+	if b.due.IsZero() || time.Now().Before(b.due) {
+		b.mu.Unlock()
+		return
+	}
+	entries := b.takeLocked()
+	b.mu.Unlock()
+	_ = b.p.deliverBatch(b.ctx, entries)
+}
+
+// add buffers e and reports whether the batch is now full and whether e
+// started it.
+func (b *batcher) add(e batchEntry) (full, first bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	first = len(b.entries) == 0
+	if first {
+		b.due = time.Now().Add(b.config.MaxWait)
+	}
+	b.entries = append(b.entries, e)
+	b.size += len(e.msg.Event)
+	full = len(b.entries) >= b.config.MaxEvents || (b.config.MaxBytes > 0 && b.size >= b.config.MaxBytes)
+	return full, first
+}
+
+// take removes and returns the buffered events.
+func (b *batcher) take() []batchEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.takeLocked()
+}
+
+func (b *batcher) takeLocked() []batchEntry {
+	entries := b.entries
+	b.entries, b.size, b.due = nil, 0, time.Time{}
+	return entries
+}
+
+// deliverBatch delivers entries to the sink. Events the sink rejects are
+// handled like any other delivery failure, each on its own; the joined
+// errors of those failures are returned. Events whose handler call was
+// cancelled while they waited are dropped.
+func (p *Pipeline) deliverBatch(ctx context.Context, entries []batchEntry) error {
+	live := entries[:0]
+	for _, e := range entries {
+		if e.ctx != nil && e.ctx.Err() != nil {
+			p.settle(e.dedupeKeys, false)
+			e.result <- e.ctx.Err()
+			continue
+		}
+		live = append(live, e)
+	}
+	entries = live
+	if len(entries) == 0 {
+		return nil
+	}
+	start := time.Now()
+
+	errs := make([]error, len(entries))
+	if bs, ok := p.sink.(sink.BatchSink); ok {
+		msgs := make([]sink.Message, len(entries))
+		for i, e := range entries {
+			msgs[i] = e.msg
+		}
+		err := bs.DeliverBatch(ctx, msgs)
+		var berr *sink.BatchError
+		switch {
+		case err == nil:
+		case errors.As(err, &berr) && len(berr.Errors) == len(entries):
+			copy(errs, berr.Errors)
+		default:
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+	} else {
+		for i, e := range entries {
+			errs[i] = p.sink.Deliver(ctx, e.msg.Event, e.msg.Headers)
+		}
+	}
+
+	var failed int
+	var unhandled []error
+	for i, e := range entries {
+		err := errs[i]
+		if err != nil {
+			failed++
+			err = p.handleFailure(ctx, e.evt, "SINK_DELIVERY_FAILED", err)
+		}
+		p.settle(e.dedupeKeys, errs[i] == nil)
+		if e.result != nil {
+			e.result <- err
+		}
+		if err != nil {
+			unhandled = append(unhandled, err)
+		}
+	}
+
+	p.logger.Info("batch delivered",
+		"flow", p.config.FlowName,
+		"events", len(entries),
+		"failed", failed,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+	return errors.Join(unhandled...)
+}
@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/delivery"
+	"github.com/lsm/fiso/internal/dlq"
+)
+
+func TestPipeline_Batch_FlushesWhenFull(t *testing.T) {
+	src := &concurrentSource{events: numberedEvents(3), done: make(chan struct{})}
+	sk := &mockBatchSink{}
+	p := New(Config{FlowName: "batched", Batch: &Batch{MaxEvents: 3, MaxWait: time.Minute}},
+		src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	if got := fmt.Sprint(sk.batchSizes()); got != "[3]" {
+		t.Fatalf("batch sizes = %s, want [3]", got)
+	}
+	for key, err := range src.results {
+		if err != nil {
+			t.Errorf("event %s: unexpected error %v", key, err)
+		}
+	}
+	if sk.batches[0][0].Headers["Content-Type"] != "application/cloudevents+json" {
+		t.Errorf("expected CloudEvent headers, got %v", sk.batches[0][0].Headers)
+	}
+}
+
+func TestPipeline_Batch_FlushesOnMaxWait(t *testing.T) {
+	src := &concurrentSource{events: numberedEvents(2), done: make(chan struct{})}
+	sk := &mockBatchSink{}
+	p := New(Config{FlowName: "batched", Batch: &Batch{MaxEvents: 10, MaxWait: 20 * time.Millisecond}},
+		src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	start := time.Now()
+	runUntil(t, p, src.done)
+
+	if got := fmt.Sprint(sk.batchSizes()); got != "[2]" {
+		t.Fatalf("batch sizes = %s, want [2]", got)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("batch flushed after %v, before maxWait", elapsed)
+	}
+}
+
+func TestPipeline_Batch_DropsCancelledEvents(t *testing.T) {
+	sk := &mockSink{}
+	p := New(Config{FlowName: "batched"}, &mockSource{}, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	b := newBatcher(context.Background(), p, Batch{MaxEvents: 2, MaxWait: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	evts := numberedEvents(2)
+	if err := b.submit(ctx, evts[0]); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled submit: expected context.Canceled, got %v", err)
+	}
+	if err := b.submit(context.Background(), evts[1]); err != nil {
+		t.Fatalf("submit: unexpected error %v", err)
+	}
+
+	if sk.count() != 1 {
+		t.Fatalf("expected only the live event delivered, got %d", sk.count())
+	}
+}
+
+func TestPipeline_Batch_FlushesOnMaxBytes(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: numberedEvents(3)}}
+	sk := &mockBatchSink{}
+	// Each wrapped event is well over 100 bytes.
+	p := New(Config{FlowName: "batched", Batch: &Batch{MaxEvents: 10, MaxBytes: 100}},
+		src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	_ = p.Run(context.Background())
+
+	if got := fmt.Sprint(sk.batchSizes()); got != "[1 1 1]" {
+		t.Fatalf("batch sizes = %s, want [1 1 1]", got)
+	}
+}
+
+func TestPipeline_Batch_PartialFailure(t *testing.T) {
+	src := &concurrentSource{events: numberedEvents(3), done: make(chan struct{})}
+	sk := &mockBatchSink{fail: map[int]bool{1: true}}
+	pub := &mockPublisher{}
+	p := New(Config{FlowName: "batched", SourceType: "http", PropagateErrors: true, Batch: &Batch{MaxEvents: 3, MaxWait: time.Minute}},
+		src, nil, sk, dlq.NewHandler(pub), nil)
+
+	runUntil(t, p, src.done)
+
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	if pub.published[0].headers["fiso-error-code"] != "SINK_DELIVERY_FAILED" {
+		t.Errorf("expected SINK_DELIVERY_FAILED, got %s", pub.published[0].headers["fiso-error-code"])
+	}
+	var failed int
+	for _, err := range src.results {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected 1 failed request, got %d (%v)", failed, src.results)
+	}
+}
+
+func TestPipeline_Batch_KafkaSinkOrDLQ_AcksBatch(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: numberedEvents(3)}}
+	sk := &mockBatchSink{fail: map[int]bool{0: true, 2: true}}
+	pub := &mockPublisher{}
+	p := New(Config{
+		FlowName:        "batched",
+		SourceType:      "kafka",
+		PropagateErrors: true,
+		CommitPolicy:    delivery.CommitPolicySinkOrDLQ,
+		Batch:           &Batch{MaxEvents: 3},
+	}, src, nil, sk, dlq.NewHandler(pub), nil)
+
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pub.count() != 2 {
+		t.Fatalf("expected 2 DLQ events, got %d", pub.count())
+	}
+	if string(pub.published[0].key) != "k0" || string(pub.published[1].key) != "k2" {
+		t.Errorf("unexpected DLQ events: %s, %s", pub.published[0].key, pub.published[1].key)
+	}
+	if fmt.Sprint(src.flushErrs) != "[<nil>]" {
+		t.Errorf("expected the batch to be acknowledged, got %v", src.flushErrs)
+	}
+}
+
+func TestPipeline_Batch_KafkaStrictPolicy_FailsBatch(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: numberedEvents(2)}}
+	sk := &mockBatchSink{fail: map[int]bool{1: true}}
+	pub := &mockPublisher{}
+	p := New(Config{
+		FlowName:        "batched",
+		SourceType:      "kafka",
+		PropagateErrors: true,
+		CommitPolicy:    delivery.CommitPolicySink,
+		Batch:           &Batch{MaxEvents: 2},
+	}, src, nil, sk, dlq.NewHandler(pub), nil)
+
+	_ = p.Run(context.Background())
+
+	if len(src.flushErrs) != 1 || src.flushErrs[0] == nil {
+		t.Fatalf("expected the flush to fail, got %v", src.flushErrs)
+	}
+	if pub.count() != 0 {
+		t.Errorf("expected 0 DLQ events for strict sink policy, got %d", pub.count())
+	}
+}
+
+func TestPipeline_Batch_NonBatchSink(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: numberedEvents(3)}}
+	sk := &mockSink{}
+	p := New(Config{FlowName: "batched", Batch: &Batch{MaxEvents: 2}},
+		src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	_ = p.Run(context.Background())
+
+	if sk.count() != 3 {
+		t.Fatalf("expected 3 events delivered one at a time, got %d", sk.count())
+	}
+}
+
+func TestPipeline_Batch_SinkErrorFailsAllEvents(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: numberedEvents(2)}}
+	sk := &mockSink{err: errors.New("sink unavailable")}
+	pub := &mockPublisher{}
+	p := New(Config{FlowName: "batched", SourceType: "kafka", Batch: &Batch{MaxEvents: 2}},
+		src, nil, sk, dlq.NewHandler(pub), nil)
+
+	_ = p.Run(context.Background())
+
+	if pub.count() != 2 {
+		t.Fatalf("expected 2 DLQ events, got %d", pub.count())
+	}
+}
@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/lsm/fiso/internal/correlation"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// DedupeStore remembers the keys of delivered events for a window.
+type DedupeStore interface {
+	Seen(key string) (bool, error)
+	Add(key string) error
+	Close() error
+}
+
+// Dedupe acknowledges events whose key was delivered within the window of
+// its store without delivering them again. Keys are recorded once an event
+// is delivered, so events that failed are processed again when redelivered.
+// A key is reserved from the time its event passes the dedupe stage until
+// the event is delivered or fails, and other events with that key arriving
+// meanwhile, such as a copy in the same batch, are dropped as duplicates.
+type Dedupe struct {
+	key   cel.Program // nil: the CloudEvent ID
+	store DedupeStore
+
+	mu       sync.Mutex
+	reserved map[string]struct{}
+}
+
+// NewDedupe compiles the key expression of a dedupe stage. key is a CEL
+// expression with the variables of CloudEvents overrides, evaluated against
+// the decoded source payload. When it is empty, the key is the CloudEvent ID
+// given by the CloudEvents id override or, for events that already are
+// CloudEvents, their id.
+func NewDedupe(key string, store DedupeStore, functions ...celext.Option) (*Dedupe, error) {
+	d := &Dedupe{store: store, reserved: make(map[string]struct{})}
+	if key == "" {
+		return d, nil
+	}
+	env, err := newOverrideEnv(functions...)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(key)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile key %q: %w", key, issues.Err())
+	}
+	if d.key, err = env.Program(ast); err != nil {
+		return nil, fmt.Errorf("key %q: %w", key, err)
+	}
+	return d, nil
+}
+
+// Close closes the store.
+func (d *Dedupe) Close() error {
+	return d.store.Close()
+}
+
+// reserve reserves key for an event and reports whether it is a duplicate:
+// either key is in the store or another event holds it.
+func (d *Dedupe) reserve(key string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.reserved[key]; ok {
+		return true, nil
+	}
+	seen, err := d.store.Seen(key)
+	if err != nil || seen {
+		return seen, err
+	}
+	d.reserved[key] = struct{}{}
+	return false, nil
+}
+
+// release releases reserved keys, first adding them to the store if their
+// event was delivered.
+func (d *Dedupe) release(keys []string, delivered bool) []error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var errs []error
+	for _, key := range keys {
+		if delivered {
+			if err := d.store.Add(key); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+		delete(d.reserved, key)
+	}
+	return errs
+}
+
+// dedupeKey returns the dedupe key of evt, whose decoded payload is
+// original. Events without a key are not deduplicated: a null key, and a
+// literal CloudEvents id override, which does not identify events.
+func (p *Pipeline) dedupeKey(original []byte, evt source.Event) (string, error) {
+	var parsed map[string]interface{}
+	_ = json.Unmarshal(original, &parsed)
+
+	if prg := p.config.Dedupe.key; prg != nil {
+		out, _, err := prg.Eval(celVars(parsed, evt))
+		if err != nil {
+			return "", fmt.Errorf("dedupe key: %w", err)
+		}
+		switch v := toNative(out).(type) {
+		case nil:
+			return "", nil
+		case string:
+			return v, nil
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("dedupe key: %w", err)
+			}
+			return string(b), nil
+		}
+	}
+
+	if ce := p.config.CloudEvents; ce != nil && ce.ID != "" {
+		return evaluateCELExpression(p.ceIDProgram, parsed, evt), nil
+	}
+	if isCloudEvent(original) {
+		id, _ := parsed["id"].(string)
+		return id, nil
+	}
+	return "", nil
+}
+
+// duplicate records that evt was dropped as a duplicate.
+func (p *Pipeline) duplicate(evt source.Event, corrID correlation.ID, key string) {
+	if p.config.Metrics != nil {
+		p.config.Metrics.EventsTotal.WithLabelValues(p.config.FlowName, "duplicate").Inc()
+	}
+	p.logger.Debug("duplicate event dropped",
+		"correlation_id", corrID.Value,
+		"flow", p.config.FlowName,
+		"dedupe_key", key,
+		"topic", evt.Topic,
+		"offset", evt.Offset,
+	)
+}
+
+// settle releases the dedupe keys of an event once it was delivered or
+// failed, recording them if it was delivered. A failure to record one only
+// risks a duplicate delivery later, so it is logged.
+func (p *Pipeline) settle(keys []string, delivered bool) {
+	if p.config.Dedupe == nil || len(keys) == 0 {
+		return
+	}
+	for _, err := range p.config.Dedupe.release(keys, delivered) {
+		p.logger.Warn("failed to record dedupe key",
+			"flow", p.config.FlowName,
+			"error", err,
+		)
+	}
+}
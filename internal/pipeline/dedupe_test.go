@@ -0,0 +1,265 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/lsm/fiso/internal/delivery"
+	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/observability"
+	"github.com/lsm/fiso/internal/source"
+)
+
+type memDedupeStore struct {
+	mu     sync.Mutex
+	keys   []string
+	err    error
+	closed bool
+}
+
+func (m *memDedupeStore) Seen(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	for _, k := range m.keys {
+		if k == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memDedupeStore) Add(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *memDedupeStore) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestPipeline_Dedupe_CloudEventsID(t *testing.T) {
+	store := &memDedupeStore{}
+	metrics := observability.NewMetrics(prometheus.NewRegistry())
+	sk := &mockSink{}
+	cfg := Config{
+		FlowName:    "orders",
+		CloudEvents: &CloudEventsOverrides{ID: `"order-" + data.orderId`},
+		Dedupe:      newTestDedupe(t, "", store),
+		Metrics:     metrics,
+	}
+	p := runEvents(t, cfg, sk, &mockPublisher{}, `{"orderId":"1"}`, `{"orderId":"1"}`, `{"orderId":"2"}`)
+
+	if sk.count() != 2 {
+		t.Errorf("expected 2 delivered events, got %d", sk.count())
+	}
+	if len(store.keys) != 2 || store.keys[0] != "order-1" || store.keys[1] != "order-2" {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+	if got := testutil.ToFloat64(metrics.EventsTotal.WithLabelValues("orders", "duplicate")); got != 1 {
+		t.Errorf("duplicate events metric = %v, want 1", got)
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if !store.closed {
+		t.Error("expected Shutdown to close the dedupe store")
+	}
+}
+
+func TestPipeline_Dedupe_SourceCloudEventID(t *testing.T) {
+	store := &memDedupeStore{}
+	sk := &mockSink{}
+	ce := `{"specversion":"1.0","id":"evt-1","type":"order.created","source":"shop","data":{}}`
+	runEvents(t, Config{FlowName: "orders", Dedupe: newTestDedupe(t, "", store)}, sk, &mockPublisher{},
+		ce, ce, `{"plain":true}`, `{"plain":true}`)
+
+	// Events that are not CloudEvents have no key and are all delivered.
+	if sk.count() != 3 {
+		t.Errorf("expected 3 delivered events, got %d", sk.count())
+	}
+	if len(store.keys) != 1 || store.keys[0] != "evt-1" {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+}
+
+func TestPipeline_Dedupe_KeyExpression(t *testing.T) {
+	store := &memDedupeStore{}
+	sk := &mockSink{}
+	runEvents(t, Config{FlowName: "orders", Dedupe: newTestDedupe(t, "[data.id, data.version]", store)}, sk, &mockPublisher{},
+		`{"id":1,"version":1}`, `{"id":1,"version":2}`, `{"id":1,"version":1}`, `{"id":null,"version":null}`)
+
+	if sk.count() != 3 {
+		t.Errorf("expected 3 delivered events, got %d", sk.count())
+	}
+	if len(store.keys) != 3 || store.keys[0] != "[1,1]" {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+}
+
+func TestPipeline_Dedupe_FailedDeliveryNotRecorded(t *testing.T) {
+	store := &memDedupeStore{}
+	sk := &mockSink{err: errors.New("unavailable")}
+	pub := &mockPublisher{}
+	runEvents(t, Config{FlowName: "orders", Dedupe: newTestDedupe(t, "data.id", store)}, sk, pub, `{"id":"a"}`, `{"id":"a"}`)
+
+	if len(store.keys) != 0 {
+		t.Errorf("expected no recorded keys, got %v", store.keys)
+	}
+	if pub.count() != 2 {
+		t.Errorf("expected both attempts in the DLQ, got %d", pub.count())
+	}
+}
+
+func TestPipeline_Dedupe_SameBatch(t *testing.T) {
+	store := &memDedupeStore{}
+	src := &mockBatchSource{mockSource: mockSource{events: []source.Event{
+		{Value: []byte(`{"id":"a"}`), Topic: "orders", Offset: 1},
+		{Value: []byte(`{"id":"a"}`), Topic: "orders", Offset: 2},
+		{Value: []byte(`{"id":"b"}`), Topic: "orders", Offset: 3},
+	}}}
+	sk := &mockBatchSink{fail: map[int]bool{1: true}}
+	cfg := Config{FlowName: "orders", Batch: &Batch{MaxEvents: 3}, Dedupe: newTestDedupe(t, "data.id", store)}
+	p := New(cfg, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// The copy of a was dropped while a was in flight; b failed and
+	// released its key.
+	if sizes := sk.batchSizes(); len(sizes) != 1 || sizes[0] != 2 {
+		t.Fatalf("batch sizes = %v, want [2]", sizes)
+	}
+	if len(store.keys) != 1 || store.keys[0] != "a" {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+	if len(p.config.Dedupe.reserved) != 0 {
+		t.Errorf("expected no reserved keys, got %v", p.config.Dedupe.reserved)
+	}
+}
+
+func TestPipeline_Dedupe_FailedStageReleasesKey(t *testing.T) {
+	store := &memDedupeStore{}
+	pub := &mockPublisher{}
+	enricher := &mockEnricher{fn: func([]byte) ([]byte, error) { return nil, errors.New("lookup failed") }}
+	cfg := Config{FlowName: "orders", Enrichers: []Enricher{enricher}, Dedupe: newTestDedupe(t, "data.id", store)}
+	p := runEvents(t, cfg, &mockSink{}, pub, `{"id":"a"}`, `{"id":"a"}`)
+
+	// The second copy was not held back by a reservation of the first.
+	if pub.count() != 2 {
+		t.Errorf("expected both events in the DLQ, got %d", pub.count())
+	}
+	if len(p.config.Dedupe.reserved) != 0 {
+		t.Errorf("expected no reserved keys, got %v", p.config.Dedupe.reserved)
+	}
+}
+
+func TestPipeline_Dedupe_Failures(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		store *memDedupeStore
+	}{
+		{"key error", "data.missing", &memDedupeStore{}},
+		{"store error", "data.id", &memDedupeStore{err: errors.New("disk full")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sk := &mockSink{}
+			pub := &mockPublisher{}
+			runEvents(t, Config{FlowName: "orders", Dedupe: newTestDedupe(t, tt.key, tt.store)}, sk, pub, `{"id":"a"}`)
+
+			if sk.count() != 0 {
+				t.Errorf("expected no delivered events, got %d", sk.count())
+			}
+			if pub.count() != 1 {
+				t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+			}
+			if code := pub.published[0].headers["fiso-error-code"]; code != "DEDUPE_FAILED" {
+				t.Errorf("expected DEDUPE_FAILED, got %s", code)
+			}
+		})
+	}
+}
+
+func TestPipeline_Dedupe_Routes(t *testing.T) {
+	store := &memDedupeStore{}
+	a, b := &mockSink{}, &mockSink{err: errors.New("unavailable")}
+	router := newTestRouter(t, RouteFanOut, Route{Name: "a", Sink: a}, Route{Name: "b", Sink: b})
+	cfg := Config{
+		FlowName:     "orders",
+		SourceType:   "kafka",
+		CommitPolicy: delivery.CommitPolicySinkOrDLQ,
+		Router:       router,
+		Dedupe:       newTestDedupe(t, "data.id", store),
+	}
+	runEvents(t, cfg, nil, &mockPublisher{}, `{"id":"x"}`, `{"id":"x"}`)
+
+	// Route a delivered the first event and route b sent it to the DLQ, so
+	// the redelivery is a duplicate.
+	if a.count() != 1 {
+		t.Errorf("expected 1 event on route a, got %d", a.count())
+	}
+	if len(store.keys) != 1 {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+}
+
+// flakySink fails its first n deliveries.
+type flakySink struct {
+	mockSink
+	n int
+}
+
+func (f *flakySink) Deliver(ctx context.Context, event []byte, headers map[string]string) error {
+	f.mu.Lock()
+	if f.n > 0 {
+		f.n--
+		f.mu.Unlock()
+		return errors.New("unavailable")
+	}
+	f.mu.Unlock()
+	return f.mockSink.Deliver(ctx, event, headers)
+}
+
+func TestPipeline_Dedupe_RoutesStrictPolicy(t *testing.T) {
+	store := &memDedupeStore{}
+	a, b := &mockSink{}, &flakySink{n: 1}
+	router := newTestRouter(t, RouteFanOut, Route{Name: "a", Sink: a}, Route{Name: "b", Sink: b})
+	cfg := Config{
+		FlowName:     "orders",
+		SourceType:   "kafka",
+		CommitPolicy: delivery.CommitPolicySink,
+		Router:       router,
+		Dedupe:       newTestDedupe(t, "data.id", store),
+	}
+	runEvents(t, cfg, nil, &mockPublisher{}, `{"id":"x"}`, `{"id":"x"}`)
+
+	// Route b failed without a DLQ, so the redelivery reaches it.
+	if b.count() != 1 {
+		t.Errorf("expected the redelivery on route b, got %d events", b.count())
+	}
+	if a.count() != 2 {
+		t.Errorf("expected 2 events on route a, got %d", a.count())
+	}
+	if len(store.keys) != 1 || store.keys[0] != "x" {
+		t.Errorf("recorded keys = %v", store.keys)
+	}
+}
+
+func TestNewDedupe_InvalidKey(t *testing.T) {
+	if _, err := NewDedupe("data.id +", &memDedupeStore{}); err == nil {
+		t.Error("expected a compile error")
+	}
+}
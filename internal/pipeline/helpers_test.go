@@ -0,0 +1,204 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/sink"
+	"github.com/lsm/fiso/internal/source"
+)
+
+// Shared sources, sinks, events and constructors of the pipeline stage
+// tests.
+
+// concurrentSource calls the handler for all events at once, like
+// concurrent HTTP requests, and records each result by event key.
+type concurrentSource struct {
+	events  []source.Event
+	mu      sync.Mutex
+	results map[string]error
+	done    chan struct{} // closed once every handler call returned
+}
+
+func (m *concurrentSource) Start(ctx context.Context, handler func(context.Context, source.Event) error) error {
+	m.results = make(map[string]error)
+	var wg sync.WaitGroup
+	for _, evt := range m.events {
+		wg.Add(1)
+		go func(evt source.Event) {
+			defer wg.Done()
+			err := handler(ctx, evt)
+			m.mu.Lock()
+			m.results[string(evt.Key)] = err
+			m.mu.Unlock()
+		}(evt)
+	}
+	wg.Wait()
+	close(m.done)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (m *concurrentSource) Close() error { return nil }
+
+// mockBatchSource adds all events, flushing whenever the handler is full
+// and once at the end, and records the flush results.
+type mockBatchSource struct {
+	mockSource
+	flushErrs []error
+}
+
+func (m *mockBatchSource) StartBatch(ctx context.Context, h source.BatchHandler) error {
+	for _, evt := range m.events {
+		full, err := h.Add(ctx, evt)
+		if err != nil {
+			return err
+		}
+		if full {
+			m.flushErrs = append(m.flushErrs, h.Flush(ctx))
+		}
+	}
+	if !h.Due().IsZero() {
+		m.flushErrs = append(m.flushErrs, h.Flush(ctx))
+	}
+	return nil
+}
+
+// mockBatchSink records batches and fails the events listed in fail.
+type mockBatchSink struct {
+	mockSink
+	batches [][]sink.Message
+	fail    map[int]bool
+}
+
+func (m *mockBatchSink) DeliverBatch(_ context.Context, msgs []sink.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, msgs)
+	if m.fail == nil {
+		return nil
+	}
+	errs := make([]error, len(msgs))
+	for i := range msgs {
+		if m.fail[i] {
+			errs[i] = fmt.Errorf("event %d rejected", i)
+		}
+	}
+	return &sink.BatchError{Errors: errs}
+}
+
+func (m *mockBatchSink) batchSizes() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sizes := make([]int, len(m.batches))
+	for i, b := range m.batches {
+		sizes[i] = len(b)
+	}
+	return sizes
+}
+
+// testEvents returns events of the orders topic with the given values,
+// keyed k0, k1, ... and at offsets 0, 1, ...
+func testEvents(values ...string) []source.Event {
+	events := make([]source.Event, len(values))
+	for i, v := range values {
+		events[i] = source.Event{Key: []byte(fmt.Sprintf("k%d", i)), Value: []byte(v), Topic: "orders", Offset: int64(i)}
+	}
+	return events
+}
+
+// numberedEvents returns n test events with the values {"n":0}, {"n":1}, ...
+func numberedEvents(n int) []source.Event {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf(`{"n":%d}`, i)
+	}
+	return testEvents(values...)
+}
+
+// keyedEvents returns perKey test events for each of keys keys, k0, k1, ...,
+// interleaving the keys. The values hold the key and the position of the
+// event among those of its key.
+func keyedEvents(keys, perKey int) []source.Event {
+	var values []string
+	for n := 0; n < perKey; n++ {
+		for k := 0; k < keys; k++ {
+			values = append(values, fmt.Sprintf(`{"key":"k%d","n":%d}`, k, n))
+		}
+	}
+	events := testEvents(values...)
+	for i := range events {
+		events[i].Key = []byte(fmt.Sprintf("k%d", i%keys))
+	}
+	return events
+}
+
+// runUntil runs p until done is closed, then stops it and waits for Run to
+// return.
+func runUntil(t *testing.T, p *Pipeline, done <-chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Run(ctx) }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+	cancel()
+	<-errCh
+}
+
+// runEvents runs a pipeline of cfg over test events with the given values,
+// one at a time, and stops it once they were handled.
+func runEvents(t *testing.T, cfg Config, sk *mockSink, pub *mockPublisher, values ...string) *Pipeline {
+	t.Helper()
+	src := &mockSource{events: testEvents(values...), done: make(chan struct{})}
+	p := New(cfg, src, nil, sk, dlq.NewHandler(pub), nil)
+	runUntil(t, p, src.done)
+	return p
+}
+
+func newTestRouter(t *testing.T, mode string, routes ...Route) *Router {
+	t.Helper()
+	r, err := NewRouter(mode, routes)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return r
+}
+
+func newTestDedupe(t *testing.T, key string, store DedupeStore) *Dedupe {
+	t.Helper()
+	d, err := NewDedupe(key, store)
+	if err != nil {
+		t.Fatalf("NewDedupe: %v", err)
+	}
+	return d
+}
+
+func newTestOrdering(t *testing.T, key string, maxInFlight int) *Ordering {
+	t.Helper()
+	o, err := NewOrdering(key, maxInFlight)
+	if err != nil {
+		t.Fatalf("NewOrdering: %v", err)
+	}
+	return o
+}
+
+// newTestAggregator returns the aggregator of a pipeline with spec, whose
+// clock reads now.
+func newTestAggregator(t *testing.T, spec AggregateSpec, sk *mockSink, pub *mockPublisher, now *time.Time) *aggregator {
+	t.Helper()
+	agg, err := NewAggregate(spec)
+	if err != nil {
+		t.Fatalf("NewAggregate: %v", err)
+	}
+	agg.now = func() time.Time { return *now }
+	p := New(Config{FlowName: "orders", Aggregate: agg}, &mockSource{}, nil, sk, dlq.NewHandler(pub), nil)
+	return newAggregator(context.Background(), p, agg)
+}
@@ -0,0 +1,272 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// DefaultMaxInFlight is the default number of events an Ordering processes
+// at once.
+const DefaultMaxInFlight = 16
+
+// Ordering processes the events of a key one at a time, in the order they
+// arrive, and the events of different keys in parallel, up to MaxInFlight
+// events at once. Events with an empty key are not ordered.
+type Ordering struct {
+	key         cel.Program // nil: the source event key
+	maxInFlight int
+}
+
+// NewOrdering compiles the key expression of an ordering. key is a CEL
+// expression with the variables of CloudEvents overrides, evaluated against
+// the decoded source payload; when it is empty, the key is the key
+// of the source event, such as the Kafka message key. maxInFlight defaults
+// to DefaultMaxInFlight.
+func NewOrdering(key string, maxInFlight int, functions ...celext.Option) (*Ordering, error) {
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	o := &Ordering{maxInFlight: maxInFlight}
+	if key == "" {
+		return o, nil
+	}
+	env, err := newOverrideEnv(functions...)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(key)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile key %q: %w", key, issues.Err())
+	}
+	if o.key, err = env.Program(ast); err != nil {
+		return nil, fmt.Errorf("key %q: %w", key, err)
+	}
+	return o, nil
+}
+
+// orderingKey returns the ordering key of evt, whose decoded payload is
+// payload. A null key is empty.
+func (o *Ordering) orderingKey(payload []byte, evt source.Event) (string, error) {
+	var parsed map[string]interface{}
+	_ = json.Unmarshal(payload, &parsed)
+	out, _, err := o.key.Eval(celVars(parsed, evt))
+	if err != nil {
+		return "", fmt.Errorf("ordering key: %w", err)
+	}
+	switch v := toNative(out).(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("ordering key: %w", err)
+		}
+		return string(b), nil
+	}
+}
+
+// keyedExecutor runs events through the pipeline according to an Ordering.
+// It is the source.BatchHandler of sources that acknowledge batches: events
+// are dispatched as they are added, and Flush waits for them, so that Kafka
+// offsets are committed once every event before them was processed. Events
+// of other sources are dispatched from their handler call, which returns
+// the event's result.
+//
+// A Kafka event whose failure is left to the source parks its key: the
+// events after it with that key fail without being processed, so that they
+// are not delivered before it, until it is redelivered.
+type keyedExecutor struct {
+	p     *Pipeline
+	slots chan struct{} // held by each event from dispatch until processed
+
+	mu      sync.Mutex
+	tails   map[string]chan struct{} // closed once the latest event of a key was processed
+	parked  map[string]position      // the failed event of each parked key
+	pending []pendingEvent           // dispatched since the last Flush
+}
+
+type pendingEvent struct {
+	evt    source.Event
+	result <-chan error
+}
+
+func newKeyedExecutor(p *Pipeline, o *Ordering) *keyedExecutor {
+	return &keyedExecutor{
+		p:      p,
+		slots:  make(chan struct{}, o.maxInFlight),
+		tails:  make(map[string]chan struct{}),
+		parked: make(map[string]position),
+	}
+}
+
+// dispatch starts processing evt once the events before it with the same
+// key were processed, and returns the channel of its result. It blocks
+// while MaxInFlight events are dispatched and not yet processed.
+func (e *keyedExecutor) dispatch(ctx context.Context, evt source.Event) (<-chan error, error) {
+	key, err := e.key(ctx, evt)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var prev, done chan struct{}
+	if key != "" {
+		done = make(chan struct{})
+		e.mu.Lock()
+		prev = e.tails[key]
+		e.tails[key] = done
+		e.mu.Unlock()
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() { <-e.slots }()
+		if prev != nil {
+			<-prev
+		}
+		result <- e.run(ctx, key, evt)
+		if done != nil {
+			close(done)
+			e.mu.Lock()
+			if e.tails[key] == done {
+				delete(e.tails, key)
+			}
+			e.mu.Unlock()
+		}
+	}()
+	return result, nil
+}
+
+// run processes evt, unless its key is parked or ctx was cancelled while
+// it waited.
+func (e *keyedExecutor) run(ctx context.Context, key string, evt source.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if key == "" {
+		return e.p.processEvent(ctx, evt)
+	}
+
+	pos := eventPosition(evt)
+	e.mu.Lock()
+	failed, parked := e.parked[key]
+	if parked && failed == pos {
+		delete(e.parked, key) // redelivered
+		parked = false
+	}
+	e.mu.Unlock()
+	if parked {
+		return fmt.Errorf("ordering: event at offset %d with the same key failed", failed.offset)
+	}
+
+	err := e.p.processEvent(ctx, evt)
+	if err != nil && e.p.config.SourceType == "kafka" {
+		e.mu.Lock()
+		e.parked[key] = pos
+		e.mu.Unlock()
+	}
+	return err
+}
+
+// key returns the ordering key of evt. An expression is evaluated against
+// the payload decoded by the Decoder of the pipeline, if any, which decodes
+// it again when processing the event. Failures are handled, and the result
+// of handling them is returned.
+func (e *keyedExecutor) key(ctx context.Context, evt source.Event) (string, error) {
+	o := e.p.config.Ordering
+	if o.key == nil {
+		return string(evt.Key), nil
+	}
+	payload := evt.Value
+	if e.p.config.Decoder != nil {
+		decoded, err := e.p.config.Decoder.Decode(payload)
+		if err != nil {
+			return "", e.p.handleFailure(ctx, evt, "SCHEMA_DECODE_FAILED", err)
+		}
+		payload = decoded
+	}
+	key, err := o.orderingKey(payload, evt)
+	if err != nil {
+		return "", e.p.handleFailure(ctx, evt, "ORDERING_FAILED", err)
+	}
+	return key, nil
+}
+
+// process runs evt and waits for its result.
+func (e *keyedExecutor) process(ctx context.Context, evt source.Event) error {
+	result, err := e.dispatch(ctx, evt)
+	if result == nil {
+		return err
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Add implements source.BatchHandler.
+func (e *keyedExecutor) Add(ctx context.Context, evt source.Event) (bool, error) {
+	result, err := e.dispatch(ctx, evt)
+	if result == nil {
+		return false, e.p.reportFailure(evt, err)
+	}
+	e.mu.Lock()
+	e.pending = append(e.pending, pendingEvent{evt: evt, result: result})
+	e.mu.Unlock()
+	return false, nil
+}
+
+// Due implements source.BatchHandler. Dispatched events are due at once,
+// so that the source flushes after each poll.
+func (e *keyedExecutor) Due() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.pending) == 0 {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Flush implements source.BatchHandler. It waits until the dispatched
+// events were processed.
+func (e *keyedExecutor) Flush(context.Context) error {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	var errs []error
+	for _, pe := range pending {
+		if err := e.p.reportFailure(pe.evt, <-pe.result); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// start runs the source for a pipeline with an Ordering.
+func (e *keyedExecutor) start(ctx context.Context) error {
+	if bs, ok := e.p.source.(source.BatchSource); ok {
+		return bs.StartBatch(ctx, e)
+	}
+	return e.p.source.Start(ctx, func(ctx context.Context, evt source.Event) error {
+		return e.p.reportFailure(evt, e.process(ctx, evt))
+	})
+}
@@ -0,0 +1,243 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lsm/fiso/internal/delivery"
+	"github.com/lsm/fiso/internal/dlq"
+)
+
+// slowSink takes a while to deliver each event and records the order of
+// the events of each key and the most deliveries in progress at once.
+type slowSink struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	byKey       map[string][]float64
+	fail        bool
+}
+
+func (s *slowSink) Deliver(_ context.Context, event []byte, _ map[string]string) error {
+	s.mu.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	var ce struct {
+		Data struct {
+			Key string  `json:"key"`
+			N   float64 `json:"n"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(event, &ce)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.byKey == nil {
+		s.byKey = make(map[string][]float64)
+	}
+	s.byKey[ce.Data.Key] = append(s.byKey[ce.Data.Key], ce.Data.N)
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (s *slowSink) Close() error { return nil }
+
+func TestPipeline_Ordering_BatchSource(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: keyedEvents(8, 5)}}
+	sk := &slowSink{}
+	cfg := Config{FlowName: "orders", Ordering: newTestOrdering(t, "", 4)}
+	p := New(cfg, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// The final flush waited for every event.
+	if len(src.flushErrs) != 1 || src.flushErrs[0] != nil {
+		t.Fatalf("flush results = %v", src.flushErrs)
+	}
+	if len(sk.byKey) != 8 {
+		t.Fatalf("expected events of 8 keys, got %d", len(sk.byKey))
+	}
+	for key, ns := range sk.byKey {
+		if got := fmt.Sprint(ns); got != "[0 1 2 3 4]" {
+			t.Errorf("key %s delivered in order %s", key, got)
+		}
+	}
+	if sk.maxInFlight < 2 || sk.maxInFlight > 4 {
+		t.Errorf("max deliveries in flight = %d, want 2 to 4", sk.maxInFlight)
+	}
+}
+
+func TestPipeline_Ordering_SameKeyIsSequential(t *testing.T) {
+	events := keyedEvents(1, 4)
+	src := &concurrentSource{events: events, done: make(chan struct{})}
+	sk := &slowSink{}
+	cfg := Config{FlowName: "orders", Ordering: newTestOrdering(t, "data.key", 4)}
+	p := New(cfg, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	runUntil(t, p, src.done)
+
+	if len(sk.byKey["k0"]) != 4 {
+		t.Fatalf("expected 4 delivered events, got %v", sk.byKey)
+	}
+	if sk.maxInFlight != 1 {
+		t.Errorf("max deliveries in flight = %d, want 1", sk.maxInFlight)
+	}
+}
+
+func TestPipeline_Ordering_EmptyKeysAreNotOrdered(t *testing.T) {
+	events := keyedEvents(1, 4)
+	for i := range events {
+		events[i].Key = nil
+	}
+	src := &mockBatchSource{mockSource: mockSource{events: events}}
+	sk := &slowSink{}
+	p := New(Config{FlowName: "orders", Ordering: newTestOrdering(t, "", 4)},
+		src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	_ = p.Run(context.Background())
+
+	if sk.maxInFlight < 2 {
+		t.Errorf("max deliveries in flight = %d, want events without a key in parallel", sk.maxInFlight)
+	}
+}
+
+func TestPipeline_Ordering_FlushReportsFailures(t *testing.T) {
+	src := &mockBatchSource{mockSource: mockSource{events: keyedEvents(2, 1)}}
+	cfg := Config{FlowName: "orders", PropagateErrors: true, Ordering: newTestOrdering(t, "", 2)}
+	p := New(cfg, src, nil, &slowSink{fail: true}, dlq.NewHandler(&mockPublisher{}), nil)
+	_ = p.Run(context.Background())
+
+	if len(src.flushErrs) != 1 || src.flushErrs[0] == nil {
+		t.Errorf("expected the flush to fail, got %v", src.flushErrs)
+	}
+}
+
+func TestPipeline_Ordering_KeyError_SendsToDLQ(t *testing.T) {
+	pub := &mockPublisher{}
+	sk := &mockSink{}
+	runEvents(t, Config{FlowName: "orders", Ordering: newTestOrdering(t, "data.missing", 2)}, sk, pub, `{"id":"a"}`)
+
+	if sk.count() != 0 {
+		t.Errorf("expected no delivered events, got %d", sk.count())
+	}
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	if code := pub.published[0].headers["fiso-error-code"]; code != "ORDERING_FAILED" {
+		t.Errorf("expected ORDERING_FAILED, got %s", code)
+	}
+}
+
+func TestPipeline_Ordering_FailureParksKey(t *testing.T) {
+	sk := &flakySink{n: 1}
+	cfg := Config{
+		FlowName:     "orders",
+		SourceType:   "kafka",
+		CommitPolicy: delivery.CommitPolicySink,
+		Ordering:     newTestOrdering(t, "", 2),
+	}
+	p := New(cfg, &mockSource{}, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	e := newKeyedExecutor(p, cfg.Ordering)
+	ctx := context.Background()
+	events := keyedEvents(2, 2) // k0, k1, k0, k1
+
+	if err := e.process(ctx, events[0]); err == nil {
+		t.Fatal("expected the first event to fail")
+	}
+	if err := e.process(ctx, events[2]); err == nil || !strings.Contains(err.Error(), "offset 0") {
+		t.Fatalf("expected the next event of k0 to be parked, got %v", err)
+	}
+	if err := e.process(ctx, events[1]); err != nil {
+		t.Fatalf("other key: unexpected error %v", err)
+	}
+	// The redelivered event unparks its key.
+	for _, i := range []int{0, 2} {
+		if err := e.process(ctx, events[i]); err != nil {
+			t.Fatalf("event %d: unexpected error %v", i, err)
+		}
+	}
+	if sk.count() != 3 {
+		t.Errorf("expected 3 delivered events, got %d", sk.count())
+	}
+}
+
+func TestPipeline_Ordering_DecodedKey(t *testing.T) {
+	events := keyedEvents(2, 3)
+	for i := range events {
+		events[i].Value = append([]byte("wire:"), events[i].Value...)
+	}
+	src := &mockBatchSource{mockSource: mockSource{events: events}}
+	sk := &slowSink{}
+	cfg := Config{FlowName: "orders", Decoder: mockDecoder{}, Ordering: newTestOrdering(t, "data.key", 4)}
+	p := New(cfg, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, key := range []string{"k0", "k1"} {
+		if got := fmt.Sprint(sk.byKey[key]); got != "[0 1 2]" {
+			t.Errorf("key %s delivered in order %s", key, got)
+		}
+	}
+}
+
+func TestPipeline_Ordering_DecodeError_SendsToDLQ(t *testing.T) {
+	pub := &mockPublisher{}
+	cfg := Config{FlowName: "orders", Decoder: mockDecoder{}, Ordering: newTestOrdering(t, "data.key", 2)}
+	runEvents(t, cfg, &mockSink{}, pub, `{"key":"a"}`)
+
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	if code := pub.published[0].headers["fiso-error-code"]; code != "SCHEMA_DECODE_FAILED" {
+		t.Errorf("expected SCHEMA_DECODE_FAILED, got %s", code)
+	}
+}
+
+func TestPipeline_Ordering_Conflicts(t *testing.T) {
+	agg, err := NewAggregate(AggregateSpec{Key: "data.id", Size: time.Minute})
+	if err != nil {
+		t.Fatalf("NewAggregate: %v", err)
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"aggregate", Config{Ordering: newTestOrdering(t, "", 2), Aggregate: agg}},
+		{"batch", Config{Ordering: newTestOrdering(t, "", 2), Batch: &Batch{}}},
+		{"aggregate with batch", Config{Aggregate: agg, Batch: &Batch{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.cfg, &mockSource{}, nil, &mockSink{}, dlq.NewHandler(&mockPublisher{}), nil)
+			if err := p.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "cannot be used with") {
+				t.Errorf("Run() = %v, want a conflict error", err)
+			}
+		})
+	}
+}
+
+func TestNewOrdering(t *testing.T) {
+	o, err := NewOrdering("", 0)
+	if err != nil {
+		t.Fatalf("NewOrdering: %v", err)
+	}
+	if o.maxInFlight != DefaultMaxInFlight {
+		t.Errorf("maxInFlight = %d, want %d", o.maxInFlight, DefaultMaxInFlight)
+	}
+	if _, err := NewOrdering("data.id +", 1); err == nil {
+		t.Error("expected a compile error")
+	}
+}
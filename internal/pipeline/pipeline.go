@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -17,9 +18,12 @@ import (
 	"github.com/lsm/fiso/internal/delivery"
 	"github.com/lsm/fiso/internal/dlq"
 	"github.com/lsm/fiso/internal/interceptor"
+	"github.com/lsm/fiso/internal/observability"
+	"github.com/lsm/fiso/internal/schema"
 	"github.com/lsm/fiso/internal/sink"
 	"github.com/lsm/fiso/internal/source"
 	"github.com/lsm/fiso/internal/transform"
+	"github.com/lsm/fiso/internal/transform/celext"
 )
 
 // CloudEventsOverrides allows customizing CloudEvent envelope fields per CloudEvents v1.0 spec.
@@ -59,6 +63,43 @@ type Config struct {
 	PropagateErrors bool   // When true, return processing errors to the source handler.
 	CommitPolicy    delivery.CommitPolicy
 	CloudEvents     *CloudEventsOverrides
+	Decoder         schema.Codec           // Optional: decodes wire-format source payloads to JSON
+	Filter          transform.Filter       // Optional: events it does not match are acknowledged without delivery
+	LogFiltered     bool                   // Log filtered events at debug level
+	Metrics         *observability.Metrics // Optional: flow metrics
+	Validation      *Validation            // Optional: JSON Schema validation before and after transform
+	Enrichers       []Enricher             // Optional: add looked-up data to events before transform, in order
+	Dedupe          *Dedupe                // Optional: acknowledge events already delivered without delivering them again
+	Batch           *Batch                 // Optional: deliver events in batches (not with Router)
+	Aggregate       *Aggregate             // Optional: deliver one event per key and window (not with Batch)
+	Ordering        *Ordering              // Optional: process events of different keys in parallel (not with Batch or Aggregate)
+	Router          *Router                // Optional: deliver to the sinks of matching routes instead of the pipeline sink
+	CELFunctions    []celext.Option        // Optional: configures the fiso CEL functions of CloudEvents overrides
+}
+
+// Enricher adds looked-up data to event payloads before transform. Failed
+// lookups, including timeouts, send the event to the DLQ as ENRICH_FAILED.
+type Enricher interface {
+	Enrich(ctx context.Context, payload []byte, evt source.Event) ([]byte, error)
+}
+
+// Validation failure modes.
+const (
+	ValidationFailDLQ  = "dlq"  // send the event to the DLQ as SCHEMA_VALIDATION_FAILED
+	ValidationFailDrop = "drop" // log and acknowledge the event without delivering it
+	ValidationFailWarn = "warn" // deliver the event with a validation warning header
+)
+
+// HeaderValidationWarning carries the violations of events delivered despite
+// failing validation in ValidationFailWarn mode.
+const HeaderValidationWarning = "fiso-validation-warning"
+
+// Validation checks events against JSON Schemas. Before sees the (decoded)
+// source payload, After the transformed payload.
+type Validation struct {
+	Before    schema.Validator
+	After     schema.Validator
+	OnFailure string // dlq, drop or warn (default: dlq)
 }
 
 // Pipeline orchestrates the source → transform → interceptors → sink flow.
@@ -81,7 +122,7 @@ type Pipeline struct {
 }
 
 // New creates a new Pipeline. If transformer is nil, events pass through untransformed.
-// If chain is nil, no interceptors are applied.
+// If chain is nil, no interceptors are applied. sk may be nil when cfg.Router is set.
 func New(cfg Config, src source.Source, tr transform.Transformer, sk sink.Sink, dlqHandler *dlq.Handler, chain *interceptor.Chain) *Pipeline {
 	cfg.CommitPolicy = delivery.NormalizeCommitPolicy(string(cfg.CommitPolicy))
 
@@ -97,13 +138,13 @@ func New(cfg Config, src source.Source, tr transform.Transformer, sk sink.Sink,
 
 	// Compile CEL programs for CloudEvent overrides (if they're CEL expressions, not JSONPath)
 	if cfg.CloudEvents != nil {
-		p.ceIDProgram, _ = compileCELExpression(cfg.CloudEvents.ID)
-		p.ceSourceProgram, _ = compileCELExpression(cfg.CloudEvents.Source)
-		p.ceTypeProgram, _ = compileCELExpression(cfg.CloudEvents.Type)
-		p.ceSubjectProgram, _ = compileCELExpression(cfg.CloudEvents.Subject)
-		p.ceDataProgram, _ = compileCELExpression(cfg.CloudEvents.Data)
-		p.ceDataContentTypeProgram, _ = compileCELExpression(cfg.CloudEvents.DataContentType)
-		p.ceDataSchemaProgram, _ = compileCELExpression(cfg.CloudEvents.DataSchema)
+		p.ceIDProgram, _ = compileCELExpression(cfg.CloudEvents.ID, cfg.CELFunctions...)
+		p.ceSourceProgram, _ = compileCELExpression(cfg.CloudEvents.Source, cfg.CELFunctions...)
+		p.ceTypeProgram, _ = compileCELExpression(cfg.CloudEvents.Type, cfg.CELFunctions...)
+		p.ceSubjectProgram, _ = compileCELExpression(cfg.CloudEvents.Subject, cfg.CELFunctions...)
+		p.ceDataProgram, _ = compileCELExpression(cfg.CloudEvents.Data, cfg.CELFunctions...)
+		p.ceDataContentTypeProgram, _ = compileCELExpression(cfg.CloudEvents.DataContentType, cfg.CELFunctions...)
+		p.ceDataSchemaProgram, _ = compileCELExpression(cfg.CloudEvents.DataSchema, cfg.CELFunctions...)
 	}
 
 	return p
@@ -113,42 +154,211 @@ func New(cfg Config, src source.Source, tr transform.Transformer, sk sink.Sink,
 func (p *Pipeline) Run(ctx context.Context) error {
 	p.logger.Info("starting pipeline", "flow", p.config.FlowName)
 
-	return p.source.Start(ctx, func(ctx context.Context, evt source.Event) error {
-		if err := p.processEvent(ctx, evt); err != nil {
-			p.logger.Error("event processing failed, sending to DLQ",
-				"flow", p.config.FlowName,
-				"topic", evt.Topic,
-				"offset", evt.Offset,
-				"error", err,
-			)
-			if p.config.PropagateErrors {
-				return err
-			}
+	if p.config.Ordering != nil && (p.config.Aggregate != nil || p.config.Batch != nil) {
+		return errors.New("ordering cannot be used with batch or aggregate")
+	}
+	if p.config.Aggregate != nil && p.config.Batch != nil {
+		return errors.New("aggregate cannot be used with batch")
+	}
+
+	if p.config.Aggregate != nil {
+		return newAggregator(ctx, p, p.config.Aggregate).start(ctx)
+	}
+	if p.config.Ordering != nil {
+		return newKeyedExecutor(p, p.config.Ordering).start(ctx)
+	}
+
+	if p.config.Batch != nil && p.config.Router == nil {
+		b := newBatcher(ctx, p, *p.config.Batch)
+		if bs, ok := p.source.(source.BatchSource); ok {
+			return bs.StartBatch(ctx, b)
 		}
-		return nil
+		return p.source.Start(ctx, func(ctx context.Context, evt source.Event) error {
+			return p.reportFailure(evt, b.submit(ctx, evt))
+		})
+	}
+
+	return p.source.Start(ctx, func(ctx context.Context, evt source.Event) error {
+		return p.reportFailure(evt, p.processEvent(ctx, evt))
 	})
 }
 
+// reportFailure logs a processing error of evt and returns it if errors
+// are propagated to the source.
+func (p *Pipeline) reportFailure(evt source.Event, err error) error {
+	if err == nil {
+		return nil
+	}
+	p.logger.Error("event processing failed, sending to DLQ",
+		"flow", p.config.FlowName,
+		"topic", evt.Topic,
+		"offset", evt.Offset,
+		"error", err,
+	)
+	if p.config.PropagateErrors {
+		return err
+	}
+	return nil
+}
+
 func (p *Pipeline) processEvent(ctx context.Context, evt source.Event) error {
 	start := time.Now()
 
 	// Get correlation ID from event or extract from headers as fallback
+	corrID := eventCorrelationID(evt)
+
+	st, err := p.stage(ctx, evt, corrID)
+	if st == nil {
+		return err
+	}
+	return p.deliver(ctx, evt, st, start)
+}
+
+// deliver delivers st to the routes of the event, or to the pipeline sink
+// when there is no Router.
+func (p *Pipeline) deliver(ctx context.Context, evt source.Event, st *stagedEvent, start time.Time) error {
+	if p.config.Router != nil {
+		return p.deliverRoutes(ctx, evt, st, start)
+	}
+
+	msg, err := p.wrap(ctx, evt, st)
+	if msg == nil {
+		p.settle(st.dedupeKeys, false)
+		return err
+	}
+
+	if err := p.sink.Deliver(ctx, msg.Event, msg.Headers); err != nil {
+		p.settle(st.dedupeKeys, false)
+		return p.handleFailure(ctx, evt, "SINK_DELIVERY_FAILED", err)
+	}
+	p.settle(st.dedupeKeys, true)
+
+	p.logger.Info("event delivered",
+		"correlation_id", st.corrID.Value,
+		"flow", p.config.FlowName,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+
+	return nil
+}
+
+// eventCorrelationID returns the correlation ID of evt, falling back to its
+// headers.
+func eventCorrelationID(evt source.Event) correlation.ID {
 	corrID := correlation.ID{Value: evt.CorrelationID, Source: "event"}
 	if corrID.Value == "" {
 		corrID = correlation.ExtractOrGenerate(evt.Headers)
 	}
+	return corrID
+}
+
+// stagedEvent is an event that passed decoding, validation, transform and
+// interceptors, ready to be wrapped for a sink.
+type stagedEvent struct {
+	corrID     correlation.ID
+	dedupeKeys []string // reserved until the event is delivered or fails
+	original   []byte   // decoded source payload, for CloudEvent overrides
+	payload    []byte
+	headers    map[string]string // set by the transform
+	warnings   []string
+}
+
+// prepare runs evt through decoding, validation, transform and interceptors
+// and wraps the result in a CloudEvent ready for the sink, returning it with
+// the dedupe keys of the event, which the caller settles. It returns a nil
+// message when the event failed or was dropped; the error is then the
+// result of handling the failure.
+func (p *Pipeline) prepare(ctx context.Context, evt source.Event, corrID correlation.ID) (*sink.Message, []string, error) {
+	st, err := p.stage(ctx, evt, corrID)
+	if st == nil {
+		return nil, nil, err
+	}
+	msg, err := p.wrap(ctx, evt, st)
+	if msg == nil {
+		p.settle(st.dedupeKeys, false)
+		return nil, nil, err
+	}
+	return msg, st.dedupeKeys, nil
+}
 
+// stage runs evt through decoding, deduplication, filtering, validation,
+// enrichment, transform and interceptors.
+// Like prepare, it returns nil when the event failed or was dropped. The
+// dedupe keys of a staged event stay reserved until the caller settles them.
+func (p *Pipeline) stage(ctx context.Context, evt source.Event, corrID correlation.ID) (st *stagedEvent, err error) {
 	originalPayload := evt.Value // preserve for CE field resolution
 	inputBytes := len(evt.Value)
 	payload := evt.Value
 
+	// Decode wire-format payloads (Avro, Protobuf) to JSON so transforms
+	// and CloudEvent overrides see JSON. The DLQ receives the raw event.
+	if p.config.Decoder != nil {
+		decoded, err := p.config.Decoder.Decode(payload)
+		if err != nil {
+			return nil, p.handleFailure(ctx, evt, "SCHEMA_DECODE_FAILED", err)
+		}
+		originalPayload = decoded
+		payload = decoded
+	}
+
+	var dedupeKeys []string
+	if p.config.Dedupe != nil {
+		key, err := p.dedupeKey(originalPayload, evt)
+		if err != nil {
+			return nil, p.handleFailure(ctx, evt, "DEDUPE_FAILED", err)
+		}
+		if key != "" {
+			seen, err := p.config.Dedupe.reserve(key)
+			if err != nil {
+				return nil, p.handleFailure(ctx, evt, "DEDUPE_FAILED", err)
+			}
+			if seen {
+				p.duplicate(evt, corrID, key)
+				return nil, nil
+			}
+			dedupeKeys = []string{key}
+			defer func() {
+				if st == nil {
+					p.settle(dedupeKeys, false)
+				}
+			}()
+		}
+	}
+
+	if p.config.Filter != nil {
+		keep, err := p.config.Filter.Match(ctx, payload, evt)
+		if err != nil {
+			return nil, p.handleFailure(ctx, evt, "FILTER_FAILED", err)
+		}
+		if !keep {
+			p.filtered(evt, corrID)
+			return nil, nil
+		}
+	}
+
+	var warnings []string
+	if v := p.config.Validation; v != nil && v.Before != nil {
+		if done, err := p.validate(ctx, evt, v.Before, "before", payload, &warnings); done {
+			return nil, err
+		}
+	}
+
+	for _, e := range p.config.Enrichers {
+		enriched, err := e.Enrich(ctx, payload, evt)
+		if err != nil {
+			return nil, p.handleFailure(ctx, evt, "ENRICH_FAILED", err)
+		}
+		payload = enriched
+	}
+
 	// Transform
+	var headers map[string]string
 	if p.transformer != nil {
-		transformed, err := p.transformer.Transform(ctx, payload)
+		transformed, h, err := applyTransform(ctx, p.transformer, payload, evt)
 		if err != nil {
-			return p.handleFailure(ctx, evt, "TRANSFORM_FAILED", err)
+			return nil, p.handleFailure(ctx, evt, "TRANSFORM_FAILED", err)
 		}
-		payload = transformed
+		payload, headers = transformed, h
 		p.logger.Debug("transform completed",
 			"correlation_id", corrID.Value,
 			"input_bytes", inputBytes,
@@ -156,6 +366,12 @@ func (p *Pipeline) processEvent(ctx context.Context, evt source.Event) error {
 		)
 	}
 
+	if v := p.config.Validation; v != nil && v.After != nil {
+		if done, err := p.validate(ctx, evt, v.After, "after", payload, &warnings); done {
+			return nil, err
+		}
+	}
+
 	// Run interceptors
 	if p.interceptors != nil && p.interceptors.Len() > 0 {
 		req := &interceptor.Request{
@@ -165,44 +381,121 @@ func (p *Pipeline) processEvent(ctx context.Context, evt source.Event) error {
 		}
 		result, err := p.interceptors.Process(ctx, req)
 		if err != nil {
-			return p.handleFailure(ctx, evt, "INTERCEPTOR_FAILED", err)
+			return nil, p.handleFailure(ctx, evt, "INTERCEPTOR_FAILED", err)
 		}
 		payload = result.Payload
 	}
 
+	return &stagedEvent{corrID: corrID, dedupeKeys: dedupeKeys, original: originalPayload, payload: payload, headers: headers, warnings: warnings}, nil
+}
+
+// applyTransform runs tr on payload, passing the metadata of evt to
+// transformers implementing transform.EventTransformer.
+func applyTransform(ctx context.Context, tr transform.Transformer, payload []byte, evt source.Event) ([]byte, map[string]string, error) {
+	if et, ok := tr.(transform.EventTransformer); ok {
+		return et.TransformEvent(ctx, payload, evt)
+	}
+	out, err := tr.Transform(ctx, payload)
+	return out, nil, err
+}
+
+// wrap wraps the payload of st in a CloudEvent and adds the sink headers.
+func (p *Pipeline) wrap(ctx context.Context, evt source.Event, st *stagedEvent) (*sink.Message, error) {
+	msg, err := p.message(evt, st)
+	if err != nil {
+		return nil, p.handleFailure(ctx, evt, "CLOUDEVENT_WRAP_FAILED", err)
+	}
+	return msg, nil
+}
+
+// message is wrap without the handling of failures.
+func (p *Pipeline) message(evt source.Event, st *stagedEvent) (*sink.Message, error) {
 	// Wrap in CloudEvent (skip if already in CE format)
 	var wrapped []byte
 	var err error
-	if isCloudEvent(payload) {
+	if isCloudEvent(st.payload) {
 		// Already a CloudEvent, pass through (optionally apply overrides)
-		wrapped, err = p.passOrMergeCloudEvent(payload, originalPayload)
+		wrapped, err = p.passOrMergeCloudEvent(st.payload, st.original, evt)
 	} else {
-		wrapped, err = p.wrapCloudEvent(payload, originalPayload)
+		wrapped, err = p.wrapCloudEvent(st.payload, st.original, evt)
 	}
 	if err != nil {
-		return p.handleFailure(ctx, evt, "CLOUDEVENT_WRAP_FAILED", err)
+		return nil, err
 	}
 
-	// Deliver to sink
-	headers := map[string]string{
-		"Content-Type": "application/cloudevents+json",
+	// Headers for the sink. Transform headers cannot replace the content
+	// type, which describes the envelope.
+	headers := make(map[string]string, len(st.headers)+3)
+	for k, v := range st.headers {
+		headers[k] = v
+	}
+	headers["Content-Type"] = "application/cloudevents+json"
+	headers = correlation.AddToHeaders(headers, st.corrID)
+	if len(st.warnings) > 0 {
+		headers[HeaderValidationWarning] = strings.Join(st.warnings, "; ")
 	}
-	headers = correlation.AddToHeaders(headers, corrID)
 
-	if err := p.sink.Deliver(ctx, wrapped, headers); err != nil {
-		return p.handleFailure(ctx, evt, "SINK_DELIVERY_FAILED", err)
+	return &sink.Message{Event: wrapped, Headers: headers}, nil
+}
+
+// filtered records that evt was filtered out.
+func (p *Pipeline) filtered(evt source.Event, corrID correlation.ID) {
+	if p.config.Metrics != nil {
+		p.config.Metrics.EventsTotal.WithLabelValues(p.config.FlowName, "filtered").Inc()
 	}
+	if p.config.LogFiltered {
+		p.logger.Debug("event filtered",
+			"correlation_id", corrID.Value,
+			"flow", p.config.FlowName,
+			"topic", evt.Topic,
+			"offset", evt.Offset,
+		)
+	}
+}
 
-	p.logger.Info("event delivered",
-		"correlation_id", corrID.Value,
-		"flow", p.config.FlowName,
-		"latency_ms", time.Since(start).Milliseconds(),
-	)
+// validate checks payload against v. It reports done when processing of
+// the event should stop: the event failed validation and was sent to the
+// DLQ or dropped. In warn mode the violations are appended to warnings and
+// processing continues.
+func (p *Pipeline) validate(ctx context.Context, evt source.Event, v schema.Validator, stage string, payload []byte, warnings *[]string) (bool, error) {
+	err := v.Validate(ctx, payload)
+	if err == nil {
+		return false, nil
+	}
+	err = fmt.Errorf("validate %s transform: %w", stage, err)
 
-	return nil
+	var verr *schema.ValidationError
+	if !errors.As(err, &verr) {
+		// The schema could not be resolved, so the event is not known to be
+		// invalid: handle it like any other processing failure, whatever
+		// the failure mode.
+		return true, p.handleFailure(ctx, evt, "SCHEMA_VALIDATION_FAILED", err)
+	}
+
+	switch p.config.Validation.OnFailure {
+	case ValidationFailDrop:
+		p.logger.Warn("event failed validation, dropping",
+			"flow", p.config.FlowName,
+			"topic", evt.Topic,
+			"offset", evt.Offset,
+			"error", err,
+		)
+		return true, nil
+	case ValidationFailWarn:
+		p.logger.Warn("event failed validation, delivering with warning",
+			"flow", p.config.FlowName,
+			"topic", evt.Topic,
+			"offset", evt.Offset,
+			"error", err,
+		)
+		*warnings = append(*warnings, stage+" transform: "+verr.Error())
+		return false, nil
+	default:
+		return true, p.handleFailure(ctx, evt, "SCHEMA_VALIDATION_FAILED", err)
+	}
 }
 
-func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
+func (p *Pipeline) wrapCloudEvent(data, originalInput []byte, evt source.Event) ([]byte, error) {
 	eventType := p.config.EventType
 	if eventType == "" {
 		eventType = "fiso.event"
@@ -231,7 +524,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve ID
 		if p.config.CloudEvents.ID != "" {
 			if p.ceIDProgram != nil {
-				ceID = evaluateCELExpression(p.ceIDProgram, parsed)
+				ceID = evaluateCELExpression(p.ceIDProgram, parsed, evt)
 			} else {
 				ceID = p.config.CloudEvents.ID
 			}
@@ -240,7 +533,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve Source
 		if p.config.CloudEvents.Source != "" {
 			if p.ceSourceProgram != nil {
-				ceSource = evaluateCELExpression(p.ceSourceProgram, parsed)
+				ceSource = evaluateCELExpression(p.ceSourceProgram, parsed, evt)
 			} else {
 				ceSource = p.config.CloudEvents.Source
 			}
@@ -249,7 +542,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve Type
 		if p.config.CloudEvents.Type != "" {
 			if p.ceTypeProgram != nil {
-				eventType = evaluateCELExpression(p.ceTypeProgram, parsed)
+				eventType = evaluateCELExpression(p.ceTypeProgram, parsed, evt)
 			} else {
 				eventType = p.config.CloudEvents.Type
 			}
@@ -258,7 +551,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve Subject
 		if p.config.CloudEvents.Subject != "" {
 			if p.ceSubjectProgram != nil {
-				ceSubject = evaluateCELExpression(p.ceSubjectProgram, parsed)
+				ceSubject = evaluateCELExpression(p.ceSubjectProgram, parsed, evt)
 			} else {
 				ceSubject = p.config.CloudEvents.Subject
 			}
@@ -268,7 +561,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		if p.config.CloudEvents.Data != "" {
 			var dataValue interface{}
 			if p.ceDataProgram != nil {
-				dataValue = evaluateCELValue(p.ceDataProgram, parsed)
+				dataValue = evaluateCELValue(p.ceDataProgram, parsed, evt)
 			} else {
 				dataValue = p.config.CloudEvents.Data
 			}
@@ -285,7 +578,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve DataContentType
 		if p.config.CloudEvents.DataContentType != "" {
 			if p.ceDataContentTypeProgram != nil {
-				ceDataContentType = evaluateCELExpression(p.ceDataContentTypeProgram, parsed)
+				ceDataContentType = evaluateCELExpression(p.ceDataContentTypeProgram, parsed, evt)
 			} else {
 				ceDataContentType = p.config.CloudEvents.DataContentType
 			}
@@ -294,7 +587,7 @@ func (p *Pipeline) wrapCloudEvent(data, originalInput []byte) ([]byte, error) {
 		// Resolve DataSchema
 		if p.config.CloudEvents.DataSchema != "" {
 			if p.ceDataSchemaProgram != nil {
-				ceDataSchema = evaluateCELExpression(p.ceDataSchemaProgram, parsed)
+				ceDataSchema = evaluateCELExpression(p.ceDataSchemaProgram, parsed, evt)
 			} else {
 				ceDataSchema = p.config.CloudEvents.DataSchema
 			}
@@ -347,7 +640,7 @@ func isCloudEvent(data []byte) bool {
 // passOrMergeCloudEvent handles events that are already in CloudEvent format.
 // If no overrides are configured, it passes through unchanged.
 // If overrides are configured, it merges them into the existing CloudEvent.
-func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, error) {
+func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte, evt source.Event) ([]byte, error) {
 	// If no overrides configured, pass through unchanged
 	if p.config.CloudEvents == nil {
 		return data, nil
@@ -374,7 +667,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 	// Apply overrides
 	if p.config.CloudEvents.ID != "" {
 		if p.ceIDProgram != nil {
-			if id := evaluateCELExpression(p.ceIDProgram, parsed); id != "" {
+			if id := evaluateCELExpression(p.ceIDProgram, parsed, evt); id != "" {
 				existingCE["id"] = id
 			}
 		} else {
@@ -384,7 +677,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.Source != "" {
 		if p.ceSourceProgram != nil {
-			if source := evaluateCELExpression(p.ceSourceProgram, parsed); source != "" {
+			if source := evaluateCELExpression(p.ceSourceProgram, parsed, evt); source != "" {
 				existingCE["source"] = source
 			}
 		} else {
@@ -394,7 +687,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.Type != "" {
 		if p.ceTypeProgram != nil {
-			if t := evaluateCELExpression(p.ceTypeProgram, parsed); t != "" {
+			if t := evaluateCELExpression(p.ceTypeProgram, parsed, evt); t != "" {
 				existingCE["type"] = t
 			}
 		} else {
@@ -404,7 +697,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.Subject != "" {
 		if p.ceSubjectProgram != nil {
-			if subj := evaluateCELExpression(p.ceSubjectProgram, parsed); subj != "" {
+			if subj := evaluateCELExpression(p.ceSubjectProgram, parsed, evt); subj != "" {
 				existingCE["subject"] = subj
 			}
 		} else {
@@ -414,7 +707,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.Data != "" {
 		if p.ceDataProgram != nil {
-			if dataVal := evaluateCELValue(p.ceDataProgram, parsed); dataVal != nil {
+			if dataVal := evaluateCELValue(p.ceDataProgram, parsed, evt); dataVal != nil {
 				existingCE["data"] = dataVal
 			}
 		} else {
@@ -424,7 +717,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.DataContentType != "" {
 		if p.ceDataContentTypeProgram != nil {
-			if ct := evaluateCELExpression(p.ceDataContentTypeProgram, parsed); ct != "" {
+			if ct := evaluateCELExpression(p.ceDataContentTypeProgram, parsed, evt); ct != "" {
 				existingCE["datacontenttype"] = ct
 			}
 		} else {
@@ -434,7 +727,7 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 
 	if p.config.CloudEvents.DataSchema != "" {
 		if p.ceDataSchemaProgram != nil {
-			if ds := evaluateCELExpression(p.ceDataSchemaProgram, parsed); ds != "" {
+			if ds := evaluateCELExpression(p.ceDataSchemaProgram, parsed, evt); ds != "" {
 				existingCE["dataschema"] = ds
 			}
 		} else {
@@ -446,13 +739,19 @@ func (p *Pipeline) passOrMergeCloudEvent(data, originalInput []byte) ([]byte, er
 }
 
 func (p *Pipeline) handleFailure(ctx context.Context, evt source.Event, code string, cause error) error {
+	return p.handleRouteFailure(ctx, evt, "", code, cause)
+}
+
+// handleRouteFailure handles a failure like handleFailure. A failure to
+// deliver to one route names the route in the DLQ message.
+func (p *Pipeline) handleRouteFailure(ctx context.Context, evt source.Event, route, code string, cause error) error {
 	if cause == nil {
 		return nil
 	}
 
 	// For non-Kafka sources, always preserve request-response error semantics.
 	if p.config.SourceType != "kafka" {
-		if dlqErr := p.sendToDLQ(ctx, evt, code, cause.Error()); dlqErr != nil {
+		if dlqErr := p.sendToDLQ(ctx, evt, route, code, cause.Error()); dlqErr != nil {
 			return errors.Join(cause, dlqErr)
 		}
 		return cause
@@ -460,7 +759,7 @@ func (p *Pipeline) handleFailure(ctx context.Context, evt source.Event, code str
 
 	switch p.config.CommitPolicy {
 	case delivery.CommitPolicySinkOrDLQ:
-		if dlqErr := p.sendToDLQ(ctx, evt, code, cause.Error()); dlqErr != nil {
+		if dlqErr := p.sendToDLQ(ctx, evt, route, code, cause.Error()); dlqErr != nil {
 			return errors.Join(cause, dlqErr)
 		}
 		return nil // durable fallback via DLQ, safe to ack
@@ -470,20 +769,21 @@ func (p *Pipeline) handleFailure(ctx context.Context, evt source.Event, code str
 		return cause
 
 	default:
-		if dlqErr := p.sendToDLQ(ctx, evt, code, cause.Error()); dlqErr != nil {
+		if dlqErr := p.sendToDLQ(ctx, evt, route, code, cause.Error()); dlqErr != nil {
 			return errors.Join(cause, dlqErr)
 		}
 		return nil
 	}
 }
 
-func (p *Pipeline) sendToDLQ(ctx context.Context, evt source.Event, code, message string) error {
+func (p *Pipeline) sendToDLQ(ctx context.Context, evt source.Event, route, code, message string) error {
 	info := dlq.FailureInfo{
 		OriginalTopic: evt.Topic,
 		ErrorCode:     code,
 		ErrorMessage:  message,
 		FlowName:      p.config.FlowName,
 		CorrelationID: evt.CorrelationID,
+		Route:         route,
 	}
 	if err := p.dlq.Send(ctx, evt.Key, evt.Value, info); err != nil {
 		p.logger.Error("failed to send to DLQ",
@@ -497,7 +797,7 @@ func (p *Pipeline) sendToDLQ(ctx context.Context, evt source.Event, code, messag
 }
 
 // Shutdown performs graceful shutdown of the pipeline components.
-// Closes source, sink, and DLQ in order. Returns all errors joined.
+// Closes source, sink, dedupe store and DLQ in order. Returns all errors joined.
 func (p *Pipeline) Shutdown(ctx context.Context) error {
 	p.logger.Info("shutting down pipeline", "flow", p.config.FlowName)
 
@@ -513,9 +813,23 @@ func (p *Pipeline) Shutdown(ctx context.Context) error {
 			errs = append(errs, fmt.Errorf("interceptor close: %w", err))
 		}
 	}
-	if err := p.sink.Close(); err != nil {
-		p.logger.Error("sink close error", "flow", p.config.FlowName, "error", err)
-		errs = append(errs, fmt.Errorf("sink close: %w", err))
+	if p.sink != nil {
+		if err := p.sink.Close(); err != nil {
+			p.logger.Error("sink close error", "flow", p.config.FlowName, "error", err)
+			errs = append(errs, fmt.Errorf("sink close: %w", err))
+		}
+	}
+	if p.config.Router != nil {
+		if err := p.config.Router.Close(); err != nil {
+			p.logger.Error("route sink close error", "flow", p.config.FlowName, "error", err)
+			errs = append(errs, fmt.Errorf("route sink close: %w", err))
+		}
+	}
+	if p.config.Dedupe != nil {
+		if err := p.config.Dedupe.Close(); err != nil {
+			p.logger.Error("dedupe store close error", "flow", p.config.FlowName, "error", err)
+			errs = append(errs, fmt.Errorf("dedupe store close: %w", err))
+		}
 	}
 	if err := p.dlq.Close(); err != nil {
 		p.logger.Error("dlq close error", "flow", p.config.FlowName, "error", err)
@@ -529,25 +843,14 @@ func (p *Pipeline) Shutdown(ctx context.Context) error {
 // compileCELExpression compiles a CEL expression.
 // Returns nil if the expression is empty or doesn't contain CEL syntax (treated as literal).
 // Simple field access like "data.foo" or complex expressions are both supported.
-func compileCELExpression(expr string) (cel.Program, error) {
+func compileCELExpression(expr string, functions ...celext.Option) (cel.Program, error) {
 	if expr == "" {
 		return nil, nil
 	}
 
-	// Create CEL environment with same variables as transform
-	env, err := cel.NewEnv(
-		cel.Variable("data", cel.DynType),
-		cel.Variable("time", cel.DynType),
-		cel.Variable("source", cel.DynType),
-		cel.Variable("type", cel.DynType),
-		cel.Variable("id", cel.DynType),
-		cel.Variable("subject", cel.DynType),
-		ext.Strings(),
-		ext.Encoders(),
-		ext.Math(),
-	)
+	env, err := newOverrideEnv(functions...)
 	if err != nil {
-		return nil, fmt.Errorf("cel env: %w", err)
+		return nil, err
 	}
 
 	// Compile the expression
@@ -567,21 +870,62 @@ func compileCELExpression(expr string) (cel.Program, error) {
 	return prg, nil
 }
 
-// evaluateCELExpression evaluates a compiled CEL program against input data.
+// newOverrideEnv creates the CEL environment of override expressions, with
+// the same variables as transforms.
+func newOverrideEnv(functions ...celext.Option) (*cel.Env, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("data", cel.DynType),
+		cel.Variable("time", cel.DynType),
+		cel.Variable("source", cel.DynType),
+		cel.Variable("type", cel.DynType),
+		cel.Variable("id", cel.DynType),
+		cel.Variable("subject", cel.DynType),
+		cel.Variable("headers", cel.DynType),
+		cel.Variable("key", cel.DynType),
+		cel.Variable("topic", cel.DynType),
+		cel.Variable("partition", cel.DynType),
+		cel.Variable("offset", cel.DynType),
+		cel.Variable("correlationId", cel.DynType),
+		ext.Strings(),
+		ext.Encoders(),
+		ext.Math(),
+		celext.Library(functions...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+	return env, nil
+}
+
+// celVars binds the variables of CEL override expressions: the input data
+// and the metadata of its source event.
+func celVars(inputData map[string]interface{}, evt source.Event) map[string]interface{} {
+	headers := evt.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	return map[string]interface{}{
+		"data":          inputData,
+		"time":          time.Now().Format(time.RFC3339),
+		"headers":       headers,
+		"key":           string(evt.Key),
+		"topic":         evt.Topic,
+		"partition":     int64(evt.Partition),
+		"offset":        evt.Offset,
+		"correlationId": evt.CorrelationID,
+	}
+}
+
+// evaluateCELExpression evaluates a compiled CEL program against input data
+// and the metadata of evt.
 // Returns empty string if program is nil or evaluation fails.
-func evaluateCELExpression(prg cel.Program, inputData map[string]interface{}) string {
+func evaluateCELExpression(prg cel.Program, inputData map[string]interface{}, evt source.Event) string {
 	if prg == nil {
 		return ""
 	}
 
-	// Prepare CEL variables (same structure as transform)
-	vars := map[string]interface{}{
-		"data": inputData,
-		"time": time.Now().Format(time.RFC3339),
-	}
-
 	// Evaluate the expression
-	out, _, err := prg.Eval(vars)
+	out, _, err := prg.Eval(celVars(inputData, evt))
 	if err != nil {
 		return "" // Return empty on evaluation error
 	}
@@ -593,22 +937,17 @@ func evaluateCELExpression(prg cel.Program, inputData map[string]interface{}) st
 	return fmt.Sprintf("%v", out.Value())
 }
 
-// evaluateCELValue evaluates a compiled CEL program against input data.
+// evaluateCELValue evaluates a compiled CEL program against input data
+// and the metadata of evt.
 // Returns the raw value (interface{}) instead of converting to string.
 // Returns nil if program is nil or evaluation fails.
-func evaluateCELValue(prg cel.Program, inputData map[string]interface{}) interface{} {
+func evaluateCELValue(prg cel.Program, inputData map[string]interface{}, evt source.Event) interface{} {
 	if prg == nil {
 		return nil
 	}
 
-	// Prepare CEL variables (same structure as transform)
-	vars := map[string]interface{}{
-		"data": inputData,
-		"time": time.Now().Format(time.RFC3339),
-	}
-
 	// Evaluate the expression
-	out, _, err := prg.Eval(vars)
+	out, _, err := prg.Eval(celVars(inputData, evt))
 	if err != nil {
 		return nil // Return nil on evaluation error
 	}
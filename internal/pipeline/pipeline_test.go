@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,7 +15,12 @@ import (
 	"github.com/lsm/fiso/internal/delivery"
 	"github.com/lsm/fiso/internal/dlq"
 	"github.com/lsm/fiso/internal/interceptor"
+	"github.com/lsm/fiso/internal/observability"
+	"github.com/lsm/fiso/internal/schema"
 	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // --- Mocks ---
@@ -22,9 +28,23 @@ import (
 type mockSource struct {
 	events   []source.Event
 	closeErr error
+	done     chan struct{} // Optional: closed once the events were handled
 }
 
 func (m *mockSource) Start(ctx context.Context, handler func(context.Context, source.Event) error) error {
+	err := m.handle(ctx, handler)
+	if m.done != nil {
+		close(m.done)
+	}
+	if err != nil {
+		return err
+	}
+	// Block until context is cancelled to simulate a real source.
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (m *mockSource) handle(ctx context.Context, handler func(context.Context, source.Event) error) error {
 	for _, evt := range m.events {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -34,9 +54,7 @@ func (m *mockSource) Start(ctx context.Context, handler func(context.Context, so
 			return fmt.Errorf("unexpected handler error: %w", err)
 		}
 	}
-	// Block until context is cancelled to simulate a real source.
-	<-ctx.Done()
-	return ctx.Err()
+	return nil
 }
 
 func (m *mockSource) Close() error { return m.closeErr }
@@ -183,6 +201,187 @@ func TestPipeline_TransformError_SendsToDLQ(t *testing.T) {
 	}
 }
 
+// mockDecoder strips a "wire:" prefix, failing payloads without one.
+type mockDecoder struct{}
+
+func (mockDecoder) Decode(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte("wire:")) {
+		return nil, fmt.Errorf("unknown magic byte")
+	}
+	return bytes.TrimPrefix(data, []byte("wire:")), nil
+}
+
+func (d mockDecoder) Validate(data []byte) error {
+	_, err := d.Decode(data)
+	return err
+}
+
+func TestPipeline_Decoder(t *testing.T) {
+	src := &mockSource{
+		events: []source.Event{
+			{Key: []byte("k1"), Value: []byte(`wire:{"id":"a"}`), Topic: "orders"},
+			{Key: []byte("k2"), Value: []byte(`{"id":"b"}`), Topic: "orders"},
+		},
+	}
+	var transformed []string
+	transformer := &mockTransformer{
+		fn: func(_ context.Context, input []byte) ([]byte, error) {
+			transformed = append(transformed, string(input))
+			return input, nil
+		},
+	}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+
+	p := New(Config{
+		FlowName:    "orders",
+		Decoder:     mockDecoder{},
+		CloudEvents: &CloudEventsOverrides{Subject: "data.id"},
+	}, src, transformer, sk, dlq.NewHandler(pub), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if len(transformed) != 1 || transformed[0] != `{"id":"a"}` {
+		t.Errorf("expected the transform to see decoded JSON, got %v", transformed)
+	}
+	if sk.count() != 1 {
+		t.Fatalf("expected 1 delivered event, got %d", sk.count())
+	}
+	var ce map[string]interface{}
+	if err := json.Unmarshal(sk.received[0].event, &ce); err != nil {
+		t.Fatalf("unmarshal CloudEvent: %v", err)
+	}
+	if ce["subject"] != "a" {
+		t.Errorf("expected subject from decoded payload, got %v", ce["subject"])
+	}
+
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	msg := pub.published[0]
+	if msg.headers["fiso-error-code"] != "SCHEMA_DECODE_FAILED" {
+		t.Errorf("expected error code SCHEMA_DECODE_FAILED, got %s", msg.headers["fiso-error-code"])
+	}
+	if string(msg.value) != `{"id":"b"}` {
+		t.Errorf("expected the raw event in the DLQ, got %s", msg.value)
+	}
+}
+
+func mustValidator(t *testing.T, schemaJSON string) schema.Validator {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(schemaJSON), &doc); err != nil {
+		t.Fatal(err)
+	}
+	v, err := schema.NewValidator(schema.ValidatorConfig{Schema: doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestPipeline_Validation(t *testing.T) {
+	events := []source.Event{
+		{Key: []byte("k1"), Value: []byte(`{"id":"a","amount":5}`), Topic: "orders"},
+		{Key: []byte("k2"), Value: []byte(`{"amount":"x"}`), Topic: "orders"},
+	}
+	before := `{"type":"object","required":["id"],"properties":{"amount":{"type":"number"}}}`
+
+	tests := []struct {
+		name      string
+		onFailure string
+		delivered int
+		dlq       int
+		warning   string
+	}{
+		{"dlq", ValidationFailDLQ, 1, 1, ""},
+		{"default", "", 1, 1, ""},
+		{"drop", ValidationFailDrop, 1, 0, ""},
+		{"warn", ValidationFailWarn, 2, 0, "before transform: (root): missing property 'id'; /amount: got string, want number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sk := &mockSink{}
+			pub := &mockPublisher{}
+			p := New(Config{
+				FlowName:   "orders",
+				SourceType: "kafka",
+				Validation: &Validation{Before: mustValidator(t, before), OnFailure: tt.onFailure},
+			}, &mockSource{events: events}, nil, sk, dlq.NewHandler(pub), nil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			_ = p.Run(ctx)
+
+			if sk.count() != tt.delivered {
+				t.Fatalf("expected %d delivered events, got %d", tt.delivered, sk.count())
+			}
+			if pub.count() != tt.dlq {
+				t.Fatalf("expected %d DLQ events, got %d", tt.dlq, pub.count())
+			}
+			if tt.dlq > 0 {
+				msg := pub.published[0]
+				if msg.headers["fiso-error-code"] != "SCHEMA_VALIDATION_FAILED" {
+					t.Errorf("expected error code SCHEMA_VALIDATION_FAILED, got %s", msg.headers["fiso-error-code"])
+				}
+				if !strings.Contains(msg.headers["fiso-error-message"], "/amount") {
+					t.Errorf("expected JSON pointer in error message, got %s", msg.headers["fiso-error-message"])
+				}
+			}
+			if _, ok := sk.received[0].headers[HeaderValidationWarning]; ok {
+				t.Errorf("valid event delivered with warning header")
+			}
+			if tt.warning != "" {
+				if got := sk.received[1].headers[HeaderValidationWarning]; got != tt.warning {
+					t.Errorf("warning header = %q, want %q", got, tt.warning)
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_Validation_AfterTransform(t *testing.T) {
+	src := &mockSource{
+		events: []source.Event{
+			{Key: []byte("k1"), Value: []byte(`{"id":"a"}`), Topic: "orders"},
+			{Key: []byte("k2"), Value: []byte(`{"id":7}`), Topic: "orders"},
+		},
+	}
+	transformer := &mockTransformer{
+		fn: func(_ context.Context, input []byte) ([]byte, error) {
+			var in map[string]interface{}
+			_ = json.Unmarshal(input, &in)
+			return json.Marshal(map[string]interface{}{"order_id": in["id"]})
+		},
+	}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+	p := New(Config{
+		FlowName:   "orders",
+		SourceType: "kafka",
+		Validation: &Validation{
+			Before: mustValidator(t, `{"required":["id"]}`),
+			After:  mustValidator(t, `{"properties":{"order_id":{"type":"string"}},"required":["order_id"]}`),
+		},
+	}, src, transformer, sk, dlq.NewHandler(pub), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 1 || pub.count() != 1 {
+		t.Fatalf("expected 1 delivered and 1 DLQ event, got %d and %d", sk.count(), pub.count())
+	}
+	if msg := pub.published[0].headers["fiso-error-message"]; !strings.Contains(msg, "after transform") || !strings.Contains(msg, "/order_id") {
+		t.Errorf("unexpected error message %q", msg)
+	}
+	if string(pub.published[0].value) != `{"id":7}` {
+		t.Errorf("expected the raw event in the DLQ, got %s", pub.published[0].value)
+	}
+}
+
 func TestPipeline_SinkError_SendsToDLQ(t *testing.T) {
 	src := &mockSource{
 		events: []source.Event{
@@ -2915,11 +3114,11 @@ func TestCompileCELExpression_AndEvaluateHelpers(t *testing.T) {
 		t.Fatalf("expected valid program, got prg=%v err=%v", prg, err)
 	}
 
-	if got := evaluateCELExpression(prg, map[string]interface{}{"id": "abc"}); got != "abc" {
+	if got := evaluateCELExpression(prg, map[string]interface{}{"id": "abc"}, source.Event{}); got != "abc" {
 		t.Fatalf("expected abc, got %q", got)
 	}
 
-	if got := evaluateCELExpression(nil, map[string]interface{}{"id": "abc"}); got != "" {
+	if got := evaluateCELExpression(nil, map[string]interface{}{"id": "abc"}, source.Event{}); got != "" {
 		t.Fatalf("expected empty string for nil program, got %q", got)
 	}
 
@@ -2927,15 +3126,15 @@ func TestCompileCELExpression_AndEvaluateHelpers(t *testing.T) {
 	if err != nil || errPrg == nil {
 		t.Fatalf("expected valid arithmetic program, got %v %v", errPrg, err)
 	}
-	if got := evaluateCELExpression(errPrg, map[string]interface{}{"amount": "not-a-number"}); got != "" {
+	if got := evaluateCELExpression(errPrg, map[string]interface{}{"amount": "not-a-number"}, source.Event{}); got != "" {
 		t.Fatalf("expected empty string on evaluation error, got %q", got)
 	}
 
-	if got := evaluateCELValue(nil, map[string]interface{}{"id": "abc"}); got != nil {
+	if got := evaluateCELValue(nil, map[string]interface{}{"id": "abc"}, source.Event{}); got != nil {
 		t.Fatalf("expected nil for nil program, got %#v", got)
 	}
 
-	if got := evaluateCELValue(errPrg, map[string]interface{}{"amount": "not-a-number"}); got != nil {
+	if got := evaluateCELValue(errPrg, map[string]interface{}{"amount": "not-a-number"}, source.Event{}); got != nil {
 		t.Fatalf("expected nil on evaluation error, got %#v", got)
 	}
 }
@@ -2950,3 +3149,249 @@ func TestToNative_ValueMethodFallbackAndDefaultReturn(t *testing.T) {
 		t.Fatalf("expected passthrough value, got %#v", got)
 	}
 }
+
+type mockFilter struct {
+	fn func(input []byte, evt source.Event) (bool, error)
+}
+
+func (m *mockFilter) Match(_ context.Context, input []byte, evt source.Event) (bool, error) {
+	return m.fn(input, evt)
+}
+
+func TestPipeline_Filter(t *testing.T) {
+	src := &mockSource{events: []source.Event{
+		{Key: []byte("k1"), Value: []byte(`{"op":"c"}`), Topic: "orders"},
+		{Key: []byte("k2"), Value: []byte(`{"op":"r"}`), Topic: "orders"},
+		{Key: []byte("k3"), Value: []byte(`{"op":"u"}`), Topic: "orders"},
+	}}
+	filter := &mockFilter{fn: func(input []byte, _ source.Event) (bool, error) {
+		return !bytes.Contains(input, []byte(`"r"`)), nil
+	}}
+	var transformed int
+	transformer := &mockTransformer{fn: func(_ context.Context, input []byte) ([]byte, error) {
+		transformed++
+		return input, nil
+	}}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+	metrics := observability.NewMetrics(prometheus.NewRegistry())
+
+	p := New(Config{FlowName: "cdc", SourceType: "kafka", PropagateErrors: true, Filter: filter, LogFiltered: true, Metrics: metrics},
+		src, transformer, sk, dlq.NewHandler(pub), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected filtered events to be acknowledged, got %v", err)
+	}
+
+	if sk.count() != 2 {
+		t.Fatalf("expected 2 delivered events, got %d", sk.count())
+	}
+	if transformed != 2 {
+		t.Errorf("expected the filter to run before the transform, transformed %d events", transformed)
+	}
+	if pub.count() != 0 {
+		t.Errorf("expected 0 DLQ events, got %d", pub.count())
+	}
+	if got := testutil.ToFloat64(metrics.EventsTotal.WithLabelValues("cdc", "filtered")); got != 1 {
+		t.Errorf("filtered events metric = %v, want 1", got)
+	}
+}
+
+func TestPipeline_FilterError_SendsToDLQ(t *testing.T) {
+	src := &mockSource{events: []source.Event{{Key: []byte("k1"), Value: []byte(`{}`), Topic: "orders"}}}
+	filter := &mockFilter{fn: func([]byte, source.Event) (bool, error) {
+		return false, errors.New("no such key: op")
+	}}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+
+	p := New(Config{FlowName: "cdc", SourceType: "kafka", Filter: filter}, src, nil, sk, dlq.NewHandler(pub), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 0 {
+		t.Errorf("expected no delivered events, got %d", sk.count())
+	}
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	if code := pub.published[0].headers["fiso-error-code"]; code != "FILTER_FAILED" {
+		t.Errorf("expected FILTER_FAILED, got %s", code)
+	}
+}
+
+type mockEnricher struct {
+	fn func(input []byte) ([]byte, error)
+}
+
+func (m *mockEnricher) Enrich(_ context.Context, input []byte, _ source.Event) ([]byte, error) {
+	return m.fn(input)
+}
+
+func TestPipeline_Enrichers(t *testing.T) {
+	src := &mockSource{events: []source.Event{{Key: []byte("k1"), Value: []byte(`{"id":"a"}`), Topic: "orders"}}}
+	appendEnricher := func(suffix string) Enricher {
+		return &mockEnricher{fn: func(input []byte) ([]byte, error) {
+			return append(bytes.TrimSuffix(input, []byte("}")), []byte(`,"`+suffix+`":true}`)...), nil
+		}}
+	}
+	var seen []byte
+	transformer := &mockTransformer{fn: func(_ context.Context, input []byte) ([]byte, error) {
+		seen = input
+		return input, nil
+	}}
+	sk := &mockSink{}
+
+	p := New(Config{FlowName: "orders", Enrichers: []Enricher{appendEnricher("first"), appendEnricher("second")}},
+		src, transformer, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if want := `{"id":"a","first":true,"second":true}`; string(seen) != want {
+		t.Errorf("transform input = %s, want %s", seen, want)
+	}
+	if sk.count() != 1 {
+		t.Errorf("expected 1 delivered event, got %d", sk.count())
+	}
+}
+
+func TestPipeline_EnrichError_SendsToDLQ(t *testing.T) {
+	src := &mockSource{events: []source.Event{{Key: []byte("k1"), Value: []byte(`{}`), Topic: "orders"}}}
+	enricher := &mockEnricher{fn: func([]byte) ([]byte, error) {
+		return nil, context.DeadlineExceeded
+	}}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+
+	p := New(Config{FlowName: "orders", Enrichers: []Enricher{enricher}}, src, nil, sk, dlq.NewHandler(pub), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 0 {
+		t.Errorf("expected no delivered events, got %d", sk.count())
+	}
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	if code := pub.published[0].headers["fiso-error-code"]; code != "ENRICH_FAILED" {
+		t.Errorf("expected ENRICH_FAILED, got %s", code)
+	}
+}
+
+type mockEventTransformer struct {
+	mockTransformer
+	headers map[string]string
+	seen    []source.Event
+}
+
+func (m *mockEventTransformer) TransformEvent(ctx context.Context, input []byte, evt source.Event) ([]byte, map[string]string, error) {
+	m.seen = append(m.seen, evt)
+	out, err := m.fn(ctx, input)
+	return out, m.headers, err
+}
+
+func TestPipeline_EventTransformer_Headers(t *testing.T) {
+	evt := source.Event{Key: []byte("k1"), Value: []byte(`{"id":"a"}`), Topic: "orders", Partition: 2, Offset: 7}
+	src := &mockSource{events: []source.Event{evt}}
+	transformer := &mockEventTransformer{
+		mockTransformer: mockTransformer{fn: func(_ context.Context, input []byte) ([]byte, error) { return input, nil }},
+		headers:         map[string]string{"x-tenant": "acme", "Content-Type": "text/plain"},
+	}
+	sk := &mockSink{}
+
+	p := New(Config{FlowName: "tenants"}, src, transformer, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 1 {
+		t.Fatalf("expected 1 delivered event, got %d", sk.count())
+	}
+	if len(transformer.seen) != 1 || transformer.seen[0].Partition != 2 || transformer.seen[0].Offset != 7 {
+		t.Errorf("expected the transformer to see the source event, got %+v", transformer.seen)
+	}
+	headers := sk.received[0].headers
+	if headers["x-tenant"] != "acme" {
+		t.Errorf("expected transform header x-tenant=acme, got %v", headers)
+	}
+	if headers["Content-Type"] != "application/cloudevents+json" {
+		t.Errorf("expected the envelope content type to be kept, got %q", headers["Content-Type"])
+	}
+}
+
+func TestPipeline_CloudEventsOverrides_Metadata(t *testing.T) {
+	src := &mockSource{events: []source.Event{{
+		Key:     []byte("order-1"),
+		Value:   []byte(`{"id":"a"}`),
+		Topic:   "orders",
+		Offset:  42,
+		Headers: map[string]string{"x-tenant": "acme"},
+	}}}
+	sk := &mockSink{}
+
+	p := New(Config{
+		FlowName: "tenants",
+		CloudEvents: &CloudEventsOverrides{
+			ID:      `topic + "-" + string(offset)`,
+			Source:  `"tenant/" + headers["x-tenant"]`,
+			Subject: "key",
+		},
+	}, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 1 {
+		t.Fatalf("expected 1 delivered event, got %d", sk.count())
+	}
+	var ce map[string]interface{}
+	if err := json.Unmarshal(sk.received[0].event, &ce); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if ce["id"] != "orders-42" || ce["source"] != "tenant/acme" || ce["subject"] != "order-1" {
+		t.Errorf("unexpected CloudEvent attributes: id=%v source=%v subject=%v", ce["id"], ce["source"], ce["subject"])
+	}
+}
+
+func TestPipeline_CloudEventsOverrides_FisoFunctions(t *testing.T) {
+	src := &mockSource{events: []source.Event{{Value: []byte(`{"region":"eu","type":"created"}`)}}}
+	sk := &mockSink{}
+
+	p := New(Config{
+		FlowName: "orders",
+		CloudEvents: &CloudEventsOverrides{
+			ID:     "fiso.uuid()",
+			Source: `"orders/" + fiso.lookup("regions", data.region, "unknown")`,
+			Type:   `"order." + data.type`,
+		},
+		CELFunctions: []celext.Option{
+			celext.WithUUID(func() string { return "fixed-id" }),
+			celext.WithLookups(map[string]map[string]string{"regions": {"eu": "europe"}}),
+		},
+	}, src, nil, sk, dlq.NewHandler(&mockPublisher{}), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = p.Run(ctx)
+
+	if sk.count() != 1 {
+		t.Fatalf("expected 1 delivered event, got %d", sk.count())
+	}
+	var ce map[string]interface{}
+	if err := json.Unmarshal(sk.received[0].event, &ce); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if ce["id"] != "fixed-id" || ce["source"] != "orders/europe" || ce["type"] != "order.created" {
+		t.Errorf("unexpected CloudEvent attributes: id=%v source=%v type=%v", ce["id"], ce["source"], ce["type"])
+	}
+}
@@ -0,0 +1,202 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/sink"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// Route modes.
+const (
+	RouteFirstMatch = "first-match" // deliver to the first matching route
+	RouteFanOut     = "fan-out"     // deliver to every matching route
+)
+
+// Route delivers the events matching When to its own sink.
+type Route struct {
+	Name        string
+	When        string                // CEL condition with the variables of CloudEvents overrides, over the transformed event; empty matches every event
+	Transformer transform.Transformer // Optional: applied after the pipeline transform, for this route only
+	Sink        sink.Sink
+}
+
+// Router selects the routes of an event. Routes are tried in order.
+type Router struct {
+	mode   string
+	routes []route
+}
+
+type route struct {
+	Route
+	when cel.Program // nil matches every event
+}
+
+// NewRouter compiles the conditions of routes. mode is RouteFirstMatch
+// (the default) or RouteFanOut.
+func NewRouter(mode string, routes []Route, functions ...celext.Option) (*Router, error) {
+	switch mode {
+	case "":
+		mode = RouteFirstMatch
+	case RouteFirstMatch, RouteFanOut:
+	default:
+		return nil, fmt.Errorf("unknown route mode %q", mode)
+	}
+
+	env, err := newOverrideEnv(functions...)
+	if err != nil {
+		return nil, err
+	}
+	r := &Router{mode: mode, routes: make([]route, len(routes))}
+	for i, rt := range routes {
+		r.routes[i].Route = rt
+		if rt.When == "" {
+			continue
+		}
+		prg, err := compileCondition(env, rt.When)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", rt.Name, err)
+		}
+		r.routes[i].when = prg
+	}
+	return r, nil
+}
+
+// compileCondition compiles a boolean CEL expression in env.
+func compileCondition(env *cel.Env, expr string) (cel.Program, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("compile %q: %w", expr, issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("condition %q must return a bool, got %s", expr, t)
+	}
+	return env.Program(ast)
+}
+
+// match returns the routes of payload, the transformed evt. A condition
+// that fails to evaluate, for example on a missing field, does not match.
+func (r *Router) match(payload []byte, evt source.Event) []*route {
+	var data map[string]interface{}
+	_ = json.Unmarshal(payload, &data)
+	vars := celVars(data, evt)
+
+	var matched []*route
+	for i := range r.routes {
+		rt := &r.routes[i]
+		if rt.when != nil {
+			out, _, err := rt.when.Eval(vars)
+			if err != nil || out != types.True {
+				continue
+			}
+		}
+		matched = append(matched, rt)
+		if r.mode == RouteFirstMatch {
+			break
+		}
+	}
+	return matched
+}
+
+// named returns the route named name, or nil.
+func (r *Router) named(name string) *route {
+	for i := range r.routes {
+		if r.routes[i].Name == name {
+			return &r.routes[i]
+		}
+	}
+	return nil
+}
+
+// Close closes the sinks of all routes.
+func (r *Router) Close() error {
+	var errs []error
+	for _, rt := range r.routes {
+		if err := rt.Sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("route %s: %w", rt.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliverRoutes delivers st to its routes. The failure of each route is
+// handled on its own, so the event is acknowledged only once every matching
+// route delivered it or had its failure handled, for example by the DLQ,
+// whose message names the failed route. An event replayed from the DLQ of
+// this flow with that name in its dlq.HeaderRoute header is delivered only
+// to that route. An event no route matches fails with NO_ROUTE_MATCHED.
+func (p *Pipeline) deliverRoutes(ctx context.Context, evt source.Event, st *stagedEvent, start time.Time) error {
+	routes := p.config.Router.match(st.payload, evt)
+	if name := evt.Headers[dlq.HeaderRoute]; name != "" && evt.Headers["fiso-flow-name"] == p.config.FlowName {
+		if rt := p.config.Router.named(name); rt != nil {
+			routes = []*route{rt}
+		}
+	}
+	if len(routes) == 0 {
+		return p.handleFailure(ctx, evt, "NO_ROUTE_MATCHED", errors.New("no route matched the event"))
+	}
+
+	var errs []error
+	var delivered bool // by at least one route
+	for _, rt := range routes {
+		ok, err := p.deliverRoute(ctx, evt, st, rt)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !ok {
+			continue
+		}
+		delivered = true
+		p.logger.Info("event delivered",
+			"correlation_id", st.corrID.Value,
+			"flow", p.config.FlowName,
+			"route", rt.Name,
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+	}
+	// A route whose failure is left to the source must see the redelivery.
+	p.settle(st.dedupeKeys, delivered && len(errs) == 0)
+	return errors.Join(errs...)
+}
+
+// deliverRoute delivers st to rt and reports whether the sink accepted it.
+// Failures are handled like those of the pipeline sink.
+func (p *Pipeline) deliverRoute(ctx context.Context, evt source.Event, st *stagedEvent, rt *route) (bool, error) {
+	if rt.Transformer != nil {
+		transformed, headers, err := applyTransform(ctx, rt.Transformer, st.payload, evt)
+		if err != nil {
+			return false, p.handleRouteFailure(ctx, evt, rt.Name, "TRANSFORM_FAILED", fmt.Errorf("route %s: %w", rt.Name, err))
+		}
+		routed := *st
+		routed.payload = transformed
+		if len(headers) > 0 {
+			routed.headers = make(map[string]string, len(st.headers)+len(headers))
+			for k, v := range st.headers {
+				routed.headers[k] = v
+			}
+			for k, v := range headers {
+				routed.headers[k] = v
+			}
+		}
+		st = &routed
+	}
+
+	msg, err := p.message(evt, st)
+	if err != nil {
+		return false, p.handleRouteFailure(ctx, evt, rt.Name, "CLOUDEVENT_WRAP_FAILED", fmt.Errorf("route %s: %w", rt.Name, err))
+	}
+
+	if err := rt.Sink.Deliver(ctx, msg.Event, msg.Headers); err != nil {
+		return false, p.handleRouteFailure(ctx, evt, rt.Name, "SINK_DELIVERY_FAILED", fmt.Errorf("route %s: %w", rt.Name, err))
+	}
+	return true, nil
+}
@@ -0,0 +1,248 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/delivery"
+	"github.com/lsm/fiso/internal/dlq"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+func TestPipeline_Routes_FirstMatch(t *testing.T) {
+	src := &concurrentSource{events: testEvents(`{"total":5000}`, `{"total":10}`), done: make(chan struct{})}
+	high, rest := &mockSink{}, &mockSink{}
+	router := newTestRouter(t, "",
+		Route{Name: "high-value", When: "data.total > 1000", Sink: high},
+		Route{Name: "default", Sink: rest},
+	)
+	p := New(Config{FlowName: "orders", Router: router}, src, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	if high.count() != 1 || rest.count() != 1 {
+		t.Fatalf("expected 1 event per route, got high=%d default=%d", high.count(), rest.count())
+	}
+	var ce struct {
+		Data map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(high.received[0].event, &ce); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if ce.Data["total"] != 5000 {
+		t.Errorf("high-value route got total %d, want 5000", ce.Data["total"])
+	}
+}
+
+func TestPipeline_Routes_FanOut(t *testing.T) {
+	src := &concurrentSource{events: testEvents(`{"total":5000}`, `{"total":10}`), done: make(chan struct{})}
+	audit, high := &mockSink{}, &mockSink{}
+	router := newTestRouter(t, RouteFanOut,
+		Route{Name: "audit", Sink: audit},
+		Route{Name: "high-value", When: "data.total > 1000", Sink: high},
+	)
+	p := New(Config{FlowName: "orders", Router: router}, src, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	if audit.count() != 2 {
+		t.Errorf("expected 2 audit events, got %d", audit.count())
+	}
+	if high.count() != 1 {
+		t.Errorf("expected 1 high-value event, got %d", high.count())
+	}
+}
+
+func TestPipeline_Routes_NoMatch_SendsToDLQ(t *testing.T) {
+	src := &concurrentSource{events: testEvents(`{"total":10}`, `{"amount":"n/a"}`), done: make(chan struct{})}
+	sk := &mockSink{}
+	pub := &mockPublisher{}
+	router := newTestRouter(t, "", Route{Name: "high-value", When: "data.total > 1000", Sink: sk})
+	p := New(Config{FlowName: "orders", SourceType: "kafka", Router: router}, src, nil, nil, dlq.NewHandler(pub), nil)
+
+	runUntil(t, p, src.done)
+
+	if sk.count() != 0 {
+		t.Errorf("expected no delivered events, got %d", sk.count())
+	}
+	if pub.count() != 2 {
+		t.Fatalf("expected 2 DLQ events, got %d", pub.count())
+	}
+	if code := pub.published[0].headers["fiso-error-code"]; code != "NO_ROUTE_MATCHED" {
+		t.Errorf("expected NO_ROUTE_MATCHED, got %s", code)
+	}
+}
+
+func TestPipeline_Routes_PartialFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    delivery.CommitPolicy
+		wantDLQ   int
+		wantError bool
+	}{
+		{"sink_or_dlq acks after DLQ", delivery.CommitPolicySinkOrDLQ, 1, false},
+		{"strict sink policy fails the event", delivery.CommitPolicySink, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &concurrentSource{events: testEvents(`{"total":5000}`), done: make(chan struct{})}
+			ok, failing := &mockSink{}, &mockSink{err: errors.New("webhook unavailable")}
+			pub := &mockPublisher{}
+			router := newTestRouter(t, RouteFanOut,
+				Route{Name: "audit", Sink: ok},
+				Route{Name: "webhook", Sink: failing},
+			)
+			p := New(Config{
+				FlowName:        "orders",
+				SourceType:      "kafka",
+				PropagateErrors: true,
+				CommitPolicy:    tt.policy,
+				Router:          router,
+			}, src, nil, nil, dlq.NewHandler(pub), nil)
+
+			runUntil(t, p, src.done)
+
+			if ok.count() != 1 {
+				t.Errorf("expected the audit route to deliver, got %d", ok.count())
+			}
+			if pub.count() != tt.wantDLQ {
+				t.Fatalf("expected %d DLQ events, got %d", tt.wantDLQ, pub.count())
+			}
+			if tt.wantDLQ > 0 && !strings.Contains(pub.published[0].headers["fiso-error-message"], "route webhook") {
+				t.Errorf("expected the DLQ message to name the route, got %v", pub.published[0].headers)
+			}
+			if tt.wantDLQ > 0 && pub.published[0].headers[dlq.HeaderRoute] != "webhook" {
+				t.Errorf("expected the %s header to name the route, got %v", dlq.HeaderRoute, pub.published[0].headers)
+			}
+			err := src.results["k0"]
+			if (err != nil) != tt.wantError {
+				t.Errorf("handler error = %v, want error %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestPipeline_Routes_WrapFailureNamesRoute(t *testing.T) {
+	src := &concurrentSource{events: testEvents(`{"total":5000}`), done: make(chan struct{})}
+	ok := &mockSink{}
+	pub := &mockPublisher{}
+	invalid := &mockTransformer{fn: func(context.Context, []byte) ([]byte, error) { return []byte("{"), nil }}
+	router := newTestRouter(t, RouteFanOut,
+		Route{Name: "audit", Sink: ok},
+		Route{Name: "webhook", Transformer: invalid, Sink: &mockSink{}},
+	)
+	p := New(Config{FlowName: "orders", SourceType: "kafka", Router: router}, src, nil, nil, dlq.NewHandler(pub), nil)
+
+	runUntil(t, p, src.done)
+
+	if pub.count() != 1 {
+		t.Fatalf("expected 1 DLQ event, got %d", pub.count())
+	}
+	headers := pub.published[0].headers
+	if headers["fiso-error-code"] != "CLOUDEVENT_WRAP_FAILED" {
+		t.Errorf("expected CLOUDEVENT_WRAP_FAILED, got %s", headers["fiso-error-code"])
+	}
+	if headers[dlq.HeaderRoute] != "webhook" || !strings.Contains(headers["fiso-error-message"], "route webhook") {
+		t.Errorf("expected the DLQ event to name the route, got %v", headers)
+	}
+	if ok.count() != 1 {
+		t.Errorf("expected the audit route to deliver, got %d", ok.count())
+	}
+}
+
+func TestPipeline_Routes_ReplayToFailedRoute(t *testing.T) {
+	replayed := func(flow string) source.Event {
+		return source.Event{
+			Key:     []byte(flow),
+			Value:   []byte(`{"total":5000}`),
+			Topic:   "orders",
+			Headers: map[string]string{dlq.HeaderRoute: "webhook", "fiso-flow-name": flow},
+		}
+	}
+	src := &concurrentSource{events: []source.Event{replayed("orders"), replayed("other")}, done: make(chan struct{})}
+	audit, webhook := &mockSink{}, &mockSink{}
+	router := newTestRouter(t, RouteFanOut, Route{Name: "audit", Sink: audit}, Route{Name: "webhook", Sink: webhook})
+	p := New(Config{FlowName: "orders", Router: router}, src, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	// Only the event from this flow's DLQ skips the audit route.
+	if audit.count() != 1 || webhook.count() != 2 {
+		t.Errorf("expected audit=1 webhook=2, got audit=%d webhook=%d", audit.count(), webhook.count())
+	}
+}
+
+func TestPipeline_Routes_ConditionVariables(t *testing.T) {
+	events := []source.Event{
+		{Key: []byte("a"), Value: []byte(`{"country":"NO"}`), Topic: "orders", Headers: map[string]string{"tenant": "acme"}},
+		{Key: []byte("b"), Value: []byte(`{"country":"US"}`), Topic: "orders", Headers: map[string]string{"tenant": "acme"}},
+		{Key: []byte("c"), Value: []byte(`{"country":"NO"}`), Topic: "orders"},
+	}
+	src := &concurrentSource{events: events, done: make(chan struct{})}
+	eu := &mockSink{}
+	router, err := NewRouter(RouteFirstMatch, []Route{{
+		Name: "eu",
+		When: `has(headers.tenant) && fiso.lookup("regions", data.country, "") == "eu"`,
+		Sink: eu,
+	}, {Name: "rest", Sink: &mockSink{}}}, celext.WithLookups(map[string]map[string]string{"regions": {"NO": "eu"}}))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	p := New(Config{FlowName: "orders", Router: router}, src, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	if eu.count() != 1 {
+		t.Errorf("expected 1 event on the eu route, got %d", eu.count())
+	}
+}
+
+func TestPipeline_Routes_RouteTransform(t *testing.T) {
+	src := &concurrentSource{events: testEvents(`{"total":5000}`), done: make(chan struct{})}
+	plain, slim := &mockSink{}, &mockSink{}
+	router := newTestRouter(t, RouteFanOut,
+		Route{Name: "plain", Sink: plain},
+		Route{Name: "slim", Sink: slim, Transformer: &mockTransformer{
+			fn: func(_ context.Context, _ []byte) ([]byte, error) { return []byte(`{"slim":true}`), nil },
+		}},
+	)
+	p := New(Config{FlowName: "orders", Router: router}, src, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	runUntil(t, p, src.done)
+
+	if plain.count() != 1 || slim.count() != 1 {
+		t.Fatalf("expected 1 event per route, got plain=%d slim=%d", plain.count(), slim.count())
+	}
+	if !strings.Contains(string(plain.received[0].event), `"total":5000`) {
+		t.Errorf("plain route got %s", plain.received[0].event)
+	}
+	if !strings.Contains(string(slim.received[0].event), `"slim":true`) {
+		t.Errorf("slim route got %s", slim.received[0].event)
+	}
+}
+
+func TestNewRouter_InvalidCondition(t *testing.T) {
+	for _, when := range []string{"data.total >", `"high"`} {
+		if _, err := NewRouter("", []Route{{Name: "r", When: when, Sink: &mockSink{}}}); err == nil {
+			t.Errorf("expected an error for condition %q", when)
+		}
+	}
+	if _, err := NewRouter("broadcast", nil); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestPipeline_Routes_ShutdownClosesRouteSinks(t *testing.T) {
+	closeErr := errors.New("close failed")
+	router := newTestRouter(t, "", Route{Name: "a", Sink: &mockSink{}}, Route{Name: "b", Sink: &mockSink{closeErr: closeErr}})
+	p := New(Config{FlowName: "orders", Router: router}, &mockSource{}, nil, nil, dlq.NewHandler(&mockPublisher{}), nil)
+
+	err := p.Shutdown(context.Background())
+	if !errors.Is(err, closeErr) {
+		t.Fatalf("expected the route sink close error, got %v", err)
+	}
+}
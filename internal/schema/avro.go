@@ -0,0 +1,826 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvroEncoder encodes JSON payloads as Confluent wire-format Avro using the
+// writer schema of a registry subject. It accepts the JSON produced by
+// AvroCodec: union values may be plain (the first matching branch is used)
+// or wrapped as {"<branch type>": value}, bytes and fixed are base64
+// strings, and logical types accept either their string form or the
+// underlying number. Missing record fields take their schema default.
+type AvroEncoder struct {
+	resolver *subjectResolver
+	mu       sync.Mutex
+	schemas  map[int]*avroType
+}
+
+// Encode converts a JSON payload to wire-format Avro.
+func (e *AvroEncoder) Encode(ctx context.Context, data []byte) ([]byte, error) {
+	s, err := e.resolver.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, err := e.schema(s)
+	if err != nil {
+		return nil, err
+	}
+	v, err := unmarshalNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	out, err := appendAvro(AppendWireHeader(nil, s.ID), t, v)
+	if err != nil {
+		return nil, fmt.Errorf("encode avro with schema %d: %w", s.ID, err)
+	}
+	return out, nil
+}
+
+// ContentType returns application/avro.
+func (e *AvroEncoder) ContentType() string { return "application/avro" }
+
+func (e *AvroEncoder) schema(s *Schema) (*avroType, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if t, ok := e.schemas[s.ID]; ok {
+		return t, nil
+	}
+	if typ := strings.ToUpper(s.Type); typ != "" && typ != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %s, not AVRO", s.ID, s.Type)
+	}
+	t, err := parseAvroSchema(s.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", s.ID, err)
+	}
+	e.schemas[s.ID] = t
+	return t, nil
+}
+
+// AvroCodec decodes Confluent wire-format Avro messages to JSON. Writer
+// schemas are fetched from the registry by the ID in each message.
+//
+// Records become JSON objects, unions decode to the value of the selected
+// branch, bytes and fixed become base64 strings, and the date,
+// timestamp-millis, timestamp-micros and decimal logical types become
+// strings.
+type AvroCodec struct {
+	registry Registry
+	mu       sync.RWMutex
+	schemas  map[int]*avroType
+}
+
+// NewAvroCodec creates an Avro codec that resolves schemas from registry.
+func NewAvroCodec(registry Registry) *AvroCodec {
+	return &AvroCodec{registry: registry, schemas: make(map[int]*avroType)}
+}
+
+// Decode converts a wire-format Avro message to JSON.
+func (c *AvroCodec) Decode(data []byte) ([]byte, error) {
+	id, payload, err := ParseWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	t, err := c.schema(id)
+	if err != nil {
+		return nil, err
+	}
+	r := &avroReader{buf: payload}
+	v, err := r.decode(t)
+	if err != nil {
+		return nil, fmt.Errorf("decode avro with schema %d: %w", id, err)
+	}
+	return json.Marshal(v)
+}
+
+// Validate checks that data decodes with its writer schema.
+func (c *AvroCodec) Validate(data []byte) error {
+	_, err := c.Decode(data)
+	return err
+}
+
+func (c *AvroCodec) schema(id int) (*avroType, error) {
+	c.mu.RLock()
+	t, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	s, err := lookup(c.registry, id)
+	if err != nil {
+		return nil, err
+	}
+	if typ := strings.ToUpper(s.Type); typ != "" && typ != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %s, not AVRO", id, s.Type)
+	}
+	t, err = parseAvroSchema(s.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = t
+	c.mu.Unlock()
+	return t, nil
+}
+
+// avroType is a parsed Avro schema node.
+type avroType struct {
+	kind     string // a primitive type name, record, enum, array, map, fixed or union
+	name     string
+	logical  string
+	scale    int
+	fields   []avroField
+	symbols  []string
+	items    *avroType
+	values   *avroType
+	size     int
+	branches []*avroType
+}
+
+type avroField struct {
+	name       string
+	typ        *avroType
+	def        interface{}
+	hasDefault bool
+}
+
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parseAvroSchema parses an Avro schema in its JSON form.
+func parseAvroSchema(text string) (*avroType, error) {
+	var node interface{}
+	if err := json.Unmarshal([]byte(text), &node); err != nil {
+		// Primitive schemas may be given without quotes.
+		if avroPrimitives[strings.TrimSpace(text)] {
+			return &avroType{kind: strings.TrimSpace(text)}, nil
+		}
+		return nil, fmt.Errorf("parse avro schema: %w", err)
+	}
+	p := &avroParser{names: make(map[string]*avroType)}
+	return p.parse(node, "")
+}
+
+type avroParser struct {
+	names map[string]*avroType
+}
+
+func (p *avroParser) parse(node interface{}, namespace string) (*avroType, error) {
+	switch n := node.(type) {
+	case string:
+		if avroPrimitives[n] {
+			return &avroType{kind: n}, nil
+		}
+		if t, ok := p.names[qualify(n, namespace)]; ok {
+			return t, nil
+		}
+		if t, ok := p.names[n]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("unknown avro type %q", n)
+
+	case []interface{}:
+		t := &avroType{kind: "union"}
+		for _, b := range n {
+			bt, err := p.parse(b, namespace)
+			if err != nil {
+				return nil, err
+			}
+			t.branches = append(t.branches, bt)
+		}
+		return t, nil
+
+	case map[string]interface{}:
+		return p.parseComplex(n, namespace)
+	}
+	return nil, fmt.Errorf("invalid avro schema node %v", node)
+}
+
+func (p *avroParser) parseComplex(n map[string]interface{}, namespace string) (*avroType, error) {
+	typ, ok := n["type"].(string)
+	if !ok {
+		// {"type": {...}} or {"type": [...]}
+		return p.parse(n["type"], namespace)
+	}
+	logical, _ := n["logicalType"].(string)
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := n["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("avro %s requires a name", typ)
+		}
+		if ns, ok := n["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		fullname := qualify(name, namespace)
+		if i := strings.LastIndex(fullname, "."); i >= 0 {
+			namespace = fullname[:i]
+		}
+		t := &avroType{kind: typ, name: fullname, logical: logical}
+		if typ == "error" {
+			t.kind = "record"
+		}
+		// Register before parsing fields so records can refer to themselves.
+		p.names[fullname] = t
+
+		switch typ {
+		case "record", "error":
+			fields, _ := n["fields"].([]interface{})
+			for _, f := range fields {
+				fm, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("record %s: invalid field", fullname)
+				}
+				fname, _ := fm["name"].(string)
+				ft, err := p.parse(fm["type"], namespace)
+				if err != nil {
+					return nil, fmt.Errorf("record %s field %q: %w", fullname, fname, err)
+				}
+				def, hasDefault := fm["default"]
+				t.fields = append(t.fields, avroField{name: fname, typ: ft, def: def, hasDefault: hasDefault})
+			}
+		case "enum":
+			symbols, _ := n["symbols"].([]interface{})
+			for _, s := range symbols {
+				sym, _ := s.(string)
+				t.symbols = append(t.symbols, sym)
+			}
+		case "fixed":
+			size, _ := n["size"].(float64)
+			t.size = int(size)
+			scale, _ := n["scale"].(float64)
+			t.scale = int(scale)
+		}
+		return t, nil
+
+	case "array":
+		items, err := p.parse(n["items"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("array items: %w", err)
+		}
+		return &avroType{kind: "array", items: items}, nil
+
+	case "map":
+		values, err := p.parse(n["values"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("map values: %w", err)
+		}
+		return &avroType{kind: "map", values: values}, nil
+	}
+
+	if avroPrimitives[typ] {
+		scale, _ := n["scale"].(float64)
+		return &avroType{kind: typ, logical: logical, scale: int(scale)}, nil
+	}
+	return p.parse(typ, namespace)
+}
+
+func qualify(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// maxZeroWidthItems caps the array items of a message that take no bytes
+// to encode, such as nulls, so that a large block count cannot make a few
+// bytes decode for a long time.
+const maxZeroWidthItems = 1 << 16
+
+// avroReader decodes Avro binary encoding.
+type avroReader struct {
+	buf        []byte
+	pos        int
+	zeroWidths int64 // zero-width array items decoded so far
+}
+
+func (r *avroReader) long() (int64, error) {
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint at offset %d", r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *avroReader) read(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("length %d exceeds remaining %d bytes at offset %d", n, len(r.buf)-r.pos, r.pos)
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *avroReader) bytes() ([]byte, error) {
+	n, err := r.long()
+	if err != nil {
+		return nil, err
+	}
+	return r.read(n)
+}
+
+func (r *avroReader) decode(t *avroType) (interface{}, error) {
+	switch t.kind {
+	case "null":
+		return nil, nil
+
+	case "boolean":
+		b, err := r.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+
+	case "int", "long":
+		v, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == "int" && (v < math.MinInt32 || v > math.MaxInt32) {
+			return nil, fmt.Errorf("int value %d out of range", v)
+		}
+		switch t.logical {
+		case "date":
+			return time.Unix(v*86400, 0).UTC().Format("2006-01-02"), nil
+		case "timestamp-millis":
+			return time.UnixMilli(v).UTC().Format(time.RFC3339Nano), nil
+		case "timestamp-micros":
+			return time.UnixMicro(v).UTC().Format(time.RFC3339Nano), nil
+		}
+		return v, nil
+
+	case "float":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+
+	case "double":
+		b, err := r.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+
+	case "bytes":
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return bytesValue(t, b), nil
+
+	case "fixed":
+		b, err := r.read(int64(t.size))
+		if err != nil {
+			return nil, err
+		}
+		return bytesValue(t, b), nil
+
+	case "string":
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+
+	case "record":
+		out := make(map[string]interface{}, len(t.fields))
+		for _, f := range t.fields {
+			v, err := r.decode(f.typ)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.name, f.name, err)
+			}
+			out[f.name] = v
+		}
+		return out, nil
+
+	case "enum":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(t.symbols)) {
+			return nil, fmt.Errorf("enum %s: index %d out of range", t.name, i)
+		}
+		return t.symbols[i], nil
+
+	case "array":
+		out := []interface{}{}
+		err := r.blocks(zeroWidth(t.items, nil), func() error {
+			v, err := r.decode(t.items)
+			if err != nil {
+				return err
+			}
+			out = append(out, v)
+			return nil
+		})
+		return out, err
+
+	case "map":
+		out := map[string]interface{}{}
+		err := r.blocks(false, func() error {
+			k, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			v, err := r.decode(t.values)
+			if err != nil {
+				return err
+			}
+			out[string(k)] = v
+			return nil
+		})
+		return out, err
+
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(t.branches)) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return r.decode(t.branches[i])
+	}
+	return nil, fmt.Errorf("unsupported avro type %q", t.kind)
+}
+
+// blocks reads the blocks of an array or map, calling item for each entry.
+// Entries take at least one byte each unless zero is set.
+func (r *avroReader) blocks(zero bool, item func() error) error {
+	for {
+		count, err := r.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			if count == math.MinInt64 {
+				return fmt.Errorf("invalid block count %d", count)
+			}
+			// A negative count is followed by the block size in bytes.
+			count = -count
+			size, err := r.long()
+			if err != nil {
+				return err
+			}
+			if size < 0 || size > int64(len(r.buf)-r.pos) {
+				return fmt.Errorf("block size %d exceeds remaining bytes", size)
+			}
+		}
+		if zero {
+			r.zeroWidths += count
+			if r.zeroWidths > maxZeroWidthItems {
+				return fmt.Errorf("more than %d zero-width array items", maxZeroWidthItems)
+			}
+		} else if count > int64(len(r.buf)-r.pos) {
+			return fmt.Errorf("block count %d exceeds remaining bytes", count)
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// zeroWidth reports whether values of t are encoded in zero bytes. visiting
+// holds the records being checked, which a recursive record is not.
+func zeroWidth(t *avroType, visiting map[*avroType]bool) bool {
+	switch t.kind {
+	case "null":
+		return true
+	case "fixed":
+		return t.size == 0
+	case "record":
+		if visiting[t] {
+			return false
+		}
+		if visiting == nil {
+			visiting = make(map[*avroType]bool)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		for _, f := range t.fields {
+			if !zeroWidth(f.typ, visiting) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func bytesValue(t *avroType, b []byte) interface{} {
+	if t.logical == "decimal" {
+		unscaled := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			// Two's complement negative number.
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.scale)), nil)
+		return new(big.Rat).SetFrac(unscaled, scale).FloatString(t.scale)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// unmarshalNumbers decodes JSON keeping numbers as json.Number, so 64-bit
+// integers survive intact.
+func unmarshalNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON data: %w", err)
+	}
+	return v, nil
+}
+
+// appendAvro appends the Avro binary encoding of v to dst.
+func appendAvro(dst []byte, t *avroType, v interface{}) ([]byte, error) {
+	switch t.kind {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("expected null, got %T", v)
+		}
+		return dst, nil
+
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected boolean, got %T", v)
+		}
+		if b {
+			return append(dst, 1), nil
+		}
+		return append(dst, 0), nil
+
+	case "int", "long":
+		n, err := avroInteger(t, v)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == "int" && (n < math.MinInt32 || n > math.MaxInt32) {
+			return nil, fmt.Errorf("%d overflows int", n)
+		}
+		return binary.AppendVarint(dst, n), nil
+
+	case "float", "double":
+		f, err := avroNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == "float" {
+			return binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(f))), nil
+		}
+		return binary.LittleEndian.AppendUint64(dst, math.Float64bits(f)), nil
+
+	case "bytes", "fixed":
+		b, err := avroBytes(t, v)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == "fixed" {
+			if len(b) != t.size {
+				return nil, fmt.Errorf("fixed %s: expected %d bytes, got %d", t.name, t.size, len(b))
+			}
+			return append(dst, b...), nil
+		}
+		return append(binary.AppendVarint(dst, int64(len(b))), b...), nil
+
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", v)
+		}
+		return append(binary.AppendVarint(dst, int64(len(str))), str...), nil
+
+	case "record":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record %s: expected object, got %T", t.name, v)
+		}
+		var err error
+		for _, f := range t.fields {
+			fv, present := m[f.name]
+			if !present && f.hasDefault {
+				fv = f.def
+			}
+			if dst, err = appendAvro(dst, f.typ, fv); err != nil {
+				if !present && !f.hasDefault {
+					return nil, fmt.Errorf("%s.%s: missing field", t.name, f.name)
+				}
+				return nil, fmt.Errorf("%s.%s: %w", t.name, f.name, err)
+			}
+		}
+		return dst, nil
+
+	case "enum":
+		sym, _ := v.(string)
+		for i, s := range t.symbols {
+			if s == sym {
+				return binary.AppendVarint(dst, int64(i)), nil
+			}
+		}
+		return nil, fmt.Errorf("enum %s: unknown symbol %v", t.name, v)
+
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", v)
+		}
+		if len(items) > 0 {
+			dst = binary.AppendVarint(dst, int64(len(items)))
+			var err error
+			for i, item := range items {
+				if dst, err = appendAvro(dst, t.items, item); err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+		}
+		return append(dst, 0), nil
+
+	case "map":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", v)
+		}
+		if len(m) > 0 {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			dst = binary.AppendVarint(dst, int64(len(m)))
+			var err error
+			for _, k := range keys {
+				dst = append(binary.AppendVarint(dst, int64(len(k))), k...)
+				if dst, err = appendAvro(dst, t.values, m[k]); err != nil {
+					return nil, fmt.Errorf("[%q]: %w", k, err)
+				}
+			}
+		}
+		return append(dst, 0), nil
+
+	case "union":
+		i, bv := unionBranch(t, v)
+		if i < 0 {
+			return nil, fmt.Errorf("value of type %T matches no union branch", v)
+		}
+		return appendAvro(binary.AppendVarint(dst, int64(i)), t.branches[i], bv)
+	}
+	return nil, fmt.Errorf("unsupported avro type %q", t.kind)
+}
+
+// unionBranch selects the union branch for v. A single-key object naming a
+// branch ({"string": "x"}, {"com.acme.Order": {...}}) selects that branch
+// explicitly; otherwise the first branch v can be encoded as is used.
+func unionBranch(t *avroType, v interface{}) (int, interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) == 1 {
+		for k, inner := range m {
+			for i, b := range t.branches {
+				if b.branchName() == k {
+					return i, inner
+				}
+			}
+		}
+	}
+	for i, b := range t.branches {
+		if _, err := appendAvro(nil, b, v); err == nil {
+			return i, v
+		}
+	}
+	return -1, nil
+}
+
+func (t *avroType) branchName() string {
+	if t.name != "" {
+		return t.name
+	}
+	return t.kind
+}
+
+func avroInteger(t *avroType, v interface{}) (int64, error) {
+	if str, ok := v.(string); ok {
+		switch t.logical {
+		case "date":
+			d, err := time.Parse("2006-01-02", str)
+			if err != nil {
+				return 0, err
+			}
+			return d.Unix() / 86400, nil
+		case "timestamp-millis", "timestamp-micros":
+			ts, err := time.Parse(time.RFC3339Nano, str)
+			if err != nil {
+				return 0, err
+			}
+			if t.logical == "timestamp-millis" {
+				return ts.UnixMilli(), nil
+			}
+			return ts.UnixMicro(), nil
+		}
+		return 0, fmt.Errorf("expected %s, got string", t.kind)
+	}
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("expected %s, got %s", t.kind, n)
+		}
+		return i, nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("expected %s, got %v", t.kind, n)
+		}
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("expected %s, got %T", t.kind, v)
+}
+
+func avroNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Float64()
+	case float64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("expected number, got %T", v)
+}
+
+func avroBytes(t *avroType, v interface{}) ([]byte, error) {
+	if t.logical == "decimal" {
+		return decimalBytes(t, v)
+	}
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 string, got %T", v)
+	}
+	return base64.StdEncoding.DecodeString(str)
+}
+
+// decimalBytes encodes a decimal, given as a string or number, as the
+// big-endian two's complement of its unscaled value.
+func decimalBytes(t *avroType, v interface{}) ([]byte, error) {
+	var text string
+	switch n := v.(type) {
+	case string:
+		text = n
+	case json.Number:
+		text = n.String()
+	case float64:
+		text = strconv.FormatFloat(n, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("expected decimal, got %T", v)
+	}
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", text)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.scale)), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("decimal %s has more than %d fractional digits", text, t.scale)
+	}
+	unscaled := r.Num()
+
+	size := t.size
+	if t.kind == "fixed" && size <= 0 {
+		return nil, fmt.Errorf("fixed %s: invalid size %d", t.name, size)
+	}
+	if t.kind == "bytes" {
+		// Minimal length that keeps the sign bit.
+		size = unscaled.BitLen()/8 + 1
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(size*8-1))
+	if unscaled.Cmp(limit) >= 0 || unscaled.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return nil, fmt.Errorf("decimal %s does not fit in %d bytes", text, size)
+	}
+	if unscaled.Sign() < 0 {
+		// Two's complement: x + 2^(8*size) has exactly size bytes.
+		unscaled = new(big.Int).Add(unscaled, new(big.Int).Lsh(limit, 1))
+	}
+	b := unscaled.Bytes()
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out, nil
+}
@@ -0,0 +1,201 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// idRegistry serves schemas by ID and counts lookups.
+type idRegistry struct {
+	schemas map[int]*Schema
+	calls   int
+}
+
+func (r *idRegistry) GetByID(_ context.Context, id int) (*Schema, error) {
+	r.calls++
+	s, ok := r.schemas[id]
+	if !ok {
+		return nil, fmt.Errorf("schema %d not found", id)
+	}
+	return s, nil
+}
+
+func (r *idRegistry) GetLatest(_ context.Context, subject string) (*Schema, error) {
+	return nil, fmt.Errorf("subject %s not found", subject)
+}
+
+func wire(id int, payload ...[]byte) []byte {
+	out := []byte{MagicByte, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(out[1:], uint32(id))
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func avroLong(v int64) []byte {
+	return binary.AppendVarint(nil, v)
+}
+
+func avroString(s string) []byte {
+	return append(avroLong(int64(len(s))), s...)
+}
+
+const orderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "amount", "type": "double"},
+		{"name": "quantity", "type": "int"},
+		{"name": "paid", "type": "boolean"},
+		{"name": "note", "type": ["null", "string"]},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "SHIPPED"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "long"}},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "next", "type": ["null", "Order"]}
+	]
+}`
+
+func TestAvroCodec_Decode(t *testing.T) {
+	reg := &idRegistry{schemas: map[int]*Schema{42: {ID: 42, Schema: orderSchema}}}
+	codec := NewAvroCodec(reg)
+
+	amount := make([]byte, 8)
+	binary.LittleEndian.PutUint64(amount, 0x4058ff5c28f5c28f) // 99.99
+	msg := wire(42,
+		avroString("o-1"),
+		amount,
+		avroLong(3),
+		[]byte{1},
+		avroLong(1), avroString("fragile"),
+		avroLong(1),
+		avroLong(2), avroString("a"), avroString("b"), avroLong(0),
+		avroLong(-1), avroLong(4), avroString("x"), avroLong(7), avroLong(0),
+		avroLong(1700000000000),
+		avroLong(19000),
+		avroLong(2), []byte{0xfe, 0x0c}, // -500 → -5.00
+		avroLong(0),
+	)
+
+	out, err := codec.Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"id":       "o-1",
+		"amount":   99.99,
+		"quantity": float64(3),
+		"paid":     true,
+		"note":     "fragile",
+		"status":   "SHIPPED",
+		"created":  "2023-11-14T22:13:20Z",
+		"day":      "2022-01-08",
+		"price":    "-5.00",
+		"next":     nil,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if tags, _ := got["tags"].([]interface{}); len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("tags = %v", got["tags"])
+	}
+	if attrs, _ := got["attrs"].(map[string]interface{}); attrs["x"] != float64(7) {
+		t.Errorf("attrs = %v", got["attrs"])
+	}
+
+	if _, err := codec.Decode(msg); err != nil {
+		t.Fatalf("second Decode() error = %v", err)
+	}
+	if reg.calls != 1 {
+		t.Errorf("expected parsed schema to be cached, got %d lookups", reg.calls)
+	}
+}
+
+func TestAvroCodec_Errors(t *testing.T) {
+	reg := &idRegistry{schemas: map[int]*Schema{
+		1: {ID: 1, Schema: `"string"`},
+		2: {ID: 2, Type: "PROTOBUF", Schema: `syntax = "proto3";`},
+		3: {ID: 3, Schema: `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "Missing"}]}`},
+		4: {ID: 4, Schema: `{"type": "array", "items": "null"}`},
+		5: {ID: 5, Schema: `{"type": "array", "items": {"type": "record", "name": "Empty", "fields": []}}`},
+		6: {ID: 6, Schema: `"int"`},
+		7: {ID: 7, Schema: `{"type": "array", "items": "string"}`},
+	}}
+	codec := NewAvroCodec(reg)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"short message", []byte{0, 1}, "too short"},
+		{"bad magic byte", []byte{1, 0, 0, 0, 1, 0}, "magic byte"},
+		{"unknown schema", wire(9, avroString("x")), "not found"},
+		{"wrong schema type", wire(2), "not AVRO"},
+		{"invalid schema", wire(3), "unknown avro type"},
+		{"truncated payload", wire(1, avroLong(10), []byte("abc")), "exceeds remaining"},
+		{"huge null array", wire(4, avroLong(math.MaxInt64)), "zero-width"},
+		{"huge empty record array", wire(5, avroLong(maxZeroWidthItems), avroLong(1)), "zero-width"},
+		{"int out of range", wire(6, avroLong(math.MaxInt32+1)), "out of range"},
+		{"huge block count", wire(7, avroLong(1<<40), avroString("a")), "exceeds remaining"},
+		{"minimum block count", wire(7, avroLong(math.MinInt64)), "invalid block count"},
+		{"huge block size", wire(7, avroLong(-1), avroLong(1<<40), avroString("a")), "exceeds remaining"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := codec.Validate(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	out, err := codec.Decode(wire(1, avroString("plain")))
+	if err != nil || string(out) != `"plain"` {
+		t.Errorf("Decode() = %s, %v", out, err)
+	}
+}
+
+func TestAvroCodec_ZeroWidthItems(t *testing.T) {
+	reg := &idRegistry{schemas: map[int]*Schema{1: {ID: 1, Schema: `{"type": "array", "items": "null"}`}}}
+	out, err := NewAvroCodec(reg).Decode(wire(1, avroLong(3), avroLong(0)))
+	if err != nil || string(out) != `[null,null,null]` {
+		t.Errorf("Decode() = %s, %v", out, err)
+	}
+}
+
+func FuzzAvroCodec_Decode(f *testing.F) {
+	reg := &idRegistry{schemas: map[int]*Schema{
+		1: {ID: 1, Schema: orderSchema},
+		2: {ID: 2, Schema: `{"type": "array", "items": "null"}`},
+		3: {ID: 3, Schema: `{"type": "map", "values": {"type": "array", "items": "int"}}`},
+	}}
+	codec := NewAvroCodec(reg)
+
+	f.Add(wire(1, avroString("o-1"), make([]byte, 8), avroLong(3), []byte{0}, avroLong(0), avroLong(0),
+		avroLong(0), avroLong(0), avroLong(0), avroLong(0), avroLong(0), avroLong(0)))
+	f.Add(wire(2, avroLong(2), avroLong(0)))
+	f.Add(wire(3, avroLong(-1), avroLong(4), avroString("k"), avroLong(1), avroLong(5), avroLong(0), avroLong(0)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		out, err := codec.Decode(data)
+		if err == nil && !json.Valid(out) {
+			t.Errorf("Decode(%x) = %s, not JSON", data, out)
+		}
+	})
+}
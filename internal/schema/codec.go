@@ -1,8 +1,15 @@
 package schema
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 // Codec decodes wire-format messages and validates them against a schema.
@@ -13,18 +20,34 @@ type Codec interface {
 	Validate(data []byte) error
 }
 
-// JSONCodec validates JSON data against a JSON Schema.
+// JSONCodec validates JSON data against a JSON Schema. Schemas without a
+// $schema keyword are treated as draft 2020-12; earlier drafts are honoured
+// when declared. The format keyword is asserted, not just annotated.
 type JSONCodec struct {
-	schema map[string]interface{}
+	schema *jsonschema.Schema
 }
 
+// jsonSchemaURL is the location inline schemas are compiled under. Relative
+// $ref values resolve against it, i.e. against the working directory.
+const jsonSchemaURL = "schema.json"
+
 // NewJSONCodec creates a JSON codec from a JSON Schema string.
 func NewJSONCodec(schemaStr string) (*JSONCodec, error) {
-	var schema map[string]interface{}
-	if err := json.Unmarshal([]byte(schemaStr), &schema); err != nil {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schemaStr))
+	if err != nil {
 		return nil, fmt.Errorf("parse JSON schema: %w", err)
 	}
-	return &JSONCodec{schema: schema}, nil
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.AssertFormat()
+	if err := c.AddResource(jsonSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("load JSON schema: %w", err)
+	}
+	compiled, err := c.Compile(jsonSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("compile JSON schema: %w", err)
+	}
+	return &JSONCodec{schema: compiled}, nil
 }
 
 // Decode for JSON is a no-op passthrough — data is already JSON.
@@ -35,83 +58,81 @@ func (c *JSONCodec) Decode(data []byte) ([]byte, error) {
 	return data, nil
 }
 
-// Validate checks that the JSON data has the required fields defined in the schema.
+// Validate checks data against the schema. A document that does not match
+// is reported as a *ValidationError listing every violation.
 func (c *JSONCodec) Validate(data []byte) error {
 	if !json.Valid(data) {
 		return fmt.Errorf("invalid JSON data")
 	}
-
-	var parsed map[string]interface{}
-	if err := json.Unmarshal(data, &parsed); err != nil {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
 		return fmt.Errorf("unmarshal JSON for validation: %w", err)
 	}
 
-	// Check required fields if defined in schema
-	if required, ok := c.schema["required"]; ok {
-		if reqList, ok := required.([]interface{}); ok {
-			for _, req := range reqList {
-				fieldName, ok := req.(string)
-				if !ok {
-					continue
-				}
-				if _, exists := parsed[fieldName]; !exists {
-					return fmt.Errorf("missing required field: %s", fieldName)
-				}
-			}
-		}
+	err = c.schema.Validate(doc)
+	var verr *jsonschema.ValidationError
+	if errors.As(err, &verr) {
+		return &ValidationError{Violations: violations(verr, nil)}
 	}
+	return err
+}
+
+// ValidationError lists the ways a JSON document violates its schema.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Strings(), "; ")
+}
 
-	// Check type constraints on properties
-	properties, _ := c.schema["properties"].(map[string]interface{})
-	if properties != nil {
-		for key, val := range parsed {
-			propSchema, ok := properties[key].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			expectedType, _ := propSchema["type"].(string)
-			if expectedType == "" {
-				continue
-			}
-			if err := checkType(expectedType, val); err != nil {
-				return fmt.Errorf("field %q: %w", key, err)
-			}
-		}
+// Strings returns the violations in their display form.
+func (e *ValidationError) Strings() []string {
+	out := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		out[i] = v.String()
 	}
+	return out
+}
+
+// Violation is a single schema violation.
+type Violation struct {
+	// Path is the JSON pointer (RFC 6901) of the offending value; the empty
+	// string is the document root.
+	Path    string
+	Message string
+}
 
-	return nil
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + v.Message
+}
+
+var messagePrinter = message.NewPrinter(language.English)
+
+// violations flattens a validation error tree into its leaf errors, which
+// carry the specific failures; inner nodes only say that a subschema failed.
+func violations(e *jsonschema.ValidationError, out []Violation) []Violation {
+	if len(e.Causes) == 0 {
+		return append(out, Violation{
+			Path:    jsonPointer(e.InstanceLocation),
+			Message: e.ErrorKind.LocalizedString(messagePrinter),
+		})
+	}
+	for _, cause := range e.Causes {
+		out = violations(cause, out)
+	}
+	return out
 }
 
-func checkType(expected string, val interface{}) error {
-	switch expected {
-	case "string":
-		if _, ok := val.(string); !ok {
-			return fmt.Errorf("expected string, got %T", val)
-		}
-	case "number":
-		if _, ok := val.(float64); !ok {
-			return fmt.Errorf("expected number, got %T", val)
-		}
-	case "integer":
-		f, ok := val.(float64)
-		if !ok {
-			return fmt.Errorf("expected integer, got %T", val)
-		}
-		if f != float64(int64(f)) {
-			return fmt.Errorf("expected integer, got float")
-		}
-	case "boolean":
-		if _, ok := val.(bool); !ok {
-			return fmt.Errorf("expected boolean, got %T", val)
-		}
-	case "object":
-		if _, ok := val.(map[string]interface{}); !ok {
-			return fmt.Errorf("expected object, got %T", val)
-		}
-	case "array":
-		if _, ok := val.([]interface{}); !ok {
-			return fmt.Errorf("expected array, got %T", val)
-		}
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
 	}
-	return nil
+	return b.String()
 }
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -245,3 +246,107 @@ func TestJSONCodec_Validate_IntegerValid(t *testing.T) {
 		t.Fatal("expected error for string where integer expected")
 	}
 }
+
+func TestJSONCodec_Validate_Draft2020(t *testing.T) {
+	schema := `{
+		"$defs": {
+			"item": {
+				"type": "object",
+				"required": ["sku"],
+				"properties": {
+					"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+					"qty": {"type": "integer", "minimum": 1}
+				},
+				"unevaluatedProperties": false
+			}
+		},
+		"type": "object",
+		"properties": {
+			"email": {"type": "string", "format": "email"},
+			"items": {"type": "array", "items": {"$ref": "#/$defs/item"}, "minItems": 1},
+			"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+			"a/b": {"const": 1}
+		}
+	}`
+	c, err := NewJSONCodec(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	valid := `{"email":"a@example.com","items":[{"sku":"ABC-1","qty":2}],"point":[1,2],"a/b":1}`
+	if err := c.Validate([]byte(valid)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		path string
+	}{
+		{"format", `{"email":"not-an-email"}`, "/email"},
+		{"ref and pattern", `{"items":[{"sku":"abc"}]}`, "/items/0/sku"},
+		{"minimum", `{"items":[{"sku":"ABC-1","qty":0}]}`, "/items/0/qty"},
+		{"unevaluated properties", `{"items":[{"sku":"ABC-1","color":"red"}]}`, "/items/0/color"},
+		{"prefix items", `{"point":[1,2,3]}`, "/point/2"},
+		{"escaped pointer", `{"a/b":2}`, "/a~1b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Validate([]byte(tt.doc))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			for _, v := range verr.Violations {
+				if v.Path == tt.path {
+					return
+				}
+			}
+			t.Errorf("no violation at %s in %v", tt.path, verr.Violations)
+		})
+	}
+}
+
+func TestJSONCodec_Validate_AllViolations(t *testing.T) {
+	c, _ := NewJSONCodec(`{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"name": {"type": "string"}, "age": {"type": "integer"}}
+	}`)
+
+	err := c.Validate([]byte(`{"name":1,"age":"x"}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	got := verr.Strings()
+	want := []string{"(root): missing property 'id'", "/name: got number, want string", "/age: got string, want integer"}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if g == w {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("violations %v missing %q", got, w)
+		}
+	}
+}
+
+func TestJSONCodec_DeclaredDraft(t *testing.T) {
+	// Draft-07 ignores keywords it does not define, such as prefixItems.
+	c, err := NewJSONCodec(`{"$schema":"http://json-schema.org/draft-07/schema#","prefixItems":[{"type":"string"}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Validate([]byte(`[1]`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewJSONCodec_InvalidKeyword(t *testing.T) {
+	if _, err := NewJSONCodec(`{"type":"strnig"}`); err == nil {
+		t.Fatal("expected compile error for invalid type")
+	}
+}
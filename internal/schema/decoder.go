@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Wire formats accepted by NewDecoder.
+const (
+	FormatAvro     = "avro"
+	FormatProtobuf = "protobuf"
+	FormatJSON     = "json"
+)
+
+// DecoderConfig configures decoding of Confluent wire-format source
+// payloads. It is read from `source.config.schema` in flow definitions.
+type DecoderConfig struct {
+	RegistryURL string
+	Format      string        // avro, protobuf or json
+	CacheTTL    time.Duration // registry cache TTL (default: 5m)
+}
+
+// DecoderConfigFromMap reads a DecoderConfig from its YAML map form.
+func DecoderConfigFromMap(m map[string]interface{}) (DecoderConfig, error) {
+	var cfg DecoderConfig
+	cfg.RegistryURL, _ = m["registryUrl"].(string)
+	cfg.Format, _ = m["format"].(string)
+	if ttl, ok := m["cacheTTL"].(string); ok && ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return cfg, fmt.Errorf("cacheTTL %q is not a valid duration", ttl)
+		}
+		cfg.CacheTTL = d
+	}
+	return cfg, nil
+}
+
+// NewDecoder creates a codec that decodes wire-format payloads to JSON.
+func NewDecoder(cfg DecoderConfig) (Codec, error) {
+	var opts []RegistryOption
+	if cfg.CacheTTL > 0 {
+		opts = append(opts, WithCacheTTL(cfg.CacheTTL))
+	}
+	registry, err := NewConfluentRegistry(cfg.RegistryURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(cfg.Format) {
+	case FormatAvro:
+		return NewAvroCodec(registry), nil
+	case FormatProtobuf:
+		return NewProtobufCodec(registry), nil
+	case FormatJSON:
+		return NewRegistryJSONCodec(registry), nil
+	default:
+		return nil, fmt.Errorf("unsupported schema format %q (valid: avro, protobuf, json)", cfg.Format)
+	}
+}
+
+// RegistryJSONCodec decodes Confluent wire-format JSON Schema messages: it
+// strips the wire-format header and validates the payload against the
+// writer schema.
+type RegistryJSONCodec struct {
+	registry Registry
+	mu       sync.RWMutex
+	codecs   map[int]*JSONCodec
+}
+
+// NewRegistryJSONCodec creates a JSON Schema codec that resolves schemas
+// from registry.
+func NewRegistryJSONCodec(registry Registry) *RegistryJSONCodec {
+	return &RegistryJSONCodec{registry: registry, codecs: make(map[int]*JSONCodec)}
+}
+
+// Decode strips the wire-format header and returns the validated JSON
+// payload.
+func (c *RegistryJSONCodec) Decode(data []byte) ([]byte, error) {
+	id, payload, err := ParseWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := c.codec(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := codec.Validate(payload); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	return payload, nil
+}
+
+// Validate checks that data matches its writer schema.
+func (c *RegistryJSONCodec) Validate(data []byte) error {
+	_, err := c.Decode(data)
+	return err
+}
+
+func (c *RegistryJSONCodec) codec(id int) (*JSONCodec, error) {
+	c.mu.RLock()
+	codec, ok := c.codecs[id]
+	c.mu.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	s, err := lookup(c.registry, id)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(s.Type, "JSON") {
+		return nil, fmt.Errorf("schema %d is %s, not JSON", id, schemaTypeName(s))
+	}
+	codec, err = NewJSONCodec(s.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.codecs[id] = codec
+	c.mu.Unlock()
+	return codec, nil
+}
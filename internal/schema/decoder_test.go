@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecoderConfigFromMap(t *testing.T) {
+	cfg, err := DecoderConfigFromMap(map[string]interface{}{
+		"registryUrl": "http://registry:8081",
+		"format":      "avro",
+		"cacheTTL":    "1m",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RegistryURL != "http://registry:8081" || cfg.Format != "avro" || cfg.CacheTTL != time.Minute {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if _, err := DecoderConfigFromMap(map[string]interface{}{"cacheTTL": "soon"}); err == nil {
+		t.Error("expected error for invalid cacheTTL")
+	}
+}
+
+func TestNewDecoder(t *testing.T) {
+	for format, want := range map[string]string{
+		"avro":     "*schema.AvroCodec",
+		"Protobuf": "*schema.ProtobufCodec",
+		"json":     "*schema.RegistryJSONCodec",
+	} {
+		codec, err := NewDecoder(DecoderConfig{RegistryURL: "http://registry:8081", Format: format, CacheTTL: time.Minute})
+		if err != nil {
+			t.Fatalf("NewDecoder(%s) error = %v", format, err)
+		}
+		if got := fmt.Sprintf("%T", codec); got != want {
+			t.Errorf("NewDecoder(%s) = %s, want %s", format, got, want)
+		}
+	}
+
+	if _, err := NewDecoder(DecoderConfig{RegistryURL: "http://registry:8081", Format: "thrift"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if _, err := NewDecoder(DecoderConfig{Format: "avro"}); err == nil {
+		t.Error("expected error without registry URL")
+	}
+}
+
+func TestRegistryJSONCodec(t *testing.T) {
+	reg := &idRegistry{schemas: map[int]*Schema{
+		1: {ID: 1, Type: "JSON", Schema: `{"required": ["id"]}`},
+		2: {ID: 2, Schema: `"string"`},
+	}}
+	codec := NewRegistryJSONCodec(reg)
+
+	out, err := codec.Decode(wire(1, []byte(`{"id":1}`)))
+	if err != nil || string(out) != `{"id":1}` {
+		t.Errorf("Decode() = %s, %v", out, err)
+	}
+	if err := codec.Validate(wire(1, []byte(`{"name":"x"}`))); err == nil || !strings.Contains(err.Error(), "missing property 'id'") {
+		t.Errorf("expected required field error, got %v", err)
+	}
+	if err := codec.Validate(wire(2, []byte(`"x"`))); err == nil || !strings.Contains(err.Error(), "not JSON") {
+		t.Errorf("expected schema type error, got %v", err)
+	}
+}
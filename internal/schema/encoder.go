@@ -0,0 +1,217 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Encoder serializes JSON payloads to Confluent wire format.
+type Encoder interface {
+	// Encode converts a JSON payload to a wire-format message.
+	Encode(ctx context.Context, data []byte) ([]byte, error)
+	// ContentType is the media type of encoded messages.
+	ContentType() string
+}
+
+// SubjectRegistry resolves and registers schemas by subject.
+type SubjectRegistry interface {
+	GetLatest(ctx context.Context, subject string) (*Schema, error)
+	GetVersion(ctx context.Context, subject string, version int) (*Schema, error)
+	Register(ctx context.Context, subject string, schema *Schema) (int, error)
+}
+
+// EncoderConfig configures encoding of sink payloads. It is read from
+// `sink.config.encoding` in flow definitions.
+type EncoderConfig struct {
+	RegistryURL string
+	Format      string // avro, protobuf or json
+	Subject     string // Registry subject of the writer schema
+	// Version pins the subject version; zero uses the latest version,
+	// refreshed after CacheTTL.
+	Version int
+	// AutoRegister registers Schema (or the contents of SchemaFile) under
+	// Subject and encodes with it.
+	AutoRegister bool
+	Schema       string
+	SchemaFile   string
+	// MessageType is the fully-qualified Protobuf message to encode
+	// (default: the first message in the schema).
+	MessageType string
+	CacheTTL    time.Duration // Latest-version cache TTL (default: 5m)
+}
+
+// EncoderConfigFromMap reads an EncoderConfig from its YAML map form.
+func EncoderConfigFromMap(m map[string]interface{}) (EncoderConfig, error) {
+	var cfg EncoderConfig
+	cfg.RegistryURL, _ = m["registryUrl"].(string)
+	cfg.Format, _ = m["format"].(string)
+	cfg.Subject, _ = m["subject"].(string)
+	cfg.AutoRegister, _ = m["autoRegister"].(bool)
+	cfg.Schema, _ = m["schema"].(string)
+	cfg.SchemaFile, _ = m["schemaFile"].(string)
+	cfg.MessageType, _ = m["messageType"].(string)
+	if v, ok := m["version"]; ok {
+		version, ok := v.(int)
+		if !ok || version <= 0 {
+			return cfg, fmt.Errorf("version must be a positive integer")
+		}
+		cfg.Version = version
+	}
+	if ttl, ok := m["cacheTTL"].(string); ok && ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return cfg, fmt.Errorf("cacheTTL %q is not a valid duration", ttl)
+		}
+		cfg.CacheTTL = d
+	}
+	return cfg, nil
+}
+
+// NewEncoder creates an encoder backed by a Confluent schema registry.
+func NewEncoder(cfg EncoderConfig) (Encoder, error) {
+	registry, err := NewConfluentRegistry(cfg.RegistryURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncoderWithRegistry(cfg, registry)
+}
+
+// NewEncoderWithRegistry creates an encoder that resolves schemas from
+// registry. RegistryURL in cfg is ignored.
+func NewEncoderWithRegistry(cfg EncoderConfig, registry SubjectRegistry) (Encoder, error) {
+	if cfg.Subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	format := strings.ToLower(cfg.Format)
+	res := &subjectResolver{
+		registry: registry,
+		subject:  cfg.Subject,
+		version:  cfg.Version,
+		ttl:      cfg.CacheTTL,
+		clock:    time.Now,
+	}
+	if res.ttl <= 0 {
+		res.ttl = 5 * time.Minute
+	}
+	if cfg.AutoRegister {
+		source := cfg.Schema
+		if cfg.SchemaFile != "" {
+			data, err := os.ReadFile(filepath.Clean(cfg.SchemaFile))
+			if err != nil {
+				return nil, fmt.Errorf("read schema: %w", err)
+			}
+			source = string(data)
+		}
+		if source == "" {
+			return nil, fmt.Errorf("autoRegister requires schema or schemaFile")
+		}
+		res.register = &Schema{Schema: source, Type: strings.ToUpper(format)}
+	}
+
+	switch format {
+	case FormatAvro:
+		return &AvroEncoder{resolver: res, schemas: make(map[int]*avroType)}, nil
+	case FormatProtobuf:
+		return &ProtobufEncoder{resolver: res, messageType: cfg.MessageType, messages: make(map[int]protoMessage)}, nil
+	case FormatJSON:
+		return &JSONEncoder{resolver: res, codecs: make(map[int]*JSONCodec)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema format %q (valid: avro, protobuf, json)", cfg.Format)
+	}
+}
+
+// subjectResolver resolves the writer schema of an encoder: a registered
+// schema, a pinned version, or the latest version refreshed after ttl.
+type subjectResolver struct {
+	registry SubjectRegistry
+	subject  string
+	version  int
+	register *Schema
+	ttl      time.Duration
+	clock    func() time.Time
+
+	mu        sync.Mutex
+	current   *Schema
+	fetchedAt time.Time
+}
+
+func (r *subjectResolver) resolve(ctx context.Context) (*Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Registered and pinned schemas never change once resolved.
+	if r.current != nil && (r.register != nil || r.version > 0 || r.clock().Sub(r.fetchedAt) <= r.ttl) {
+		return r.current, nil
+	}
+
+	var s *Schema
+	var err error
+	switch {
+	case r.register != nil:
+		var id int
+		id, err = r.registry.Register(ctx, r.subject, r.register)
+		if err == nil {
+			registered := *r.register
+			registered.ID, registered.Subject = id, r.subject
+			s = &registered
+		}
+	case r.version > 0:
+		s, err = r.registry.GetVersion(ctx, r.subject, r.version)
+	default:
+		s, err = r.registry.GetLatest(ctx, r.subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.current, r.fetchedAt = s, r.clock()
+	return s, nil
+}
+
+// JSONEncoder validates JSON payloads against a registry JSON Schema and
+// prefixes them with the wire-format header.
+type JSONEncoder struct {
+	resolver *subjectResolver
+	mu       sync.Mutex
+	codecs   map[int]*JSONCodec
+}
+
+// Encode validates data and returns it in wire format.
+func (e *JSONEncoder) Encode(ctx context.Context, data []byte) ([]byte, error) {
+	s, err := e.resolver.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := e.codec(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := codec.Validate(data); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", s.ID, err)
+	}
+	return append(AppendWireHeader(nil, s.ID), data...), nil
+}
+
+// ContentType returns application/json.
+func (e *JSONEncoder) ContentType() string { return "application/json" }
+
+func (e *JSONEncoder) codec(s *Schema) (*JSONCodec, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if codec, ok := e.codecs[s.ID]; ok {
+		return codec, nil
+	}
+	if !strings.EqualFold(s.Type, "JSON") {
+		return nil, fmt.Errorf("schema %d is %s, not JSON", s.ID, schemaTypeName(s))
+	}
+	codec, err := NewJSONCodec(s.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", s.ID, err)
+	}
+	e.codecs[s.ID] = codec
+	return codec, nil
+}
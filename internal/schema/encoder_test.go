@@ -0,0 +1,329 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// subjectRegistry serves subject versions, records registrations, and also
+// answers ID lookups so encoded messages can be decoded again.
+type subjectRegistry struct {
+	versions   map[int]*Schema // by version; the highest is the latest
+	registered []*Schema
+	calls      int
+}
+
+func (r *subjectRegistry) GetLatest(_ context.Context, subject string) (*Schema, error) {
+	r.calls++
+	var latest *Schema
+	for _, s := range r.versions {
+		if latest == nil || s.Version > latest.Version {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("subject %s not found", subject)
+	}
+	return latest, nil
+}
+
+func (r *subjectRegistry) GetVersion(_ context.Context, subject string, version int) (*Schema, error) {
+	r.calls++
+	s, ok := r.versions[version]
+	if !ok {
+		return nil, fmt.Errorf("subject %s version %d not found", subject, version)
+	}
+	return s, nil
+}
+
+func (r *subjectRegistry) Register(_ context.Context, _ string, s *Schema) (int, error) {
+	r.calls++
+	r.registered = append(r.registered, s)
+	return 100 + len(r.registered), nil
+}
+
+func (r *subjectRegistry) GetByID(_ context.Context, id int) (*Schema, error) {
+	for _, s := range r.versions {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	for i, s := range r.registered {
+		if id == 101+i {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("schema %d not found", id)
+}
+
+func TestAvroEncoder_RoundTrip(t *testing.T) {
+	reg := &subjectRegistry{versions: map[int]*Schema{1: {ID: 42, Version: 1, Schema: orderSchema}}}
+	enc, err := NewEncoderWithRegistry(EncoderConfig{Format: "avro", Subject: "orders-value"}, reg)
+	if err != nil {
+		t.Fatalf("NewEncoderWithRegistry() error = %v", err)
+	}
+	if enc.ContentType() != "application/avro" {
+		t.Errorf("ContentType() = %q", enc.ContentType())
+	}
+
+	in := `{
+		"id": "o-1", "amount": 99.99, "quantity": 3, "paid": true,
+		"note": {"string": "fragile"}, "status": "SHIPPED",
+		"tags": ["a", "b"], "attrs": {"x": 7, "y": 9007199254740993},
+		"created": "2023-11-14T22:13:20Z", "day": "2022-01-08", "price": "-5.00",
+		"next": {"id": "o-2", "amount": 1, "quantity": 1, "paid": false, "status": "NEW",
+			"tags": [], "attrs": {}, "created": 0, "day": 0, "price": 12.5}
+	}`
+	msg, err := enc.Encode(context.Background(), []byte(in))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if id, _, err := ParseWireFormat(msg); err != nil || id != 42 {
+		t.Fatalf("wire header: id %d, err %v", id, err)
+	}
+
+	out, err := NewAvroCodec(reg).Decode(msg)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var got map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	dec.UseNumber()
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	checks := map[string]interface{}{
+		"note":    "fragile",
+		"created": "2023-11-14T22:13:20Z",
+		"day":     "2022-01-08",
+		"price":   "-5.00",
+		"status":  "SHIPPED",
+	}
+	for k, want := range checks {
+		if got[k] != want {
+			t.Errorf("%s = %v, want %v", k, got[k], want)
+		}
+	}
+	if attrs := got["attrs"].(map[string]interface{}); attrs["y"] != json.Number("9007199254740993") {
+		t.Errorf("long lost precision: %v", attrs["y"])
+	}
+	next := got["next"].(map[string]interface{})
+	if next["id"] != "o-2" || next["price"] != "12.50" || next["note"] != nil {
+		t.Errorf("next = %v", next)
+	}
+
+	if _, err := enc.Encode(context.Background(), []byte(in)); err != nil {
+		t.Fatal(err)
+	}
+	if reg.calls != 1 {
+		t.Errorf("expected latest schema to be cached, got %d lookups", reg.calls)
+	}
+}
+
+func TestAvroEncoder_Errors(t *testing.T) {
+	schema := `{"type": "record", "name": "R", "fields": [
+		{"name": "n", "type": "int"},
+		{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["A"]}},
+		{"name": "f", "type": {"type": "fixed", "name": "F", "size": 2}, "default": "AAA="},
+		{"name": "d", "type": "long", "default": 5}
+	]}`
+	reg := &subjectRegistry{versions: map[int]*Schema{1: {ID: 1, Version: 1, Schema: schema}}}
+	enc, _ := NewEncoderWithRegistry(EncoderConfig{Format: "avro", Subject: "r"}, reg)
+
+	if _, err := enc.Encode(context.Background(), []byte(`{"n":1,"e":"A"}`)); err != nil {
+		t.Errorf("expected defaults to fill missing fields, got %v", err)
+	}
+	tests := []struct {
+		doc     string
+		wantErr string
+	}{
+		{`{"e":"A"}`, "R.n: missing field"},
+		{`{"n":1.5,"e":"A"}`, "expected int"},
+		{`{"n":3000000000,"e":"A"}`, "overflows int"},
+		{`{"n":1,"e":"B"}`, "unknown symbol"},
+		{`{"n":1,"e":"A","f":"AAAA"}`, "expected 2 bytes"},
+		{`not json`, "invalid JSON"},
+	}
+	for _, tt := range tests {
+		_, err := enc.Encode(context.Background(), []byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Encode(%s): expected error containing %q, got %v", tt.doc, tt.wantErr, err)
+		}
+	}
+}
+
+func TestDecimalBytes(t *testing.T) {
+	tests := []struct {
+		typ  *avroType
+		in   interface{}
+		want []byte
+	}{
+		{&avroType{kind: "bytes", logical: "decimal", scale: 2}, "-5.00", []byte{0xfe, 0x0c}},
+		{&avroType{kind: "bytes", logical: "decimal", scale: 2}, "1.28", []byte{0x00, 0x80}},
+		{&avroType{kind: "bytes", logical: "decimal", scale: 0}, json.Number("0"), []byte{0x00}},
+		{&avroType{kind: "fixed", logical: "decimal", scale: 1, size: 4}, "-0.1", []byte{0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		got, err := decimalBytes(tt.typ, tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decimalBytes(%v) = %x, %v, want %x", tt.in, got, err, tt.want)
+		}
+		if back := bytesValue(tt.typ, got); tt.typ.kind == "bytes" && fmt.Sprint(back) != fmt.Sprint(tt.in) {
+			t.Errorf("round trip %v → %v", tt.in, back)
+		}
+	}
+	if _, err := decimalBytes(&avroType{kind: "bytes", scale: 1}, "1.25"); err == nil {
+		t.Error("expected error for excess fractional digits")
+	}
+	if _, err := decimalBytes(&avroType{kind: "fixed", scale: 0, size: 1}, "128"); err == nil {
+		t.Error("expected error for overflow")
+	}
+}
+
+func TestProtobufEncoder_RoundTrip(t *testing.T) {
+	reg := &subjectRegistry{versions: map[int]*Schema{
+		1: {ID: 7, Version: 1, Type: "PROTOBUF", Schema: orderProto},
+		2: {ID: 8, Version: 2, Type: "PROTOBUF", Schema: orderProto},
+	}}
+	tests := []struct {
+		messageType string
+		in          string
+		wantIndex   []byte
+	}{
+		{"", `{"order_id":"o-1","quantity":"3","tags":["a"],"created":"2024-01-02T03:04:05Z"}`, []byte{0}},
+		{"example.Refund", `{"orderId":"o-1"}`, []byte{2, 2}},
+		{"example.Order.Line", `{"sku":"s-1"}`, []byte{4, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.messageType, func(t *testing.T) {
+			enc, err := NewEncoderWithRegistry(EncoderConfig{Format: "protobuf", Subject: "orders-value", Version: 1, MessageType: tt.messageType}, reg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := enc.Encode(context.Background(), []byte(tt.in))
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			id, rest, _ := ParseWireFormat(msg)
+			if id != 7 {
+				t.Errorf("expected pinned version 1 (id 7), got id %d", id)
+			}
+			if !strings.HasPrefix(string(rest), string(tt.wantIndex)) {
+				t.Errorf("message indexes = %x, want prefix %x", rest, tt.wantIndex)
+			}
+			out, err := NewProtobufCodec(reg).Decode(msg)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !strings.Contains(string(out), `"o-1"`) && !strings.Contains(string(out), `"s-1"`) {
+				t.Errorf("Decode() = %s", out)
+			}
+		})
+	}
+
+	enc, _ := NewEncoderWithRegistry(EncoderConfig{Format: "protobuf", Subject: "orders-value", MessageType: "example.Missing"}, reg)
+	if _, err := enc.Encode(context.Background(), []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected unknown message error, got %v", err)
+	}
+	enc, _ = NewEncoderWithRegistry(EncoderConfig{Format: "protobuf", Subject: "orders-value"}, reg)
+	if _, err := enc.Encode(context.Background(), []byte(`{"unknown":1}`)); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	reg := &subjectRegistry{versions: map[int]*Schema{1: {ID: 3, Version: 1, Type: "JSON", Schema: `{"required":["id"]}`}}}
+	enc, _ := NewEncoderWithRegistry(EncoderConfig{Format: "json", Subject: "orders-value"}, reg)
+
+	msg, err := enc.Encode(context.Background(), []byte(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if id, payload, _ := ParseWireFormat(msg); id != 3 || string(payload) != `{"id":1}` {
+		t.Errorf("Encode() = %q", msg)
+	}
+	if _, err := enc.Encode(context.Background(), []byte(`{}`)); err == nil {
+		t.Error("expected validation error")
+	}
+}
+
+func TestEncoder_AutoRegister(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(path, []byte(`{"type":"record","name":"O","fields":[{"name":"id","type":"string"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := &subjectRegistry{}
+	enc, err := NewEncoderWithRegistry(EncoderConfig{Format: "avro", Subject: "orders-value", AutoRegister: true, SchemaFile: path}, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		msg, err := enc.Encode(context.Background(), []byte(`{"id":"x"}`))
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		if id, _, _ := ParseWireFormat(msg); id != 101 {
+			t.Errorf("expected registered id 101, got %d", id)
+		}
+	}
+	if len(reg.registered) != 1 || reg.registered[0].Type != "AVRO" {
+		t.Errorf("expected one AVRO registration, got %v", reg.registered)
+	}
+
+	if _, err := NewEncoderWithRegistry(EncoderConfig{Format: "avro", Subject: "s", AutoRegister: true}, reg); err == nil {
+		t.Error("expected error for autoRegister without a schema")
+	}
+}
+
+func TestEncoder_LatestRefresh(t *testing.T) {
+	reg := &subjectRegistry{versions: map[int]*Schema{1: {ID: 1, Version: 1, Type: "JSON", Schema: `{}`}}}
+	enc, _ := NewEncoderWithRegistry(EncoderConfig{Format: "json", Subject: "s", CacheTTL: time.Minute}, reg)
+	now := time.Now()
+	enc.(*JSONEncoder).resolver.clock = func() time.Time { return now }
+
+	_, _ = enc.Encode(context.Background(), []byte(`{}`))
+	reg.versions[2] = &Schema{ID: 2, Version: 2, Type: "JSON", Schema: `{}`}
+	msg, _ := enc.Encode(context.Background(), []byte(`{}`))
+	if id, _, _ := ParseWireFormat(msg); id != 1 {
+		t.Errorf("expected cached schema 1, got %d", id)
+	}
+	now = now.Add(2 * time.Minute)
+	msg, _ = enc.Encode(context.Background(), []byte(`{}`))
+	if id, _, _ := ParseWireFormat(msg); id != 2 {
+		t.Errorf("expected refreshed schema 2, got %d", id)
+	}
+}
+
+func TestNewEncoder_Config(t *testing.T) {
+	cfg, err := EncoderConfigFromMap(map[string]interface{}{
+		"registryUrl":  "http://registry:8081",
+		"format":       "protobuf",
+		"subject":      "orders-value",
+		"version":      3,
+		"messageType":  "example.Order",
+		"autoRegister": false,
+		"cacheTTL":     "1m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != 3 || cfg.MessageType != "example.Order" || cfg.CacheTTL != time.Minute {
+		t.Errorf("EncoderConfigFromMap() = %+v", cfg)
+	}
+	if _, err := EncoderConfigFromMap(map[string]interface{}{"version": "latest"}); err == nil {
+		t.Error("expected error for non-integer version")
+	}
+	if _, err := NewEncoder(EncoderConfig{Format: "thrift", RegistryURL: "http://r", Subject: "s"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := NewEncoder(EncoderConfig{Format: "avro", RegistryURL: "http://r"}); err == nil {
+		t.Error("expected error for missing subject")
+	}
+}
@@ -0,0 +1,269 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoFileName is the name the registry schema is compiled under.
+const protoFileName = "schema.proto"
+
+// ProtobufCodec decodes Confluent wire-format Protobuf messages to JSON.
+// Writer schemas are fetched from the registry by the ID in each message and
+// compiled from their .proto source; imports of the well-known types are
+// supported, references to other registry subjects are not.
+//
+// Fields use their .proto names and unset fields are emitted with their
+// default values, so transforms can rely on every field being present.
+type ProtobufCodec struct {
+	registry Registry
+	mu       sync.RWMutex
+	files    map[int]protoreflect.FileDescriptor
+}
+
+// NewProtobufCodec creates a Protobuf codec that resolves schemas from
+// registry.
+func NewProtobufCodec(registry Registry) *ProtobufCodec {
+	return &ProtobufCodec{registry: registry, files: make(map[int]protoreflect.FileDescriptor)}
+}
+
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// Decode converts a wire-format Protobuf message to JSON.
+func (c *ProtobufCodec) Decode(data []byte) ([]byte, error) {
+	id, rest, err := ParseWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes, payload, err := readMessageIndexes(rest)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := c.file(id)
+	if err != nil {
+		return nil, err
+	}
+	md, err := messageAt(fd, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil, fmt.Errorf("decode protobuf %s: %w", md.FullName(), err)
+	}
+	return protoJSON.Marshal(msg)
+}
+
+// Validate checks that data decodes with its writer schema.
+func (c *ProtobufCodec) Validate(data []byte) error {
+	_, err := c.Decode(data)
+	return err
+}
+
+func (c *ProtobufCodec) file(id int) (protoreflect.FileDescriptor, error) {
+	c.mu.RLock()
+	fd, ok := c.files[id]
+	c.mu.RUnlock()
+	if ok {
+		return fd, nil
+	}
+
+	s, err := lookup(c.registry, id)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(s.Type, "PROTOBUF") {
+		return nil, fmt.Errorf("schema %d is %s, not PROTOBUF", id, schemaTypeName(s))
+	}
+	fd, err = CompileProto(s.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.files[id] = fd
+	c.mu.Unlock()
+	return fd, nil
+}
+
+// ProtobufEncoder encodes JSON payloads as Confluent wire-format Protobuf
+// using the writer schema of a registry subject. The payload is read with
+// the protojson mapping, so both .proto and JSON field names are accepted.
+type ProtobufEncoder struct {
+	resolver    *subjectResolver
+	messageType string
+	mu          sync.Mutex
+	messages    map[int]protoMessage
+}
+
+// protoMessage is a message type resolved from a registry schema, with the
+// index path that identifies it in the wire-format header.
+type protoMessage struct {
+	desc    protoreflect.MessageDescriptor
+	indexes []int
+}
+
+// Encode converts a JSON payload to wire-format Protobuf.
+func (e *ProtobufEncoder) Encode(ctx context.Context, data []byte) ([]byte, error) {
+	s, err := e.resolver.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := e.message(s)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(pm.desc)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("encode protobuf %s: %w", pm.desc.FullName(), err)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encode protobuf %s: %w", pm.desc.FullName(), err)
+	}
+	out := appendMessageIndexes(AppendWireHeader(nil, s.ID), pm.indexes)
+	return append(out, payload...), nil
+}
+
+// ContentType returns application/protobuf.
+func (e *ProtobufEncoder) ContentType() string { return "application/protobuf" }
+
+func (e *ProtobufEncoder) message(s *Schema) (protoMessage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if pm, ok := e.messages[s.ID]; ok {
+		return pm, nil
+	}
+	if !strings.EqualFold(s.Type, "PROTOBUF") {
+		return protoMessage{}, fmt.Errorf("schema %d is %s, not PROTOBUF", s.ID, schemaTypeName(s))
+	}
+	fd, err := CompileProto(s.Schema)
+	if err != nil {
+		return protoMessage{}, fmt.Errorf("schema %d: %w", s.ID, err)
+	}
+	pm, err := findMessage(fd, e.messageType)
+	if err != nil {
+		return protoMessage{}, fmt.Errorf("schema %d: %w", s.ID, err)
+	}
+	e.messages[s.ID] = pm
+	return pm, nil
+}
+
+// findMessage resolves a message by fully-qualified name, or the first
+// message of the file when name is empty.
+func findMessage(fd protoreflect.FileDescriptor, name string) (protoMessage, error) {
+	if name == "" {
+		if fd.Messages().Len() == 0 {
+			return protoMessage{}, fmt.Errorf("schema defines no messages")
+		}
+		return protoMessage{desc: fd.Messages().Get(0), indexes: []int{0}}, nil
+	}
+	var search func(msgs protoreflect.MessageDescriptors, path []int) (protoMessage, bool)
+	search = func(msgs protoreflect.MessageDescriptors, path []int) (protoMessage, bool) {
+		for i := 0; i < msgs.Len(); i++ {
+			md := msgs.Get(i)
+			p := append(append([]int(nil), path...), i)
+			if string(md.FullName()) == name {
+				return protoMessage{desc: md, indexes: p}, true
+			}
+			if pm, ok := search(md.Messages(), p); ok {
+				return pm, true
+			}
+		}
+		return protoMessage{}, false
+	}
+	if pm, ok := search(fd.Messages(), nil); ok {
+		return pm, nil
+	}
+	return protoMessage{}, fmt.Errorf("message %s not found", name)
+}
+
+// appendMessageIndexes appends the message index path to dst, using the
+// single zero byte shorthand for the first message.
+func appendMessageIndexes(dst []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return binary.AppendVarint(dst, 0)
+	}
+	dst = binary.AppendVarint(dst, int64(len(indexes)))
+	for _, i := range indexes {
+		dst = binary.AppendVarint(dst, int64(i))
+	}
+	return dst
+}
+
+// CompileProto compiles a single .proto source into a file descriptor.
+func CompileProto(source string) (protoreflect.FileDescriptor, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{protoFileName: source}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), protoFileName)
+	if err != nil {
+		return nil, fmt.Errorf("compile protobuf schema: %w", err)
+	}
+	return files[0], nil
+}
+
+// readMessageIndexes reads the message index path that follows the schema ID
+// in Protobuf wire-format messages. A single zero byte is shorthand for the
+// first message in the file.
+func readMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("invalid message index count")
+	}
+	data = data[n:]
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+	if count < 0 || count > int64(len(data)) {
+		return nil, nil, fmt.Errorf("invalid message index count %d", count)
+	}
+	indexes := make([]int, count)
+	for i := range indexes {
+		v, n := binary.Varint(data)
+		if n <= 0 || v < 0 {
+			return nil, nil, fmt.Errorf("invalid message index")
+		}
+		indexes[i] = int(v)
+		data = data[n:]
+	}
+	return indexes, data, nil
+}
+
+// messageAt resolves a message index path: the first index selects a
+// top-level message, later ones select nested messages.
+func messageAt(fd protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	msgs := fd.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, i := range indexes {
+		if i >= msgs.Len() {
+			return nil, fmt.Errorf("message index %v not found", indexes)
+		}
+		md = msgs.Get(i)
+		msgs = md.Messages()
+	}
+	if md == nil {
+		return nil, fmt.Errorf("schema defines no messages")
+	}
+	return md, nil
+}
+
+func schemaTypeName(s *Schema) string {
+	if s.Type == "" {
+		return "AVRO"
+	}
+	return strings.ToUpper(s.Type)
+}
@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const orderProto = `
+syntax = "proto3";
+package example;
+
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  string order_id = 1;
+  int64 quantity = 2;
+  repeated string tags = 3;
+  google.protobuf.Timestamp created = 4;
+
+  message Line {
+    string sku = 1;
+  }
+}
+
+message Refund {
+  string order_id = 1;
+}
+`
+
+func TestProtobufCodec_Decode(t *testing.T) {
+	fd, err := CompileProto(orderProto)
+	if err != nil {
+		t.Fatalf("CompileProto() error = %v", err)
+	}
+	order := dynamicpb.NewMessage(fd.Messages().ByName("Order"))
+	order.Set(order.Descriptor().Fields().ByName("order_id"), protoValue("o-1"))
+	payload, err := proto.Marshal(order)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	reg := &idRegistry{schemas: map[int]*Schema{7: {ID: 7, Type: "PROTOBUF", Schema: orderProto}}}
+	codec := NewProtobufCodec(reg)
+
+	// A single zero byte selects the first message.
+	out, err := codec.Decode(wire(7, []byte{0}, payload))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if got["order_id"] != "o-1" || got["quantity"] != "0" {
+		t.Errorf("unexpected order %v", got)
+	}
+	if _, ok := got["tags"]; !ok {
+		t.Error("expected unset fields to be emitted")
+	}
+
+	// Index path [1] selects the second top-level message.
+	refund := dynamicpb.NewMessage(fd.Messages().ByName("Refund"))
+	refund.Set(refund.Descriptor().Fields().ByName("order_id"), protoValue("o-2"))
+	payload, _ = proto.Marshal(refund)
+	out, err = codec.Decode(wire(7, []byte{2, 2}, payload))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !strings.Contains(string(out), `"o-2"`) {
+		t.Errorf("unexpected refund %s", out)
+	}
+	if reg.calls != 1 {
+		t.Errorf("expected compiled schema to be cached, got %d lookups", reg.calls)
+	}
+}
+
+func TestProtobufCodec_Errors(t *testing.T) {
+	reg := &idRegistry{schemas: map[int]*Schema{
+		1: {ID: 1, Type: "PROTOBUF", Schema: orderProto},
+		2: {ID: 2, Schema: `"string"`},
+		3: {ID: 3, Type: "PROTOBUF", Schema: `syntax = "proto3"; message {`},
+	}}
+	codec := NewProtobufCodec(reg)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"wrong schema type", wire(2, []byte{0}), "not PROTOBUF"},
+		{"invalid schema", wire(3, []byte{0}), "compile protobuf schema"},
+		{"unknown message index", wire(1, []byte{2, 10}), "not found"},
+		{"invalid payload", wire(1, []byte{0}, []byte{0xff, 0xff}), "decode protobuf"},
+		{"missing indexes", wire(1), "message index"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := codec.Validate(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func protoValue(s string) protoreflect.Value {
+	return protoreflect.ValueOfString(s)
+}
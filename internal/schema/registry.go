@@ -1,11 +1,13 @@
 package schema
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
@@ -112,29 +114,84 @@ func (r *ConfluentRegistry) GetLatest(ctx context.Context, subject string) (*Sch
 	return schema, nil
 }
 
+// GetVersion retrieves a specific version of a schema for a subject.
+func (r *ConfluentRegistry) GetVersion(ctx context.Context, subject string, version int) (*Schema, error) {
+	url := fmt.Sprintf("%s/subjects/%s/versions/%d", r.baseURL, subject, version)
+	schema, err := r.fetch(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get schema %s version %d: %w", subject, version, err)
+	}
+
+	r.toCache(schema.ID, schema)
+	return schema, nil
+}
+
+// Register registers schema under subject and returns its global ID. The
+// registry returns the existing ID when the schema is already registered.
+func (r *ConfluentRegistry) Register(ctx context.Context, subject string, schema *Schema) (int, error) {
+	body := struct {
+		Schema string `json:"schema"`
+		Type   string `json:"schemaType,omitempty"`
+	}{Schema: schema.Schema}
+	if t := strings.ToUpper(schema.Type); t != "" && t != "AVRO" {
+		body.Type = t
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+
+	url := fmt.Sprintf("%s/subjects/%s/versions", r.baseURL, subject)
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := r.do(ctx, http.MethodPost, url, payload, &resp); err != nil {
+		return 0, fmt.Errorf("register schema for %s: %w", subject, err)
+	}
+
+	registered := *schema
+	registered.ID, registered.Subject = resp.ID, subject
+	r.toCache(resp.ID, &registered)
+	return resp.ID, nil
+}
+
 func (r *ConfluentRegistry) fetch(ctx context.Context, url string) (*Schema, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	var schema Schema
+	if err := r.do(ctx, http.MethodGet, url, nil, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (r *ConfluentRegistry) do(ctx context.Context, method, url string, payload []byte, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return fmt.Errorf("http request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("registry returned %d: %s", resp.StatusCode, string(body))
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("registry returned %d: %s", resp.StatusCode, string(respBody))
 	}
 
-	var schema Schema
-	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
 	}
-	return &schema, nil
+	return nil
 }
 
 func (r *ConfluentRegistry) fromCache(id int) *Schema {
@@ -158,3 +215,66 @@ func (r *ConfluentRegistry) toCache(id int, schema *Schema) {
 		fetchedAt: r.clock(),
 	}
 }
+
+// CodecFor returns a codec for a registry schema.
+func CodecFor(s *Schema) (Codec, error) {
+	switch strings.ToUpper(s.Type) {
+	case "JSON":
+		return NewJSONCodec(s.Schema)
+	case "":
+		// The registry omits schemaType for its original type, Avro.
+		return nil, fmt.Errorf("schema %d: unsupported schema type AVRO", s.ID)
+	default:
+		return nil, fmt.Errorf("schema %d: unsupported schema type %s", s.ID, s.Type)
+	}
+}
+
+// LatestCodec resolves the codec for the latest schema of a subject. The
+// codec is cached and refreshed once the TTL has passed.
+type LatestCodec struct {
+	registry Registry
+	subject  string
+	ttl      time.Duration
+	clock    func() time.Time
+
+	mu        sync.Mutex
+	codec     Codec
+	schemaID  int
+	fetchedAt time.Time
+}
+
+// NewLatestCodec creates a LatestCodec. A zero ttl defaults to 5 minutes.
+func NewLatestCodec(registry Registry, subject string, ttl time.Duration) *LatestCodec {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &LatestCodec{registry: registry, subject: subject, ttl: ttl, clock: time.Now}
+}
+
+// Subject returns the registry subject the codec is resolved from.
+func (c *LatestCodec) Subject() string {
+	return c.subject
+}
+
+// Get returns the codec for the latest schema of the subject.
+func (c *LatestCodec) Get(ctx context.Context) (Codec, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.codec != nil && c.clock().Sub(c.fetchedAt) <= c.ttl {
+		return c.codec, nil
+	}
+	s, err := c.registry.GetLatest(ctx, c.subject)
+	if err != nil {
+		return nil, err
+	}
+	if c.codec == nil || s.ID != c.schemaID {
+		codec, err := CodecFor(s)
+		if err != nil {
+			return nil, err
+		}
+		c.codec, c.schemaID = codec, s.ID
+	}
+	c.fetchedAt = c.clock()
+	return c.codec, nil
+}
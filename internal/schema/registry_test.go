@@ -3,6 +3,7 @@ package schema
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -188,3 +189,127 @@ func TestConfluentRegistry_AcceptHeader(t *testing.T) {
 	reg, _ := NewConfluentRegistry(srv.URL)
 	_, _ = reg.GetByID(context.Background(), 1)
 }
+
+// stubRegistry serves a fixed latest schema and counts lookups.
+type stubRegistry struct {
+	latest *Schema
+	err    error
+	calls  int
+}
+
+func (s *stubRegistry) GetByID(_ context.Context, id int) (*Schema, error) {
+	return nil, fmt.Errorf("schema %d not found", id)
+}
+
+func (s *stubRegistry) GetLatest(_ context.Context, _ string) (*Schema, error) {
+	s.calls++
+	return s.latest, s.err
+}
+
+func TestCodecFor(t *testing.T) {
+	if _, err := CodecFor(&Schema{ID: 1, Type: "JSON", Schema: `{"type":"object"}`}); err != nil {
+		t.Errorf("unexpected error for JSON schema: %v", err)
+	}
+	for _, typ := range []string{"", "AVRO", "PROTOBUF"} {
+		if _, err := CodecFor(&Schema{ID: 1, Type: typ, Schema: `{}`}); err == nil {
+			t.Errorf("expected error for schema type %q", typ)
+		}
+	}
+}
+
+func TestLatestCodec_CachesUntilTTL(t *testing.T) {
+	reg := &stubRegistry{latest: &Schema{ID: 7, Type: "JSON", Schema: `{"required":["id"]}`}}
+	now := time.Now()
+	lc := NewLatestCodec(reg, "orders-value", time.Minute)
+	lc.clock = func() time.Time { return now }
+
+	codec, err := lc.Get(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := codec.Validate([]byte(`{}`)); err == nil {
+		t.Error("expected validation error for missing required field")
+	}
+	if _, err := lc.Get(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.calls != 1 {
+		t.Errorf("expected 1 registry lookup, got %d", reg.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := lc.Get(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.calls != 2 {
+		t.Errorf("expected refresh after TTL, got %d lookups", reg.calls)
+	}
+}
+
+func TestLatestCodec_RegistryError(t *testing.T) {
+	reg := &stubRegistry{err: fmt.Errorf("connection refused")}
+	lc := NewLatestCodec(reg, "orders-value", 0)
+
+	if _, err := lc.Get(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if lc.Subject() != "orders-value" {
+		t.Errorf("unexpected subject %q", lc.Subject())
+	}
+}
+
+func TestConfluentRegistry_GetVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subjects/orders-value/versions/2" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(Schema{ID: 9, Subject: "orders-value", Version: 2, Schema: `"string"`})
+	}))
+	defer srv.Close()
+
+	reg, _ := NewConfluentRegistry(srv.URL)
+	s, err := reg.GetVersion(context.Background(), "orders-value", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.ID != 9 || s.Version != 2 {
+		t.Errorf("unexpected schema: %+v", s)
+	}
+}
+
+func TestConfluentRegistry_Register(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/subjects/orders-value/versions" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/vnd.schemaregistry.v1+json" {
+			t.Errorf("unexpected content type: %s", ct)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"id":12}`))
+	}))
+	defer srv.Close()
+
+	reg, _ := NewConfluentRegistry(srv.URL)
+	id, err := reg.Register(context.Background(), "orders-value", &Schema{Type: "PROTOBUF", Schema: `syntax = "proto3";`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 12 {
+		t.Errorf("expected id 12, got %d", id)
+	}
+	if got["schemaType"] != "PROTOBUF" || got["schema"] != `syntax = "proto3";` {
+		t.Errorf("unexpected request body: %v", got)
+	}
+
+	// Registered schemas are served from the cache by ID.
+	srv.Close()
+	if s, err := reg.GetByID(context.Background(), 12); err != nil || s.Subject != "orders-value" {
+		t.Errorf("GetByID() = %+v, %v", s, err)
+	}
+
+	if _, err := reg.Register(context.Background(), "orders-value", &Schema{Schema: `"string"`}); err == nil {
+		t.Error("expected error when the registry is unavailable")
+	}
+}
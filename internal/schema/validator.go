@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validator checks JSON documents against a JSON Schema.
+type Validator interface {
+	// Validate returns a *ValidationError when data does not match the
+	// schema, and other errors when the schema could not be resolved.
+	Validate(ctx context.Context, data []byte) error
+}
+
+// ValidatorConfig locates the JSON Schema of a Validator. Exactly one of
+// Schema, SchemaFile or Subject must be set.
+type ValidatorConfig struct {
+	Schema      interface{}   // Inline schema, e.g. decoded from YAML
+	SchemaFile  string        // Path to a JSON or YAML schema file
+	RegistryURL string        // Schema registry serving Subject
+	Subject     string        // Registry subject; its latest JSON schema is used
+	CacheTTL    time.Duration // How long the latest schema is cached (default: 5m)
+}
+
+// NewValidator creates a validator from cfg. Inline schemas and schema files
+// are compiled once, here; registry subjects are resolved on first use and
+// refreshed after CacheTTL.
+func NewValidator(cfg ValidatorConfig) (Validator, error) {
+	if cfg.Subject != "" {
+		registry, err := NewConfluentRegistry(cfg.RegistryURL)
+		if err != nil {
+			return nil, err
+		}
+		return &subjectValidator{latest: NewLatestCodec(registry, cfg.Subject, cfg.CacheTTL)}, nil
+	}
+
+	var raw []byte
+	var err error
+	switch {
+	case cfg.SchemaFile != "":
+		raw, err = readSchemaFile(cfg.SchemaFile)
+	case cfg.Schema != nil:
+		raw, err = json.Marshal(cfg.Schema)
+	default:
+		return nil, errors.New("one of schema, schemaFile or subject is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	codec, err := NewJSONCodec(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	return codecValidator{codec: codec}, nil
+}
+
+// readSchemaFile reads a JSON Schema written as JSON or YAML and returns it
+// as JSON.
+func readSchemaFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse schema %s: %w", path, err)
+	}
+	return json.Marshal(doc)
+}
+
+type codecValidator struct {
+	codec Codec
+}
+
+func (v codecValidator) Validate(_ context.Context, data []byte) error {
+	return v.codec.Validate(data)
+}
+
+type subjectValidator struct {
+	latest *LatestCodec
+}
+
+func (v *subjectValidator) Validate(ctx context.Context, data []byte) error {
+	codec, err := v.latest.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("subject %s: %w", v.latest.Subject(), err)
+	}
+	return codec.Validate(data)
+}
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewValidator_Inline(t *testing.T) {
+	v, err := NewValidator(ValidatorConfig{Schema: map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := v.Validate(context.Background(), []byte(`{"id":1}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var verr *ValidationError
+	if err := v.Validate(context.Background(), []byte(`{}`)); !errors.As(err, &verr) {
+		t.Errorf("expected *ValidationError, got %v", err)
+	}
+}
+
+func TestNewValidator_SchemaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.yaml")
+	content := "type: object\nproperties:\n  total:\n    type: number\n    minimum: 0\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewValidator(ValidatorConfig{SchemaFile: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = v.Validate(context.Background(), []byte(`{"total":-1}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Violations[0].Path != "/total" {
+		t.Errorf("expected violation at /total, got %v", err)
+	}
+
+	if _, err := NewValidator(ValidatorConfig{SchemaFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("expected error for missing schema file")
+	}
+}
+
+func TestNewValidator_Subject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subjects/orders-value/versions/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Schema{ID: 3, Type: "JSON", Schema: `{"type":"object","required":["id"]}`})
+	}))
+	defer srv.Close()
+
+	v, err := NewValidator(ValidatorConfig{RegistryURL: srv.URL, Subject: "orders-value"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := v.Validate(context.Background(), []byte(`{"id":1}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var verr *ValidationError
+	if err := v.Validate(context.Background(), []byte(`{}`)); !errors.As(err, &verr) {
+		t.Errorf("expected *ValidationError, got %v", err)
+	}
+
+	missing, _ := NewValidator(ValidatorConfig{RegistryURL: srv.URL, Subject: "missing"})
+	if err := missing.Validate(context.Background(), []byte(`{}`)); err == nil || errors.As(err, &verr) {
+		t.Errorf("expected registry error, got %v", err)
+	}
+}
+
+func TestNewValidator_NoSchema(t *testing.T) {
+	if _, err := NewValidator(ValidatorConfig{}); err == nil {
+		t.Fatal("expected error when no schema is configured")
+	}
+}
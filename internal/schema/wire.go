@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// MagicByte prefixes every Confluent wire-format message.
+const MagicByte = 0x0
+
+// lookupTimeout bounds registry lookups made while decoding a message.
+const lookupTimeout = 10 * time.Second
+
+// ParseWireFormat splits a Confluent wire-format message into the schema ID
+// and the encoded payload: a zero magic byte, a 4-byte big-endian schema ID
+// and the payload.
+func ParseWireFormat(data []byte) (int, []byte, error) {
+	if len(data) < 5 {
+		return 0, nil, fmt.Errorf("message too short for wire format: %d bytes", len(data))
+	}
+	if data[0] != MagicByte {
+		return 0, nil, fmt.Errorf("unknown magic byte 0x%02x", data[0])
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
+
+// lookup fetches a writer schema by ID with a bounded timeout, since Codec
+// methods carry no context.
+func lookup(registry Registry, id int) (*Schema, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	return registry.GetByID(ctx, id)
+}
+
+// AppendWireHeader appends the wire-format header for schema id to dst.
+func AppendWireHeader(dst []byte, id int) []byte {
+	dst = append(dst, MagicByte)
+	return binary.BigEndian.AppendUint32(dst, uint32(id))
+}
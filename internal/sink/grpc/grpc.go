@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/lsm/fiso/internal/cloudevent"
 	"github.com/lsm/fiso/internal/correlation"
 	"github.com/lsm/fiso/internal/tracing"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -21,6 +22,10 @@ type Config struct {
 	Address string
 	TLS     bool
 	Timeout time.Duration
+	// CloudEventsMode selects the CloudEvents content mode: structured
+	// (default), binary or none. Binary mode sends attributes as ce-*
+	// metadata.
+	CloudEventsMode string
 }
 
 // Sink delivers events via gRPC unary call.
@@ -30,6 +35,7 @@ type Config struct {
 type Sink struct {
 	conn    *grpc.ClientConn
 	timeout time.Duration
+	mode    string
 	logger  *slog.Logger
 	tracer  trace.Tracer
 }
@@ -39,6 +45,9 @@ func NewSink(cfg Config) (*Sink, error) {
 	if cfg.Address == "" {
 		return nil, fmt.Errorf("gRPC address is required")
 	}
+	if !cloudevent.ValidMode(cfg.CloudEventsMode) {
+		return nil, fmt.Errorf("invalid cloudevents mode %q", cfg.CloudEventsMode)
+	}
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
@@ -58,6 +67,7 @@ func NewSink(cfg Config) (*Sink, error) {
 	return &Sink{
 		conn:    conn,
 		timeout: cfg.Timeout,
+		mode:    cfg.CloudEventsMode,
 		logger:  slog.Default(),
 		tracer:  noop.NewTracerProvider().Tracer("grpc-sink"),
 	}, nil
@@ -85,6 +95,12 @@ func (s *Sink) Deliver(ctx context.Context, event []byte, headers map[string]str
 	)
 	defer span.End()
 
+	event, headers, err := cloudevent.Encode(s.mode, cloudevent.GRPC, event, headers)
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		return fmt.Errorf("encode cloudevent: %w", err)
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
@@ -97,7 +113,7 @@ func (s *Sink) Deliver(ctx context.Context, event []byte, headers map[string]str
 
 	// Use raw codec for the unary call
 	var resp []byte
-	err := s.conn.Invoke(ctx, "/fiso.v1.EventService/Deliver", event, &resp, grpc.ForceCodec(rawCodec{}))
+	err = s.conn.Invoke(ctx, "/fiso.v1.EventService/Deliver", event, &resp, grpc.ForceCodec(rawCodec{}))
 	if err != nil {
 		tracing.SetSpanError(span, err)
 		s.logger.Error("delivery failed",
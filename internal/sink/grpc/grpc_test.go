@@ -106,6 +106,47 @@ func TestSink_Deliver(t *testing.T) {
 	}
 }
 
+func TestSink_Deliver_BinaryMode(t *testing.T) {
+	ts := newTestServer()
+	ts.start()
+	defer ts.stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(ts.dialer()),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	s := &Sink{conn: conn, timeout: 5e9, mode: "binary", logger: slog.Default()}
+
+	event := []byte(`{"specversion":"1.0","id":"123","source":"/orders","type":"test.event",` +
+		`"datacontenttype":"application/json","data":{"id":"o-1"}}`)
+	headers := map[string]string{"content-type": "application/cloudevents+json"}
+
+	if err := s.Deliver(context.Background(), event, headers); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if string(ts.events[0]) != `{"id":"o-1"}` {
+		t.Errorf("expected event data as payload, got %s", ts.events[0])
+	}
+	for k, want := range map[string]string{
+		"ce-id":              "123",
+		"ce-source":          "/orders",
+		"ce-type":            "test.event",
+		"ce-datacontenttype": "application/json",
+	} {
+		if got := ts.headers[0].Get(k); len(got) == 0 || got[0] != want {
+			t.Errorf("metadata %s = %v, want %q", k, got, want)
+		}
+	}
+}
+
 func TestSink_MultipleDeliveries(t *testing.T) {
 	ts := newTestServer()
 	ts.start()
@@ -153,6 +194,13 @@ func TestSink_Close(t *testing.T) {
 	}
 }
 
+func TestNewSink_InvalidCloudEventsMode(t *testing.T) {
+	_, err := NewSink(Config{Address: "localhost:50051", CloudEventsMode: "batch"})
+	if err == nil {
+		t.Fatal("expected error for invalid cloudevents mode")
+	}
+}
+
 func TestNewSink_MissingAddress(t *testing.T) {
 	_, err := NewSink(Config{})
 	if err == nil {
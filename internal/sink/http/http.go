@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,7 +12,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/lsm/fiso/internal/cloudevent"
 	"github.com/lsm/fiso/internal/correlation"
+	"github.com/lsm/fiso/internal/sink"
 	"github.com/lsm/fiso/internal/tracing"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/trace"
@@ -31,8 +34,27 @@ type Config struct {
 	Method  string
 	Headers map[string]string
 	Retry   RetryConfig
+	// CloudEventsMode selects the CloudEvents content mode: structured
+	// (default), binary or none.
+	CloudEventsMode string
+	// BatchFormat selects the body of DeliverBatch requests: cloudevents
+	// (default), a JSON array of structured events, or ndjson, one event
+	// per line.
+	BatchFormat string
 }
 
+// Batch formats.
+const (
+	BatchFormatCloudEvents = "cloudevents"
+	BatchFormatNDJSON      = "ndjson"
+)
+
+// Batch content types.
+const (
+	ContentTypeCloudEventsBatch = "application/cloudevents-batch+json"
+	ContentTypeNDJSON           = "application/x-ndjson"
+)
+
 // Sink delivers events to an HTTP endpoint.
 type Sink struct {
 	client *http.Client
@@ -46,6 +68,16 @@ func NewSink(cfg Config) (*Sink, error) {
 	if cfg.URL == "" {
 		return nil, fmt.Errorf("url is required")
 	}
+	if !cloudevent.ValidMode(cfg.CloudEventsMode) {
+		return nil, fmt.Errorf("invalid cloudevents mode %q", cfg.CloudEventsMode)
+	}
+	switch cfg.BatchFormat {
+	case "":
+		cfg.BatchFormat = BatchFormatCloudEvents
+	case BatchFormatCloudEvents, BatchFormatNDJSON:
+	default:
+		return nil, fmt.Errorf("invalid batch format %q", cfg.BatchFormat)
+	}
 	if cfg.Method == "" {
 		cfg.Method = "POST"
 	}
@@ -77,6 +109,48 @@ func (s *Sink) SetTracer(tracer trace.Tracer) {
 
 // Deliver sends the event payload to the configured HTTP endpoint.
 func (s *Sink) Deliver(ctx context.Context, event []byte, headers map[string]string) error {
+	event, headers, err := cloudevent.Encode(s.config.CloudEventsMode, cloudevent.HTTP, event, headers)
+	if err != nil {
+		return fmt.Errorf("encode cloudevent: %w", err)
+	}
+	return s.send(ctx, event, headers)
+}
+
+// DeliverBatch sends msgs to the configured HTTP endpoint in one request,
+// formatted as set by Config.BatchFormat. Per-event headers are not sent;
+// static headers and trace context are. A failed request fails every
+// event of the batch.
+func (s *Sink) DeliverBatch(ctx context.Context, msgs []sink.Message) error {
+	var body bytes.Buffer
+	var contentType string
+	switch s.config.BatchFormat {
+	case BatchFormatNDJSON:
+		contentType = ContentTypeNDJSON
+		for _, msg := range msgs {
+			event, _, err := cloudevent.Encode(s.config.CloudEventsMode, cloudevent.HTTP, msg.Event, msg.Headers)
+			if err != nil {
+				return fmt.Errorf("encode cloudevent: %w", err)
+			}
+			if err := json.Compact(&body, event); err != nil {
+				return fmt.Errorf("ndjson batch: event is not JSON: %w", err)
+			}
+			body.WriteByte('\n')
+		}
+	default:
+		contentType = ContentTypeCloudEventsBatch
+		events := make([]json.RawMessage, len(msgs))
+		for i, msg := range msgs {
+			events[i] = msg.Event
+		}
+		if err := json.NewEncoder(&body).Encode(events); err != nil {
+			return fmt.Errorf("cloudevents batch: %w", err)
+		}
+	}
+	return s.send(ctx, body.Bytes(), map[string]string{"Content-Type": contentType})
+}
+
+// send posts body with retries.
+func (s *Sink) send(ctx context.Context, event []byte, headers map[string]string) error {
 	start := time.Now()
 
 	// Extract correlation ID from headers
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lsm/fiso/internal/sink"
 	"go.opentelemetry.io/otel/trace/noop"
 )
 
@@ -52,6 +53,157 @@ func TestDeliver_Success(t *testing.T) {
 	}
 }
 
+func TestDeliver_CloudEventsModes(t *testing.T) {
+	var receivedBody []byte
+	var receivedHeaders http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := []byte(`{"specversion":"1.0","id":"evt-1","source":"fiso-flow/orders","type":"order.created",` +
+		`"subject":"order 1","datacontenttype":"application/json","data":{"id":"o-1"}}`)
+
+	tests := []struct {
+		mode        string
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{"structured", string(event), map[string]string{
+			"Content-Type": "application/cloudevents+json",
+			"Ce-Id":        "",
+		}},
+		{"binary", `{"id":"o-1"}`, map[string]string{
+			"Content-Type":   "application/json",
+			"Ce-Specversion": "1.0",
+			"Ce-Id":          "evt-1",
+			"Ce-Source":      "fiso-flow/orders",
+			"Ce-Type":        "order.created",
+			"Ce-Subject":     "order%201",
+			"X-Custom":       "test-value",
+		}},
+		{"none", `{"id":"o-1"}`, map[string]string{
+			"Content-Type": "application/json",
+			"Ce-Id":        "",
+			"X-Custom":     "test-value",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s, err := NewSink(Config{URL: server.URL, CloudEventsMode: tt.mode})
+			if err != nil {
+				t.Fatalf("failed to create sink: %v", err)
+			}
+			defer func() { _ = s.Close() }()
+
+			headers := map[string]string{
+				"Content-Type": "application/cloudevents+json",
+				"X-Custom":     "test-value",
+			}
+			if err := s.Deliver(context.Background(), event, headers); err != nil {
+				t.Fatalf("deliver failed: %v", err)
+			}
+			if string(receivedBody) != tt.wantBody {
+				t.Errorf("body = %s, want %s", receivedBody, tt.wantBody)
+			}
+			for k, want := range tt.wantHeaders {
+				if got := receivedHeaders.Get(k); got != want {
+					t.Errorf("header %s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverBatch(t *testing.T) {
+	var receivedBody []byte
+	var receivedHeaders http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	msgs := []sink.Message{
+		{Event: []byte(`{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"n":1}}`), Headers: map[string]string{"X-Event": "1"}},
+		{Event: []byte("{\n  \"specversion\": \"1.0\", \"id\": \"2\", \"source\": \"s\", \"type\": \"t\", \"data\": {\"n\": 2}\n}")},
+	}
+
+	tests := []struct {
+		format          string
+		mode            string
+		wantContentType string
+		wantBody        string
+	}{
+		{"", "", "application/cloudevents-batch+json",
+			`[{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"n":1}},{"specversion":"1.0","id":"2","source":"s","type":"t","data":{"n":2}}]` + "\n"},
+		{"ndjson", "", "application/x-ndjson",
+			`{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"n":1}}` + "\n" +
+				`{"specversion":"1.0","id":"2","source":"s","type":"t","data":{"n":2}}` + "\n"},
+		{"ndjson", "none", "application/x-ndjson", `{"n":1}` + "\n" + `{"n":2}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.mode, func(t *testing.T) {
+			s, err := NewSink(Config{URL: server.URL, BatchFormat: tt.format, CloudEventsMode: tt.mode, Headers: map[string]string{"Authorization": "Bearer t"}})
+			if err != nil {
+				t.Fatalf("failed to create sink: %v", err)
+			}
+			defer func() { _ = s.Close() }()
+
+			if err := s.DeliverBatch(context.Background(), msgs); err != nil {
+				t.Fatalf("deliver batch failed: %v", err)
+			}
+			if string(receivedBody) != tt.wantBody {
+				t.Errorf("body = %q, want %q", receivedBody, tt.wantBody)
+			}
+			if got := receivedHeaders.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if receivedHeaders.Get("Authorization") != "Bearer t" {
+				t.Error("expected static headers to be sent")
+			}
+			if receivedHeaders.Get("X-Event") != "" {
+				t.Error("expected per-event headers to be dropped")
+			}
+		})
+	}
+}
+
+func TestDeliverBatch_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	s, err := NewSink(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	err = s.DeliverBatch(context.Background(), []sink.Message{{Event: []byte(`{}`)}})
+	if !isPermanent(err) {
+		t.Fatalf("expected permanent status error, got %v", err)
+	}
+}
+
+func TestNewSink_InvalidBatchFormat(t *testing.T) {
+	_, err := NewSink(Config{URL: "http://localhost", BatchFormat: "csv"})
+	if err == nil {
+		t.Fatal("expected error for invalid batch format")
+	}
+}
+
+func TestNewSink_InvalidCloudEventsMode(t *testing.T) {
+	_, err := NewSink(Config{URL: "http://localhost", CloudEventsMode: "batch"})
+	if err == nil {
+		t.Fatal("expected error for invalid cloudevents mode")
+	}
+}
+
 func TestDeliver_ServerError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
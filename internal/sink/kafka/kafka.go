@@ -2,13 +2,17 @@ package kafka
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/lsm/fiso/internal/cloudevent"
 	"github.com/lsm/fiso/internal/correlation"
 	"github.com/lsm/fiso/internal/delivery"
 	"github.com/lsm/fiso/internal/kafka"
+	"github.com/lsm/fiso/internal/schema"
+	"github.com/lsm/fiso/internal/sink"
 	kafkasource "github.com/lsm/fiso/internal/source/kafka"
 	"github.com/lsm/fiso/internal/tracing"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -19,6 +23,7 @@ import (
 // publisher abstracts the kafka publisher for testing.
 type publisher interface {
 	Publish(ctx context.Context, topic string, key, value []byte, headers map[string]string) error
+	PublishBatch(ctx context.Context, topic string, msgs []kafkasource.Message) []error
 	Close() error
 }
 
@@ -27,6 +32,14 @@ type Config struct {
 	Cluster              *kafka.ClusterConfig // Cluster config with auth/TLS (required)
 	Topic                string
 	RequireTransactional bool // when true, Deliver requires a transactional producer in context
+	// Encoder, when set, serializes the CloudEvent data with a registry
+	// schema and publishes the event in binary mode: the encoded data is
+	// the record value and the CloudEvent attributes become ce_* headers.
+	Encoder schema.Encoder
+	// CloudEventsMode selects the CloudEvents content mode: structured
+	// (default), binary or none. With an Encoder it defaults to binary and
+	// structured is not allowed.
+	CloudEventsMode string
 }
 
 // Sink delivers events to a Kafka topic.
@@ -34,6 +47,8 @@ type Sink struct {
 	publisher            publisher
 	topic                string
 	requireTransactional bool
+	encoder              schema.Encoder
+	mode                 string
 	logger               *slog.Logger
 	tracer               trace.Tracer
 }
@@ -46,6 +61,12 @@ func NewSink(cfg Config) (*Sink, error) {
 	if cfg.Topic == "" {
 		return nil, fmt.Errorf("topic is required")
 	}
+	if !cloudevent.ValidMode(cfg.CloudEventsMode) {
+		return nil, fmt.Errorf("invalid cloudevents mode %q", cfg.CloudEventsMode)
+	}
+	if cfg.Encoder != nil && cfg.CloudEventsMode == cloudevent.ModeStructured {
+		return nil, fmt.Errorf("cloudevents mode structured cannot be used with an encoder")
+	}
 
 	pub, err := kafkasource.NewPublisher(cfg.Cluster)
 	if err != nil {
@@ -56,6 +77,8 @@ func NewSink(cfg Config) (*Sink, error) {
 		publisher:            pub,
 		topic:                cfg.Topic,
 		requireTransactional: cfg.RequireTransactional,
+		encoder:              cfg.Encoder,
+		mode:                 cfg.CloudEventsMode,
 		logger:               slog.Default(),
 		tracer:               noop.NewTracerProvider().Tracer("kafka-sink"),
 	}, nil
@@ -85,7 +108,10 @@ func (s *Sink) Deliver(ctx context.Context, event []byte, headers map[string]str
 	// Inject trace context into headers for propagation
 	headers = correlation.InjectTraceContext(ctx, headers)
 
-	err := s.publish(ctx, event, headers)
+	event, headers, err := s.render(ctx, event, headers)
+	if err == nil {
+		err = s.publish(ctx, event, headers)
+	}
 	if err != nil {
 		tracing.SetSpanError(span, err)
 		s.logger.Error("delivery failed",
@@ -105,6 +131,72 @@ func (s *Sink) Deliver(ctx context.Context, event []byte, headers map[string]str
 	return nil
 }
 
+// DeliverBatch sends msgs to the configured Kafka topic in a single produce
+// call. Messages that cannot be encoded or written are reported in a
+// *sink.BatchError; the others are delivered.
+func (s *Sink) DeliverBatch(ctx context.Context, msgs []sink.Message) error {
+	start := time.Now()
+
+	ctx, span := tracing.StartSpan(ctx, s.tracer, tracing.SpanKafkaPublish,
+		trace.WithAttributes(tracing.KafkaTopicAttr(s.topic)),
+	)
+	defer span.End()
+
+	if _, ok := delivery.KafkaTransactionalProducerFromContext(ctx); ok || s.requireTransactional {
+		err := fmt.Errorf("batch delivery is not supported with transactional producers")
+		tracing.SetSpanError(span, err)
+		return err
+	}
+
+	errs := make([]error, len(msgs))
+	batch := make([]kafkasource.Message, 0, len(msgs))
+	index := make([]int, 0, len(msgs))
+	for i, msg := range msgs {
+		headers := correlation.InjectTraceContext(ctx, msg.Headers)
+		value, headers, err := s.render(ctx, msg.Event, headers)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		batch = append(batch, kafkasource.Message{Value: value, Headers: headers})
+		index = append(index, i)
+	}
+	if len(batch) > 0 {
+		for j, err := range s.publisher.PublishBatch(ctx, s.topic, batch) {
+			errs[index[j]] = err
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			berr := &sink.BatchError{Errors: errs}
+			tracing.SetSpanError(span, berr)
+			s.logger.Error("batch delivery failed",
+				"target", s.topic,
+				"events", len(msgs),
+				"error", berr,
+			)
+			return berr
+		}
+	}
+
+	tracing.SetSpanOK(span)
+	s.logger.Info("batch delivered",
+		"target", s.topic,
+		"events", len(msgs),
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+	return nil
+}
+
+// render converts an event to the record value and headers to publish.
+func (s *Sink) render(ctx context.Context, event []byte, headers map[string]string) ([]byte, map[string]string, error) {
+	if s.encoder != nil {
+		return s.encode(ctx, event, headers)
+	}
+	return cloudevent.Encode(s.mode, cloudevent.Kafka, event, headers)
+}
+
 func (s *Sink) publish(ctx context.Context, event []byte, headers map[string]string) error {
 	if txProducer, ok := delivery.KafkaTransactionalProducerFromContext(ctx); ok {
 		record := &kgo.Record{Topic: s.topic, Value: event}
@@ -125,6 +217,42 @@ func (s *Sink) publish(ctx context.Context, event []byte, headers map[string]str
 	return s.publisher.Publish(ctx, s.topic, nil, event, headers)
 }
 
+// encode renders a structured JSON CloudEvent in the sink's content mode
+// (binary unless set to none) with its data serialized by the sink's
+// encoder. Payloads that are not CloudEvents are encoded whole and keep
+// their headers.
+func (s *Sink) encode(ctx context.Context, event []byte, headers map[string]string) ([]byte, map[string]string, error) {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(event, &attrs); err != nil || attrs["specversion"] == nil {
+		value, err := s.encoder.Encode(ctx, event)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode event: %w", err)
+		}
+		return value, headers, nil
+	}
+	if _, ok := attrs["data_base64"]; ok {
+		return nil, nil, fmt.Errorf("encode event: data_base64 is not supported")
+	}
+
+	mode := s.mode
+	if mode == "" {
+		mode = cloudevent.ModeBinary
+	}
+	data, out, err := cloudevent.Encode(mode, cloudevent.Kafka, event, headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode event: %w", err)
+	}
+	if data == nil {
+		data = []byte("null")
+	}
+	value, err := s.encoder.Encode(ctx, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode event data: %w", err)
+	}
+	out["content-type"] = s.encoder.ContentType()
+	return value, out, nil
+}
+
 // Close shuts down the Kafka publisher.
 func (s *Sink) Close() error {
 	return s.publisher.Close()
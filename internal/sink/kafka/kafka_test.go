@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/lsm/fiso/internal/delivery"
 	intkafka "github.com/lsm/fiso/internal/kafka"
+	"github.com/lsm/fiso/internal/schema"
+	"github.com/lsm/fiso/internal/sink"
+	kafkasource "github.com/lsm/fiso/internal/source/kafka"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"go.opentelemetry.io/otel/trace/noop"
 )
@@ -22,6 +27,8 @@ type mockPublisher struct {
 		value   []byte
 		headers map[string]string
 	}
+	batch     []kafkasource.Message
+	batchErrs []error
 }
 
 type mockTxProducer struct {
@@ -46,6 +53,15 @@ func (m *mockPublisher) Publish(ctx context.Context, topic string, key, value []
 	return m.publishErr
 }
 
+func (m *mockPublisher) PublishBatch(_ context.Context, topic string, msgs []kafkasource.Message) []error {
+	m.record.topic = topic
+	m.batch = append(m.batch, msgs...)
+	if m.batchErrs != nil {
+		return m.batchErrs
+	}
+	return make([]error, len(msgs))
+}
+
 func (m *mockPublisher) Close() error {
 	m.closed = true
 	return nil
@@ -472,3 +488,210 @@ func TestSetTracer(t *testing.T) {
 		t.Error("expected tracer to be set")
 	}
 }
+
+// mockEncoder prefixes payloads so tests can see what was encoded.
+type mockEncoder struct {
+	err error
+}
+
+func (m mockEncoder) Encode(_ context.Context, data []byte) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return append([]byte("enc:"), data...), nil
+}
+
+func (mockEncoder) ContentType() string { return "application/avro" }
+
+func TestSink_Deliver_EncodesBinaryCloudEvent(t *testing.T) {
+	mp := &mockPublisher{}
+	s := &Sink{
+		publisher: mp,
+		topic:     "orders-avro",
+		encoder:   mockEncoder{},
+		logger:    slog.Default(),
+		tracer:    noop.NewTracerProvider().Tracer("test"),
+	}
+	event := []byte(`{"specversion":"1.0","id":"evt-1","source":"fiso-flow/orders","type":"order.created",` +
+		`"subject":"o-1","datacontenttype":"application/json","traceid":7,"data":{"id":"o-1","total":12}}`)
+	headers := map[string]string{
+		"Content-Type":        "application/cloudevents+json",
+		"fiso-correlation-id": "corr-1",
+	}
+
+	if err := s.Deliver(context.Background(), event, headers); err != nil {
+		t.Fatalf("deliver failed: %v", err)
+	}
+	if string(mp.record.value) != `enc:{"id":"o-1","total":12}` {
+		t.Errorf("expected encoded data as value, got %s", mp.record.value)
+	}
+	want := map[string]string{
+		"ce_specversion":      "1.0",
+		"ce_id":               "evt-1",
+		"ce_source":           "fiso-flow/orders",
+		"ce_type":             "order.created",
+		"ce_subject":          "o-1",
+		"ce_traceid":          "7",
+		"content-type":        "application/avro",
+		"fiso-correlation-id": "corr-1",
+	}
+	for k, v := range want {
+		if mp.record.headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, mp.record.headers[k], v)
+		}
+	}
+	for _, k := range []string{"Content-Type", "ce_data", "ce_datacontenttype"} {
+		if _, ok := mp.record.headers[k]; ok {
+			t.Errorf("unexpected header %s", k)
+		}
+	}
+}
+
+func TestSink_Deliver_EncodesNonCloudEvent(t *testing.T) {
+	mp := &mockPublisher{}
+	s := &Sink{publisher: mp, topic: "t", encoder: mockEncoder{}, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+	if err := s.Deliver(context.Background(), []byte(`{"id":1}`), map[string]string{"x": "y"}); err != nil {
+		t.Fatalf("deliver failed: %v", err)
+	}
+	if string(mp.record.value) != `enc:{"id":1}` || mp.record.headers["x"] != "y" {
+		t.Errorf("unexpected record: %s %v", mp.record.value, mp.record.headers)
+	}
+}
+
+func TestSink_Deliver_EncodeError(t *testing.T) {
+	mp := &mockPublisher{}
+	s := &Sink{publisher: mp, topic: "t", encoder: mockEncoder{err: errors.New("missing field")}, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+	err := s.Deliver(context.Background(), []byte(`{"specversion":"1.0","data":{}}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "encode event data: missing field") {
+		t.Fatalf("expected encode error, got %v", err)
+	}
+	if mp.record.value != nil {
+		t.Error("expected nothing to be published")
+	}
+
+	err = s.Deliver(context.Background(), []byte(`{"specversion":"1.0","data_base64":"AA=="}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "data_base64") {
+		t.Fatalf("expected data_base64 error, got %v", err)
+	}
+}
+
+func TestSink_Deliver_CloudEventsModes(t *testing.T) {
+	event := []byte(`{"specversion":"1.0","id":"evt-1","source":"fiso-flow/orders","type":"order.created",` +
+		`"datacontenttype":"application/json","data":{"id":"o-1"}}`)
+	headers := map[string]string{"Content-Type": "application/cloudevents+json"}
+
+	tests := []struct {
+		name        string
+		mode        string
+		encoder     schema.Encoder
+		wantValue   string
+		wantHeaders map[string]string
+	}{
+		{"structured", "", nil, string(event), map[string]string{
+			"Content-Type": "application/cloudevents+json",
+		}},
+		{"binary", "binary", nil, `{"id":"o-1"}`, map[string]string{
+			"content-type":   "application/json",
+			"ce_specversion": "1.0",
+			"ce_id":          "evt-1",
+			"ce_source":      "fiso-flow/orders",
+			"ce_type":        "order.created",
+		}},
+		{"none", "none", nil, `{"id":"o-1"}`, map[string]string{
+			"content-type": "application/json",
+		}},
+		{"none with encoder", "none", mockEncoder{}, `enc:{"id":"o-1"}`, map[string]string{
+			"content-type": "application/avro",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := &mockPublisher{}
+			s := &Sink{publisher: mp, topic: "t", encoder: tt.encoder, mode: tt.mode,
+				logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+			if err := s.Deliver(context.Background(), event, headers); err != nil {
+				t.Fatalf("deliver failed: %v", err)
+			}
+			if string(mp.record.value) != tt.wantValue {
+				t.Errorf("value = %s, want %s", mp.record.value, tt.wantValue)
+			}
+			if !reflect.DeepEqual(mp.record.headers, tt.wantHeaders) {
+				t.Errorf("headers = %v, want %v", mp.record.headers, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestNewSink_CloudEventsModeErrors(t *testing.T) {
+	cluster := &intkafka.ClusterConfig{Brokers: []string{"localhost:9092"}}
+	if _, err := NewSink(Config{Cluster: cluster, Topic: "t", CloudEventsMode: "batch"}); err == nil {
+		t.Error("expected error for invalid cloudevents mode")
+	}
+	_, err := NewSink(Config{Cluster: cluster, Topic: "t", Encoder: mockEncoder{}, CloudEventsMode: "structured"})
+	if err == nil || !strings.Contains(err.Error(), "structured") {
+		t.Errorf("expected structured mode error, got %v", err)
+	}
+}
+
+func TestSink_DeliverBatch(t *testing.T) {
+	mp := &mockPublisher{}
+	s := &Sink{publisher: mp, topic: "orders", mode: "binary", logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+	msgs := []sink.Message{
+		{Event: []byte(`{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"n":1}}`), Headers: map[string]string{"fiso-correlation-id": "c-1"}},
+		{Event: []byte(`{"specversion":"1.0","id":"2","source":"s","type":"t","data":{"n":2}}`)},
+	}
+	if err := s.DeliverBatch(context.Background(), msgs); err != nil {
+		t.Fatalf("deliver batch failed: %v", err)
+	}
+	if mp.record.topic != "orders" || len(mp.batch) != 2 {
+		t.Fatalf("expected 2 records on orders, got %d on %s", len(mp.batch), mp.record.topic)
+	}
+	if string(mp.batch[1].Value) != `{"n":2}` || mp.batch[1].Headers["ce_id"] != "2" {
+		t.Errorf("unexpected record: %s %v", mp.batch[1].Value, mp.batch[1].Headers)
+	}
+	if mp.batch[0].Headers["fiso-correlation-id"] != "c-1" {
+		t.Errorf("expected per-event headers, got %v", mp.batch[0].Headers)
+	}
+}
+
+func TestSink_DeliverBatch_PartialFailure(t *testing.T) {
+	mp := &mockPublisher{batchErrs: []error{nil, errors.New("record too large")}}
+	s := &Sink{publisher: mp, topic: "t", encoder: mockEncoder{}, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+	msgs := []sink.Message{
+		{Event: []byte(`{"specversion":"1.0","data_base64":"AA=="}`)}, // cannot be encoded
+		{Event: []byte(`{"id":1}`)},
+		{Event: []byte(`{"id":2}`)},
+	}
+	err := s.DeliverBatch(context.Background(), msgs)
+
+	var berr *sink.BatchError
+	if !errors.As(err, &berr) {
+		t.Fatalf("expected *sink.BatchError, got %v", err)
+	}
+	if len(mp.batch) != 2 {
+		t.Errorf("expected the 2 encodable events to be published, got %d", len(mp.batch))
+	}
+	if berr.Errors[0] == nil || berr.Errors[1] != nil || berr.Errors[2] == nil {
+		t.Errorf("unexpected per-event errors: %v", berr.Errors)
+	}
+	if !strings.Contains(err.Error(), "2 of 3 events failed") {
+		t.Errorf("unexpected error text: %v", err)
+	}
+}
+
+func TestSink_DeliverBatch_Transactional(t *testing.T) {
+	mp := &mockPublisher{}
+	s := &Sink{publisher: mp, topic: "t", requireTransactional: true, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+
+	if err := s.DeliverBatch(context.Background(), []sink.Message{{Event: []byte(`{}`)}}); err == nil {
+		t.Fatal("expected error for transactional batch delivery")
+	}
+	if len(mp.batch) != 0 {
+		t.Error("expected nothing to be published")
+	}
+}
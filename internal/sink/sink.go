@@ -1,6 +1,9 @@
 package sink
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // Sink delivers processed events to a destination.
 type Sink interface {
@@ -12,3 +15,40 @@ type Sink interface {
 	// Close performs graceful shutdown.
 	Close() error
 }
+
+// Message is an event with its headers, as passed to DeliverBatch.
+type Message struct {
+	Event   []byte
+	Headers map[string]string
+}
+
+// BatchSink is implemented by sinks that can deliver several events at
+// once. Pipelines with batching enabled deliver to other sinks one event
+// at a time.
+type BatchSink interface {
+	Sink
+
+	// DeliverBatch sends msgs to the destination. A *BatchError reports
+	// which messages failed; any other error applies to all of them.
+	DeliverBatch(ctx context.Context, msgs []Message) error
+}
+
+// BatchError reports the messages of a batch that were not delivered.
+// Errors has one entry per message, nil for those that were delivered.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	var failed int
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d events failed: %v", failed, len(e.Errors), first)
+}
@@ -7,7 +7,9 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"strings"
 
+	"github.com/lsm/fiso/internal/cloudevent"
 	"github.com/lsm/fiso/internal/correlation"
 	"github.com/lsm/fiso/internal/source"
 )
@@ -73,6 +75,12 @@ func (s *Source) Start(ctx context.Context, handler func(context.Context, source
 			}
 		}
 
+		body, err = normalizeCloudEvent(body, headers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Extract trace context and correlation ID from headers
 		ctx := correlation.ExtractTraceContext(r.Context(), headers)
 		corrID := correlation.ExtractOrGenerate(headers)
@@ -126,6 +134,28 @@ func (s *Source) Start(ctx context.Context, handler func(context.Context, source
 	}
 }
 
+// normalizeCloudEvent converts a binary-mode CloudEvent, whose attributes
+// arrive as ce-* headers, to a structured JSON event so flows see the same
+// payload whichever content mode the sender used. The ce-* headers are
+// removed and Content-Type is set to application/cloudevents+json. Other
+// requests, including structured-mode events, are returned unchanged.
+func normalizeCloudEvent(body []byte, headers map[string]string) ([]byte, error) {
+	event, ok, err := cloudevent.FromBinary(cloudevent.HTTP, body, headers)
+	if !ok {
+		return body, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k := range headers {
+		if strings.HasPrefix(strings.ToLower(k), cloudevent.HTTP.Prefix) || strings.EqualFold(k, "Content-Type") {
+			delete(headers, k)
+		}
+	}
+	headers["Content-Type"] = cloudevent.ContentTypeStructured
+	return event, nil
+}
+
 // Close stops the HTTP server.
 func (s *Source) Close() error {
 	if s.server != nil {
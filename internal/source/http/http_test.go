@@ -638,3 +638,80 @@ func TestSource_ListenerFailureDuringServe(t *testing.T) {
 		<-errCh
 	}
 }
+
+func TestSource_NormalizesBinaryCloudEvents(t *testing.T) {
+	src, err := NewSource(Config{ListenAddr: "127.0.0.1:0"}, nil)
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+
+	var mu sync.Mutex
+	var received []source.Event
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = src.Start(ctx, func(_ context.Context, evt source.Event) error {
+			mu.Lock()
+			received = append(received, evt)
+			mu.Unlock()
+			return nil
+		})
+	}()
+
+	<-src.ready
+
+	post := func(body string, headers map[string]string) int {
+		req, _ := http.NewRequest(http.MethodPost, "http://"+src.ListenAddr+"/", bytes.NewReader([]byte(body)))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	status := post(`{"id":"o-1"}`, map[string]string{
+		"Content-Type":   "application/json",
+		"Ce-Specversion": "1.0",
+		"Ce-Id":          "evt-1",
+		"Ce-Source":      "/orders",
+		"Ce-Type":        "order.created",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+
+	structured := `{"specversion":"1.0","id":"evt-2","source":"/orders","type":"order.created","data":{"id":"o-2"}}`
+	if status := post(structured, map[string]string{"Content-Type": "application/cloudevents+json"}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+
+	if status := post(`{}`, map[string]string{"Ce-Specversion": "1.0", "Ce-Id": "evt-3"}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for incomplete binary event, got %d", status)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	want := `{"data":{"id":"o-1"},"datacontenttype":"application/json","id":"evt-1","source":"/orders","specversion":"1.0","type":"order.created"}`
+	if string(received[0].Value) != want {
+		t.Errorf("binary event = %s, want %s", received[0].Value, want)
+	}
+	if received[0].Headers["Content-Type"] != "application/cloudevents+json" {
+		t.Errorf("expected structured content type, got %v", received[0].Headers)
+	}
+	if _, ok := received[0].Headers["Ce-Id"]; ok {
+		t.Error("expected ce- headers to be removed")
+	}
+	if string(received[1].Value) != structured {
+		t.Errorf("structured event changed: %s", received[1].Value)
+	}
+}
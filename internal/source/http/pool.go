@@ -168,6 +168,12 @@ func (p *ServerPool) PreRegister(listenAddr, path string) (*RouteHandle, error)
 			}
 		}
 
+		body, err = normalizeCloudEvent(body, headers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		evt := source.Event{
 			Value:   body,
 			Headers: headers,
@@ -269,6 +275,12 @@ func (p *ServerPool) Register(listenAddr, path string, handler func(context.Cont
 			}
 		}
 
+		body, err = normalizeCloudEvent(body, headers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		evt := source.Event{
 			Value:   body,
 			Headers: headers,
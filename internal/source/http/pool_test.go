@@ -828,3 +828,51 @@ func TestServerPool_StartTwice(t *testing.T) {
 		t.Errorf("expected 200, got %d", resp.StatusCode)
 	}
 }
+
+func TestServerPool_NormalizesBinaryCloudEvents(t *testing.T) {
+	pool := NewServerPool(nil)
+
+	var mu sync.Mutex
+	var received source.Event
+	_, err := pool.Register("127.0.0.1:0", "/events", func(_ context.Context, evt source.Event) error {
+		mu.Lock()
+		received = evt
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- pool.Start(ctx)
+	}()
+	pool.WaitReady()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://"+pool.ListenAddr("127.0.0.1:0")+"/events", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Id", "evt-1")
+	req.Header.Set("Ce-Source", "/greetings")
+	req.Header.Set("Ce-Type", "greeting")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	<-errCh
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := `{"data":"hello","datacontenttype":"text/plain","id":"evt-1","source":"/greetings","specversion":"1.0","type":"greeting"}`
+	if string(received.Value) != want {
+		t.Errorf("event = %s, want %s", received.Value, want)
+	}
+}
@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -381,6 +382,141 @@ func (s *Source) startTransactional(ctx context.Context, handler func(context.Co
 	}
 }
 
+// StartBatch consumes events from Kafka for a batching pipeline. Offsets
+// are committed only after handler.Flush delivers the batch the records
+// were added to. Blocks until ctx is cancelled; records buffered but not
+// yet flushed are left uncommitted and redelivered after a restart.
+func (s *Source) StartBatch(ctx context.Context, handler source.BatchHandler) error {
+	if s.txSession != nil {
+		return fmt.Errorf("batching is not supported with kafka transactions")
+	}
+	s.logger.Info("starting kafka consumer", "topic", s.topic, "batching", true)
+
+	// Records added to the handler since the last flush, and those a
+	// source.RetainingHandler still held at the last flush. Records that the
+	// handler dropped or sent to the DLQ are committed with the batch.
+	var pending []*kgo.Record
+	flush := func() error {
+		if err := handler.Flush(ctx); err != nil {
+			pending = nil
+			return err
+		}
+		var commit []*kgo.Record
+		commit, pending = releasable(pending, handler)
+		if len(commit) == 0 {
+			return nil
+		}
+		s.client.MarkCommitRecords(commit...)
+		if err := s.client.CommitMarkedOffsets(ctx); err != nil {
+			last := commit[len(commit)-1]
+			s.logger.Error("commit error", "topic", last.Topic, "offset", last.Offset, "error", err)
+		}
+		return nil
+	}
+
+	for {
+		// Wake up when the buffered batch is due.
+		pollCtx, cancel := ctx, context.CancelFunc(func() {})
+		if due := handler.Due(); !due.IsZero() {
+			pollCtx, cancel = context.WithDeadline(ctx, due)
+		}
+		fetches := s.client.PollFetches(pollCtx)
+		timedOut := pollCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+
+		if errs := fetches.Errors(); len(errs) > 0 && !timedOut {
+			for _, err := range errs {
+				s.logger.Error("fetch error", "topic", err.Topic, "partition", err.Partition, "error", err.Err)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		var handlerErr error
+		for iter := fetches.RecordIter(); !iter.Done(); {
+			record := iter.Next()
+			evt := buildEvent(record)
+
+			recordCtx, corrID, span := s.startRecordSpan(ctx, record, evt)
+			s.logger.Info("event received",
+				"correlation_id", corrID.Value,
+				"correlation_source", corrID.Source,
+				"topic", record.Topic,
+				"offset", record.Offset,
+				"partition", record.Partition,
+			)
+
+			full, err := handler.Add(recordCtx, evt)
+			if err != nil {
+				handlerErr = err
+				tracing.SetSpanError(span, err)
+				span.End()
+				s.logger.Error("handler error", "topic", record.Topic, "offset", record.Offset, "error", err)
+				break
+			}
+			tracing.SetSpanOK(span)
+			span.End()
+
+			pending = append(pending, record)
+			if full {
+				if handlerErr = flush(); handlerErr != nil {
+					break
+				}
+			}
+		}
+
+		// Deliver what was buffered before a failed record, so that only
+		// the failed record and those after it are redelivered.
+		if handlerErr != nil && len(pending) > 0 {
+			if err := flush(); err != nil {
+				handlerErr = errors.Join(handlerErr, err)
+			}
+		}
+		if handlerErr == nil {
+			if due := handler.Due(); due.IsZero() || !time.Now().Before(due) {
+				handlerErr = flush()
+			}
+		}
+
+		if handlerErr != nil && s.stopOnHandlerError {
+			return handlerErr
+		}
+
+		if ctx.Err() != nil {
+			s.logger.Info("kafka source draining complete", "topic", s.topic)
+			return ctx.Err()
+		}
+	}
+}
+
+// releasable splits the records of a flushed batch into those that can be
+// committed and those the handler still retains, if it is a
+// source.RetainingHandler. Offsets are committed per partition, so the
+// records after a retained one are kept too.
+func releasable(records []*kgo.Record, handler source.BatchHandler) (release, keep []*kgo.Record) {
+	rh, ok := handler.(source.RetainingHandler)
+	if !ok {
+		return records, nil
+	}
+	type topicPartition struct {
+		topic     string
+		partition int32
+	}
+	held := make(map[topicPartition]bool)
+	for _, r := range records {
+		tp := topicPartition{r.Topic, r.Partition}
+		if held[tp] || rh.Retains(source.Event{Topic: r.Topic, Partition: r.Partition, Offset: r.Offset}) {
+			held[tp] = true
+			keep = append(keep, r)
+			continue
+		}
+		release = append(release, r)
+	}
+	return release, keep
+}
+
 func (s *Source) startRecordSpan(ctx context.Context, record *kgo.Record, evt source.Event) (context.Context, correlation.ID, trace.Span) {
 	corrID := correlation.ExtractOrGenerate(evt.Headers)
 
@@ -398,11 +534,12 @@ func (s *Source) startRecordSpan(ctx context.Context, record *kgo.Record, evt so
 
 func buildEvent(record *kgo.Record) source.Event {
 	evt := source.Event{
-		Key:     record.Key,
-		Value:   record.Value,
-		Headers: make(map[string]string, len(record.Headers)),
-		Offset:  record.Offset,
-		Topic:   record.Topic,
+		Key:       record.Key,
+		Value:     record.Value,
+		Headers:   make(map[string]string, len(record.Headers)),
+		Offset:    record.Offset,
+		Partition: record.Partition,
+		Topic:     record.Topic,
 	}
 	for _, h := range record.Headers {
 		evt.Headers[h.Key] = string(h.Value)
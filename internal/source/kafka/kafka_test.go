@@ -420,7 +420,7 @@ func TestSource_Start_ProcessesRecords(t *testing.T) {
 			Topics: []kgo.FetchTopic{{
 				Topic: "test-topic",
 				Partitions: []kgo.FetchPartition{{
-					Partition: 0,
+					Partition: 3,
 					Records: []*kgo.Record{
 						{
 							Key:   []byte("key1"),
@@ -428,8 +428,9 @@ func TestSource_Start_ProcessesRecords(t *testing.T) {
 							Headers: []kgo.RecordHeader{
 								{Key: "ce-type", Value: []byte("test.event")},
 							},
-							Offset: 42,
-							Topic:  "test-topic",
+							Offset:    42,
+							Partition: 3,
+							Topic:     "test-topic",
 						},
 					},
 				}},
@@ -472,6 +473,9 @@ func TestSource_Start_ProcessesRecords(t *testing.T) {
 	if received[0].Offset != 42 {
 		t.Errorf("expected offset 42, got %d", received[0].Offset)
 	}
+	if received[0].Partition != 3 {
+		t.Errorf("expected partition 3, got %d", received[0].Partition)
+	}
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 	if len(mc.committed) == 0 {
@@ -901,3 +905,234 @@ func TestSource_Start_Transactional_AbortAndContinueWhenNotStrict(t *testing.T)
 		t.Fatalf("expected first end call to abort, got %+v", tx.endCalls)
 	}
 }
+
+// sequenceConsumer returns its fetches one poll at a time, then blocks
+// until the poll context is done.
+type sequenceConsumer struct {
+	mockConsumer
+	sequence []kgo.Fetches
+}
+
+func (m *sequenceConsumer) PollFetches(ctx context.Context) kgo.Fetches {
+	m.mu.Lock()
+	if len(m.sequence) > 0 {
+		next := m.sequence[0]
+		m.sequence = m.sequence[1:]
+		m.mu.Unlock()
+		return next
+	}
+	m.mu.Unlock()
+	<-ctx.Done()
+	return kgo.NewErrFetch(ctx.Err())
+}
+
+func (m *sequenceConsumer) committedOffsets() []int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	offsets := make([]int64, len(m.committed))
+	for i, r := range m.committed {
+		offsets[i] = r.Offset
+	}
+	return offsets
+}
+
+func recordFetches(offsets ...int64) kgo.Fetches {
+	records := make([]*kgo.Record, len(offsets))
+	for i, o := range offsets {
+		records[i] = &kgo.Record{Topic: "test-topic", Value: []byte(fmt.Sprintf(`{"n":%d}`, o)), Offset: o}
+	}
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{{
+		Topic:      "test-topic",
+		Partitions: []kgo.FetchPartition{{Records: records}},
+	}}}}
+}
+
+// batchRecorder is a source.BatchHandler that is full at maxEvents and due
+// wait after its first buffered event.
+type batchRecorder struct {
+	mu        sync.Mutex
+	maxEvents int
+	wait      time.Duration
+	buffered  []int64
+	due       time.Time
+	flushed   [][]int64
+	flushErr  error
+	addErr    func(source.Event) error
+	committed func() []int64
+	// commitsAtFlush records the committed offsets seen by each flush.
+	commitsAtFlush [][]int64
+}
+
+func (b *batchRecorder) Add(_ context.Context, evt source.Event) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.addErr != nil {
+		if err := b.addErr(evt); err != nil {
+			return false, err
+		}
+	}
+	if len(b.buffered) == 0 {
+		b.due = time.Now().Add(b.wait)
+	}
+	b.buffered = append(b.buffered, evt.Offset)
+	return len(b.buffered) >= b.maxEvents, nil
+}
+
+func (b *batchRecorder) Due() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.due
+}
+
+func (b *batchRecorder) Flush(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.commitsAtFlush = append(b.commitsAtFlush, b.committed())
+	if len(b.buffered) > 0 {
+		b.flushed = append(b.flushed, b.buffered)
+	}
+	b.buffered, b.due = nil, time.Time{}
+	return b.flushErr
+}
+
+func TestSource_StartBatch_CommitsAfterFlush(t *testing.T) {
+	mc := &sequenceConsumer{sequence: []kgo.Fetches{recordFetches(1, 2, 3)}}
+	s := &Source{client: mc, topic: "test-topic", logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+	h := &batchRecorder{maxEvents: 2, wait: 20 * time.Millisecond, committed: mc.committedOffsets}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.StartBatch(ctx, h) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mc.committedOffsets()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if fmt.Sprint(h.flushed) != "[[1 2] [3]]" {
+		t.Errorf("flushed batches = %v, want [[1 2] [3]]", h.flushed)
+	}
+	// Nothing is committed before the first flush; record 3 is committed
+	// only after its batch is flushed on maxWait.
+	if len(h.commitsAtFlush) < 2 || len(h.commitsAtFlush[0]) != 0 || fmt.Sprint(h.commitsAtFlush[1]) != "[1 2]" {
+		t.Errorf("commits at flush = %v", h.commitsAtFlush)
+	}
+	if got := fmt.Sprint(mc.committedOffsets()); got != "[1 2 3]" {
+		t.Errorf("committed = %s, want [1 2 3]", got)
+	}
+}
+
+func TestSource_StartBatch_FlushErrorSkipsCommit(t *testing.T) {
+	mc := &sequenceConsumer{sequence: []kgo.Fetches{recordFetches(1, 2)}}
+	s := &Source{client: mc, topic: "test-topic", stopOnHandlerError: true, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+	h := &batchRecorder{maxEvents: 2, wait: time.Second, flushErr: errors.New("sink down"), committed: mc.committedOffsets}
+
+	err := s.StartBatch(context.Background(), h)
+	if err == nil || err.Error() != "sink down" {
+		t.Fatalf("expected flush error, got %v", err)
+	}
+	if len(mc.committedOffsets()) != 0 {
+		t.Errorf("expected no commits, got %v", mc.committedOffsets())
+	}
+}
+
+func TestSource_StartBatch_AddErrorFlushesEarlierRecords(t *testing.T) {
+	mc := &sequenceConsumer{sequence: []kgo.Fetches{recordFetches(1, 2, 3)}}
+	s := &Source{client: mc, topic: "test-topic", stopOnHandlerError: true, logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+	h := &batchRecorder{maxEvents: 10, wait: time.Second, committed: mc.committedOffsets,
+		addErr: func(evt source.Event) error {
+			if evt.Offset == 2 {
+				return errors.New("transform failed")
+			}
+			return nil
+		},
+	}
+
+	err := s.StartBatch(context.Background(), h)
+	if err == nil || err.Error() != "transform failed" {
+		t.Fatalf("expected add error, got %v", err)
+	}
+	if got := fmt.Sprint(mc.committedOffsets()); got != "[1]" {
+		t.Errorf("committed = %s, want [1]", got)
+	}
+}
+
+// retainingRecorder retains the offsets from holdFrom until its second
+// flush, and is due again right after the first.
+type retainingRecorder struct {
+	batchRecorder
+	holdFrom int64
+	flushes  int
+}
+
+func (r *retainingRecorder) Due() time.Time {
+	due := r.batchRecorder.Due()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.flushes == 1 {
+		return time.Now()
+	}
+	return due
+}
+
+func (r *retainingRecorder) Flush(ctx context.Context) error {
+	if err := r.batchRecorder.Flush(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+	return nil
+}
+
+func (r *retainingRecorder) Retains(evt source.Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushes < 2 && evt.Offset >= r.holdFrom
+}
+
+func TestSource_StartBatch_RetainingHandler(t *testing.T) {
+	mc := &sequenceConsumer{sequence: []kgo.Fetches{recordFetches(1, 2, 3)}}
+	s := &Source{client: mc, topic: "test-topic", logger: slog.Default(), tracer: noop.NewTracerProvider().Tracer("test")}
+	h := &retainingRecorder{batchRecorder: batchRecorder{maxEvents: 10, wait: 20 * time.Millisecond, committed: mc.committedOffsets}, holdFrom: 2}
+
+	released, kept := releasable(recordFetches(1, 2, 3).Records(), h)
+	if len(released) != 1 || released[0].Offset != 1 || len(kept) != 2 {
+		t.Fatalf("releasable = %d records, kept %d", len(released), len(kept))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.StartBatch(ctx, h) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mc.committedOffsets()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The first flush commits record 1 only: record 2 is retained, and
+	// record 3 comes after it. The second flush commits the rest.
+	if len(h.commitsAtFlush) < 2 || fmt.Sprint(h.commitsAtFlush[1]) != "[1]" {
+		t.Errorf("commits at flush = %v, want [1] before the second flush", h.commitsAtFlush)
+	}
+	if got := fmt.Sprint(mc.committedOffsets()); got != "[1 2 3]" {
+		t.Errorf("committed = %s, want [1 2 3]", got)
+	}
+}
+
+func TestSource_StartBatch_Transactional(t *testing.T) {
+	s := &Source{txSession: &mockTxSession{}, topic: "test-topic", logger: slog.Default()}
+	if err := s.StartBatch(context.Background(), &batchRecorder{}); err == nil {
+		t.Fatal("expected error for batching with transactions")
+	}
+}
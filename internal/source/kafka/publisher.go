@@ -57,6 +57,36 @@ func (p *Publisher) Publish(ctx context.Context, topic string, key, value []byte
 	return nil
 }
 
+// Message is a record to publish with PublishBatch.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// PublishBatch sends msgs to the specified Kafka topic in a single produce
+// call. It returns one error per message, nil for messages that were
+// written.
+func (p *Publisher) PublishBatch(ctx context.Context, topic string, msgs []Message) []error {
+	records := make([]*kgo.Record, len(msgs))
+	index := make(map[*kgo.Record]int, len(msgs))
+	for i, msg := range msgs {
+		records[i] = &kgo.Record{Topic: topic, Key: msg.Key, Value: msg.Value}
+		for k, v := range msg.Headers {
+			records[i].Headers = append(records[i].Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+		}
+		index[records[i]] = i
+	}
+
+	errs := make([]error, len(msgs))
+	for _, result := range p.client.ProduceSync(ctx, records...) {
+		if result.Err != nil {
+			errs[index[result.Record]] = fmt.Errorf("kafka publish: %w", result.Err)
+		}
+	}
+	return errs
+}
+
 // Close shuts down the publisher.
 func (p *Publisher) Close() error {
 	p.client.Close()
@@ -130,3 +130,48 @@ func TestPublisher_Close_Mock(t *testing.T) {
 		t.Error("expected producer to be closed")
 	}
 }
+
+// rejectingProducer fails records whose value is "bad".
+type rejectingProducer struct {
+	records []*kgo.Record
+}
+
+func (m *rejectingProducer) ProduceSync(_ context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	m.records = append(m.records, rs...)
+	results := make(kgo.ProduceResults, 0, len(rs))
+	// Report results in reverse order; PublishBatch must match them by record.
+	for i := len(rs) - 1; i >= 0; i-- {
+		var err error
+		if string(rs[i].Value) == "bad" {
+			err = errors.New("record too large")
+		}
+		results = append(results, kgo.ProduceResult{Record: rs[i], Err: err})
+	}
+	return results
+}
+
+func (m *rejectingProducer) Close() {}
+
+func TestPublisher_PublishBatch(t *testing.T) {
+	mp := &rejectingProducer{}
+	pub := &Publisher{client: mp}
+
+	errs := pub.PublishBatch(context.Background(), "orders", []Message{
+		{Key: []byte("k1"), Value: []byte("ok"), Headers: map[string]string{"h": "v"}},
+		{Value: []byte("bad")},
+		{Value: []byte("ok")},
+	})
+
+	if len(mp.records) != 3 {
+		t.Fatalf("expected 3 records in one produce, got %d", len(mp.records))
+	}
+	if mp.records[0].Topic != "orders" || string(mp.records[0].Key) != "k1" || len(mp.records[0].Headers) != 1 {
+		t.Errorf("unexpected record: %+v", mp.records[0])
+	}
+	if errs[0] != nil || errs[2] != nil {
+		t.Errorf("expected records 0 and 2 to succeed, got %v", errs)
+	}
+	if errs[1] == nil {
+		t.Error("expected record 1 to fail")
+	}
+}
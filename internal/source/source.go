@@ -1,6 +1,9 @@
 package source
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Event represents a raw event consumed from a source.
 type Event struct {
@@ -8,6 +11,7 @@ type Event struct {
 	Value         []byte
 	Headers       map[string]string
 	Offset        int64
+	Partition     int32
 	Topic         string
 	CorrelationID string
 }
@@ -21,3 +25,46 @@ type Source interface {
 	// Close performs graceful shutdown.
 	Close() error
 }
+
+// BatchHandler buffers events for delivery in batches.
+type BatchHandler interface {
+	// Add processes evt and buffers it for delivery. It reports whether
+	// the batch is full and should be flushed. An error means evt failed
+	// and must not be acknowledged.
+	Add(ctx context.Context, evt Event) (full bool, err error)
+
+	// Due returns when the buffered events must be flushed, or the zero
+	// time when nothing is buffered.
+	Due() time.Time
+
+	// Flush delivers the buffered events. Events the sink rejects are
+	// handled individually; an error means the batch must not be
+	// acknowledged.
+	Flush(ctx context.Context) error
+}
+
+// BatchSource is implemented by sources that acknowledge events, such as
+// Kafka offset commits, so that a batching pipeline can acknowledge events
+// only once their batch has been delivered. Pipelines hand events from
+// other sources to the handler one request at a time and reply when the
+// batch is delivered.
+type BatchSource interface {
+	Source
+
+	// StartBatch is like Start, but acknowledges the events passed to
+	// handler.Add only after a later handler.Flush succeeds.
+	StartBatch(ctx context.Context, handler BatchHandler) error
+}
+
+// RetainingHandler is a BatchHandler that keeps some events buffered across
+// flushes, such as events in open aggregation windows. After a successful
+// Flush, a BatchSource acknowledges only the events the handler no longer
+// retains. Sources with cumulative acknowledgements, such as Kafka offsets,
+// also hold back the events after a retained one.
+type RetainingHandler interface {
+	BatchHandler
+
+	// Retains reports whether the added event at the Topic, Partition and
+	// Offset of evt is still buffered.
+	Retains(evt Event) bool
+}
@@ -24,6 +24,10 @@ const (
 	AttrSignalName     = "temporal.signal.name"
 	AttrTargetName     = "fiso.target.name"
 	AttrErrorType      = "error.type"
+
+	AttrRewriteOriginalPath   = "fiso.rewrite.original_path"
+	AttrRewriteOriginalMethod = "fiso.rewrite.original_method"
+	AttrRewriteRules          = "fiso.rewrite.rules"
 )
 
 // Span name constants for consistent span naming.
@@ -146,6 +150,21 @@ func ErrorTypeAttr(errType string) attribute.KeyValue {
 	return attribute.String(AttrErrorType, errType)
 }
 
+// RewriteOriginalPathAttr returns an attribute for the path before rewriting.
+func RewriteOriginalPathAttr(path string) attribute.KeyValue {
+	return attribute.String(AttrRewriteOriginalPath, path)
+}
+
+// RewriteOriginalMethodAttr returns an attribute for the method before rewriting.
+func RewriteOriginalMethodAttr(method string) attribute.KeyValue {
+	return attribute.String(AttrRewriteOriginalMethod, method)
+}
+
+// RewriteRulesAttr returns an attribute for the number of rewrite rules applied.
+func RewriteRulesAttr(n int) attribute.KeyValue {
+	return attribute.Int(AttrRewriteRules, n)
+}
+
 // SpanFromContext returns the current span from the context.
 func SpanFromContext(ctx context.Context) trace.Span {
 	return trace.SpanFromContext(ctx)
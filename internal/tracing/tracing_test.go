@@ -314,6 +314,9 @@ func TestAttributeConstructors(t *testing.T) {
 		{"SignalNameAttr", SignalNameAttr("complete"), AttrSignalName, "complete"},
 		{"TargetNameAttr", TargetNameAttr("my-target"), AttrTargetName, "my-target"},
 		{"ErrorTypeAttr", ErrorTypeAttr("ValidationError"), AttrErrorType, "ValidationError"},
+		{"RewriteOriginalPathAttr", RewriteOriginalPathAttr("/customers/1"), AttrRewriteOriginalPath, "/customers/1"},
+		{"RewriteOriginalMethodAttr", RewriteOriginalMethodAttr("POST"), AttrRewriteOriginalMethod, "POST"},
+		{"RewriteRulesAttr", RewriteRulesAttr(2), AttrRewriteRules, 2},
 	}
 
 	for _, tt := range tests {
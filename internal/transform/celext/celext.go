@@ -0,0 +1,345 @@
+// Package celext provides the fiso CEL function library used by transforms,
+// filters and CloudEvents overrides. All functions live in the fiso
+// namespace:
+//
+//	fiso.uuid()                                  random UUID v4
+//	fiso.now()                                   current time
+//	fiso.sha256(string|bytes)                    hex SHA-256 digest
+//	fiso.hmacSha256(key, message)                hex HMAC-SHA256
+//	fiso.parseTime(value, layout[, zone])        timestamp
+//	fiso.formatTime(timestamp, layout[, zone])   string
+//	fiso.jsonPath(json, path)                    value at path in a JSON string, or null
+//	fiso.round(double, places)                   currency rounding, half away from zero
+//	fiso.regexExtract(string, pattern)           first match, or null
+//	fiso.regexExtractAll(string, pattern)        all matches
+//	fiso.base64url.encode(string|bytes)          unpadded base64url
+//	fiso.base64url.decode(string)                bytes
+//	fiso.lookup(table, key[, default])           value of key in a lookup table
+//
+// Layouts are Go reference layouts such as "2006-01-02 15:04" or the names
+// of the time package layouts: RFC3339, RFC1123, DateTime and so on. Zones
+// are IANA names such as "Europe/Berlin"; the zone database is embedded.
+package celext
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // parseTime and formatTime must not depend on the host zone database
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/uuid"
+	"github.com/lsm/fiso/internal/jsonpath"
+)
+
+// maxCachedPatterns bounds the compiled regular expressions kept by a
+// library. Patterns are usually literals, so the cache rarely fills up.
+const maxCachedPatterns = 256
+
+// Option configures the library.
+type Option func(*library)
+
+// WithClock sets the clock of fiso.now, for deterministic tests.
+func WithClock(now func() time.Time) Option {
+	return func(l *library) {
+		l.now = now
+	}
+}
+
+// WithUUID sets the generator of fiso.uuid, for deterministic tests.
+func WithUUID(gen func() string) Option {
+	return func(l *library) {
+		l.uuid = gen
+	}
+}
+
+// WithLookups sets the tables of fiso.lookup, by name.
+func WithLookups(tables map[string]map[string]string) Option {
+	return func(l *library) {
+		l.lookups = tables
+	}
+}
+
+// Library returns the fiso CEL functions as an environment option.
+func Library(opts ...Option) cel.EnvOption {
+	l := &library{
+		now:      time.Now,
+		uuid:     uuid.NewString,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return cel.Lib(l)
+}
+
+type library struct {
+	now     func() time.Time
+	uuid    func() string
+	lookups map[string]map[string]string
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+func (*library) LibraryName() string {
+	return "fiso"
+}
+
+func (l *library) CompileOptions() []cel.EnvOption {
+	listOfStrings := cel.ListType(cel.StringType)
+	return []cel.EnvOption{
+		cel.Function("fiso.uuid",
+			cel.Overload("fiso_uuid", nil, cel.StringType,
+				cel.FunctionBinding(func(...ref.Val) ref.Val {
+					return types.String(l.uuid())
+				}))),
+		cel.Function("fiso.now",
+			cel.Overload("fiso_now", nil, cel.TimestampType,
+				cel.FunctionBinding(func(...ref.Val) ref.Val {
+					return types.Timestamp{Time: l.now()}
+				}))),
+		cel.Function("fiso.sha256",
+			cel.Overload("fiso_sha256_string", []*cel.Type{cel.StringType}, cel.StringType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					sum := sha256.Sum256([]byte(v.(types.String)))
+					return types.String(hex.EncodeToString(sum[:]))
+				})),
+			cel.Overload("fiso_sha256_bytes", []*cel.Type{cel.BytesType}, cel.StringType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					sum := sha256.Sum256(v.(types.Bytes))
+					return types.String(hex.EncodeToString(sum[:]))
+				}))),
+		cel.Function("fiso.hmacSha256",
+			cel.Overload("fiso_hmac_sha256_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.StringType,
+				cel.BinaryBinding(func(key, msg ref.Val) ref.Val {
+					mac := hmac.New(sha256.New, []byte(key.(types.String)))
+					mac.Write([]byte(msg.(types.String)))
+					return types.String(hex.EncodeToString(mac.Sum(nil)))
+				}))),
+		cel.Function("fiso.parseTime",
+			cel.Overload("fiso_parse_time_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.TimestampType,
+				cel.BinaryBinding(func(value, layout ref.Val) ref.Val {
+					return parseTime(string(value.(types.String)), string(layout.(types.String)), "")
+				})),
+			cel.Overload("fiso_parse_time_string_string_string", []*cel.Type{cel.StringType, cel.StringType, cel.StringType}, cel.TimestampType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return parseTime(string(args[0].(types.String)), string(args[1].(types.String)), string(args[2].(types.String)))
+				}))),
+		cel.Function("fiso.formatTime",
+			cel.Overload("fiso_format_time_timestamp_string", []*cel.Type{cel.TimestampType, cel.StringType}, cel.StringType,
+				cel.BinaryBinding(func(ts, layout ref.Val) ref.Val {
+					return formatTime(ts.(types.Timestamp).Time, string(layout.(types.String)), "")
+				})),
+			cel.Overload("fiso_format_time_timestamp_string_string", []*cel.Type{cel.TimestampType, cel.StringType, cel.StringType}, cel.StringType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return formatTime(args[0].(types.Timestamp).Time, string(args[1].(types.String)), string(args[2].(types.String)))
+				}))),
+		cel.Function("fiso.jsonPath",
+			cel.Overload("fiso_json_path_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DynType,
+				cel.BinaryBinding(func(doc, path ref.Val) ref.Val {
+					return jsonPath(string(doc.(types.String)), string(path.(types.String)))
+				}))),
+		cel.Function("fiso.round",
+			cel.Overload("fiso_round_double_int", []*cel.Type{cel.DoubleType, cel.IntType}, cel.DoubleType,
+				cel.BinaryBinding(func(x, places ref.Val) ref.Val {
+					return round(float64(x.(types.Double)), int64(places.(types.Int)))
+				}))),
+		cel.Function("fiso.regexExtract",
+			cel.Overload("fiso_regex_extract_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DynType,
+				cel.BinaryBinding(func(s, pattern ref.Val) ref.Val {
+					re, err := l.regexp(string(pattern.(types.String)))
+					if err != nil {
+						return types.WrapErr(fmt.Errorf("fiso.regexExtract: %w", err))
+					}
+					m := re.FindStringSubmatch(string(s.(types.String)))
+					if m == nil {
+						return types.NullValue
+					}
+					return types.String(m[min(1, len(m)-1)])
+				}))),
+		cel.Function("fiso.regexExtractAll",
+			cel.Overload("fiso_regex_extract_all_string_string", []*cel.Type{cel.StringType, cel.StringType}, listOfStrings,
+				cel.BinaryBinding(func(s, pattern ref.Val) ref.Val {
+					re, err := l.regexp(string(pattern.(types.String)))
+					if err != nil {
+						return types.WrapErr(fmt.Errorf("fiso.regexExtractAll: %w", err))
+					}
+					matches := []string{}
+					for _, m := range re.FindAllStringSubmatch(string(s.(types.String)), -1) {
+						matches = append(matches, m[min(1, len(m)-1)])
+					}
+					return types.NewStringList(types.DefaultTypeAdapter, matches)
+				}))),
+		cel.Function("fiso.base64url.encode",
+			cel.Overload("fiso_base64url_encode_string", []*cel.Type{cel.StringType}, cel.StringType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					return types.String(base64.RawURLEncoding.EncodeToString([]byte(v.(types.String))))
+				})),
+			cel.Overload("fiso_base64url_encode_bytes", []*cel.Type{cel.BytesType}, cel.StringType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					return types.String(base64.RawURLEncoding.EncodeToString(v.(types.Bytes)))
+				}))),
+		cel.Function("fiso.base64url.decode",
+			cel.Overload("fiso_base64url_decode_string", []*cel.Type{cel.StringType}, cel.BytesType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(string(v.(types.String)), "="))
+					if err != nil {
+						return types.WrapErr(fmt.Errorf("fiso.base64url.decode: %w", err))
+					}
+					return types.Bytes(b)
+				}))),
+		cel.Function("fiso.lookup",
+			cel.Overload("fiso_lookup_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DynType,
+				cel.BinaryBinding(func(table, key ref.Val) ref.Val {
+					return l.lookup(string(table.(types.String)), string(key.(types.String)), types.NullValue)
+				})),
+			cel.Overload("fiso_lookup_string_string_string", []*cel.Type{cel.StringType, cel.StringType, cel.StringType}, cel.StringType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return l.lookup(string(args[0].(types.String)), string(args[1].(types.String)), args[2])
+				}))),
+	}
+}
+
+func (*library) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// regexp compiles pattern, caching the result.
+func (l *library) regexp(pattern string) (*regexp.Regexp, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if re, ok := l.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(l.patterns) < maxCachedPatterns {
+		l.patterns[pattern] = re
+	}
+	return re, nil
+}
+
+func (l *library) lookup(table, key string, def ref.Val) ref.Val {
+	entries, ok := l.lookups[table]
+	if !ok {
+		return types.NewErr("fiso.lookup: unknown table %q", table)
+	}
+	if v, ok := entries[key]; ok {
+		return types.String(v)
+	}
+	return def
+}
+
+// layouts are the named layouts of parseTime and formatTime.
+var layouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// zones caches loaded time zones by name.
+var zones sync.Map
+
+func location(zone string) (*time.Location, error) {
+	if zone == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := zones.Load(zone); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+	zones.Store(zone, loc)
+	return loc, nil
+}
+
+func layout(name string) string {
+	if l, ok := layouts[name]; ok {
+		return l
+	}
+	return name
+}
+
+// parseTime parses value in zone, UTC by default. Zone offsets in value take
+// precedence.
+func parseTime(value, layoutName, zone string) ref.Val {
+	loc, err := location(zone)
+	if err != nil {
+		return types.WrapErr(fmt.Errorf("fiso.parseTime: %w", err))
+	}
+	t, err := time.ParseInLocation(layout(layoutName), value, loc)
+	if err != nil {
+		return types.WrapErr(fmt.Errorf("fiso.parseTime: %w", err))
+	}
+	return types.Timestamp{Time: t}
+}
+
+// formatTime formats t in zone, UTC by default.
+func formatTime(t time.Time, layoutName, zone string) ref.Val {
+	loc, err := location(zone)
+	if err != nil {
+		return types.WrapErr(fmt.Errorf("fiso.formatTime: %w", err))
+	}
+	return types.String(t.In(loc).Format(layout(layoutName)))
+}
+
+// jsonPath returns the value at path, with or without a "$." prefix, in the
+// JSON object doc, or null when the path does not exist.
+func jsonPath(doc, path string) ref.Val {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &parsed); err != nil {
+		return types.WrapErr(fmt.Errorf("fiso.jsonPath: %w", err))
+	}
+	v, err := jsonpath.Resolve(parsed, strings.TrimPrefix(path, "$."))
+	if err != nil {
+		return types.NullValue
+	}
+	return types.DefaultTypeAdapter.NativeToValue(v)
+}
+
+// round rounds x to places decimal places, half away from zero. It rounds
+// the shortest decimal representation of x, so 1.005 rounds to 1.01 as
+// written rather than to 1.00 as stored.
+func round(x float64, places int64) ref.Val {
+	if places < 0 || places > 15 {
+		return types.NewErr("fiso.round: places must be between 0 and 15, got %d", places)
+	}
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(x, 'f', -1, 64))
+	if !ok {
+		return types.NewErr("fiso.round: cannot round %v", x)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(places), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Lsh(m.Abs(m), 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(r.Num().Sign())))
+	}
+	f, _ := new(big.Rat).SetFrac(q, scale).Float64()
+	return types.Double(f)
+}
@@ -0,0 +1,134 @@
+package celext
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+)
+
+func eval(t *testing.T, expr string, vars map[string]interface{}, opts ...Option) (interface{}, error) {
+	t.Helper()
+	env, err := cel.NewEnv(cel.Variable("data", cel.DynType), Library(opts...))
+	if err != nil {
+		t.Fatalf("cel env: %v", err)
+	}
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		t.Fatalf("compile %q: %v", expr, issues.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("program %q: %v", expr, err)
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if out == types.NullValue {
+		return nil, nil
+	}
+	return out.Value(), nil
+}
+
+func TestLibrary(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 30, 0, 0, time.UTC)
+	opts := []Option{
+		WithClock(func() time.Time { return now }),
+		WithUUID(func() string { return "00000000-0000-4000-8000-000000000000" }),
+		WithLookups(map[string]map[string]string{"countries": {"DE": "Germany"}}),
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{`fiso.uuid()`, "00000000-0000-4000-8000-000000000000"},
+		{`fiso.formatTime(fiso.now(), "RFC3339")`, "2024-03-10T12:30:00Z"},
+		{`fiso.sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`fiso.sha256(b"abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`fiso.hmacSha256("key", "The quick brown fox jumps over the lazy dog")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`fiso.formatTime(fiso.parseTime("10/03/2024 14:05", "02/01/2006 15:04", "Europe/Berlin"), "RFC3339")`, "2024-03-10T13:05:00Z"},
+		{`fiso.formatTime(fiso.parseTime("2024-03-10", "DateOnly"), "DateTime", "America/New_York")`, "2024-03-09 19:00:00"},
+		{`fiso.parseTime("2024-03-10T12:30:00+02:00", "RFC3339") < fiso.now()`, true},
+		{`fiso.jsonPath(data.raw, "$.order.id")`, "A1"},
+		{`fiso.jsonPath(data.raw, "order.missing")`, nil},
+		{`fiso.jsonPath(data.raw, "order").total`, 12.5},
+		{`fiso.round(1.005, 2)`, 1.01},
+		{`fiso.round(-2.5, 0)`, -3.0},
+		{`fiso.round(19.999, 2)`, 20.0},
+		{`fiso.round(0.125, 2)`, 0.13},
+		{`fiso.regexExtract("order ORD-123 shipped", "ORD-([0-9]+)")`, "123"},
+		{`fiso.regexExtract("order ORD-123 shipped", "ORD-[0-9]+")`, "ORD-123"},
+		{`fiso.regexExtract("no order", "ORD-([0-9]+)")`, nil},
+		{`fiso.regexExtractAll("a1 b22 c333", "[a-z]([0-9]+)") == ["1", "22", "333"]`, true},
+		{`fiso.regexExtractAll("none", "[0-9]+") == []`, true},
+		{`fiso.base64url.encode("hi?>")`, "aGk_Pg"},
+		{`fiso.base64url.encode(b"\xff\xfe")`, "__4"},
+		{`string(fiso.base64url.decode("aGk_Pg"))`, "hi?>"},
+		{`string(fiso.base64url.decode("aGk_Pg=="))`, "hi?>"},
+		{`fiso.lookup("countries", "DE")`, "Germany"},
+		{`fiso.lookup("countries", "FR")`, nil},
+		{`fiso.lookup("countries", "FR", "unknown")`, "unknown"},
+	}
+	vars := map[string]interface{}{"data": map[string]interface{}{"raw": `{"order": {"id": "A1", "total": 12.5}}`}}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := eval(t, tt.expr, vars, opts...)
+			if err != nil {
+				t.Fatalf("eval: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibrary_Defaults(t *testing.T) {
+	got, err := eval(t, `fiso.uuid()`, nil)
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if s, _ := got.(string); len(s) != 36 || s[14] != '4' {
+		t.Errorf("expected a UUID v4, got %v", got)
+	}
+
+	before := time.Now()
+	got, err = eval(t, `fiso.now()`, nil)
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if ts, _ := got.(time.Time); ts.Before(before) || ts.After(time.Now()) {
+		t.Errorf("expected the current time, got %v", got)
+	}
+}
+
+func TestLibrary_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`fiso.parseTime("yesterday", "RFC3339")`, "fiso.parseTime"},
+		{`fiso.parseTime("2024-03-10", "DateOnly", "Mars/Olympus")`, "unknown time zone"},
+		{`fiso.formatTime(fiso.now(), "RFC3339", "Mars/Olympus")`, "fiso.formatTime"},
+		{`fiso.jsonPath("not json", "a")`, "fiso.jsonPath"},
+		{`fiso.round(1.5, -1)`, "places must be between 0 and 15"},
+		{`fiso.regexExtract("a", "(")`, "fiso.regexExtract"},
+		{`fiso.regexExtractAll("a", "(")`, "fiso.regexExtractAll"},
+		{`fiso.base64url.decode("!!")`, "fiso.base64url.decode"},
+		{`fiso.lookup("missing", "a")`, `unknown table "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := eval(t, tt.expr, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
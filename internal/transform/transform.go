@@ -1,6 +1,10 @@
 package transform
 
-import "context"
+import (
+	"context"
+
+	"github.com/lsm/fiso/internal/source"
+)
 
 // Transformer applies a transformation to an event payload.
 type Transformer interface {
@@ -8,3 +12,21 @@ type Transformer interface {
 	// Returns an error if the transformation fails (routes to DLQ).
 	Transform(ctx context.Context, input []byte) ([]byte, error)
 }
+
+// EventTransformer is a Transformer that also sees the metadata of the
+// source event and can set headers for the sink.
+type EventTransformer interface {
+	Transformer
+
+	// TransformEvent transforms input, the payload of evt, and returns the
+	// transformed payload and the headers to add to the sink message.
+	TransformEvent(ctx context.Context, input []byte, evt source.Event) ([]byte, map[string]string, error)
+}
+
+// Filter selects the events a flow delivers.
+type Filter interface {
+	// Match reports whether evt should be delivered. input is the event
+	// payload, decoded if the flow decodes its source. Returns an error if
+	// the condition cannot be evaluated (routes to DLQ).
+	Match(ctx context.Context, input []byte, evt source.Event) (bool, error)
+}
@@ -0,0 +1,49 @@
+package unified
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// Expression evaluates a CEL expression to a value. It sees the same
+// variables as Transformer.TransformEvent.
+type Expression struct {
+	program cel.Program
+}
+
+// NewExpression compiles a CEL expression. functions configure the fiso
+// CEL functions. For example: `"/customers/" + data.customer_id`
+func NewExpression(expr string, functions ...celext.Option) (*Expression, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+
+	env, err := newEnv(functions...)
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("cel compile: %w", issues.Err())
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("cel program: %w", err)
+	}
+	return &Expression{program: prg}, nil
+}
+
+// Eval evaluates the expression over the parsed JSON input and the metadata
+// of evt. The result is a JSON-compatible Go value.
+func (e *Expression) Eval(input map[string]interface{}, evt source.Event) (interface{}, error) {
+	out, _, err := e.program.Eval(newActivation(input, evt))
+	if err != nil {
+		return nil, fmt.Errorf("cel eval: %w", err)
+	}
+	return toNative(out), nil
+}
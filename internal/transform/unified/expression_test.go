@@ -0,0 +1,50 @@
+package unified
+
+import (
+	"testing"
+
+	"github.com/lsm/fiso/internal/source"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	e, err := NewExpression(`"/customers/" + data.customer_id + "?tenant=" + headers["x-tenant"]`)
+	if err != nil {
+		t.Fatalf("NewExpression: %v", err)
+	}
+	input := map[string]interface{}{"data": map[string]interface{}{"customer_id": "C1"}}
+	got, err := e.Eval(input, source.Event{Headers: map[string]string{"x-tenant": "acme"}})
+	if err != nil {
+		t.Fatalf("Eval: %v", err)
+	}
+	if got != "/customers/C1?tenant=acme" {
+		t.Errorf("Eval = %v", got)
+	}
+
+	obj, err := NewExpression(`{"ids": [data.customer_id], "limit": 1}`)
+	if err != nil {
+		t.Fatalf("NewExpression: %v", err)
+	}
+	got, err = obj.Eval(input, source.Event{})
+	if err != nil {
+		t.Fatalf("Eval: %v", err)
+	}
+	m, ok := got.(map[string]interface{})
+	if !ok || m["limit"] != int64(1) {
+		t.Errorf("Eval = %#v, want a native map", got)
+	}
+}
+
+func TestExpression_Errors(t *testing.T) {
+	for _, expr := range []string{"", ">>>"} {
+		if _, err := NewExpression(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+	e, err := NewExpression("data.missing.field")
+	if err != nil {
+		t.Fatalf("NewExpression: %v", err)
+	}
+	if _, err := e.Eval(map[string]interface{}{"data": map[string]interface{}{}}, source.Event{}); err == nil {
+		t.Error("expected an eval error")
+	}
+}
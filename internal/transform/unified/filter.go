@@ -0,0 +1,67 @@
+package unified
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
+)
+
+// Filter evaluates a CEL condition over JSON event payloads. It sees the
+// same variables as Transformer.TransformEvent.
+type Filter struct {
+	program cel.Program
+}
+
+// NewFilter compiles a boolean CEL expression. functions configure the fiso
+// CEL functions. For example: `data.op != "r" && headers["x-heartbeat"] != "true"`
+func NewFilter(expr string, functions ...celext.Option) (*Filter, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+
+	env, err := newEnv(functions...)
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("cel compile: %w", issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("cel compile: expression must return a bool, got %s", t)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("cel program: %w", err)
+	}
+	return &Filter{program: prg}, nil
+}
+
+// Match implements transform.Filter.
+func (f *Filter) Match(ctx context.Context, input []byte, evt source.Event) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("context error: %w", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(input, &parsed); err != nil {
+		return false, fmt.Errorf("unmarshal input: %w", err)
+	}
+
+	out, _, err := f.program.Eval(newActivation(parsed, evt))
+	if err != nil {
+		return false, fmt.Errorf("cel eval: %w", err)
+	}
+	match, ok := out.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("cel eval: expression returned %s, not a bool", out.Type().TypeName())
+	}
+	return bool(match), nil
+}
@@ -0,0 +1,73 @@
+package unified
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lsm/fiso/internal/source"
+)
+
+func TestFilter_Match(t *testing.T) {
+	evt := source.Event{
+		Key:     []byte("order-1"),
+		Topic:   "dbserver.inventory.orders",
+		Headers: map[string]string{"x-tenant": "acme"},
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		input string
+		want  bool
+	}{
+		{"data field", `data.op != "r"`, `{"data": {"op": "c"}}`, true},
+		{"data field filtered", `data.op != "r"`, `{"data": {"op": "r"}}`, false},
+		{"event attribute", `type == "order.created"`, `{"type": "order.created"}`, true},
+		{"header", `headers["x-tenant"] == "acme"`, `{}`, true},
+		{"missing header", `!("x-heartbeat" in headers)`, `{}`, true},
+		{"key and topic", `key.startsWith("order-") && topic.endsWith(".orders")`, `{}`, true},
+		{"optional field", `has(data.total) && data.total > 100`, `{"data": {}}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("NewFilter: %v", err)
+			}
+			got, err := f.Match(context.Background(), []byte(tt.input), evt)
+			if err != nil {
+				t.Fatalf("Match: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{"", ">>>invalid<<<", `"not a bool"`, `key + "x"`} {
+		if _, err := NewFilter(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestFilter_MatchErrors(t *testing.T) {
+	f, err := NewFilter(`data.total > 100`)
+	if err != nil {
+		t.Fatalf("NewFilter: %v", err)
+	}
+	if _, err := f.Match(context.Background(), []byte(`not json`), source.Event{}); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if _, err := f.Match(context.Background(), []byte(`{"data": {}}`), source.Event{}); err == nil {
+		t.Error("expected error for a missing field")
+	}
+	dyn, err := NewFilter(`data.flag`)
+	if err != nil {
+		t.Fatalf("NewFilter: %v", err)
+	}
+	if _, err := dyn.Match(context.Background(), []byte(`{"data": {"flag": "yes"}}`), source.Event{}); err == nil {
+		t.Error("expected error for a non-bool result")
+	}
+}
@@ -0,0 +1,244 @@
+package unified
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Binding is a let variable: a CEL expression evaluated once per event,
+// which later expressions reference by Name.
+type Binding struct {
+	Name string
+	Expr string
+}
+
+// Step is one field map of a transform. Fields map output paths to CEL
+// expressions; a dotted path such as "customer.address.city" builds nested
+// objects, and `\.` is a literal dot in a key, as in "field\.with\.dots".
+// Let variables are evaluated before the fields and are visible to
+// this step only. OmitIf maps a path of Fields, or a parent object of one,
+// to a condition that omits it from the output when true.
+type Step struct {
+	Let    []Binding
+	Fields map[string]string
+	OmitIf map[string]string
+}
+
+// step is a compiled Step.
+type step struct {
+	let     []binding
+	program cel.Program
+}
+
+// binding is a compiled Binding.
+type binding struct {
+	name    string
+	program cel.Program
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// declaredVariables returns the names of the variables of newEnv.
+func declaredVariables() map[string]bool {
+	names := map[string]bool{
+		"headers": true, "key": true, "topic": true,
+		"partition": true, "offset": true, "correlationId": true,
+	}
+	for _, name := range eventVariables {
+		names[name] = true
+	}
+	return names
+}
+
+// compileBindings compiles let in order, each binding seeing the ones
+// before it, and returns env extended with all of them. declared holds the
+// variables of env; the names of the bindings are added to it.
+func compileBindings(env *cel.Env, let []Binding, declared map[string]bool) ([]binding, *cel.Env, error) {
+	bindings := make([]binding, 0, len(let))
+	for _, l := range let {
+		if !identifierPattern.MatchString(l.Name) {
+			return nil, nil, fmt.Errorf("let %q: name must be an identifier", l.Name)
+		}
+		if declared[l.Name] {
+			return nil, nil, fmt.Errorf("let %q: variable is already defined", l.Name)
+		}
+		ast, issues := env.Compile(l.Expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, nil, fmt.Errorf("let %s: cel compile: %w", l.Name, issues.Err())
+		}
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, nil, fmt.Errorf("let %s: cel program: %w", l.Name, err)
+		}
+		bindings = append(bindings, binding{name: l.Name, program: prg})
+
+		if env, err = env.Extend(cel.Variable(l.Name, cel.DynType)); err != nil {
+			return nil, nil, fmt.Errorf("let %s: cel env: %w", l.Name, err)
+		}
+		declared[l.Name] = true
+	}
+	return bindings, env, nil
+}
+
+// evalBindings evaluates bindings in order and adds their values to
+// activation.
+func evalBindings(bindings []binding, activation map[string]interface{}) error {
+	for _, b := range bindings {
+		v, _, err := b.program.Eval(activation)
+		if err != nil {
+			return fmt.Errorf("let %s: cel eval: %w", b.name, err)
+		}
+		activation[b.name] = v
+	}
+	return nil
+}
+
+// fieldNode is an output field: a leaf holding an expression, or an object
+// holding nested fields.
+type fieldNode struct {
+	path     string
+	leaf     bool
+	expr     string
+	omitIf   string
+	children map[string]*fieldNode
+}
+
+// splitPath splits a field path at its dots. `\.` is a literal dot and
+// `\\` a literal backslash.
+func splitPath(path string) ([]string, error) {
+	var segs []string
+	var seg strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\':
+			if i+1 == len(path) || (path[i+1] != '.' && path[i+1] != '\\') {
+				return nil, fmt.Errorf("field %q has an invalid escape", path)
+			}
+			i++
+			seg.WriteByte(path[i])
+		case c == '.':
+			segs = append(segs, seg.String())
+			seg.Reset()
+		default:
+			seg.WriteByte(c)
+		}
+	}
+	segs = append(segs, seg.String())
+	for _, s := range segs {
+		if s == "" {
+			return nil, fmt.Errorf("field %q has an empty path segment", path)
+		}
+	}
+	return segs, nil
+}
+
+// buildFieldsExpression converts fields to a CEL object literal, nesting
+// dotted paths and making the entries named in omitIf optional.
+// For example: {"a.b": "data.x"} with omitIf {"a.b": "data.x == 0"} becomes
+// {"a": {?"b": (data.x == 0) ? optional.none() : optional.of(data.x)}}
+func buildFieldsExpression(fields, omitIf map[string]string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("fields map is empty")
+	}
+
+	// Sorted paths visit a path before the paths nested under it
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	root := &fieldNode{}
+	for _, path := range paths {
+		if strings.TrimSpace(fields[path]) == "" {
+			return "", fmt.Errorf("field %q has an empty expression", path)
+		}
+		segs, err := splitPath(path)
+		if err != nil {
+			return "", err
+		}
+		n := root
+		for _, seg := range segs {
+			if n.leaf {
+				return "", fmt.Errorf("field %q conflicts with field %q", path, n.path)
+			}
+			child := n.children[seg]
+			if child == nil {
+				if n.children == nil {
+					n.children = make(map[string]*fieldNode)
+				}
+				child = &fieldNode{path: path}
+				n.children[seg] = child
+			}
+			n = child
+		}
+		if n.leaf || n.children != nil {
+			return "", fmt.Errorf("field %q conflicts with field %q", path, n.path)
+		}
+		n.leaf, n.path, n.expr = true, path, fields[path]
+	}
+
+	for path, cond := range omitIf {
+		segs, err := splitPath(path)
+		if err != nil {
+			return "", fmt.Errorf("omitIf: %w", err)
+		}
+		n := root
+		for _, seg := range segs {
+			if n = n.children[seg]; n == nil {
+				return "", fmt.Errorf("omitIf %q does not name a field", path)
+			}
+		}
+		n.omitIf = cond
+	}
+
+	var builder strings.Builder
+	builder.Grow(128) // Pre-allocate reasonable size
+	root.write(&builder)
+	return builder.String(), nil
+}
+
+func (n *fieldNode) write(b *strings.Builder) {
+	if n.leaf {
+		b.WriteString(n.expr)
+		return
+	}
+
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		child := n.children[k]
+		if child.omitIf != "" {
+			b.WriteByte('?')
+		}
+
+		// Write key as JSON string (handles escaping)
+		jsonKey, _ := json.Marshal(k)
+		b.Write(jsonKey)
+		b.WriteByte(':')
+
+		if child.omitIf == "" {
+			child.write(b)
+			continue
+		}
+		b.WriteByte('(')
+		b.WriteString(child.omitIf)
+		b.WriteString(") ? optional.none() : optional.of(")
+		child.write(b)
+		b.WriteByte(')')
+	}
+	b.WriteByte('}')
+}
@@ -0,0 +1,209 @@
+package unified
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lsm/fiso/internal/source"
+)
+
+func TestTransform_NestedFieldPaths(t *testing.T) {
+	tr, err := NewTransformer(map[string]string{
+		"id":                    "data.id",
+		"customer.name":         "data.name",
+		"customer.address.city": "data.city",
+		"customer.address.zip":  "data.zip",
+	})
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	result, err := tr.Transform(context.Background(), []byte(`{"data": {"id": 1, "name": "Ada", "city": "London", "zip": "N1"}}`))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	want := `{"customer":{"address":{"city":"London","zip":"N1"},"name":"Ada"},"id":1}`
+	if string(result) != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+}
+
+func TestTransform_EscapedDots(t *testing.T) {
+	tr, err := NewStepTransformer([]Step{{
+		Fields: map[string]string{
+			`field\.with\.dots`: "data.value",
+			`meta.k8s\.io/name`: "data.value",
+			`path\\name`:        "data.value",
+			`optional\.field`:   "data.value",
+		},
+		OmitIf: map[string]string{`optional\.field`: "true"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	result, err := tr.Transform(context.Background(), []byte(`{"data": {"value": "v"}}`))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	want := `{"field.with.dots":"v","meta":{"k8s.io/name":"v"},"path\\name":"v"}`
+	if string(result) != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+}
+
+func TestTransform_OmitIf(t *testing.T) {
+	tr, err := NewStepTransformer([]Step{{
+		Fields: map[string]string{
+			"id":               "data.id",
+			"email":            "data.email",
+			"shipping.city":    "data.city",
+			"shipping.express": "data.express",
+		},
+		OmitIf: map[string]string{
+			"email":            "!has(data.email)",
+			"shipping":         "!has(data.city)",
+			"shipping.express": "!data.express",
+		},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"data": {"id": 1, "email": "a@b.c", "city": "Oslo", "express": true}}`, `{"email":"a@b.c","id":1,"shipping":{"city":"Oslo","express":true}}`},
+		{`{"data": {"id": 2, "city": "Oslo", "express": false}}`, `{"id":2,"shipping":{"city":"Oslo"}}`},
+		{`{"data": {"id": 3, "express": false}}`, `{"id":3}`},
+	}
+	for _, tt := range tests {
+		result, err := tr.Transform(context.Background(), []byte(tt.input))
+		if err != nil {
+			t.Fatalf("transform %s failed: %v", tt.input, err)
+		}
+		if string(result) != tt.want {
+			t.Errorf("transform %s = %s, want %s", tt.input, result, tt.want)
+		}
+	}
+}
+
+func TestTransform_Let(t *testing.T) {
+	tr, err := NewStepTransformer([]Step{{
+		Let: []Binding{{Name: "discount", Expr: "subtotal > 100.0 ? 0.1 : 0.0"}},
+		Fields: map[string]string{
+			"subtotal": "subtotal",
+			"total":    "subtotal * (1.0 - discount)",
+		},
+	}},
+		WithLet([]Binding{
+			{Name: "subtotal", Expr: "double(data.price) * double(data.quantity)"},
+			{Name: "tenant", Expr: `headers["x-tenant"]`},
+		}),
+		WithHeaders(map[string]string{"x-tenant": "tenant"}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	evt := source.Event{Headers: map[string]string{"x-tenant": "acme"}}
+	result, headers, err := tr.TransformEvent(context.Background(), []byte(`{"data": {"price": 50, "quantity": 4}}`), evt)
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	if want := `{"subtotal":200,"total":180}`; string(result) != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+	if headers["x-tenant"] != "acme" {
+		t.Errorf("headers = %v, want x-tenant from the shared let", headers)
+	}
+}
+
+func TestTransform_Steps(t *testing.T) {
+	tr, err := NewStepTransformer([]Step{
+		{Fields: map[string]string{
+			"order.id":    "data.legacy_id",
+			"order.total": "data.price * data.quantity",
+		}},
+		{
+			Let: []Binding{{Name: "large", Expr: "data.order.total > 1000"}},
+			Fields: map[string]string{
+				"id":       "data.order.id",
+				"priority": `large ? "high" : "normal"`,
+				"source":   "source",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	result, err := tr.Transform(context.Background(), []byte(`{"source": "shop", "data": {"legacy_id": "A1", "price": 600, "quantity": 2}}`))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	if want := `{"id":"A1","priority":"high","source":"shop"}`; string(result) != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+}
+
+func TestTransform_StepEvalError(t *testing.T) {
+	tr, err := NewStepTransformer([]Step{
+		{Fields: map[string]string{"id": "data.id"}},
+		{Fields: map[string]string{"total": "data.total"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+	_, err = tr.Transform(context.Background(), []byte(`{"data": {"id": 1}}`))
+	if err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Errorf("expected an error naming step 2, got %v", err)
+	}
+}
+
+func TestNewStepTransformer_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		opts  []Option
+		want  string
+	}{
+		{"no steps", nil, nil, "steps cannot be empty"},
+		{"empty step", []Step{{Fields: map[string]string{"a": "1"}}, {}}, nil, "step 2: fields cannot be empty"},
+		{"conflicting paths", []Step{{Fields: map[string]string{"a": "1", "a.b": "2"}}}, nil, `field "a.b" conflicts with field "a"`},
+		{"empty segment", []Step{{Fields: map[string]string{"a..b": "1"}}}, nil, "empty path segment"},
+		{"empty expression", []Step{{Fields: map[string]string{"a": "", "a.b": "2"}}}, nil, `field "a" has an empty expression`},
+		{"leaf after branch", []Step{{Fields: map[string]string{`a\.b`: "1", "a": "2", "a.c": "3"}}}, nil, "conflicts with field"},
+		{"invalid escape", []Step{{Fields: map[string]string{`a\b`: "1"}}}, nil, "invalid escape"},
+		{"unknown omitIf", []Step{{Fields: map[string]string{"a": "1"}, OmitIf: map[string]string{"b": "true"}}}, nil, `omitIf "b" does not name a field`},
+		{"invalid omitIf", []Step{{Fields: map[string]string{"a": "1"}, OmitIf: map[string]string{"a": ">>>"}}}, nil, "cel compile"},
+		{"let name", []Step{{Fields: map[string]string{"a": "1"}}}, []Option{WithLet([]Binding{{Name: "a-b", Expr: "1"}})}, "must be an identifier"},
+		{"let shadows a variable", []Step{{Fields: map[string]string{"a": "1"}}}, []Option{WithLet([]Binding{{Name: "data", Expr: "1"}})}, "already defined"},
+		{"step let shadows a let", []Step{{Let: []Binding{{Name: "x", Expr: "2"}}, Fields: map[string]string{"a": "x"}}}, []Option{WithLet([]Binding{{Name: "x", Expr: "1"}})}, "already defined"},
+		{"let order", []Step{{Fields: map[string]string{"a": "y"}}}, []Option{WithLet([]Binding{{Name: "x", Expr: "y"}, {Name: "y", Expr: "1"}})}, "let x: cel compile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStepTransformer(tt.steps, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBuildFieldsExpression(t *testing.T) {
+	expr, err := buildFieldsExpression(
+		map[string]string{"a.b": "data.x", "c": "1"},
+		map[string]string{"a.b": "data.x == 0"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"a":{?"b":(data.x == 0) ? optional.none() : optional.of(data.x)},"c":1}`
+	if expr != want {
+		t.Errorf("expr = %s, want %s", expr, want)
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 	"time"
 
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
 	"github.com/google/cel-go/common/types/traits"
 	"github.com/google/cel-go/ext"
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
 )
 
 const (
@@ -35,12 +39,42 @@ func WithMaxOutputBytes(n int) Option {
 	}
 }
 
+// WithLet sets let variables shared by all steps and headers. They are
+// evaluated in order, once per event, before the first step.
+func WithLet(let []Binding) Option {
+	return func(t *Transformer) {
+		t.let = let
+	}
+}
+
+// WithFunctions configures the fiso CEL functions, for example their
+// lookup tables.
+func WithFunctions(opts ...celext.Option) Option {
+	return func(t *Transformer) {
+		t.functions = opts
+	}
+}
+
+// WithHeaders sets headers for the sink message. Each value is a CEL
+// expression evaluated like a field; headers that evaluate to null are not
+// set, other non-string values are formatted as strings.
+func WithHeaders(headers map[string]string) Option {
+	return func(t *Transformer) {
+		t.headers = headers
+	}
+}
+
 // Transformer applies a unified fields-based transform to JSON event payloads.
-// The fields map defines output fields as CEL expressions, compiled into a single
-// optimized CEL program for better performance than per-event goroutine evaluation.
+// The fields map of each step defines output fields as CEL expressions, compiled
+// into a single optimized CEL program for better performance than per-event
+// goroutine evaluation.
 type Transformer struct {
-	program        cel.Program
-	fields         map[string]string
+	letBindings    []binding
+	steps          []step
+	headersProgram cel.Program // nil without headers
+	let            []Binding
+	functions      []celext.Option
+	headers        map[string]string
 	timeout        time.Duration
 	maxOutputBytes int
 }
@@ -52,51 +86,153 @@ func NewTransformer(fields map[string]string, opts ...Option) (*Transformer, err
 	if len(fields) == 0 {
 		return nil, fmt.Errorf("fields cannot be empty")
 	}
+	return NewStepTransformer([]Step{{Fields: fields}}, opts...)
+}
 
-	// Build CEL expression from fields map
-	expr, err := buildCelExpression(fields)
-	if err != nil {
-		return nil, fmt.Errorf("build cel expression: %w", err)
+// NewStepTransformer creates a unified transformer that applies steps in
+// order. The first step reads the input event; each later step reads the
+// output of the step before it as data, while the other variables keep
+// their values.
+func NewStepTransformer(steps []Step, opts ...Option) (*Transformer, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("steps cannot be empty")
+	}
+
+	t := &Transformer{
+		timeout:        defaultTimeout,
+		maxOutputBytes: defaultMaxOutputBytes,
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
 
 	// Create CEL environment with standard extensions
-	env, err := cel.NewEnv(
-		cel.Variable("data", cel.DynType),
-		cel.Variable("time", cel.DynType),
-		cel.Variable("source", cel.DynType),
-		cel.Variable("type", cel.DynType),
-		cel.Variable("id", cel.DynType),
-		cel.Variable("subject", cel.DynType),
-		ext.Strings(),
-		ext.Encoders(),
-		ext.Math(),
-	)
+	env, err := newEnv(t.functions...)
 	if err != nil {
 		return nil, fmt.Errorf("cel env: %w", err)
 	}
 
+	// Shared lets extend the environment of the steps and headers
+	declared := declaredVariables()
+	t.letBindings, env, err = compileBindings(env, t.let, declared)
+	if err != nil {
+		return nil, err
+	}
+
+	t.steps = make([]step, len(steps))
+	for i, s := range steps {
+		compiled, err := compileStep(env, s, maps.Clone(declared))
+		if err != nil {
+			if len(steps) > 1 {
+				return nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		t.steps[i] = compiled
+	}
+
+	// Compile the header expressions into a second map literal
+	if len(t.headers) > 0 {
+		expr, err := buildCelExpression(t.headers)
+		if err != nil {
+			return nil, fmt.Errorf("build cel expression: %w", err)
+		}
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("cel compile headers: %w", issues.Err())
+		}
+		if t.headersProgram, err = env.Program(ast); err != nil {
+			return nil, fmt.Errorf("cel program headers: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// compileStep compiles the lets of s and its fields into one program.
+func compileStep(env *cel.Env, s Step, declared map[string]bool) (step, error) {
+	if len(s.Fields) == 0 {
+		return step{}, fmt.Errorf("fields cannot be empty")
+	}
+
+	let, env, err := compileBindings(env, s.Let, declared)
+	if err != nil {
+		return step{}, err
+	}
+
+	// Build CEL expression from fields map
+	expr, err := buildFieldsExpression(s.Fields, s.OmitIf)
+	if err != nil {
+		return step{}, fmt.Errorf("build cel expression: %w", err)
+	}
+
 	// Compile the expression
 	ast, issues := env.Compile(expr)
 	if issues != nil && issues.Err() != nil {
-		return nil, fmt.Errorf("cel compile: %w", issues.Err())
+		return step{}, fmt.Errorf("cel compile: %w", issues.Err())
 	}
 
 	// Create the program
 	prg, err := env.Program(ast)
 	if err != nil {
-		return nil, fmt.Errorf("cel program: %w", err)
+		return step{}, fmt.Errorf("cel program: %w", err)
 	}
+	return step{let: let, program: prg}, nil
+}
 
-	t := &Transformer{
-		program:        prg,
-		fields:         fields,
-		timeout:        defaultTimeout,
-		maxOutputBytes: defaultMaxOutputBytes,
+// eventVariables are the CEL variables taken from the top-level fields of
+// the input event.
+var eventVariables = []string{"data", "time", "source", "type", "id", "subject"}
+
+// newEnv creates the CEL environment of transforms and filters: the
+// eventVariables, the metadata of the source event and the fiso functions.
+func newEnv(functions ...celext.Option) (*cel.Env, error) {
+	opts := make([]cel.EnvOption, 0, len(eventVariables)+11)
+	for _, name := range eventVariables {
+		opts = append(opts, cel.Variable(name, cel.DynType))
 	}
-	for _, opt := range opts {
-		opt(t)
+	opts = append(opts,
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("key", cel.StringType),
+		cel.Variable("topic", cel.StringType),
+		cel.Variable("partition", cel.IntType),
+		cel.Variable("offset", cel.IntType),
+		cel.Variable("correlationId", cel.StringType),
+		ext.Strings(),
+		ext.Encoders(),
+		ext.Math(),
+		cel.OptionalTypes(),
+		celext.Library(functions...),
+	)
+	return cel.NewEnv(opts...)
+}
+
+// newActivation binds the top-level fields of parsed, nil for the
+// eventVariables it lacks, and the metadata of evt.
+func newActivation(parsed map[string]interface{}, evt source.Event) map[string]interface{} {
+	activation := make(map[string]interface{}, len(parsed)+len(eventVariables)+6)
+	for k, v := range parsed {
+		activation[k] = v
 	}
-	return t, nil
+
+	// Ensure all declared variables have a value to avoid runtime errors
+	// for expressions that don't reference all variables.
+	for _, name := range eventVariables {
+		if _, ok := activation[name]; !ok {
+			activation[name] = nil
+		}
+	}
+
+	headers := evt.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	activation["headers"] = headers
+	activation["key"] = string(evt.Key)
+	activation["topic"] = evt.Topic
+	activation["partition"] = int64(evt.Partition)
+	activation["offset"] = evt.Offset
+	activation["correlationId"] = evt.CorrelationID
+	return activation
 }
 
 // buildCelExpression converts a fields map to a CEL object literal expression.
@@ -132,35 +268,59 @@ func buildCelExpression(fields map[string]string) (string, error) {
 
 // Transform applies the unified transform to the input JSON payload.
 // It uses direct CEL evaluation without per-event goroutines for better performance.
+// Metadata variables are empty and headers are not evaluated.
 func (t *Transformer) Transform(ctx context.Context, input []byte) ([]byte, error) {
+	output, _, err := t.transform(ctx, input, source.Event{}, false)
+	return output, err
+}
+
+// TransformEvent implements transform.EventTransformer. Expressions can
+// read the metadata of evt: headers, key, topic, partition, offset and
+// correlationId.
+func (t *Transformer) TransformEvent(ctx context.Context, input []byte, evt source.Event) ([]byte, map[string]string, error) {
+	return t.transform(ctx, input, evt, true)
+}
+
+func (t *Transformer) transform(ctx context.Context, input []byte, evt source.Event, withHeaders bool) ([]byte, map[string]string, error) {
 	if err := ctx.Err(); err != nil {
-		return nil, fmt.Errorf("context error: %w", err)
+		return nil, nil, fmt.Errorf("context error: %w", err)
 	}
 
 	// Parse input JSON
 	var parsed map[string]interface{}
 	if err := json.Unmarshal(input, &parsed); err != nil {
-		return nil, fmt.Errorf("unmarshal input: %w", err)
+		return nil, nil, fmt.Errorf("unmarshal input: %w", err)
 	}
 
-	// Build activation with all input fields
-	activation := make(map[string]interface{}, len(parsed)+6)
-	for k, v := range parsed {
-		activation[k] = v
-	}
-
-	// Ensure all declared variables have a value to avoid runtime errors
-	// for expressions that don't reference all variables.
-	for _, name := range []string{"data", "time", "source", "type", "id", "subject"} {
-		if _, ok := activation[name]; !ok {
-			activation[name] = nil
-		}
+	// Build activation with all input fields and the shared lets
+	activation := newActivation(parsed, evt)
+	if err := evalBindings(t.letBindings, activation); err != nil {
+		return nil, nil, err
 	}
 
 	// Direct CEL evaluation without goroutine (key performance optimization)
-	out, _, err := t.program.Eval(activation)
-	if err != nil {
-		return nil, fmt.Errorf("cel eval: %w", err)
+	var out ref.Val
+	for i, s := range t.steps {
+		vars := activation
+		if i > 0 || len(s.let) > 0 {
+			vars = maps.Clone(activation)
+			if i > 0 {
+				vars["data"] = out
+			}
+		}
+		err := evalBindings(s.let, vars)
+		if err == nil {
+			out, _, err = s.program.Eval(vars)
+			if err != nil {
+				err = fmt.Errorf("cel eval: %w", err)
+			}
+		}
+		if err != nil {
+			if len(t.steps) > 1 {
+				return nil, nil, fmt.Errorf("step %d: %w", i+1, err)
+			}
+			return nil, nil, err
+		}
 	}
 
 	// Convert CEL types to native Go types
@@ -169,15 +329,33 @@ func (t *Transformer) Transform(ctx context.Context, input []byte) ([]byte, erro
 	// Marshal output
 	output, err := json.Marshal(nativeVal)
 	if err != nil {
-		return nil, fmt.Errorf("marshal output: %w", err)
+		return nil, nil, fmt.Errorf("marshal output: %w", err)
 	}
 
 	// Check output size limit
 	if len(output) > t.maxOutputBytes {
-		return nil, fmt.Errorf("output size %d exceeds max %d bytes", len(output), t.maxOutputBytes)
+		return nil, nil, fmt.Errorf("output size %d exceeds max %d bytes", len(output), t.maxOutputBytes)
 	}
 
-	return output, nil
+	if !withHeaders || t.headersProgram == nil {
+		return output, nil, nil
+	}
+	hv, _, err := t.headersProgram.Eval(activation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cel eval headers: %w", err)
+	}
+	nativeHeaders, _ := toNative(hv).(map[string]interface{})
+	headers := make(map[string]string, len(nativeHeaders))
+	for k, v := range nativeHeaders {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			headers[k] = v
+		default:
+			headers[k] = fmt.Sprint(v)
+		}
+	}
+	return output, headers, nil
 }
 
 // toNative recursively converts CEL ref.Val types to native Go types
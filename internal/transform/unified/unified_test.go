@@ -8,6 +8,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/lsm/fiso/internal/source"
+	"github.com/lsm/fiso/internal/transform/celext"
 )
 
 func TestNewTransformer_ValidFields(t *testing.T) {
@@ -883,10 +886,24 @@ func TestTransform_KeysWithSpecialCharacters(t *testing.T) {
 	}
 
 	for key := range fields {
+		if key == "field.with.dots" {
+			continue
+		}
 		if out[key] != "test" {
 			t.Errorf("expected %s='test', got %v", key, out[key])
 		}
 	}
+
+	// Dotted keys build nested objects; see TestTransform_EscapedDots for
+	// literal dots.
+	if out["field.with.dots"] != nil {
+		t.Errorf("expected no flat dotted key, got %v", out["field.with.dots"])
+	}
+	nested, _ := out["field"].(map[string]interface{})
+	with, _ := nested["with"].(map[string]interface{})
+	if with["dots"] != "test" {
+		t.Errorf(`expected {"field":{"with":{"dots":"test"}}}, got %v`, out["field"])
+	}
 }
 
 func TestToNative_ComplexTypes(t *testing.T) {
@@ -1492,3 +1509,113 @@ func TestTransform_AllCELTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestTransformEvent_Metadata(t *testing.T) {
+	fields := map[string]string{
+		"tenant":    `headers["x-tenant"]`,
+		"key":       "key",
+		"topic":     "topic",
+		"partition": "partition",
+		"offset":    "offset",
+		"corr":      "correlationId",
+		"order_id":  "data.id",
+	}
+	tr, err := NewTransformer(fields)
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	evt := source.Event{
+		Key:           []byte("order-1"),
+		Topic:         "orders",
+		Partition:     2,
+		Offset:        41,
+		CorrelationID: "corr-9",
+		Headers:       map[string]string{"x-tenant": "acme"},
+	}
+	result, headers, err := tr.TransformEvent(context.Background(), []byte(`{"data": {"id": "ord-1"}}`), evt)
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	if headers != nil {
+		t.Errorf("expected no headers without WithHeaders, got %v", headers)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(result, &out); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	want := map[string]interface{}{
+		"tenant": "acme", "key": "order-1", "topic": "orders",
+		"partition": float64(2), "offset": float64(41), "corr": "corr-9", "order_id": "ord-1",
+	}
+	for k, v := range want {
+		if out[k] != v {
+			t.Errorf("%s = %v, want %v", k, out[k], v)
+		}
+	}
+}
+
+func TestTransformEvent_Headers(t *testing.T) {
+	tr, err := NewTransformer(
+		map[string]string{"id": "data.id"},
+		WithHeaders(map[string]string{
+			"x-tenant":   `headers["x-tenant"]`,
+			"x-priority": `data.total > 1000 ? "high" : "normal"`,
+			"x-total":    "data.total",
+			"x-skipped":  "null",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	evt := source.Event{Headers: map[string]string{"x-tenant": "acme"}}
+	_, headers, err := tr.TransformEvent(context.Background(), []byte(`{"data": {"id": "a", "total": 1500}}`), evt)
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	want := map[string]string{"x-tenant": "acme", "x-priority": "high", "x-total": "1500"}
+	if fmt.Sprint(headers) != fmt.Sprint(want) {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+
+	// Transform ignores headers.
+	if _, err := tr.Transform(context.Background(), []byte(`{"data": {"id": "a", "total": 1}}`)); err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+}
+
+func TestNewTransformer_InvalidHeaderExpression(t *testing.T) {
+	_, err := NewTransformer(map[string]string{"id": "data.id"}, WithHeaders(map[string]string{"x-bad": ">>>"}))
+	if err == nil {
+		t.Fatal("expected error for invalid header expression")
+	}
+}
+
+func TestTransform_FisoFunctions(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tr, err := NewTransformer(map[string]string{
+		"id":        "fiso.uuid()",
+		"processed": `fiso.formatTime(fiso.now(), "RFC3339")`,
+		"placed":    `fiso.formatTime(fiso.parseTime(data.placed, "DateTime", "Europe/Berlin"), "RFC3339")`,
+		"total":     "fiso.round(data.total, 2)",
+		"country":   `fiso.lookup("countries", data.country, "unknown")`,
+	}, WithFunctions(
+		celext.WithClock(func() time.Time { return now }),
+		celext.WithUUID(func() string { return "fixed-id" }),
+		celext.WithLookups(map[string]map[string]string{"countries": {"DE": "Germany"}}),
+	))
+	if err != nil {
+		t.Fatalf("failed to create transformer: %v", err)
+	}
+
+	result, err := tr.Transform(context.Background(), []byte(`{"data": {"placed": "2024-03-10 09:15:00", "total": 10.005, "country": "DE"}}`))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	want := `{"country":"Germany","id":"fixed-id","placed":"2024-03-10T08:15:00Z","processed":"2024-03-10T12:00:00Z","total":10.01}`
+	if string(result) != want {
+		t.Errorf("result = %s, want %s", result, want)
+	}
+}